@@ -184,6 +184,18 @@ var (
 	// channel and the outpoint is missing from the index.
 	ErrMissingIndexEntry = fmt.Errorf("missing outpoint from index")
 
+	// ErrNotChannelResponder is returned when attempting to rebuild the
+	// AcceptChannel message of a channel that we initiated, as we never
+	// sent one for it.
+	ErrNotChannelResponder = fmt.Errorf("channel was initiated by us, " +
+		"no AcceptChannel was sent")
+
+	// ErrNotChannelInitiator is returned when attempting to rebuild the
+	// AcceptChannel message we received for a channel that the remote
+	// party initiated, as we never received one for it.
+	ErrNotChannelInitiator = fmt.Errorf("channel was initiated by the " +
+		"remote party, no AcceptChannel was received")
+
 	// errHeightNotFound is returned when a query for channel balances at
 	// a height that we have not reached yet is made.
 	errHeightNotReached = fmt.Errorf("height requested greater than " +
@@ -198,6 +210,10 @@ const (
 	// A tlv type definition used to serialize and deserialize a KeyLocator
 	// from the database.
 	keyLocType tlv.Type = 1
+
+	// A tlv type definition used to serialize and deserialize the maximum
+	// HTLC expiry delta of a channel from the database.
+	maxHtlcExpiryDeltaType tlv.Type = 2
 )
 
 // indexStatus is an enum-like type that describes what state the
@@ -728,6 +744,12 @@ type OpenChannel struct {
 	// have private key isolation from lnd.
 	RevocationKeyLocator keychain.KeyLocator
 
+	// MaxHtlcExpiryDelta is the maximum number of blocks the expiry of the
+	// HTLCs we offer on this channel may lie beyond the current block
+	// height, as required by the remote party during funding. A value of
+	// zero means the channel doesn't bound the expiry.
+	MaxHtlcExpiryDelta uint32
+
 	// TODO(roasbeef): eww
 	Db *DB
 
@@ -1142,6 +1164,89 @@ func (c *OpenChannel) ChanSyncMsg() (*lnwire.ChannelReestablish, error) {
 	}, nil
 }
 
+// RebuildAcceptChannel reconstructs the AcceptChannel message we sent to the
+// initiator of this channel from its persisted keys and constraints. This is
+// useful for recovery and debugging after a crash.
+//
+// NOTE: The pending channel ID and the explicit channel type, if any, aren't
+// persisted, so they are left unset in the rebuilt message.
+func (c *OpenChannel) RebuildAcceptChannel() (*lnwire.AcceptChannel, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.IsInitiator {
+		return nil, ErrNotChannelResponder
+	}
+
+	// The first commitment point we sent is derived from the very first
+	// secret of our revocation producer.
+	firstCommitSecret, err := c.RevocationProducer.AtIndex(0)
+	if err != nil {
+		return nil, err
+	}
+
+	// The constraints we required from the initiator are stored within
+	// their config, while the dust limit and keys are our own.
+	return &lnwire.AcceptChannel{
+		DustLimit:           c.LocalChanCfg.DustLimit,
+		MaxValueInFlight:    c.RemoteChanCfg.MaxPendingAmount,
+		ChannelReserve:      c.RemoteChanCfg.ChanReserve,
+		HtlcMinimum:         c.RemoteChanCfg.MinHTLC,
+		MinAcceptDepth:      uint32(c.NumConfsRequired),
+		CsvDelay:            c.RemoteChanCfg.CsvDelay,
+		MaxAcceptedHTLCs:    c.RemoteChanCfg.MaxAcceptedHtlcs,
+		FundingKey:          c.LocalChanCfg.MultiSigKey.PubKey,
+		RevocationPoint:     c.LocalChanCfg.RevocationBasePoint.PubKey,
+		PaymentPoint:        c.LocalChanCfg.PaymentBasePoint.PubKey,
+		DelayedPaymentPoint: c.LocalChanCfg.DelayBasePoint.PubKey,
+		HtlcPoint:           c.LocalChanCfg.HtlcBasePoint.PubKey,
+		FirstCommitmentPoint: input.ComputeCommitmentPoint(
+			firstCommitSecret[:],
+		),
+		UpfrontShutdownScript: c.LocalShutdownScript,
+	}, nil
+}
+
+// RebuildReceivedAcceptChannel reconstructs the AcceptChannel message the
+// responder of a channel we initiated sent us, from its persisted keys and
+// constraints. This allows the message to be validated again, e.g. after new
+// validation rules have been introduced.
+//
+// NOTE: The pending channel ID and the explicit channel type, if any, aren't
+// persisted, so they are left unset in the rebuilt message.
+func (c *OpenChannel) RebuildReceivedAcceptChannel() (*lnwire.AcceptChannel,
+	error) {
+
+	c.RLock()
+	defer c.RUnlock()
+
+	if !c.IsInitiator {
+		return nil, ErrNotChannelInitiator
+	}
+
+	// The constraints the responder required from us are stored within
+	// our config, while the dust limit and keys are its own. Until the
+	// first state update, the current revocation of the remote party is
+	// the first commitment point it sent.
+	localCfg, remoteCfg := c.LocalChanCfg, c.RemoteChanCfg
+	return &lnwire.AcceptChannel{
+		DustLimit:             remoteCfg.DustLimit,
+		MaxValueInFlight:      localCfg.MaxPendingAmount,
+		ChannelReserve:        localCfg.ChanReserve,
+		HtlcMinimum:           localCfg.MinHTLC,
+		MinAcceptDepth:        uint32(c.NumConfsRequired),
+		CsvDelay:              localCfg.CsvDelay,
+		MaxAcceptedHTLCs:      localCfg.MaxAcceptedHtlcs,
+		FundingKey:            remoteCfg.MultiSigKey.PubKey,
+		RevocationPoint:       remoteCfg.RevocationBasePoint.PubKey,
+		PaymentPoint:          remoteCfg.PaymentBasePoint.PubKey,
+		DelayedPaymentPoint:   remoteCfg.DelayBasePoint.PubKey,
+		HtlcPoint:             remoteCfg.HtlcBasePoint.PubKey,
+		FirstCommitmentPoint:  c.RemoteCurrentRevocation,
+		UpfrontShutdownScript: c.RemoteShutdownScript,
+	}, nil
+}
+
 // isBorked returns true if the channel has been marked as borked in the
 // database. This requires an existing database transaction to already be
 // active.
@@ -3296,12 +3401,16 @@ func putChanInfo(chanBucket kvdb.RwBucket, channel *OpenChannel) error {
 		return err
 	}
 
-	// Write the RevocationKeyLocator as the first entry in a tlv stream.
+	// Write the RevocationKeyLocator as the first entry in a tlv stream,
+	// followed by the max HTLC expiry delta.
 	keyLocRecord := MakeKeyLocRecord(
 		keyLocType, &channel.RevocationKeyLocator,
 	)
+	maxHtlcExpiryDeltaRecord := tlv.MakePrimitiveRecord(
+		maxHtlcExpiryDeltaType, &channel.MaxHtlcExpiryDelta,
+	)
 
-	tlvStream, err := tlv.NewStream(keyLocRecord)
+	tlvStream, err := tlv.NewStream(keyLocRecord, maxHtlcExpiryDeltaRecord)
 	if err != nil {
 		return err
 	}
@@ -3499,8 +3608,13 @@ func fetchChanInfo(chanBucket kvdb.RBucket, channel *OpenChannel) error {
 		}
 	}
 
+	// Channels stored before the max HTLC expiry delta was added don't
+	// carry the record, leaving the expiry unbounded.
 	keyLocRecord := MakeKeyLocRecord(keyLocType, &channel.RevocationKeyLocator)
-	tlvStream, err := tlv.NewStream(keyLocRecord)
+	maxHtlcExpiryDeltaRecord := tlv.MakePrimitiveRecord(
+		maxHtlcExpiryDeltaType, &channel.MaxHtlcExpiryDelta,
+	)
+	tlvStream, err := tlv.NewStream(keyLocRecord, maxHtlcExpiryDeltaRecord)
 	if err != nil {
 		return err
 	}
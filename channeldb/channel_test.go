@@ -17,6 +17,7 @@ import (
 	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/kvdb"
 	"github.com/lightningnetwork/lnd/lntest/channels"
@@ -334,6 +335,7 @@ func createTestChannelState(t *testing.T, cdb *DB) *OpenChannel {
 		Packager:                NewChannelPackager(chanID),
 		FundingTxn:              channels.TestFundingTx,
 		ThawHeight:              uint32(defaultPendingHeight),
+		MaxHtlcExpiryDelta:      1008,
 	}
 }
 
@@ -535,6 +537,118 @@ func TestOptionalShutdown(t *testing.T) {
 	}
 }
 
+// TestRebuildAcceptChannel asserts that the AcceptChannel rebuilt from a
+// persisted channel matches the one originally sent, and that none can be
+// rebuilt for channels we initiated.
+func TestRebuildAcceptChannel(t *testing.T) {
+	t.Parallel()
+
+	cdb, cleanUp, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanUp()
+
+	shutdown := lnwire.DeliveryAddress(bytes.Repeat([]byte{2}, 22))
+	state := createTestChannel(
+		t, cdb, localShutdownOption(shutdown),
+		func(params *testChannelParams) {
+			params.channel.IsInitiator = false
+		},
+	)
+
+	// Assemble the message we would have sent when accepting the
+	// channel, before it was written to disk.
+	firstCommitSecret, err := state.RevocationProducer.AtIndex(0)
+	require.NoError(t, err)
+
+	localCfg, remoteCfg := state.LocalChanCfg, state.RemoteChanCfg
+	original := &lnwire.AcceptChannel{
+		DustLimit:           localCfg.DustLimit,
+		MaxValueInFlight:    remoteCfg.MaxPendingAmount,
+		ChannelReserve:      remoteCfg.ChanReserve,
+		HtlcMinimum:         remoteCfg.MinHTLC,
+		MinAcceptDepth:      uint32(state.NumConfsRequired),
+		CsvDelay:            remoteCfg.CsvDelay,
+		MaxAcceptedHTLCs:    remoteCfg.MaxAcceptedHtlcs,
+		FundingKey:          localCfg.MultiSigKey.PubKey,
+		RevocationPoint:     localCfg.RevocationBasePoint.PubKey,
+		PaymentPoint:        localCfg.PaymentBasePoint.PubKey,
+		DelayedPaymentPoint: localCfg.DelayBasePoint.PubKey,
+		HtlcPoint:           localCfg.HtlcBasePoint.PubKey,
+		FirstCommitmentPoint: input.ComputeCommitmentPoint(
+			firstCommitSecret[:],
+		),
+		UpfrontShutdownScript: shutdown,
+	}
+
+	openChannels, err := cdb.FetchOpenChannels(state.IdentityPub)
+	require.NoError(t, err)
+	require.Len(t, openChannels, 1)
+
+	rebuilt, err := openChannels[0].RebuildAcceptChannel()
+	require.NoError(t, err)
+
+	var expected, actual bytes.Buffer
+	require.NoError(t, original.Encode(&expected, 0))
+	require.NoError(t, rebuilt.Encode(&actual, 0))
+	require.Equal(t, expected.Bytes(), actual.Bytes())
+
+	// We never send an AcceptChannel for channels we initiate.
+	openChannels[0].IsInitiator = true
+	_, err = openChannels[0].RebuildAcceptChannel()
+	require.Equal(t, ErrNotChannelResponder, err)
+}
+
+// TestRebuildReceivedAcceptChannel asserts that the AcceptChannel rebuilt from
+// a persisted channel we initiated matches the one originally received, and
+// that none can be rebuilt for channels the remote party initiated.
+func TestRebuildReceivedAcceptChannel(t *testing.T) {
+	t.Parallel()
+
+	cdb, cleanUp, err := MakeTestDB()
+	require.NoError(t, err)
+	defer cleanUp()
+
+	shutdown := lnwire.DeliveryAddress(bytes.Repeat([]byte{3}, 22))
+	state := createTestChannel(t, cdb, remoteShutdownOption(shutdown))
+
+	// Assemble the message we would have received from the responder,
+	// before the channel was written to disk.
+	localCfg, remoteCfg := state.LocalChanCfg, state.RemoteChanCfg
+	original := &lnwire.AcceptChannel{
+		DustLimit:             remoteCfg.DustLimit,
+		MaxValueInFlight:      localCfg.MaxPendingAmount,
+		ChannelReserve:        localCfg.ChanReserve,
+		HtlcMinimum:           localCfg.MinHTLC,
+		MinAcceptDepth:        uint32(state.NumConfsRequired),
+		CsvDelay:              localCfg.CsvDelay,
+		MaxAcceptedHTLCs:      localCfg.MaxAcceptedHtlcs,
+		FundingKey:            remoteCfg.MultiSigKey.PubKey,
+		RevocationPoint:       remoteCfg.RevocationBasePoint.PubKey,
+		PaymentPoint:          remoteCfg.PaymentBasePoint.PubKey,
+		DelayedPaymentPoint:   remoteCfg.DelayBasePoint.PubKey,
+		HtlcPoint:             remoteCfg.HtlcBasePoint.PubKey,
+		FirstCommitmentPoint:  state.RemoteCurrentRevocation,
+		UpfrontShutdownScript: shutdown,
+	}
+
+	openChannels, err := cdb.FetchOpenChannels(state.IdentityPub)
+	require.NoError(t, err)
+	require.Len(t, openChannels, 1)
+
+	rebuilt, err := openChannels[0].RebuildReceivedAcceptChannel()
+	require.NoError(t, err)
+
+	var expected, actual bytes.Buffer
+	require.NoError(t, original.Encode(&expected, 0))
+	require.NoError(t, rebuilt.Encode(&actual, 0))
+	require.Equal(t, expected.Bytes(), actual.Bytes())
+
+	// We never receive an AcceptChannel for channels we don't initiate.
+	openChannels[0].IsInitiator = false
+	_, err = openChannels[0].RebuildReceivedAcceptChannel()
+	require.Equal(t, ErrNotChannelInitiator, err)
+}
+
 func assertCommitmentEqual(t *testing.T, a, b *ChannelCommitment) {
 	if !reflect.DeepEqual(a, b) {
 		_, _, line, _ := runtime.Caller(1)
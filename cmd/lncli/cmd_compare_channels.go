@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/urfave/cli"
+)
+
+// defaultCompareMaxRatio is the default factor by which the values of a
+// parameter may differ across the channels with a peer before the parameter is
+// flagged as inconsistent.
+const defaultCompareMaxRatio = 10
+
+var compareChannelsCommand = cli.Command{
+	Name:     "comparechannels",
+	Category: "Channels",
+	Usage: "Compare the negotiated parameters of all channels with a " +
+		"peer.",
+	Description: `
+	Compares the parameters negotiated when opening the channels with the
+	given peer, such as the channel reserves, dust limits and CSV delays
+	of both sides, to spot inconsistencies between them. For each
+	parameter the values of all channels are listed, and the parameter is
+	flagged as inconsistent if its largest value exceeds its smallest one
+	by more than the given ratio, or if it is zero for some channels only.
+	Note that the channel reserves scale with the capacity by default, so
+	they differ between channels of different sizes.
+
+	The local constraints are the ones our node must adhere to, which the
+	peer chose, and the remote constraints are the ones the peer must
+	adhere to, which our node chose.`,
+	ArgsUsage: "peer",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "peer",
+			Usage: "the 66-byte, hex-encoded pubkey of the peer " +
+				"to compare the channels with",
+		},
+		cli.Float64Flag{
+			Name: "max_ratio",
+			Usage: "the factor by which the values of a " +
+				"parameter may differ before it is flagged " +
+				"as inconsistent",
+			Value: defaultCompareMaxRatio,
+		},
+	},
+	Action: actionDecorator(compareChannels),
+}
+
+// ChannelParamValue is the value of a parameter of a single channel.
+type ChannelParamValue struct {
+	ChannelPoint string `json:"channel_point"`
+	Value        uint64 `json:"value"`
+}
+
+// ChannelParamComparison lists the values of a parameter across the channels
+// with a peer.
+type ChannelParamComparison struct {
+	Param        string              `json:"param"`
+	Min          uint64              `json:"min"`
+	Max          uint64              `json:"max"`
+	Inconsistent bool                `json:"inconsistent"`
+	Values       []ChannelParamValue `json:"values"`
+}
+
+// ChannelComparison is the result of comparing the channels with a peer.
+type ChannelComparison struct {
+	RemotePubkey string                    `json:"remote_pubkey"`
+	NumChannels  int                       `json:"num_channels"`
+	Params       []*ChannelParamComparison `json:"params"`
+}
+
+func compareChannels(ctx *cli.Context) error {
+	ctxc := getContext()
+	args := ctx.Args()
+
+	var peer string
+	switch {
+	case ctx.IsSet("peer"):
+		peer = ctx.String("peer")
+	case args.Present():
+		peer = args.First()
+	default:
+		return errors.New("peer argument missing")
+	}
+
+	peerKey, err := route.NewVertexFromStr(peer)
+	if err != nil {
+		return fmt.Errorf("invalid peer pubkey: %v", err)
+	}
+
+	maxRatio := ctx.Float64("max_ratio")
+	if maxRatio < 1 {
+		return errors.New("max_ratio must be at least 1")
+	}
+
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	resp, err := client.ListChannels(ctxc, &lnrpc.ListChannelsRequest{
+		Peer: peerKey[:],
+	})
+	if err != nil {
+		return err
+	}
+
+	comparison := compareChannelParams(resp.Channels, maxRatio)
+	comparison.RemotePubkey = peerKey.String()
+
+	printJSON(comparison)
+	return nil
+}
+
+// compareChannelParams compares the negotiated parameters of the given
+// channels, flagging the ones whose largest value exceeds the smallest one by
+// more than maxRatio. A parameter that is zero for some of the channels only
+// is always flagged.
+func compareChannelParams(channels []*lnrpc.Channel,
+	maxRatio float64) *ChannelComparison {
+
+	type param struct {
+		name  string
+		value func(*lnrpc.Channel) uint64
+	}
+
+	sides := []struct {
+		prefix      string
+		constraints func(*lnrpc.Channel) *lnrpc.ChannelConstraints
+	}{
+		{"local", (*lnrpc.Channel).GetLocalConstraints},
+		{"remote", (*lnrpc.Channel).GetRemoteConstraints},
+	}
+
+	var params []param
+	for _, side := range sides {
+		constraints := side.constraints
+		params = append(params, []param{{
+			name: side.prefix + "_chan_reserve_sat",
+			value: func(c *lnrpc.Channel) uint64 {
+				return constraints(c).GetChanReserveSat()
+			},
+		}, {
+			name: side.prefix + "_dust_limit_sat",
+			value: func(c *lnrpc.Channel) uint64 {
+				return constraints(c).GetDustLimitSat()
+			},
+		}, {
+			name: side.prefix + "_max_pending_amt_msat",
+			value: func(c *lnrpc.Channel) uint64 {
+				return constraints(c).GetMaxPendingAmtMsat()
+			},
+		}, {
+			name: side.prefix + "_min_htlc_msat",
+			value: func(c *lnrpc.Channel) uint64 {
+				return constraints(c).GetMinHtlcMsat()
+			},
+		}, {
+			name: side.prefix + "_max_accepted_htlcs",
+			value: func(c *lnrpc.Channel) uint64 {
+				return uint64(
+					constraints(c).GetMaxAcceptedHtlcs(),
+				)
+			},
+		}, {
+			name: side.prefix + "_csv_delay",
+			value: func(c *lnrpc.Channel) uint64 {
+				return uint64(constraints(c).GetCsvDelay())
+			},
+		}}...)
+	}
+
+	comparison := &ChannelComparison{
+		NumChannels: len(channels),
+	}
+	for _, p := range params {
+		paramComparison := &ChannelParamComparison{
+			Param: p.name,
+		}
+
+		for i, channel := range channels {
+			value := p.value(channel)
+			if i == 0 || value < paramComparison.Min {
+				paramComparison.Min = value
+			}
+			if value > paramComparison.Max {
+				paramComparison.Max = value
+			}
+
+			paramComparison.Values = append(
+				paramComparison.Values, ChannelParamValue{
+					ChannelPoint: channel.ChannelPoint,
+					Value:        value,
+				},
+			)
+		}
+
+		paramComparison.Inconsistent = float64(paramComparison.Max) >
+			float64(paramComparison.Min)*maxRatio
+
+		comparison.Params = append(comparison.Params, paramComparison)
+	}
+
+	return comparison
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompareChannelParams tests that a parameter is flagged as inconsistent
+// if its values differ by more than the max ratio across the channels.
+func TestCompareChannelParams(t *testing.T) {
+	constraints := func(reserve uint64) *lnrpc.ChannelConstraints {
+		return &lnrpc.ChannelConstraints{
+			CsvDelay:          144,
+			ChanReserveSat:    reserve,
+			DustLimitSat:      573,
+			MaxPendingAmtMsat: 990000000,
+			MinHtlcMsat:       1000,
+			MaxAcceptedHtlcs:  483,
+		}
+	}
+
+	// The peer requires a reserve from us in the second channel that is
+	// more than ten times the one of the first channel, while the
+	// reserves we require from the peer only differ slightly.
+	channels := []*lnrpc.Channel{
+		{
+			ChannelPoint:      "a:0",
+			LocalConstraints:  constraints(10000),
+			RemoteConstraints: constraints(10000),
+		},
+		{
+			ChannelPoint:      "b:1",
+			LocalConstraints:  constraints(150000),
+			RemoteConstraints: constraints(20000),
+		},
+	}
+
+	comparison := compareChannelParams(channels, defaultCompareMaxRatio)
+	require.Equal(t, 2, comparison.NumChannels)
+	require.Len(t, comparison.Params, 12)
+
+	params := make(map[string]*ChannelParamComparison)
+	for _, param := range comparison.Params {
+		params[param.Param] = param
+	}
+
+	require.Equal(t, &ChannelParamComparison{
+		Param:        "local_chan_reserve_sat",
+		Min:          10000,
+		Max:          150000,
+		Inconsistent: true,
+		Values: []ChannelParamValue{
+			{ChannelPoint: "a:0", Value: 10000},
+			{ChannelPoint: "b:1", Value: 150000},
+		},
+	}, params["local_chan_reserve_sat"])
+
+	remoteReserve := params["remote_chan_reserve_sat"]
+	require.False(t, remoteReserve.Inconsistent)
+	require.EqualValues(t, 10000, remoteReserve.Min)
+	require.EqualValues(t, 20000, remoteReserve.Max)
+
+	for name, param := range params {
+		if name == "local_chan_reserve_sat" {
+			continue
+		}
+		require.False(t, param.Inconsistent, name)
+	}
+
+	// With a larger max ratio, the reserves are consistent as well.
+	comparison = compareChannelParams(channels, 20)
+	for _, param := range comparison.Params {
+		require.False(t, param.Inconsistent, param.Param)
+	}
+
+	// A parameter that is zero for some channels only is inconsistent.
+	channels[0].RemoteConstraints.ChanReserveSat = 0
+	comparison = compareChannelParams(channels, 20)
+	for _, param := range comparison.Params {
+		require.Equal(
+			t, param.Param == "remote_chan_reserve_sat",
+			param.Inconsistent, param.Param,
+		)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/urfave/cli"
+)
+
+var diagnoseAcceptChannelCommand = cli.Command{
+	Name:     "diagnoseacceptchannel",
+	Category: "Channels",
+	Usage: "Estimate the cost of force closing a channel we opened and " +
+		"compare it to the channel reserve.",
+	Description: `
+	Estimate the cost of force closing a channel we initiated at the
+	current fee rate, based on the parameters the remote peer set in its
+	AcceptChannel message. The channel is flagged as uneconomical if the
+	force close cost exceeds the channel reserve the peer requires us to
+	keep, as the part of our balance we can always claim then wouldn't
+	cover the cost of claiming it on chain.`,
+	ArgsUsage: "funding_txid [output_index]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "funding_txid",
+			Usage: "the txid of the channel's funding transaction",
+		},
+		cli.IntFlag{
+			Name: "output_index",
+			Usage: "the output index for the funding output of the funding " +
+				"transaction",
+		},
+	},
+	Action: actionDecorator(diagnoseAcceptChannel),
+}
+
+func diagnoseAcceptChannel(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	if ctx.NArg() == 0 && ctx.NumFlags() == 0 {
+		_ = cli.ShowCommandHelp(ctx, "diagnoseacceptchannel")
+		return nil
+	}
+
+	channelPoint, err := parseChannelPoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := &lnrpc.DiagnoseAcceptChannelRequest{
+		ChannelPoint: channelPoint,
+	}
+	resp, err := client.DiagnoseAcceptChannel(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
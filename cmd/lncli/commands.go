@@ -1359,6 +1359,30 @@ func pendingChannels(ctx *cli.Context) error {
 	return nil
 }
 
+var listPendingNegotiationsCommand = cli.Command{
+	Name:     "listpendingnegotiations",
+	Category: "Channels",
+	Usage: "List the funding negotiations for which no funding " +
+		"transaction exists yet.",
+	Action: actionDecorator(listPendingNegotiations),
+}
+
+func listPendingNegotiations(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	req := &lnrpc.ListPendingNegotiationsRequest{}
+	resp, err := client.ListPendingNegotiations(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
+
 var listChannelsCommand = cli.Command{
 	Name:     "listchannels",
 	Category: "Channels",
@@ -343,6 +343,7 @@ func main() {
 		getInfoCommand,
 		getRecoveryInfoCommand,
 		pendingChannelsCommand,
+		listPendingNegotiationsCommand,
 		sendPaymentCommand,
 		payInvoiceCommand,
 		sendToRouteCommand,
@@ -350,6 +351,8 @@ func main() {
 		lookupInvoiceCommand,
 		listInvoicesCommand,
 		listChannelsCommand,
+		compareChannelsCommand,
+		diagnoseAcceptChannelCommand,
 		closedChannelsCommand,
 		listPaymentsCommand,
 		describeGraphCommand,
@@ -271,6 +271,7 @@ type Config struct {
 	UnsafeDisconnect   bool   `long:"unsafe-disconnect" description:"DEPRECATED: Allows the rpcserver to intentionally disconnect from peers with open channels. THIS FLAG WILL BE REMOVED IN 0.10.0"`
 	UnsafeReplay       bool   `long:"unsafe-replay" description:"Causes a link to replay the adds on its commitment txn after starting up, this enables testing of the sphinx replay logic."`
 	MaxPendingChannels int    `long:"maxpendingchannels" description:"The maximum number of incoming pending channels permitted per peer."`
+	MaxChannels        int    `long:"max-channels" description:"The maximum number of channels, pending or open, permitted in total across all peers. Both channels opened by peers and by us count towards the limit. Set to 0 for no limit."`
 	BackupFilePath     string `long:"backupfilepath" description:"The target location of the channel backup file"`
 
 	FeeURL string `long:"feeurl" description:"Optional URL for external fee estimation. If no URL is specified, the method for fee estimation will depend on the chosen backend and network. Must be set for neutrino on mainnet."`
@@ -320,6 +321,7 @@ type Config struct {
 	ChannelCommitBatchSize uint32        `long:"channel-commit-batch-size" description:"The maximum number of channel state updates that is accumulated before signing a new commitment."`
 
 	DefaultRemoteMaxHtlcs uint16 `long:"default-remote-max-htlcs" description:"The default max_htlc applied when opening or accepting channels. This value limits the number of concurrent HTLCs that the remote party can add to the commitment. The maximum possible value is 483."`
+	MinRemoteMaxHtlcs     uint16 `long:"min-remote-max-htlcs" description:"The minimum max_htlc we'll accept from a peer accepting a channel we've initiated. Channels limiting the number of concurrent HTLCs we can add to the commitment below this value are rejected. The maximum possible value is 483."`
 
 	NumGraphSyncPeers      int           `long:"numgraphsyncpeers" description:"The number of peers that we should receive new graph updates from. This option can be tuned to save bandwidth for light clients or routing nodes."`
 	HistoricalSyncInterval time.Duration `long:"historicalsyncinterval" description:"The polling interval between historical graph sync attempts. Each historical graph sync attempt ensures we reconcile with the remote peer's graph from the genesis block."`
@@ -328,6 +330,8 @@ type Config struct {
 
 	RejectPush bool `long:"rejectpush" description:"If true, lnd will not accept channel opening requests with non-zero push amounts. This should prevent accidental pushes to merchant nodes."`
 
+	RequireChannelType string `long:"require-channel-type" description:"If set, lnd will only accept channel opening requests that result in a channel of the given commitment type. Requests resulting in another commitment type are rejected." choice:"legacy" choice:"tweakless" choice:"anchors"`
+
 	RejectHTLC bool `long:"rejecthtlc" description:"If true, lnd will not forward any HTLCs that are meant as onward payments. This option will still allow lnd to send HTLCs and receive HTLCs but lnd won't be used as a hop."`
 
 	StaggerInitialReconnect bool `long:"stagger-initial-reconnect" description:"If true, will apply a randomized staggering between 0s and 30s when reconnecting to persistent peers on startup. The first 10 reconnections will be attempted instantly, regardless of the flag's value"`
@@ -344,6 +348,22 @@ type Config struct {
 
 	EnableUpfrontShutdown bool `long:"enable-upfront-shutdown" description:"If true, option upfront shutdown script will be enabled. If peers that we open channels with support this feature, we will automatically set the script to which cooperative closes should be paid out to on channel open. This offers the partial protection of a channel peer disconnecting from us if cooperative close is attempted with a different script."`
 
+	RequireRemoteUpfrontShutdown bool `long:"require-remote-upfront-shutdown" description:"If true, peers accepting a channel we've initiated must commit to a non-empty upfront shutdown script, otherwise the channel is rejected. Peers that don't support option upfront shutdown script are unable to accept our channels."`
+
+	MinAbsoluteReserve int64 `long:"min-absolute-reserve" description:"The smallest channel reserve in satoshis that we require our peers to maintain, and that we agree to maintain ourselves, regardless of the channel capacity. Channels whose peer requires a smaller reserve from us are rejected. A value of zero disables the floor."`
+
+	AllowZeroReserve []string `long:"allow-zero-reserve" description:"The hex-encoded public key of a trusted peer that doesn't need to maintain a channel reserve. We require no reserve from such a peer, and accept to maintain no reserve ourselves if the peer enables this option for us too, giving up the penalty for broadcasting a revoked state. Can be specified multiple times."`
+
+	RejectIdentityFundingKey bool `long:"reject-identity-funding-key" description:"If true, peers accepting a channel we've initiated must use a funding key distinct from their node identity key, otherwise the channel is rejected."`
+
+	RejectExcessMaxValueInFlight bool `long:"reject-excess-max-value-in-flight" description:"If true, peers accepting a channel we've initiated must set a max value in flight that is at least their minimum HTLC value and doesn't exceed the channel capacity, otherwise the channel is rejected. Many implementations signal an unbounded max value in flight with a value exceeding the capacity, so these peers are unable to accept our channels."`
+
+	MaxCommitFeeAllocation float64 `long:"max-commit-fee-allocation" description:"The largest fraction of our balance the commitment fee may take up once a peer accepting a channel we've initiated fills it with as many HTLCs as it allows, at the fee rate we proposed. Channels exceeding it are rejected. Valid values are within [0, 1], zero disables the check."`
+
+	AllowCommitTypeDowngrade bool `long:"allow-commit-type-downgrade" description:"If true, peers accepting a channel we've initiated with an older commitment type than the one we proposed are followed, and the channel is created with the older type. Otherwise, the channel is rejected."`
+
+	ShutdownScriptCompat []string `long:"shutdown-script-compat" description:"The hex-encoded public key of a known-buggy peer that encodes upfront shutdown scripts in OpenChannel and AcceptChannel messages with a two byte length prefix. Such scripts from this peer are recovered, rather than failing the funding flow. Can be specified multiple times."`
+
 	AcceptKeySend bool `long:"accept-keysend" description:"If true, spontaneous payments through keysend will be accepted. [experimental]"`
 
 	AcceptAMP bool `long:"accept-amp" description:"If true, spontaneous payments via AMP will be accepted."`
@@ -816,6 +836,13 @@ func ValidateConfig(cfg Config, usageMessage string,
 			cfg.MaxChannelFeeAllocation)
 	}
 
+	// Ensure a valid max commit fee allocation was set, if any.
+	if cfg.MaxCommitFeeAllocation < 0 || cfg.MaxCommitFeeAllocation > 1 {
+		return nil, fmt.Errorf("invalid max commit fee allocation: "+
+			"%v, must be within [0, 1]",
+			cfg.MaxCommitFeeAllocation)
+	}
+
 	if cfg.MaxCommitFeeRateAnchors < 1 {
 		return nil, fmt.Errorf("invalid max commit fee rate anchors: "+
 			"%v, must be at least 1 sat/vbyte",
@@ -1451,6 +1478,15 @@ func ValidateConfig(cfg Config, usageMessage string,
 			"less than %v", cfg.DefaultRemoteMaxHtlcs,
 			maxRemoteHtlcs)
 	}
+	if cfg.MinRemoteMaxHtlcs > maxRemoteHtlcs {
+		return nil, fmt.Errorf("min-remote-max-htlcs (%v) must be "+
+			"less than %v", cfg.MinRemoteMaxHtlcs, maxRemoteHtlcs)
+	}
+
+	if cfg.MaxChannels < 0 {
+		return nil, fmt.Errorf("max-channels (%v) must not be negative",
+			cfg.MaxChannels)
+	}
 
 	if err := cfg.Gossip.Parse(); err != nil {
 		return nil, err
@@ -126,3 +126,40 @@ func testManager(t *testing.T, test managerTest) {
 		assertSet(lnwire.StaticRemoteKeyOptional)
 	}
 }
+
+// TestZeroConfNotAdvertised asserts that the zero-conf feature isn't
+// advertised in any set, regardless of the config, as lnd can't use a channel
+// before its funding transaction confirmed. Peers are therefore never offered
+// zero-conf channels.
+func TestZeroConfNotAdvertised(t *testing.T) {
+	configs := []Config{
+		{},
+		{NoTLVOnion: true},
+		{NoStaticRemoteKey: true},
+		{NoAnchors: true},
+		{NoWumbo: true},
+	}
+
+	sets := []Set{
+		SetInit,
+		SetLegacyGlobal,
+		SetNodeAnn,
+		SetInvoice,
+		SetInvoiceAmp,
+	}
+
+	for _, cfg := range configs {
+		m, err := NewManager(cfg)
+		if err != nil {
+			t.Fatalf("unable to create feature manager: %v", err)
+		}
+
+		for _, set := range sets {
+			fv := m.Get(set)
+			if fv.HasFeature(lnwire.ZeroConfOptional) {
+				t.Fatalf("zero-conf advertised in %v with "+
+					"config %+v", set, cfg)
+			}
+		}
+	}
+}
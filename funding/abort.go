@@ -0,0 +1,95 @@
+package funding
+
+import (
+	"errors"
+)
+
+var (
+	// ErrUnknownPendingFunding is returned by AbortFunding if no funding
+	// negotiation with the given pending channel ID is in progress.
+	ErrUnknownPendingFunding = errors.New("no funding negotiation with " +
+		"the given pending channel ID in progress")
+
+	// ErrAcceptChannelPending is returned by AbortFunding if the remote
+	// party didn't accept the channel yet.
+	ErrAcceptChannelPending = errors.New("remote party didn't accept " +
+		"the channel yet")
+
+	// ErrFundingCreatedSent is returned by AbortFunding if the
+	// FundingCreated message was already sent to the remote party, after
+	// which the funding negotiation can no longer be aborted.
+	ErrFundingCreatedSent = errors.New("funding created message already " +
+		"sent")
+)
+
+// fundingAbortedError is the error the funding flow of a reservation is failed
+// with when it is aborted through AbortFunding.
+type fundingAbortedError struct{}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (fundingAbortedError) Error() string {
+	return "funding negotiation aborted"
+}
+
+// AbortFunding aborts the funding negotiation identified by the given pending
+// channel ID, for which the remote party already sent its AcceptChannel, but
+// we haven't sent the FundingCreated message yet. The reservation is canceled,
+// which releases the coins locked for it, and the remote party is notified
+// with an error message.
+func (f *Manager) AbortFunding(pendingChanID [32]byte) error {
+	resCtx, err := f.markFundingAborted(pendingChanID)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Aborting funding negotiation for pending_id(%x) with "+
+		"peer(%x)", pendingChanID[:],
+		resCtx.peer.IdentityKey().SerializeCompressed())
+
+	f.failFundingFlow(resCtx.peer, pendingChanID, fundingAbortedError{})
+
+	return nil
+}
+
+// markFundingAborted marks the reservation of the funding negotiation
+// identified by the given pending channel ID as aborted, if it can still be
+// aborted. The checks and the mark are done under the resMtx, which
+// continueFundingAccept holds while deciding whether to send the
+// FundingCreated message, so either the negotiation is aborted before the
+// message is sent, or the abort fails with ErrFundingCreatedSent.
+func (f *Manager) markFundingAborted(
+	pendingChanID [32]byte) (*reservationWithCtx, error) {
+
+	f.resMtx.Lock()
+	defer f.resMtx.Unlock()
+
+	var resCtx *reservationWithCtx
+	for _, nodeReservations := range f.activeReservations {
+		if ctx, ok := nodeReservations[pendingChanID]; ok {
+			resCtx = ctx
+			break
+		}
+	}
+	if resCtx == nil {
+		return nil, ErrUnknownPendingFunding
+	}
+
+	// Once the FundingCreated message is sent, the reservation is tracked
+	// under the permanent channel ID until the FundingSigned message is
+	// received.
+	for _, signedID := range f.signedReservations {
+		if signedID == pendingChanID {
+			return nil, ErrFundingCreatedSent
+		}
+	}
+
+	if !resCtx.hasAcceptMsg() {
+		return nil, ErrAcceptChannelPending
+	}
+
+	resCtx.aborted = true
+
+	return resCtx, nil
+}
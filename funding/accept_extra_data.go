@@ -0,0 +1,63 @@
+package funding
+
+import (
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// storeAcceptExtraData records the TLV records of the AcceptChannel of the
+// given reservation that we don't know about, under the funding outpoint of
+// the channel. Nothing is recorded if the reservation has no AcceptChannel or
+// all of its records are known.
+func (f *Manager) storeAcceptExtraData(chanPoint wire.OutPoint,
+	resCtx *reservationWithCtx) {
+
+	resCtx.updateMtx.RLock()
+	accept := resCtx.acceptMsg
+	resCtx.updateMtx.RUnlock()
+
+	if accept == nil {
+		return
+	}
+
+	unknown, err := accept.UnknownExtraData()
+	if err != nil {
+		log.Warnf("Unable to extract unknown records of AcceptChannel "+
+			"for ChannelPoint(%v): %v", chanPoint, err)
+		return
+	}
+	if len(unknown) == 0 {
+		return
+	}
+
+	log.Debugf("AcceptChannel for ChannelPoint(%v) carries unknown "+
+		"records: %x", chanPoint, unknown)
+
+	f.acceptExtraDataMtx.Lock()
+	f.acceptExtraData[chanPoint] = unknown
+	f.acceptExtraDataMtx.Unlock()
+}
+
+// deleteAcceptExtraData forgets the unknown AcceptChannel records of the
+// channel with the given funding outpoint.
+func (f *Manager) deleteAcceptExtraData(chanPoint wire.OutPoint) {
+	f.acceptExtraDataMtx.Lock()
+	delete(f.acceptExtraData, chanPoint)
+	f.acceptExtraDataMtx.Unlock()
+}
+
+// AcceptExtraData returns the TLV records of the AcceptChannel the remote
+// party sent for the pending channel with the given funding outpoint that are
+// left after extracting the records we know about. The boolean is false if no
+// such records are known, which is also the case for channels the remote
+// party initiated, and for channels that were pending before a restart, as
+// the records are kept in memory only.
+func (f *Manager) AcceptExtraData(
+	chanPoint wire.OutPoint) (lnwire.ExtraOpaqueData, bool) {
+
+	f.acceptExtraDataMtx.RLock()
+	defer f.acceptExtraDataMtx.RUnlock()
+
+	unknown, ok := f.acceptExtraData[chanPoint]
+	return unknown, ok
+}
@@ -0,0 +1,126 @@
+package funding
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// runAcceptChannelHook invokes the configured AcceptChannelHook with the
+// AcceptChannel we're about to send in response to the given OpenChannel, and
+// validates the message again afterwards. The hook must not touch the fields
+// our reservation is already committed to, and the policy fields it adjusted
+// must stay within the bounds the initiator is expected to accept.
+func (f *Manager) runAcceptChannelHook(accept *lnwire.AcceptChannel,
+	openMsg *lnwire.OpenChannel) error {
+
+	orig := *accept
+	if err := f.cfg.AcceptChannelHook(accept); err != nil {
+		return err
+	}
+
+	// The hook may only add records to the ExtraData, and leave the
+	// fields describing our contribution alone.
+	switch {
+	case accept.PendingChannelID != orig.PendingChannelID:
+		return errHookModified("PendingChannelID")
+
+	case accept.DustLimit != orig.DustLimit:
+		return errHookModified("DustLimit")
+
+	case !sameKey(accept.FundingKey, orig.FundingKey):
+		return errHookModified("FundingKey")
+
+	case !sameKey(accept.RevocationPoint, orig.RevocationPoint):
+		return errHookModified("RevocationPoint")
+
+	case !sameKey(accept.PaymentPoint, orig.PaymentPoint):
+		return errHookModified("PaymentPoint")
+
+	case !sameKey(accept.DelayedPaymentPoint, orig.DelayedPaymentPoint):
+		return errHookModified("DelayedPaymentPoint")
+
+	case !sameKey(accept.HtlcPoint, orig.HtlcPoint):
+		return errHookModified("HtlcPoint")
+
+	case !sameKey(accept.FirstCommitmentPoint, orig.FirstCommitmentPoint):
+		return errHookModified("FirstCommitmentPoint")
+
+	case !bytes.Equal(
+		accept.UpfrontShutdownScript, orig.UpfrontShutdownScript,
+	):
+		return errHookModified("UpfrontShutdownScript")
+	}
+
+	// The policy fields must be acceptable to the initiator, as they
+	// would fail the funding flow otherwise.
+	if accept.MinAcceptDepth == 0 {
+		return fmt.Errorf("AcceptChannel hook set MinAcceptDepth " +
+			"to zero")
+	}
+	if accept.MinAcceptDepth > chainntnfs.MaxNumConfs {
+		return lnwallet.ErrNumConfsTooLarge(
+			accept.MinAcceptDepth, chainntnfs.MaxNumConfs,
+		)
+	}
+
+	dustLimit := accept.DustLimit
+	if openMsg.DustLimit > dustLimit {
+		dustLimit = openMsg.DustLimit
+	}
+	if accept.ChannelReserve < dustLimit {
+		return lnwallet.ErrChanReserveTooSmall(
+			accept.ChannelReserve, dustLimit,
+		)
+	}
+
+	maxChanReserve := openMsg.FundingAmount / 5
+	if accept.ChannelReserve > maxChanReserve {
+		return lnwallet.ErrChanReserveTooLarge(
+			accept.ChannelReserve, maxChanReserve,
+		)
+	}
+
+	if accept.HtlcMinimum > accept.MaxValueInFlight {
+		return lnwallet.ErrMinHtlcTooLarge(
+			accept.HtlcMinimum, accept.MaxValueInFlight,
+		)
+	}
+
+	maxHtlcs := uint16(input.MaxHTLCNumber / 2)
+	if accept.MaxAcceptedHTLCs > maxHtlcs {
+		return lnwallet.ErrMaxHtlcNumTooLarge(
+			accept.MaxAcceptedHTLCs, maxHtlcs,
+		)
+	}
+
+	// Finally, make sure the message can still be encoded, which asserts
+	// that any records added to the ExtraData are well formed.
+	var b bytes.Buffer
+	if _, err := lnwire.WriteMessage(&b, accept, 0); err != nil {
+		return fmt.Errorf("unable to encode AcceptChannel after "+
+			"hook: %v", err)
+	}
+
+	return nil
+}
+
+// errHookModified returns the error for an AcceptChannelHook that modified a
+// field it isn't allowed to touch.
+func errHookModified(field string) error {
+	return fmt.Errorf("AcceptChannel hook modified %v", field)
+}
+
+// sameKey returns true if both public keys are nil or equal.
+func sameKey(a, b *btcec.PublicKey) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.IsEqual(b)
+}
@@ -0,0 +1,173 @@
+package funding
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// fundingLatencyBucket is the database bucket used to store how long
+	// our peers took to complete the funding of the channels we opened
+	// with them. Each entry is keyed by the identity key of the peer.
+	fundingLatencyBucket = []byte("fundingLatency")
+)
+
+// FundingLatency describes how long a peer took to complete the funding of
+// the channels we opened with it, measured from sending our FundingCreated
+// until the receipt of its FundingSigned. As the FundingCreated is only sent
+// once we funded the channel, the time it took us to do so, e.g. to finalize
+// a PSBT, isn't included.
+type FundingLatency struct {
+	// NumFundings is the number of fundings the latency was recorded for.
+	NumFundings uint32
+
+	// Last is the latency of the last funding.
+	Last time.Duration
+
+	// Min is the lowest latency of all fundings.
+	Min time.Duration
+
+	// Max is the highest latency of all fundings.
+	Max time.Duration
+
+	// Total is the sum of the latencies of all fundings.
+	Total time.Duration
+
+	// LastRecorded is the time the latency of the last funding was
+	// recorded at.
+	LastRecorded time.Time
+}
+
+// Average returns the average latency of all fundings.
+func (l *FundingLatency) Average() time.Duration {
+	if l.NumFundings == 0 {
+		return 0
+	}
+
+	return l.Total / time.Duration(l.NumFundings)
+}
+
+// add accounts for a funding with the given latency, recorded at the given
+// time.
+func (l *FundingLatency) add(latency time.Duration, recordedAt time.Time) {
+	if l.NumFundings == 0 || latency < l.Min {
+		l.Min = latency
+	}
+	if latency > l.Max {
+		l.Max = latency
+	}
+
+	l.NumFundings++
+	l.Last = latency
+	l.Total += latency
+	l.LastRecorded = recordedAt
+}
+
+// serialize writes the funding latency to the given buffer.
+func (l *FundingLatency) serialize(w *bytes.Buffer) error {
+	return channeldb.WriteElements(w,
+		l.NumFundings, uint64(l.Last), uint64(l.Min), uint64(l.Max),
+		uint64(l.Total), uint64(l.LastRecorded.UnixNano()),
+	)
+}
+
+// deserializeFundingLatency reads a funding latency written by serialize.
+func deserializeFundingLatency(r *bytes.Reader) (*FundingLatency, error) {
+	var (
+		last, min, max, total, lastRecorded uint64
+		l                                   FundingLatency
+	)
+	err := channeldb.ReadElements(r,
+		&l.NumFundings, &last, &min, &max, &total, &lastRecorded,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Last = time.Duration(last)
+	l.Min = time.Duration(min)
+	l.Max = time.Duration(max)
+	l.Total = time.Duration(total)
+	l.LastRecorded = time.Unix(0, int64(lastRecorded))
+
+	return &l, nil
+}
+
+// recordFundingLatency adds the time elapsed since the FundingCreated of the
+// given reservation was sent to the funding latency of the given peer.
+// Nothing is recorded if we didn't send a FundingCreated, which is the case
+// for channels the remote party initiated.
+func (f *Manager) recordFundingLatency(peerKey *btcec.PublicKey,
+	resCtx *reservationWithCtx) error {
+
+	resCtx.updateMtx.RLock()
+	sentAt := resCtx.fundingCreatedSent
+	resCtx.updateMtx.RUnlock()
+
+	if sentAt.IsZero() {
+		return nil
+	}
+
+	now := time.Now()
+	latency := now.Sub(sentAt)
+
+	return kvdb.Update(f.cfg.Wallet.Cfg.Database, func(tx kvdb.RwTx) error {
+		bucket, err := tx.CreateTopLevelBucket(fundingLatencyBucket)
+		if err != nil {
+			return err
+		}
+
+		key := peerKey.SerializeCompressed()
+
+		l := &FundingLatency{}
+		if value := bucket.Get(key); value != nil {
+			l, err = deserializeFundingLatency(bytes.NewReader(value))
+			if err != nil {
+				return err
+			}
+		}
+		l.add(latency, now)
+
+		var b bytes.Buffer
+		if err := l.serialize(&b); err != nil {
+			return err
+		}
+
+		return bucket.Put(key, b.Bytes())
+	}, func() {})
+}
+
+// FundingLatency returns how long the given peer took to complete the funding
+// of the channels we opened with it. The latency is persisted across
+// restarts. Nil is returned if no latency was recorded for the peer.
+func (f *Manager) FundingLatency(peerKey *btcec.PublicKey) (*FundingLatency,
+	error) {
+
+	var l *FundingLatency
+	err := kvdb.View(f.cfg.Wallet.Cfg.Database, func(tx kvdb.RTx) error {
+		bucket := tx.ReadBucket(fundingLatencyBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		value := bucket.Get(peerKey.SerializeCompressed())
+		if value == nil {
+			return nil
+		}
+
+		var err error
+		l, err = deserializeFundingLatency(bytes.NewReader(value))
+		return err
+	}, func() {
+		l = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
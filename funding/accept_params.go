@@ -0,0 +1,64 @@
+package funding
+
+import (
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// storeAcceptMsg records the AcceptChannel of the given reservation under the
+// funding outpoint of the channel. Nothing is recorded if the reservation has
+// no AcceptChannel, which is the case for channels the remote party initiated.
+func (f *Manager) storeAcceptMsg(chanPoint wire.OutPoint,
+	resCtx *reservationWithCtx) {
+
+	resCtx.updateMtx.RLock()
+	accept := resCtx.acceptMsg
+	resCtx.updateMtx.RUnlock()
+
+	if accept == nil {
+		return
+	}
+
+	f.acceptMsgsMtx.Lock()
+	f.acceptMsgs[chanPoint] = accept
+	f.acceptMsgsMtx.Unlock()
+}
+
+// deleteAcceptMsg forgets the AcceptChannel of the channel with the given
+// funding outpoint.
+func (f *Manager) deleteAcceptMsg(chanPoint wire.OutPoint) {
+	f.acceptMsgsMtx.Lock()
+	delete(f.acceptMsgs, chanPoint)
+	f.acceptMsgsMtx.Unlock()
+}
+
+// AcceptMsg returns the AcceptChannel the remote party sent for the pending
+// channel with the given funding outpoint. The boolean is false for channels
+// the remote party initiated, and for channels that were pending before a
+// restart, as the messages are kept in memory only. The returned message must
+// not be modified.
+func (f *Manager) AcceptMsg(
+	chanPoint wire.OutPoint) (*lnwire.AcceptChannel, bool) {
+
+	f.acceptMsgsMtx.RLock()
+	defer f.acceptMsgsMtx.RUnlock()
+
+	accept, ok := f.acceptMsgs[chanPoint]
+	return accept, ok
+}
+
+// deleteAcceptState forgets the AcceptChannel and its unknown records kept
+// for the channel with the given funding outpoint, once the channel is no
+// longer pending.
+func (f *Manager) deleteAcceptState(chanPoint wire.OutPoint) {
+	f.deleteAcceptMsg(chanPoint)
+	f.deleteAcceptExtraData(chanPoint)
+}
+
+// ForgetAbandonedChannel forgets the AcceptChannel and its unknown records
+// kept for the pending channel with the given funding outpoint. It must be
+// called once the channel is abandoned, as the funding flow never sees it
+// open in that case.
+func (f *Manager) ForgetAbandonedChannel(chanPoint wire.OutPoint) {
+	f.deleteAcceptState(chanPoint)
+}
@@ -0,0 +1,114 @@
+package funding
+
+import (
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// AcceptPolicy is the policy a peer applied when accepting our channels, as
+// observed in the last AcceptChannel it sent us.
+type AcceptPolicy struct {
+	// Params are the channel constraints of the last AcceptChannel.
+	Params lnwire.OpenChannelParams
+
+	// MinAcceptDepth is the number of confirmations the peer required.
+	MinAcceptDepth uint32
+
+	// Capacity is the capacity of the channel the peer accepted, which the
+	// amounts of the Params relate to.
+	Capacity btcutil.Amount
+
+	// NumAccepted is the number of channels the peer accepted since we
+	// started.
+	NumAccepted uint32
+
+	// LastAccepted is the time the peer last accepted a channel.
+	LastAccepted time.Time
+}
+
+// PreviewAccept returns the AcceptChannel the peer is expected to respond
+// with to a channel of the given capacity, based on its policy. The channel
+// reserve and the maximum value in flight are scaled by the capacity relative
+// to the one of the last accepted channel, as most implementations derive
+// them from the capacity. The keys of the message aren't set, so it can only
+// be used to preview the constraints of the channel, e.g. with
+// lnwire.ValidatePush or lnwire.ImpliedMaxHtlc.
+func (p *AcceptPolicy) PreviewAccept(
+	capacity btcutil.Amount) *lnwire.AcceptChannel {
+
+	scale := func(amt uint64) uint64 {
+		if p.Capacity == 0 {
+			return amt
+		}
+
+		return uint64(float64(amt) * float64(capacity) /
+			float64(p.Capacity))
+	}
+
+	// The reserve can't be below the dust limit, which doesn't depend on
+	// the capacity.
+	reserve := btcutil.Amount(scale(uint64(p.Params.ChannelReserve)))
+	if p.Params.ChannelReserve != 0 && reserve < p.Params.DustLimit {
+		reserve = p.Params.DustLimit
+	}
+
+	maxValueInFlight := lnwire.MilliSatoshi(
+		scale(uint64(p.Params.MaxValueInFlight)),
+	)
+	if maxValueInFlight > lnwire.NewMSatFromSatoshis(capacity) {
+		maxValueInFlight = lnwire.NewMSatFromSatoshis(capacity)
+	}
+
+	return &lnwire.AcceptChannel{
+		DustLimit:        p.Params.DustLimit,
+		MaxValueInFlight: maxValueInFlight,
+		ChannelReserve:   reserve,
+		HtlcMinimum:      p.Params.HtlcMinimum,
+		MinAcceptDepth:   p.MinAcceptDepth,
+		CsvDelay:         p.Params.CsvDelay,
+		MaxAcceptedHTLCs: p.Params.MaxAcceptedHTLCs,
+	}
+}
+
+// cacheAcceptPolicy records the parameters of the AcceptChannel the given peer
+// sent for a channel of the given capacity as its accept policy, replacing the
+// one of the channels it accepted before.
+func (f *Manager) cacheAcceptPolicy(peerKey *btcec.PublicKey,
+	capacity btcutil.Amount, msg *lnwire.AcceptChannel) {
+
+	key := newSerializedKey(peerKey)
+
+	f.acceptPoliciesMtx.Lock()
+	defer f.acceptPoliciesMtx.Unlock()
+
+	var numAccepted uint32
+	if prev, ok := f.acceptPolicies[key]; ok {
+		numAccepted = prev.NumAccepted
+	}
+
+	f.acceptPolicies[key] = &AcceptPolicy{
+		Params:         msg.ToOpenParams(),
+		MinAcceptDepth: msg.MinAcceptDepth,
+		Capacity:       capacity,
+		NumAccepted:    numAccepted + 1,
+		LastAccepted:   time.Now(),
+	}
+}
+
+// AcceptPolicy returns the policy the given peer applied when it last
+// accepted one of our channels. The boolean is false if the peer didn't accept
+// a channel since we started.
+func (f *Manager) AcceptPolicy(peerKey *btcec.PublicKey) (AcceptPolicy, bool) {
+	f.acceptPoliciesMtx.RLock()
+	defer f.acceptPoliciesMtx.RUnlock()
+
+	policy, ok := f.acceptPolicies[newSerializedKey(peerKey)]
+	if !ok {
+		return AcceptPolicy{}, false
+	}
+
+	return *policy, true
+}
@@ -0,0 +1,70 @@
+package funding
+
+import (
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/lnpeer"
+)
+
+// acceptRejectionDescriptions maps the reason labels of AcceptChannel
+// rejections caused by the content of the message to a description that is
+// sent to the peer, such that it understands why its AcceptChannel was
+// rejected. Rejections caused by reservation errors are already described by
+// the error itself, while rejections without a dedicated label may be caused
+// by internal errors we don't want to disclose.
+var acceptRejectionDescriptions = map[string]string{
+	rejectReasonMalformed:             "malformed AcceptChannel",
+	rejectReasonDuplicatePubKey:       "invalid AcceptChannel keys",
+	rejectReasonFundingKeyIsIdentity:  "funding key is node identity key",
+	rejectReasonUpfrontShutdownAbsent: "invalid upfront shutdown script",
+	rejectReasonUnknownChannelType:    "unsupported channel type",
+	rejectReasonDustLimitBelowScript:  "unacceptable dust limit",
+	rejectReasonMaxValueInFlight:      "unacceptable max value in flight",
+	rejectReasonFeeRateRange:          "unacceptable fee rate range",
+	rejectReasonAnchorReserve:         "unacceptable anchor reserve",
+	rejectReasonReserveAsymmetry:      "unacceptable reserve asymmetry",
+	rejectReasonCommitPointReuse:      "reused first commitment point",
+	rejectReasonPendingChanIDInUse:    "duplicate AcceptChannel",
+	rejectReasonUnexpectedZeroConf:    "unexpected zero-conf",
+}
+
+// acceptRejectedError is the error a funding flow is failed with when we
+// reject an AcceptChannel because of its content. As opposed to most other
+// errors, it is sent to the peer verbatim.
+type acceptRejectedError struct {
+	// description describes the reason of the rejection.
+	description string
+
+	// err is the error the AcceptChannel was rejected with.
+	err error
+}
+
+// Error returns the description of the rejection along with the underlying
+// error.
+//
+// NOTE: implements the error interface.
+func (e *acceptRejectedError) Error() string {
+	return fmt.Sprintf("%v: %v", e.description, e.err)
+}
+
+// Unwrap returns the error the AcceptChannel was rejected with.
+func (e *acceptRejectedError) Unwrap() error {
+	return e.err
+}
+
+// rejectAccept records the rejection of an AcceptChannel for the given reason
+// and fails the funding flow. If the reason has a description, the peer is
+// sent an Error carrying the description and the rejection error.
+func (f *Manager) rejectAccept(peer lnpeer.Peer, pendingChanID [32]byte,
+	reason string, err error) {
+
+	f.notifyAcceptRejected(peer.IdentityKey(), pendingChanID, reason, err)
+
+	if description, ok := acceptRejectionDescriptions[reason]; ok {
+		err = &acceptRejectedError{
+			description: description,
+			err:         err,
+		}
+	}
+	f.failFundingFlow(peer, pendingChanID, err)
+}
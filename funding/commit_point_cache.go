@@ -0,0 +1,145 @@
+package funding
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightninglabs/neutrino/cache/lru"
+)
+
+const (
+	// maxCommitPointPeers is the number of peers whose first commitment
+	// points are remembered. Once exceeded, the points of the peer that
+	// sent us one least recently are forgotten.
+	maxCommitPointPeers = 1024
+)
+
+// ErrCommitPointReused is returned when a peer sends a first commitment
+// point it already sent us for an earlier channel, which points to a bug in
+// its derivation of the per-commitment secrets.
+type ErrCommitPointReused struct {
+	// Point is the reused first commitment point.
+	Point *btcec.PublicKey
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrCommitPointReused) Error() string {
+	return fmt.Sprintf("first commitment point %x was already used for "+
+		"an earlier channel", e.Point.SerializeCompressed())
+}
+
+// seenCommitPoint is the value stored for each point in the LRU of a peer.
+type seenCommitPoint struct{}
+
+// Size returns the size of the entry, as each point counts as one.
+//
+// NOTE: Part of the cache.Value interface.
+func (seenCommitPoint) Size() (uint64, error) {
+	return 1, nil
+}
+
+// peerCommitPoints is the LRU of the first commitment points seen from a
+// single peer.
+type peerCommitPoints struct {
+	*lru.Cache
+}
+
+// Size returns the size of the entry, as each peer counts as one.
+//
+// NOTE: Part of the cache.Value interface.
+func (peerCommitPoints) Size() (uint64, error) {
+	return 1, nil
+}
+
+// commitPointCache remembers the first commitment points recently seen from
+// each peer. Each peer has its own bounded LRU of points, such that a peer
+// opening many channels can't evict the points of another peer. The peers
+// are held in an LRU as well. It is safe for concurrent use.
+type commitPointCache struct {
+	// mtx makes looking up the LRU of a peer and adding a point to it
+	// atomic.
+	mtx sync.Mutex
+
+	perPeer uint64
+
+	peers *lru.Cache
+}
+
+// newCommitPointCache returns an empty cache remembering up to the given
+// number of first commitment points per peer. If the capacity is zero, nil
+// is returned, which never reports a point as seen.
+func newCommitPointCache(perPeer int) *commitPointCache {
+	if perPeer <= 0 {
+		return nil
+	}
+
+	return &commitPointCache{
+		perPeer: uint64(perPeer),
+		peers:   lru.NewCache(maxCommitPointPeers),
+	}
+}
+
+// observe records that the peer sent us the given first commitment point,
+// and returns true if the peer already sent it before. If the LRU of the peer
+// is full, the point it sent least recently is evicted.
+func (c *commitPointCache) observe(peer, point *btcec.PublicKey) bool {
+	if c == nil {
+		return false
+	}
+
+	var peerKey, pointKey [33]byte
+	copy(peerKey[:], peer.SerializeCompressed())
+	copy(pointKey[:], point.SerializeCompressed())
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	points := c.peerPoints(peerKey)
+	if seen, _ := points.Get(pointKey); seen != nil {
+		return true
+	}
+
+	// As every entry has a size of one, and the capacities are at least
+	// one, Put can't fail.
+	_, _ = points.Put(pointKey, seenCommitPoint{})
+
+	return false
+}
+
+// peerPoints returns the LRU of the points seen from the given peer, adding
+// an empty one if the peer isn't known yet.
+//
+// NOTE: The mutex MUST be held when calling this method.
+func (c *commitPointCache) peerPoints(peerKey [33]byte) peerCommitPoints {
+	if value, _ := c.peers.Get(peerKey); value != nil {
+		return value.(peerCommitPoints)
+	}
+
+	points := peerCommitPoints{Cache: lru.NewCache(c.perPeer)}
+	_, _ = c.peers.Put(peerKey, points)
+
+	return points
+}
+
+// len returns the number of points in the cache seen from the given peer.
+func (c *commitPointCache) len(peer *btcec.PublicKey) int {
+	if c == nil {
+		return 0
+	}
+
+	var peerKey [33]byte
+	copy(peerKey[:], peer.SerializeCompressed())
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	value, _ := c.peers.Get(peerKey)
+	if value == nil {
+		return 0
+	}
+
+	return value.(peerCommitPoints).Len()
+}
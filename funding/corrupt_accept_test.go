@@ -0,0 +1,197 @@
+package funding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+)
+
+// fundingKeyOffset is the offset of the funding key within the wire encoding
+// of an AcceptChannel, including the 2-byte message type.
+const fundingKeyOffset = 2 + 32 + 8 + 8 + 8 + 8 + 4 + 2 + 2
+
+// acceptCorruption is a way of corrupting an AcceptChannel on its way to the
+// peer, to assert that the peer handles the corrupt message gracefully.
+type acceptCorruption struct {
+	// name describes the corruption.
+	name string
+
+	// modify, if set, alters the message before it is encoded. This is
+	// used to send fields that decode fine, but are out of range.
+	modify func(msg *lnwire.AcceptChannel)
+
+	// corrupt, if set, alters the wire encoding of the message, including
+	// its 2-byte message type.
+	corrupt func(raw []byte) []byte
+}
+
+// acceptCorruptions are the ways an AcceptChannel can be corrupted. The ones
+// corrupting the wire encoding make the message fail to decode, while the
+// others send out-of-range fields that must fail validation.
+var acceptCorruptions = []acceptCorruption{
+	{
+		name: "truncated fixed fields",
+		corrupt: func(raw []byte) []byte {
+			return raw[:fundingKeyOffset]
+		},
+	},
+	{
+		name: "invalid funding key",
+		corrupt: func(raw []byte) []byte {
+			raw[fundingKeyOffset] = 0x05
+			return raw
+		},
+	},
+	{
+		name: "truncated tlv record",
+		corrupt: func(raw []byte) []byte {
+			// A record of type 3 announcing a length of 5 bytes,
+			// of which only one follows.
+			return append(raw, 0x03, 0x05, 0x01)
+		},
+	},
+	{
+		name: "non-canonical tlv stream",
+		corrupt: func(raw []byte) []byte {
+			// A second upfront shutdown script record, which
+			// breaks the strictly increasing order of the types.
+			return append(raw, 0x00, 0x00)
+		},
+	},
+	{
+		name: "csv delay out of range",
+		modify: func(msg *lnwire.AcceptChannel) {
+			msg.CsvDelay = math.MaxUint16
+		},
+	},
+	{
+		name: "max accepted htlcs out of range",
+		modify: func(msg *lnwire.AcceptChannel) {
+			msg.MaxAcceptedHTLCs = input.MaxHTLCNumber/2 + 1
+		},
+	},
+	{
+		name: "min accept depth out of range",
+		modify: func(msg *lnwire.AcceptChannel) {
+			msg.MinAcceptDepth = chainntnfs.MaxNumConfs + 1
+		},
+	},
+	{
+		name: "reserve below dust limit",
+		modify: func(msg *lnwire.AcceptChannel) {
+			msg.ChannelReserve = msg.DustLimit - 1
+		},
+	},
+}
+
+// sendCorruptAccept sends the given AcceptChannel from the node to the funding
+// manager of the given peer, corrupted as described by the corruption. Like a
+// real peer connection, the message is encoded and decoded again. If it fails
+// to decode, the peer's funding manager is notified of the malformed message,
+// and the decode error is returned. Otherwise, the decoded message is
+// processed as usual, and nil is returned.
+func (n *testNode) sendCorruptAccept(t *testing.T, peer *testNode,
+	msg *lnwire.AcceptChannel, corruption acceptCorruption) error {
+
+	t.Helper()
+
+	if corruption.modify != nil {
+		corruption.modify(msg)
+	}
+
+	var b bytes.Buffer
+	_, err := lnwire.WriteMessage(&b, msg, 0)
+	require.NoError(t, err)
+
+	raw := b.Bytes()
+	if corruption.corrupt != nil {
+		raw = corruption.corrupt(raw)
+	}
+
+	decoded, decodeErr := lnwire.ReadMessage(bytes.NewReader(raw), 0)
+	if decodeErr == nil {
+		peer.fundingMgr.ProcessFundingMsg(decoded, n)
+		return nil
+	}
+
+	// Like the peer connection, we extract the pending channel ID from
+	// the raw message. As the funding manager synchronously sends an
+	// error to us, it is notified in a goroutine.
+	require.GreaterOrEqual(t, len(raw), 2+32)
+	msgType := lnwire.MessageType(binary.BigEndian.Uint16(raw[:2]))
+	require.EqualValues(t, lnwire.MsgAcceptChannel, msgType)
+
+	var pendingChanID [32]byte
+	copy(pendingChanID[:], raw[2:34])
+	go peer.fundingMgr.ProcessMalformedAccept(pendingChanID, decodeErr, n)
+
+	return decodeErr
+}
+
+// TestFundingManagerCorruptAccept asserts that the initiator handles corrupt
+// AcceptChannel messages gracefully: the funding flow is failed, the peer is
+// sent an error, and the reservation is released, regardless of whether the
+// message failed to decode or carried out-of-range fields.
+func TestFundingManagerCorruptAccept(t *testing.T) {
+	t.Parallel()
+
+	for _, corruption := range acceptCorruptions {
+		corruption := corruption
+
+		t.Run(corruption.name, func(t *testing.T) {
+			t.Parallel()
+
+			alice, bob := setupFundingManagers(t)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			initReq := &InitFundingMsg{
+				Peer:            bob,
+				TargetPubkey:    bob.privKey.PubKey(),
+				ChainHash:       *fundingNetParams.GenesisHash,
+				LocalFundingAmt: 500000,
+				FundingFeePerKw: 1000,
+				Updates: make(
+					chan *lnrpc.OpenStatusUpdate,
+				),
+				Err: make(chan error, 1),
+			}
+			alice.fundingMgr.InitFundingWorkflow(initReq)
+
+			openChannelReq := expectOpenChannelMsg(t, alice.msgChan)
+			bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+
+			acceptChannelResponse := assertFundingMsgSent(
+				t, bob.msgChan, "AcceptChannel",
+			).(*lnwire.AcceptChannel)
+
+			// Only corruptions of the wire encoding make the
+			// message fail to decode.
+			err := bob.sendCorruptAccept(
+				t, alice, acceptChannelResponse, corruption,
+			)
+			if corruption.corrupt != nil {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assertFundingMsgSent(t, alice.msgChan, "Error")
+
+			select {
+			case err := <-initReq.Err:
+				require.Error(t, err)
+			case <-time.After(time.Second * 5):
+				t.Fatalf("alice did not fail the funding flow")
+			}
+			assertNumPendingReservations(t, alice, bobPubKey, 0)
+		})
+	}
+}
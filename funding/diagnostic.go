@@ -0,0 +1,110 @@
+package funding
+
+import (
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// ForceCloseDiagnosis describes what unilaterally closing a channel with the
+// parameters of an AcceptChannel would cost at the current fee rate.
+type ForceCloseDiagnosis struct {
+	// FeePerKw is the fee rate the cost was estimated at.
+	FeePerKw chainfee.SatPerKWeight
+
+	// CommitFee is the fee of the commitment transaction at FeePerKw,
+	// including the value of any anchor outputs.
+	CommitFee btcutil.Amount
+
+	// SweepFee is the fee of the transaction sweeping our delayed output
+	// of the commitment transaction at FeePerKw.
+	SweepFee btcutil.Amount
+
+	// ForceCloseCost is the total cost of the force close, the sum of the
+	// CommitFee and the SweepFee.
+	ForceCloseCost btcutil.Amount
+
+	// ChanReserve is the reserve the remote party requires us to keep in
+	// the channel.
+	ChanReserve btcutil.Amount
+
+	// Capacity is the capacity of the channel.
+	Capacity btcutil.Amount
+
+	// Uneconomical is set if the ForceCloseCost exceeds the ChanReserve,
+	// meaning the part of our balance we can always claim wouldn't cover
+	// the cost of claiming it on chain.
+	Uneconomical bool
+}
+
+// DiagnoseAcceptChannel estimates the cost of force closing a channel of the
+// given capacity and commitment type, funded with the parameters of the
+// AcceptChannel, at the current fee rate. It is meant to let operators judge
+// the parameters before going ahead with the funding flow, and has no effect
+// on the reservation itself.
+func (f *Manager) DiagnoseAcceptChannel(msg *lnwire.AcceptChannel,
+	capacity btcutil.Amount,
+	commitType lnwallet.CommitmentType) (*ForceCloseDiagnosis, error) {
+
+	// We use the same confirmation target as for the fee rate of the
+	// initial commitment, but without capping it for anchor channels, as
+	// the commitment would need to be bumped to that rate when closing.
+	feePerKw, err := f.cfg.FeeEstimator.EstimateFeePerKW(3)
+	if err != nil {
+		return nil, err
+	}
+
+	return diagnoseForceClose(msg, capacity, commitType, feePerKw), nil
+}
+
+// DiagnoseChannel runs DiagnoseAcceptChannel for the channel with the given
+// funding outpoint, based on the AcceptChannel the remote party sent for it.
+// Only channels we initiated can be diagnosed, as we didn't receive an
+// AcceptChannel for the others.
+func (f *Manager) DiagnoseChannel(
+	chanPoint wire.OutPoint) (*ForceCloseDiagnosis, error) {
+
+	channel, err := f.cfg.Wallet.Cfg.Database.FetchChannel(chanPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	accept, err := channel.RebuildReceivedAcceptChannel()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.DiagnoseAcceptChannel(
+		accept, channel.Capacity,
+		lnwallet.CommitmentTypeOf(channel.ChanType),
+	)
+}
+
+// diagnoseForceClose estimates the cost of force closing a channel with the
+// parameters of the AcceptChannel at the given fee rate.
+func diagnoseForceClose(msg *lnwire.AcceptChannel, capacity btcutil.Amount,
+	commitType lnwallet.CommitmentType,
+	feePerKw chainfee.SatPerKWeight) *ForceCloseDiagnosis {
+
+	commitFee := lnwallet.InitiatorCommitFee(commitType, feePerKw)
+
+	var sweepWeight input.TxWeightEstimator
+	sweepWeight.AddWitnessInput(input.ToLocalTimeoutWitnessSize)
+	sweepWeight.AddP2WKHOutput()
+	sweepFee := feePerKw.FeeForWeight(int64(sweepWeight.Weight()))
+
+	cost := commitFee + sweepFee
+
+	return &ForceCloseDiagnosis{
+		FeePerKw:       feePerKw,
+		CommitFee:      commitFee,
+		SweepFee:       sweepFee,
+		ForceCloseCost: cost,
+		ChanReserve:    msg.ChannelReserve,
+		Capacity:       capacity,
+		Uneconomical:   cost > msg.ChannelReserve,
+	}
+}
@@ -0,0 +1,79 @@
+package funding
+
+import (
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/subscribe"
+)
+
+// AcceptChannelReceivedEvent represents a new event where the remote peer
+// responded to one of our pending channel requests with an AcceptChannel.
+type AcceptChannelReceivedEvent struct {
+	// PendingID is the pending channel ID of the reservation the
+	// AcceptChannel is for.
+	PendingID [32]byte
+
+	// Peer is the identity key of the peer that sent the AcceptChannel.
+	Peer *btcec.PublicKey
+}
+
+// AcceptChannelRejectedEvent represents a new event where we rejected an
+// AcceptChannel sent by the remote peer, failing the funding flow.
+type AcceptChannelRejectedEvent struct {
+	// PendingID is the pending channel ID of the reservation the
+	// AcceptChannel was for.
+	PendingID [32]byte
+
+	// Peer is the identity key of the peer that sent the AcceptChannel.
+	Peer *btcec.PublicKey
+
+	// Reason is the reason the AcceptChannel was rejected for. It matches
+	// the reason label of the rejection metric.
+	Reason string
+
+	// Err is the error the AcceptChannel was rejected with.
+	Err error
+}
+
+// AcceptChannelProcessedEvent represents a new event where we accepted the
+// contribution of the remote peer carried in an AcceptChannel, and are ready
+// to continue the funding flow.
+type AcceptChannelProcessedEvent struct {
+	// PendingID is the pending channel ID of the reservation the
+	// AcceptChannel was for.
+	PendingID [32]byte
+
+	// Peer is the identity key of the peer that sent the AcceptChannel.
+	Peer *btcec.PublicKey
+
+	// Result holds the channel parameters both parties agreed on.
+	Result *NegotiationResult
+}
+
+// SubscribeFundingEvents returns a subscribe.Client that will receive updates
+// any time the funding manager publishes a new funding negotiation event. The
+// subscription provides events from the point of subscription onwards.
+func (f *Manager) SubscribeFundingEvents() (*subscribe.Client, error) {
+	return f.ntfnServer.Subscribe()
+}
+
+// sendFundingEvent publishes the given event to all funding event
+// subscribers.
+func (f *Manager) sendFundingEvent(event interface{}) {
+	if err := f.ntfnServer.SendUpdate(event); err != nil {
+		log.Warnf("Unable to send funding event %T: %v", event, err)
+	}
+}
+
+// notifyAcceptRejected records the rejection of an AcceptChannel for the
+// given reason in our metrics, and notifies our funding event subscribers.
+func (f *Manager) notifyAcceptRejected(peerKey *btcec.PublicKey,
+	pendingChanID [32]byte, reason string, err error) {
+
+	recordAcceptRejection(reason)
+	f.sendFundingEvent(AcceptChannelRejectedEvent{
+		PendingID: pendingChanID,
+		Peer:      peerKey,
+		Reason:    reason,
+		Err:       err,
+	})
+}
@@ -18,4 +18,10 @@ type Controller interface {
 	// IsPendingChannel returns whether a particular 32-byte identifier
 	// represents a pending channel in the Controller implementation.
 	IsPendingChannel([32]byte, lnpeer.Peer) bool
+
+	// ProcessMalformedAccept is called when the counterparty sent an
+	// AcceptChannel message for the given pending channel ID that could
+	// not be decoded. The reservation it references, if any, should be
+	// cancelled right away.
+	ProcessMalformedAccept([32]byte, error, lnpeer.Peer)
 }
@@ -32,6 +32,8 @@ import (
 	"github.com/lightningnetwork/lnd/lnwallet/chanfunding"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/subscribe"
 	"golang.org/x/crypto/salsa20"
 )
 
@@ -93,6 +95,15 @@ const (
 	// Protocol.
 	MaxLtcFundingAmount = MaxBtcFundingAmount * chainreg.BtcToLtcConversionRate
 
+	// LightClientExtraConfs is the number of confirmations we require on
+	// top of the usual depth for channels extended to us when running
+	// against a light client, which can't validate the blocks it follows.
+	LightClientExtraConfs = 2
+
+	// DefaultMaxSeenCommitPoints is the default number of first commitment
+	// points remembered per peer to detect their reuse.
+	DefaultMaxSeenCommitPoints = 32
+
 	// TODO(roasbeef): tune
 	msgBufferSize = 50
 
@@ -115,6 +126,12 @@ var (
 	ErrConfirmationTimeout = errors.New("timeout waiting for funding " +
 		"confirmation")
 
+	// ErrPendingChanIDInUse is returned when a peer refers to a pending
+	// channel ID in a way that collides with one of its funding flows
+	// already in progress, e.g. by opening a second channel with the same
+	// pending channel ID, or accepting the same channel twice.
+	ErrPendingChanIDInUse = errors.New("pending channel ID already in use")
+
 	// errUpfrontShutdownScriptNotSupported is returned if an upfront shutdown
 	// script is set for a peer that does not support the feature bit.
 	errUpfrontShutdownScriptNotSupported = errors.New("peer does not support" +
@@ -138,6 +155,9 @@ type reservationWithCtx struct {
 
 	chanAmt btcutil.Amount
 
+	// commitType is the commitment type negotiated for the channel.
+	commitType lnwallet.CommitmentType
+
 	// Constraints we require for the remote.
 	remoteCsvDelay uint16
 	remoteMinHtlc  lnwire.MilliSatoshi
@@ -147,6 +167,30 @@ type reservationWithCtx struct {
 	// maxLocalCsv is the maximum csv we will accept from the remote.
 	maxLocalCsv uint16
 
+	// maxHtlcExpiryDelta is the maximum number of blocks the expiry of the
+	// HTLCs we offer may lie in the future, as required by the remote. It
+	// is zero if the remote didn't bound the expiry.
+	maxHtlcExpiryDelta uint32
+
+	// acceptMsg is the AcceptChannel the remote party responded to our
+	// OpenChannel with, once it was processed. It is guarded by the
+	// updateMtx.
+	acceptMsg *lnwire.AcceptChannel
+
+	// fundingCreatedSent is the time our FundingCreated message was sent
+	// at. It is guarded by the updateMtx.
+	fundingCreatedSent time.Time
+
+	// persisted is set if the reservation is persisted until the remote
+	// party's AcceptChannel is received, allowing it to be resumed after
+	// a restart.
+	persisted bool
+
+	// aborted is set once AbortFunding decided to abort the funding
+	// negotiation, after which the FundingCreated message must no longer
+	// be sent. It is guarded by the resMtx of the funding manager.
+	aborted bool
+
 	updateMtx   sync.RWMutex
 	lastUpdated time.Time
 
@@ -171,6 +215,32 @@ func (r *reservationWithCtx) updateTimestamp() {
 	r.lastUpdated = time.Now()
 }
 
+// setAcceptMsg records the AcceptChannel the remote party responded with.
+func (r *reservationWithCtx) setAcceptMsg(msg *lnwire.AcceptChannel) {
+	r.updateMtx.Lock()
+	defer r.updateMtx.Unlock()
+
+	r.acceptMsg = msg
+}
+
+// setFundingCreatedSent records the time our FundingCreated message was sent
+// at.
+func (r *reservationWithCtx) setFundingCreatedSent(sentAt time.Time) {
+	r.updateMtx.Lock()
+	defer r.updateMtx.Unlock()
+
+	r.fundingCreatedSent = sentAt
+}
+
+// hasAcceptMsg returns whether an AcceptChannel was already processed for the
+// reservation.
+func (r *reservationWithCtx) hasAcceptMsg() bool {
+	r.updateMtx.RLock()
+	defer r.updateMtx.RUnlock()
+
+	return r.acceptMsg != nil
+}
+
 // InitFundingMsg is sent by an outside subsystem to the funding manager in
 // order to kick off a funding workflow with a specified target peer. The
 // original request which defines the parameters of the funding workflow are
@@ -360,6 +430,20 @@ type Config struct {
 	// process to determine how many confirmations we'll require.
 	NumRequiredConfs func(btcutil.Amount, lnwire.MilliSatoshi) uint16
 
+	// DepthPolicy is an optional per-peer policy for the number of
+	// confirmations we'll require for a channel extended to us. If set,
+	// it is used in place of NumRequiredConfs, allowing operators to
+	// require fewer confirmations from trusted peers. The result is
+	// clamped to the range [1, chainntnfs.MaxNumConfs]. A depth set by
+	// the channel acceptor still takes precedence.
+	DepthPolicy DepthPolicy
+
+	// LightClient indicates that the backing chain source is a light
+	// client such as neutrino. As such a client doesn't validate the
+	// blocks it follows, we require LightClientExtraConfs confirmations
+	// on top of the usual depth for channels extended to us.
+	LightClient bool
+
 	// RequiredRemoteDelay is a function that maps the total amount in a
 	// proposed channel to the CSV delay that we'll require for the remote
 	// party. Naturally a larger channel should require a higher CSV delay
@@ -367,11 +451,34 @@ type Config struct {
 	// contract breach.
 	RequiredRemoteDelay func(btcutil.Amount) uint16
 
-	// RequiredRemoteChanReserve is a function closure that, given the
-	// channel capacity and dust limit, will return an appropriate amount
-	// for the remote peer's required channel reserve that is to be adhered
-	// to at all times.
-	RequiredRemoteChanReserve func(capacity, dustLimit btcutil.Amount) btcutil.Amount
+	// PeerRemoteDelay is an optional per-peer policy for the CSV delay
+	// we'll require for a remote party opening a channel to us. If it
+	// returns true, the returned delay is used in place of the one
+	// returned by RequiredRemoteDelay, allowing operators to require a
+	// longer delay from untrusted peers. A CSV delay set by the channel
+	// acceptor still takes precedence.
+	PeerRemoteDelay func(peer *btcec.PublicKey,
+		chanAmt btcutil.Amount) (uint16, bool)
+
+	// AllowZeroReserve is an optional per-peer policy that returns true
+	// if the given peer is trusted to have no channel reserve. For such
+	// a peer, we require no reserve, and accept a zero reserve required
+	// from us, which is otherwise rejected as being below the dust limit.
+	// The reserve waiver thus only applies once both sides enable it.
+	AllowZeroReserve func(peer *btcec.PublicKey) bool
+
+	// ReservePolicy is a function closure that, given the channel
+	// capacity, will return an appropriate amount for the remote peer's
+	// required channel reserve that is to be adhered to at all times. If
+	// the returned value is below the dust limit, the dust limit itself
+	// will be used as the reserve.
+	ReservePolicy ReservePolicy
+
+	// MinAbsoluteReserve is the smallest channel reserve we'll require the
+	// remote party to maintain, and the smallest one we'll agree to
+	// maintain ourselves, regardless of the channel capacity. A value of
+	// zero disables the floor.
+	MinAbsoluteReserve btcutil.Amount
 
 	// RequiredRemoteMaxValue is a function closure that, given the channel
 	// capacity, returns the amount of MilliSatoshis that our remote peer
@@ -418,10 +525,70 @@ type Config struct {
 	// allow for each peer.
 	MaxPendingChannels int
 
+	// MaxChannels is the maximum number of channels, pending or open, we
+	// allow in total across all peers. Both inbound and outbound channels
+	// count towards the limit. A value of zero disables the limit.
+	MaxChannels int
+
 	// RejectPush is set true if the fundingmanager should reject any
 	// incoming channels having a non-zero push amount.
 	RejectPush bool
 
+	// RequiredCommitType, if set, is the only commitment type we'll
+	// accept for channels opened to us by remote peers. Opening requests
+	// resulting in any other commitment type are rejected.
+	RequiredCommitType *lnwallet.CommitmentType
+
+	// MinRemoteMaxHtlcs is the smallest MaxAcceptedHTLCs we'll accept
+	// from a peer accepting a channel we've initiated, as a channel that
+	// only allows a handful of concurrent HTLCs is useless for routing. A
+	// value of zero disables this check.
+	MinRemoteMaxHtlcs uint16
+
+	// RequireRemoteUpfrontShutdown is set if a peer accepting a channel
+	// we've initiated must commit to a non-empty upfront shutdown script.
+	// If not set, committing to a script is optional for the peer.
+	RequireRemoteUpfrontShutdown bool
+
+	// RejectIdentityFundingKey is set if a peer accepting a channel we've
+	// initiated must use a funding key distinct from its node identity
+	// key.
+	RejectIdentityFundingKey bool
+
+	// RejectExcessMaxValueInFlight is set if a peer accepting a channel
+	// we've initiated must send a MaxValueInFlight within the bounds of
+	// its HtlcMinimum and the channel capacity. If not set, a value
+	// exceeding the capacity is accepted, as many implementations signal
+	// an unbounded value that way.
+	RejectExcessMaxValueInFlight bool
+
+	// MaxCommitFeeAllocation is the largest fraction of our balance the
+	// fee of the heaviest commitment transaction a peer accepting our
+	// channel allows may take up, at the commitment fee rate we proposed.
+	// The heaviest commitment carries as many HTLCs as the peer's
+	// MaxAcceptedHTLCs. A value of zero disables this check.
+	MaxCommitFeeAllocation float64
+
+	// ReserveAsymmetryPolicy, if set, bounds how much larger the reserve
+	// a peer accepting our channel requires us to keep may be than the
+	// reserve we require from it.
+	ReserveAsymmetryPolicy *lnwire.ReservePolicy
+
+	// HtlcMinAsymmetryPolicy bounds how far apart the HtlcMinimum of a
+	// peer accepting our channel and the one we require from it may lie
+	// before a warning is logged. If nil, DefaultHtlcMinPolicy is used.
+	HtlcMinAsymmetryPolicy *lnwire.HtlcMinPolicy
+
+	// MaxSeenCommitPoints is the number of first commitment points sent
+	// to us in AcceptChannel messages by each peer that are remembered to
+	// detect the peer reusing one. If zero, reuse isn't detected.
+	MaxSeenCommitPoints int
+
+	// SeverityPolicy decides whether a failed check of an AcceptChannel
+	// rejects it, or is only logged as a warning. If nil, every failed
+	// check is treated with its default severity.
+	SeverityPolicy SeverityPolicy
+
 	// MaxLocalCSVDelay is the maximum csv delay we will allow for our
 	// commit output. Channels that exceed this value will be failed.
 	MaxLocalCSVDelay uint16
@@ -443,6 +610,25 @@ type Config struct {
 	// is enabled.
 	EnableUpfrontShutdown bool
 
+	// AcceptChannelHook is an optional callback that is invoked with the
+	// AcceptChannel we're about to send in response to an OpenChannel. It
+	// may add custom TLV records to the message or adjust its policy
+	// fields, which are validated again afterwards. An error returned by
+	// the hook fails the funding flow.
+	AcceptChannelHook func(accept *lnwire.AcceptChannel) error
+
+	// RevalidateAcceptChannel validates the AcceptChannel we received for
+	// the channels we initiated that are still pending on startup again,
+	// as validation rules may have been added since it was accepted. If
+	// nil, AcceptChannel.Validate is used.
+	RevalidateAcceptChannel func(accept *lnwire.AcceptChannel) error
+
+	// AllowCommitTypeDowngrade allows a peer to accept the channels we
+	// open with an older commitment type than the one we proposed, in
+	// which case the channel is created with the older type. Otherwise,
+	// such an AcceptChannel is rejected.
+	AllowCommitTypeDowngrade bool
+
 	// RegisteredChains keeps track of all chains that have been registered
 	// with the daemon.
 	RegisteredChains *chainreg.ChainRegistry
@@ -514,6 +700,33 @@ type Manager struct {
 	handleFundingLockedMtx      sync.RWMutex
 	handleFundingLockedBarriers map[lnwire.ChannelID]struct{}
 
+	// ntfnServer is the subscription server funding negotiation events
+	// are published through.
+	ntfnServer *subscribe.Server
+
+	// seenCommitPoints holds the first commitment points recently sent to
+	// us in AcceptChannel messages, to detect a peer reusing one. It is
+	// nil if reuse isn't detected.
+	seenCommitPoints *commitPointCache
+
+	// acceptExtraData maps the funding outpoints of pending channels we
+	// initiated to the TLV records of the AcceptChannel of the remote
+	// party that we don't know about. It is kept in memory only.
+	acceptExtraDataMtx sync.RWMutex
+	acceptExtraData    map[wire.OutPoint]lnwire.ExtraOpaqueData
+
+	// acceptMsgs maps the funding outpoints of pending channels we
+	// initiated to the AcceptChannel of the remote party. It is kept in
+	// memory only.
+	acceptMsgsMtx sync.RWMutex
+	acceptMsgs    map[wire.OutPoint]*lnwire.AcceptChannel
+
+	// acceptPolicies caches the parameters of the last AcceptChannel each
+	// peer sent, for previewing future opens with it. It is kept in
+	// memory only.
+	acceptPoliciesMtx sync.RWMutex
+	acceptPolicies    map[serializedPubKey]*AcceptPolicy
+
 	quit chan struct{}
 	wg   sync.WaitGroup
 }
@@ -567,6 +780,11 @@ func NewFundingManager(cfg Config) (*Manager, error) {
 		fundingRequests:             make(chan *InitFundingMsg, msgBufferSize),
 		localDiscoverySignals:       make(map[lnwire.ChannelID]chan struct{}),
 		handleFundingLockedBarriers: make(map[lnwire.ChannelID]struct{}),
+		ntfnServer:                  subscribe.NewServer(),
+		seenCommitPoints:            newCommitPointCache(cfg.MaxSeenCommitPoints),
+		acceptExtraData:             make(map[wire.OutPoint]lnwire.ExtraOpaqueData),
+		acceptMsgs:                  make(map[wire.OutPoint]*lnwire.AcceptChannel),
+		acceptPolicies:              make(map[serializedPubKey]*AcceptPolicy),
 		quit:                        make(chan struct{}),
 	}, nil
 }
@@ -584,6 +802,23 @@ func (f *Manager) Start() error {
 func (f *Manager) start() error {
 	log.Tracef("Funding manager running")
 
+	if f.cfg.LightClient {
+		log.Infof("Running against a light client, requiring %d "+
+			"additional confirmations for inbound channels",
+			LightClientExtraConfs)
+	}
+
+	if err := f.ntfnServer.Start(); err != nil {
+		return err
+	}
+
+	// Reservations that were still waiting for the remote party's
+	// AcceptChannel when we went down are resumed once it arrives, unless
+	// they have been idle for too long in the meantime.
+	if err := f.pruneExpiredPendingReservations(); err != nil {
+		return err
+	}
+
 	// Upon restart, the Funding Manager will check the database to load any
 	// channels that were  waiting for their funding transactions to be
 	// confirmed on the blockchain at the time when the daemon last went
@@ -598,6 +833,12 @@ func (f *Manager) start() error {
 	for _, channel := range allChannels {
 		chanID := lnwire.NewChanIDFromOutPoint(&channel.FundingOutpoint)
 
+		// Pending channels no longer passing our validation of their
+		// AcceptChannel are flagged, but resumed all the same.
+		if channel.IsPending {
+			f.revalidatePendingChannel(channel)
+		}
+
 		// For any channels that were in a pending state when the
 		// daemon was last connected, the Funding Manager will
 		// re-initialize the channel barriers, and republish the
@@ -676,13 +917,15 @@ func (f *Manager) start() error {
 // Stop signals all helper goroutines to execute a graceful shutdown. This
 // method will block until all goroutines have exited.
 func (f *Manager) Stop() error {
+	var err error
 	f.stopped.Do(func() {
 		log.Info("Funding manager shutting down")
 		close(f.quit)
 		f.wg.Wait()
+		err = f.ntfnServer.Stop()
 	})
 
-	return nil
+	return err
 }
 
 // nextPendingChanID returns the next free pending channel ID to be used to
@@ -780,6 +1023,10 @@ func (f *Manager) failFundingFlow(peer lnpeer.Peer, tempChanID [32]byte,
 		msg = lnwire.ErrorData(e.Error())
 	case chanacceptor.ChanAcceptError:
 		msg = lnwire.ErrorData(e.Error())
+	case *acceptRejectedError:
+		msg = lnwire.ErrorData(e.Error())
+	case fundingAbortedError:
+		msg = lnwire.ErrorData(e.Error())
 
 	// For all other error types we just send a generic error.
 	default:
@@ -1169,6 +1416,222 @@ func commitmentType(localFeatures,
 	return lnwallet.CommitmentTypeLegacy
 }
 
+// commitTypeFromChannelType returns the commitment type of channels of the
+// given channel type. An error is returned for channel types whose commitment
+// type we don't support, such as the legacy anchor type.
+func commitTypeFromChannelType(
+	chanType *lnwire.ChannelType) (lnwallet.CommitmentType, error) {
+
+	fv := lnwire.NewFeatureVector(
+		(*lnwire.RawFeatureVector)(chanType), lnwire.Features,
+	)
+	switch {
+	case fv.HasFeature(lnwire.AnchorsZeroFeeHtlcTxOptional):
+		return lnwallet.CommitmentTypeAnchorsZeroFeeHtlcTx, nil
+
+	case fv.HasFeature(lnwire.AnchorsOptional):
+		return 0, errors.New("legacy anchor commitments not supported")
+
+	case fv.HasFeature(lnwire.StaticRemoteKeyOptional):
+		return lnwallet.CommitmentTypeTweakless, nil
+
+	default:
+		return lnwallet.CommitmentTypeLegacy, nil
+	}
+}
+
+// negotiateCommitType checks the channel type the peer accepted our channel
+// with, if any, against the commitment type we proposed. If the peer accepted
+// an older commitment type, the reservation is downgraded to it if our
+// AllowCommitTypeDowngrade config allows it. Otherwise, the AcceptChannel is
+// rejected and false is returned.
+func (f *Manager) negotiateCommitType(peer lnpeer.Peer,
+	resCtx *reservationWithCtx, msg *lnwire.AcceptChannel) bool {
+
+	pendingChanID := msg.PendingChannelID
+
+	chanType, err := msg.ChannelType()
+	if err != nil {
+		log.Warnf("Invalid AcceptChannel channel type: %v", err)
+		f.rejectAccept(peer, pendingChanID, rejectReasonMalformed, err)
+		return false
+	}
+
+	// The commitment type we proposed stands if the peer didn't echo any
+	// channel type.
+	if chanType == nil {
+		return true
+	}
+
+	acceptedType, err := commitTypeFromChannelType(chanType)
+	if err != nil {
+		log.Warnf("Unacceptable AcceptChannel channel type: %v", err)
+		f.rejectAccept(
+			peer, pendingChanID, rejectReasonUnknownChannelType,
+			err,
+		)
+		return false
+	}
+
+	proposedType := resCtx.commitType
+	switch {
+	case acceptedType == proposedType:
+		return true
+
+	// Older commitment types precede the newer ones, so a peer can't
+	// upgrade the commitment type we proposed.
+	case acceptedType > proposedType || !f.cfg.AllowCommitTypeDowngrade:
+		err := lnwallet.ErrCommitTypeMismatch(proposedType, acceptedType)
+		log.Warnf("Unacceptable AcceptChannel for pending_id(%x): %v",
+			pendingChanID[:], err)
+		f.rejectAccept(
+			peer, pendingChanID, acceptRejectionReason(err), err,
+		)
+		return false
+	}
+
+	err = resCtx.reservation.DowngradeCommitType(acceptedType)
+	if err != nil {
+		log.Errorf("Unable to downgrade commitment type of "+
+			"pending_id(%x): %v", pendingChanID[:], err)
+		f.rejectAccept(peer, pendingChanID, rejectReasonOther, err)
+		return false
+	}
+
+	log.Infof("Downgraded commitment type of pending_id(%x) from %v to "+
+		"%v as accepted by the peer", pendingChanID[:], proposedType,
+		acceptedType)
+	resCtx.commitType = acceptedType
+
+	return true
+}
+
+// requiredRemoteChanReserve returns the channel reserve we require the remote
+// party to maintain for a channel of the given capacity, as dictated by our
+// ReservePolicy. If the policy yields a reserve below the dust limit, then
+// we'll use the dust limit itself as the reserve as required by BOLT #2. The
+// reserve is raised to the MinAbsoluteReserve if it falls below it.
+func (f *Manager) requiredRemoteChanReserve(capacity,
+	dustLimit btcutil.Amount) btcutil.Amount {
+
+	reserve := f.cfg.ReservePolicy(capacity)
+	if reserve < dustLimit {
+		reserve = dustLimit
+	}
+	if reserve < f.cfg.MinAbsoluteReserve {
+		reserve = f.cfg.MinAbsoluteReserve
+	}
+
+	return reserve
+}
+
+// zeroReserveAllowed returns true if our AllowZeroReserve policy trusts the
+// given peer to have no channel reserve.
+func (f *Manager) zeroReserveAllowed(peerKey *btcec.PublicKey) bool {
+	return f.cfg.AllowZeroReserve != nil && f.cfg.AllowZeroReserve(peerKey)
+}
+
+// peerChanReserve returns the channel reserve we require the given peer to
+// maintain for a channel of the given capacity. If we trust the peer to have
+// no reserve, it is zero. Otherwise it is dictated by our ReservePolicy, see
+// requiredRemoteChanReserve.
+func (f *Manager) peerChanReserve(peerKey *btcec.PublicKey, capacity,
+	dustLimit btcutil.Amount) btcutil.Amount {
+
+	if f.zeroReserveAllowed(peerKey) {
+		return 0
+	}
+
+	return f.requiredRemoteChanReserve(capacity, dustLimit)
+}
+
+// validateLocalChanReserve ensures the channel reserve the remote party
+// requires us to maintain isn't below our MinAbsoluteReserve. A zero reserve
+// is accepted if we trust the peer to have no reserve either.
+func (f *Manager) validateLocalChanReserve(peerKey *btcec.PublicKey,
+	reserve btcutil.Amount) error {
+
+	if reserve == 0 && f.zeroReserveAllowed(peerKey) {
+		return nil
+	}
+
+	if reserve < f.cfg.MinAbsoluteReserve {
+		return lnwallet.ErrChanReserveTooSmall(
+			reserve, f.cfg.MinAbsoluteReserve,
+		)
+	}
+
+	return nil
+}
+
+// remoteCsvDelay returns the CSV delay we'll require for the given remote
+// party opening a channel of the given capacity to us. The per-peer policy is
+// consulted first, falling back to the RequiredRemoteDelay closure if it
+// doesn't apply to this peer.
+func (f *Manager) remoteCsvDelay(peerKey *btcec.PublicKey,
+	capacity btcutil.Amount) uint16 {
+
+	if f.cfg.PeerRemoteDelay != nil {
+		if delay, ok := f.cfg.PeerRemoteDelay(peerKey, capacity); ok {
+			return delay
+		}
+	}
+
+	return f.cfg.RequiredRemoteDelay(capacity)
+}
+
+// checkHtlcMinAsymmetry logs a warning if the HtlcMinimum we require from the
+// remote party of the given pending channel and the one it requires from us
+// lie further apart than our HtlcMinAsymmetryPolicy allows. The resulting
+// *lnwire.ErrHtlcMinAsymmetry is returned, or nil if they don't.
+func (f *Manager) checkHtlcMinAsymmetry(pendingChanID [32]byte, localMin,
+	remoteMin lnwire.MilliSatoshi) error {
+
+	policy := DefaultHtlcMinPolicy
+	if f.cfg.HtlcMinAsymmetryPolicy != nil {
+		policy = *f.cfg.HtlcMinAsymmetryPolicy
+	}
+
+	err := lnwire.ValidateHtlcMinAsymmetry(localMin, remoteMin, policy)
+	if err != nil {
+		effectiveMin, _ := lnwire.EffectiveHtlcMin(localMin, remoteMin)
+		log.Warnf("Asymmetric HTLC minimums for pending_id(%x), only "+
+			"HTLCs of at least %v can be routed in both "+
+			"directions: %v", pendingChanID[:], effectiveMin, err)
+	}
+
+	return err
+}
+
+// minAcceptDepth returns the number of confirmations we'll require for the
+// given remote party opening a channel of the given capacity to us. The
+// per-peer policy is consulted first, falling back to the NumRequiredConfs
+// closure if none is set. If we're running against a light client, the
+// depth is raised by LightClientExtraConfs.
+func (f *Manager) minAcceptDepth(peerKey *btcec.PublicKey,
+	capacity btcutil.Amount, pushAmt lnwire.MilliSatoshi) uint16 {
+
+	var depth uint32
+	if f.cfg.DepthPolicy == nil {
+		depth = uint32(f.cfg.NumRequiredConfs(capacity, pushAmt))
+	} else {
+		depth = f.cfg.DepthPolicy(route.NewVertex(peerKey), capacity)
+	}
+
+	if f.cfg.LightClient {
+		depth += LightClientExtraConfs
+	}
+
+	switch {
+	case depth < 1:
+		depth = 1
+	case depth > chainntnfs.MaxNumConfs:
+		depth = chainntnfs.MaxNumConfs
+	}
+
+	return uint16(depth)
+}
+
 // handleFundingOpen creates an initial 'ChannelReservation' within the wallet,
 // then responds to the source peer with an accept channel message progressing
 // the funding workflow.
@@ -1186,6 +1649,19 @@ func (f *Manager) handleFundingOpen(peer lnpeer.Peer,
 
 	amt := msg.FundingAmount
 
+	// Concurrent funding flows with the same peer must use distinct
+	// pending channel IDs, as the reservation of the earlier one would be
+	// overwritten otherwise.
+	if f.IsPendingChannel(msg.PendingChannelID, peer) {
+		log.Warnf("Peer %x reused pending_id(%x) of a funding flow in "+
+			"progress", peerPubKey.SerializeCompressed(),
+			msg.PendingChannelID[:])
+		f.failFundingFlow(
+			peer, msg.PendingChannelID, ErrPendingChanIDInUse,
+		)
+		return
+	}
+
 	// We get all pending channels for this peer. This is the list of the
 	// active reservations and the channels pending open in the database.
 	f.resMtx.RLock()
@@ -1234,6 +1710,13 @@ func (f *Manager) handleFundingOpen(peer lnpeer.Peer,
 		return
 	}
 
+	// Independent of the peer, the total number of our channels must stay
+	// within our limit, so we won't send an AcceptChannel otherwise.
+	if err := f.checkMaxChannels(); err != nil {
+		f.failFundingFlow(peer, msg.PendingChannelID, err)
+		return
+	}
+
 	// We'll also reject any requests to create channels until we're fully
 	// synced to the network as we won't be able to properly validate the
 	// confirmation of the funding transaction.
@@ -1263,7 +1746,7 @@ func (f *Manager) handleFundingOpen(peer lnpeer.Peer,
 	if amt < f.cfg.MinChanSize {
 		f.failFundingFlow(
 			peer, msg.PendingChannelID,
-			lnwallet.ErrChanTooSmall(amt, btcutil.Amount(f.cfg.MinChanSize)),
+			lnwallet.ErrChanTooSmall(amt, f.cfg.MinChanSize),
 		)
 		return
 	}
@@ -1313,6 +1796,21 @@ func (f *Manager) handleFundingOpen(peer lnpeer.Peer,
 	commitType := commitmentType(
 		peer.LocalFeatures(), peer.RemoteFeatures(),
 	)
+
+	// If we only accept channels of a specific commitment type, reject the
+	// request if the features we share with the peer result in another.
+	if f.cfg.RequiredCommitType != nil &&
+		commitType != *f.cfg.RequiredCommitType {
+
+		f.failFundingFlow(
+			peer, msg.PendingChannelID,
+			lnwallet.ErrCommitTypeNotAllowed(
+				commitType, *f.cfg.RequiredCommitType,
+			),
+		)
+		return
+	}
+
 	chainHash := chainhash.Hash(msg.ChainHash)
 	req := &lnwallet.InitFundingReserveMsg{
 		ChainHash:        &chainHash,
@@ -1339,15 +1837,29 @@ func (f *Manager) handleFundingOpen(peer lnpeer.Peer,
 	// As we're the responder, we get to specify the number of confirmations
 	// that we require before both of us consider the channel open. We'll
 	// use our mapping to derive the proper number of confirmations based on
-	// the amount of the channel, and also if any funds are being pushed to
-	// us. If a depth value was set by our channel acceptor, we will use
-	// that value instead.
-	numConfsReq := f.cfg.NumRequiredConfs(msg.FundingAmount, msg.PushAmount)
+	// the peer, the amount of the channel, and also if any funds are being
+	// pushed to us. If a depth value was set by our channel acceptor, we
+	// will use that value instead.
+	numConfsReq := f.minAcceptDepth(
+		peer.IdentityKey(), msg.FundingAmount, msg.PushAmount,
+	)
 	if acceptorResp.MinAcceptDepth != 0 {
 		numConfsReq = acceptorResp.MinAcceptDepth
 	}
 	reservation.SetNumConfsRequired(numConfsReq)
 
+	// The reserve the initiating party requires us to maintain must not
+	// be below our floor.
+	err = f.validateLocalChanReserve(peer.IdentityKey(), msg.ChannelReserve)
+	if err != nil {
+		log.Errorf("Unacceptable channel constraints: %v", err)
+		f.failFundingFlow(peer, msg.PendingChannelID, err)
+		return
+	}
+	if f.zeroReserveAllowed(peer.IdentityKey()) {
+		reservation.AllowZeroReserve()
+	}
+
 	// We'll also validate and apply all the constraints the initiating
 	// party is attempting to dictate for our commitment transaction.
 	channelConstraints := &channeldb.ChannelConstraints{
@@ -1400,12 +1912,12 @@ func (f *Manager) handleFundingOpen(peer lnpeer.Peer,
 
 	// Generate our required constraints for the remote party, using the
 	// values provided by the channel acceptor if they are non-zero.
-	remoteCsvDelay := f.cfg.RequiredRemoteDelay(amt)
+	remoteCsvDelay := f.remoteCsvDelay(peer.IdentityKey(), amt)
 	if acceptorResp.CSVDelay != 0 {
 		remoteCsvDelay = acceptorResp.CSVDelay
 	}
 
-	chanReserve := f.cfg.RequiredRemoteChanReserve(amt, msg.DustLimit)
+	chanReserve := f.peerChanReserve(peerPubKey, amt, msg.DustLimit)
 	if acceptorResp.Reserve != 0 {
 		chanReserve = acceptorResp.Reserve
 	}
@@ -1427,6 +1939,71 @@ func (f *Manager) handleFundingOpen(peer lnpeer.Peer,
 		minHtlc = acceptorResp.MinHtlcIn
 	}
 
+	// With our constraints known, we can assemble the response carrying
+	// our contribution, which we'll send once the initiator's
+	// contribution is recorded.
+	ourContribution := reservation.OurContribution()
+	ourCfg := ourContribution.ChannelConfig
+	firstCommitPoint := ourContribution.FirstCommitmentPoint
+	acceptParams := lnwire.AcceptChannelParams{
+		PendingChannelID: msg.PendingChannelID,
+		Amounts: lnwire.AcceptChannelAmounts{
+			DustLimit:      ourCfg.DustLimit,
+			ChannelReserve: chanReserve,
+		},
+		MSatAmounts: lnwire.AcceptChannelMSatAmounts{
+			MaxValueInFlight: remoteMaxValue,
+			HtlcMinimum:      minHtlc,
+		},
+		MinAcceptDepth:   uint32(numConfsReq),
+		CsvDelay:         remoteCsvDelay,
+		MaxAcceptedHTLCs: maxHtlcs,
+		Keys: lnwire.AcceptChannelKeys{
+			FundingKey:           ourCfg.MultiSigKey.PubKey,
+			RevocationPoint:      ourCfg.RevocationBasePoint.PubKey,
+			PaymentPoint:         ourCfg.PaymentBasePoint.PubKey,
+			DelayedPaymentPoint:  ourCfg.DelayBasePoint.PubKey,
+			HtlcPoint:            ourCfg.HtlcBasePoint.PubKey,
+			FirstCommitmentPoint: firstCommitPoint,
+		},
+		UpfrontShutdownScript: ourContribution.UpfrontShutdown,
+	}
+	fundingAccept, err := lnwire.NewAcceptChannel(acceptParams)
+	if err != nil {
+		log.Errorf("Unable to construct AcceptChannel for "+
+			"pending_id(%x): %v", msg.PendingChannelID, err)
+		if err := reservation.Cancel(); err != nil {
+			log.Errorf("Unable to cancel reservation: %v", err)
+		}
+		f.failFundingFlow(peer, msg.PendingChannelID, err)
+		return
+	}
+
+	// If a hook is configured, it gets the final say on the response.
+	// Since it may have adjusted the policy fields, we'll take them over
+	// as the constraints we require for the remote party.
+	if f.cfg.AcceptChannelHook != nil {
+		err := f.runAcceptChannelHook(fundingAccept, msg)
+		if err != nil {
+			log.Errorf("AcceptChannel hook failed for "+
+				"pending_id(%x): %v", msg.PendingChannelID, err)
+			if err := reservation.Cancel(); err != nil {
+				log.Errorf("Unable to cancel reservation: %v",
+					err)
+			}
+			f.failFundingFlow(peer, msg.PendingChannelID, err)
+			return
+		}
+
+		numConfsReq = uint16(fundingAccept.MinAcceptDepth)
+		reservation.SetNumConfsRequired(numConfsReq)
+		remoteCsvDelay = fundingAccept.CsvDelay
+		chanReserve = fundingAccept.ChannelReserve
+		remoteMaxValue = fundingAccept.MaxValueInFlight
+		maxHtlcs = fundingAccept.MaxAcceptedHTLCs
+		minHtlc = fundingAccept.HtlcMinimum
+	}
+
 	// Once the reservation has been created successfully, we add it to
 	// this peer's map of pending reservations to track this particular
 	// reservation until either abort or completion.
@@ -1437,6 +2014,7 @@ func (f *Manager) handleFundingOpen(peer lnpeer.Peer,
 	resCtx := &reservationWithCtx{
 		reservation:    reservation,
 		chanAmt:        amt,
+		commitType:     commitType,
 		remoteCsvDelay: remoteCsvDelay,
 		remoteMinHtlc:  minHtlc,
 		remoteMaxValue: remoteMaxValue,
@@ -1497,32 +2075,38 @@ func (f *Manager) handleFundingOpen(peer lnpeer.Peer,
 
 	// With the initiator's contribution recorded, respond with our
 	// contribution in the next message of the workflow.
-	ourContribution := reservation.OurContribution()
-	fundingAccept := lnwire.AcceptChannel{
-		PendingChannelID:      msg.PendingChannelID,
-		DustLimit:             ourContribution.DustLimit,
-		MaxValueInFlight:      remoteMaxValue,
-		ChannelReserve:        chanReserve,
-		MinAcceptDepth:        uint32(numConfsReq),
-		HtlcMinimum:           minHtlc,
-		CsvDelay:              remoteCsvDelay,
-		MaxAcceptedHTLCs:      maxHtlcs,
-		FundingKey:            ourContribution.MultiSigKey.PubKey,
-		RevocationPoint:       ourContribution.RevocationBasePoint.PubKey,
-		PaymentPoint:          ourContribution.PaymentBasePoint.PubKey,
-		DelayedPaymentPoint:   ourContribution.DelayBasePoint.PubKey,
-		HtlcPoint:             ourContribution.HtlcBasePoint.PubKey,
-		FirstCommitmentPoint:  ourContribution.FirstCommitmentPoint,
-		UpfrontShutdownScript: ourContribution.UpfrontShutdown,
-	}
-
-	if err := peer.SendMessage(true, &fundingAccept); err != nil {
+	if err := peer.SendMessage(true, fundingAccept); err != nil {
 		log.Errorf("unable to send funding response to peer: %v", err)
 		f.failFundingFlow(peer, msg.PendingChannelID, err)
 		return
 	}
 }
 
+// ProcessMalformedAccept cancels the reservation identified by the passed
+// pending channel ID after the remote peer sent us an AcceptChannel message we
+// were unable to decode. The reservation is cancelled synchronously, so any
+// coins locked for it are released immediately instead of waiting for the
+// zombie sweeper to time it out. If no such reservation exists, e.g. because it
+// was already cancelled, this is a no-op.
+func (f *Manager) ProcessMalformedAccept(pendingChanID [32]byte,
+	decodeErr error, peer lnpeer.Peer) {
+
+	peerKey := peer.IdentityKey()
+	if _, err := f.getReservationCtx(peerKey, pendingChanID); err != nil {
+		log.Debugf("Ignoring malformed AcceptChannel from peer(%x) "+
+			"for unknown pending_id(%x): %v",
+			peerKey.SerializeCompressed(), pendingChanID[:],
+			decodeErr)
+		return
+	}
+
+	log.Warnf("Unable to decode AcceptChannel from peer(%x) for "+
+		"pending_id(%x): %v", peerKey.SerializeCompressed(),
+		pendingChanID[:], decodeErr)
+
+	f.rejectAccept(peer, pendingChanID, rejectReasonMalformed, decodeErr)
+}
+
 // handleFundingAccept processes a response to the workflow initiation sent by
 // the remote peer. This message then queues a message with the funding
 // outpoint, and a commitment signature to the remote peer.
@@ -1534,9 +2118,43 @@ func (f *Manager) handleFundingAccept(peer lnpeer.Peer,
 
 	resCtx, err := f.getReservationCtx(peerKey, pendingChanID)
 	if err != nil {
+		// The reservation may have been lost to a restart before the
+		// AcceptChannel arrived, in which case we'll resume it.
+		resCtx, err = f.resumeReservation(peer, pendingChanID)
+	}
+	switch {
+	case err == errPendingReservationNotFound:
 		log.Warnf("Can't find reservation (peerKey:%v, chan_id:%v)",
 			peerKey, pendingChanID)
 		return
+
+	case err != nil:
+		log.Errorf("Unable to resume reservation (peerKey:%v, "+
+			"chan_id:%v): %v", peerKey, pendingChanID, err)
+		f.failFundingFlow(peer, pendingChanID, err)
+		return
+	}
+
+	// Each reservation is accepted only once, as a second AcceptChannel
+	// would be processed against a reservation that already moved on.
+	if resCtx.hasAcceptMsg() {
+		log.Warnf("Unacceptable AcceptChannel for pending_id(%x): %v",
+			pendingChanID[:], ErrPendingChanIDInUse)
+		f.rejectAccept(
+			peer, pendingChanID, rejectReasonPendingChanIDInUse,
+			ErrPendingChanIDInUse,
+		)
+		return
+	}
+
+	// Now that the AcceptChannel has been received, the reservation no
+	// longer needs to be resumed after a restart.
+	if resCtx.persisted {
+		err := f.deletePendingReservation(peerKey, pendingChanID)
+		if err != nil {
+			log.Errorf("Unable to delete pending reservation: %v",
+				err)
+		}
 	}
 
 	// Update the timestamp once the fundingAcceptMsg has been handled.
@@ -1545,6 +2163,140 @@ func (f *Manager) handleFundingAccept(peer lnpeer.Peer,
 	log.Infof("Recv'd fundingResponse for pending_id(%x)",
 		pendingChanID[:])
 
+	f.sendFundingEvent(AcceptChannelReceivedEvent{
+		PendingID: pendingChanID,
+		Peer:      peerKey,
+	})
+
+	// A first commitment point the peer already sent us for an earlier
+	// channel indicates a bug in its derivation of per-commitment secrets.
+	// The point is remembered even if the AcceptChannel is rejected.
+	if f.seenCommitPoints.observe(peerKey, msg.FirstCommitmentPoint) {
+		err := &ErrCommitPointReused{Point: msg.FirstCommitmentPoint}
+		if !f.acceptCheckFailed(
+			peer, pendingChanID, rejectReasonCommitPointReuse, err,
+		) {
+
+			return
+		}
+	}
+
+	// Make sure the peer doesn't reuse any of its keys, as this would
+	// weaken the keys derived from them.
+	if err := msg.ValidatePubKeys(); err != nil {
+		log.Warnf("Invalid AcceptChannel keys: %v", err)
+		f.rejectAccept(
+			peer, pendingChanID, rejectReasonDuplicatePubKey, err,
+		)
+		return
+	}
+
+	// If our policy requires it, the peer must not reuse its node
+	// identity key as funding key.
+	if f.cfg.RejectIdentityFundingKey {
+		err := msg.ValidateFundingKey(peerKey)
+		if err != nil && !f.acceptCheckFailed(
+			peer, pendingChanID, rejectReasonFundingKeyIsIdentity,
+			err,
+		) {
+
+			return
+		}
+	}
+
+	// We can't create a channel of a type requiring features we don't
+	// know of.
+	if err := msg.ValidateChannelType(); err != nil {
+		log.Warnf("Invalid AcceptChannel channel type: %v", err)
+
+		reason := rejectReasonMalformed
+		if _, ok := err.(*lnwire.ErrUnknownChannelType); ok {
+			reason = rejectReasonUnknownChannelType
+		}
+		f.rejectAccept(peer, pendingChanID, reason, err)
+		return
+	}
+
+	// The peer may accept the channel with an older commitment type than
+	// the one we proposed, if it doesn't support ours. We'll only follow
+	// it there if our config allows it.
+	if !f.negotiateCommitType(peer, resCtx, msg) {
+		return
+	}
+
+	// The features both of us signal determine the optional records the
+	// peer must include in its AcceptChannel.
+	wireVersion := lnwire.NegotiatedWireVersion(
+		peer.LocalFeatures(), peer.RemoteFeatures(),
+	)
+
+	// If both of us signal the upfront shutdown script feature, the peer
+	// must send the script record, even if it is zero-length.
+	upfrontShutdown := wireVersion&lnwire.WireVersionUpfrontShutdown != 0
+	if err := msg.ValidateUpfrontShutdown(upfrontShutdown); err != nil {
+		log.Warnf("Invalid AcceptChannel: %v", err)
+		f.rejectAccept(
+			peer, pendingChanID,
+			rejectReasonUpfrontShutdownAbsent, err,
+		)
+		return
+	}
+
+	// If our policy requires it, the peer must commit to the script any
+	// cooperative close will pay out to.
+	if f.cfg.RequireRemoteUpfrontShutdown &&
+		len(msg.UpfrontShutdownScript) == 0 {
+
+		err := lnwallet.ErrUpfrontShutdownRequired()
+		if !f.acceptCheckFailed(
+			peer, pendingChanID, acceptRejectionReason(err), err,
+		) {
+
+			return
+		}
+	}
+
+	// Outputs of a cooperative close paying to the peer's upfront shutdown
+	// script must not be dust, so its dust limit must cover the script
+	// type.
+	if err := msg.ValidateDustLimit(); err != nil {
+		if !f.acceptCheckFailed(
+			peer, pendingChanID,
+			rejectReasonDustLimitBelowScript, err,
+		) {
+
+			return
+		}
+	}
+
+	// If our policy requires it, the peer's max value in flight must
+	// leave room for at least one HTLC and must not exceed the capacity.
+	if f.cfg.RejectExcessMaxValueInFlight {
+		err := lnwire.ValidateMaxValueInFlight(msg, resCtx.chanAmt)
+		if err != nil && !f.acceptCheckFailed(
+			peer, pendingChanID, rejectReasonMaxValueInFlight, err,
+		) {
+
+			return
+		}
+	}
+
+	// Zero-conf requires both sides to agree, so the peer may only waive
+	// the confirmations of the funding transaction if we offered it. As we
+	// don't support zero-conf channels, our feature manager never offers
+	// it, so a MinAcceptDepth of zero is always rejected in practice.
+	zeroConfOffered := peer.LocalFeatures().HasFeature(
+		lnwire.ZeroConfOptional,
+	)
+	if err := msg.ValidateMinAcceptDepth(zeroConfOffered); err != nil {
+		log.Warnf("Invalid AcceptChannel: %v", err)
+		f.rejectAccept(
+			peer, pendingChanID, rejectReasonUnexpectedZeroConf,
+			err,
+		)
+		return
+	}
+
 	// The required number of confirmations should not be greater than the
 	// maximum number of confirmations required by the ChainNotifier to
 	// properly dispatch confirmations.
@@ -1553,42 +2305,186 @@ func (f *Manager) handleFundingAccept(peer lnpeer.Peer,
 			msg.MinAcceptDepth, chainntnfs.MaxNumConfs,
 		)
 		log.Warnf("Unacceptable channel constraints: %v", err)
-		f.failFundingFlow(peer, msg.PendingChannelID, err)
+		f.rejectAccept(
+			peer, pendingChanID, acceptRejectionReason(err), err,
+		)
 		return
 	}
 
+	// Fail early if the number of HTLCs the remote party allows us to
+	// offer is below our configured minimum.
+	if msg.MaxAcceptedHTLCs < f.cfg.MinRemoteMaxHtlcs {
+		err := lnwallet.ErrMaxHtlcsTooLow(
+			msg.MaxAcceptedHTLCs, f.cfg.MinRemoteMaxHtlcs,
+		)
+		if !f.acceptCheckFailed(
+			peer, pendingChanID, acceptRejectionReason(err), err,
+		) {
+
+			return
+		}
+	}
+
+	// The reserve the peer requires us to maintain must not be below our
+	// floor.
+	err = f.validateLocalChanReserve(peerKey, msg.ChannelReserve)
+	if err != nil {
+		log.Warnf("Unacceptable channel constraints: %v", err)
+		f.rejectAccept(
+			peer, pendingChanID, acceptRejectionReason(err), err,
+		)
+		return
+	}
+
+	// If our policy bounds it, the reserve the peer requires us to keep
+	// must not be much larger than the one we require from it.
+	if f.cfg.ReserveAsymmetryPolicy != nil {
+		remoteReserve := f.peerChanReserve(
+			peerKey, resCtx.chanAmt, msg.DustLimit,
+		)
+		err := lnwire.ValidateReserveAsymmetry(
+			msg.ChannelReserve, remoteReserve,
+			*f.cfg.ReserveAsymmetryPolicy,
+		)
+		if err != nil && !f.acceptCheckFailed(
+			peer, pendingChanID, rejectReasonReserveAsymmetry, err,
+		) {
+
+			return
+		}
+	}
+
+	// As we fund the anchor outputs of anchor channels out of our balance,
+	// the reserve the peer requires us to maintain should leave our output
+	// above its dust limit after paying for them. Unless our SeverityPolicy
+	// says otherwise, this is only warned about, as the default reserve of
+	// small channels doesn't satisfy it.
+	anchors := resCtx.commitType ==
+		lnwallet.CommitmentTypeAnchorsZeroFeeHtlcTx
+	err = lnwire.ValidateAnchorReserve(msg, anchors)
+	if err != nil && !f.acceptCheckFailed(
+		peer, pendingChanID, rejectReasonAnchorReserve, err,
+	) {
+
+		return
+	}
+
+	// Routing over the channel must respect the HtlcMinimum of both
+	// directions, so we warn if the one of the peer lies far apart from
+	// ours. This doesn't fail the funding flow.
+	_ = f.checkHtlcMinAsymmetry(
+		pendingChanID, resCtx.remoteMinHtlc, msg.HtlcMinimum,
+	)
+
 	// We'll also specify the responder's preference for the number of
 	// required confirmations, and also the set of channel constraints
 	// they've specified for commitment states we can create.
 	resCtx.reservation.SetNumConfsRequired(uint16(msg.MinAcceptDepth))
+	if f.zeroReserveAllowed(peerKey) {
+		resCtx.reservation.AllowZeroReserve()
+	}
 	channelConstraints := &channeldb.ChannelConstraints{
 		DustLimit:        msg.DustLimit,
 		ChanReserve:      msg.ChannelReserve,
 		MaxPendingAmount: msg.MaxValueInFlight,
 		MinHTLC:          msg.HtlcMinimum,
 		MaxAcceptedHtlcs: msg.MaxAcceptedHTLCs,
-		CsvDelay:         msg.CsvDelay,
+		CsvDelay:         msg.LocalToSelfDelay(),
 	}
 	err = resCtx.reservation.CommitConstraints(
 		channelConstraints, resCtx.maxLocalCsv,
 	)
 	if err != nil {
 		log.Warnf("Unacceptable channel constraints: %v", err)
+		f.rejectAccept(
+			peer, pendingChanID, acceptRejectionReason(err), err,
+		)
+		return
+	}
+
+	// The peer may bound the expiry of the HTLCs we offer. The bound is
+	// persisted with the channel, and enforced by its link once the
+	// channel is open.
+	maxHtlcExpiryDelta, _, err := msg.MaxHtlcExpiryDelta()
+	if err != nil {
+		log.Warnf("Invalid AcceptChannel max htlc expiry delta: %v",
+			err)
 		f.failFundingFlow(peer, msg.PendingChannelID, err)
 		return
 	}
+	resCtx.maxHtlcExpiryDelta = maxHtlcExpiryDelta
+	resCtx.reservation.SetMaxHtlcExpiryDelta(maxHtlcExpiryDelta)
+
+	// The peer may signal the range of commitment fee rates it prefers. As
+	// we already proposed our fee rate in the OpenChannel, the range must
+	// contain it.
+	feeRange, ok, err := msg.FeeRateRange()
+	if err != nil {
+		log.Warnf("Invalid AcceptChannel fee rate range: %v", err)
+		f.failFundingFlow(peer, msg.PendingChannelID, err)
+		return
+	}
+	if ok {
+		feePerKw := uint32(resCtx.reservation.CommitFeePerKw())
+		proposal := lnwire.FeeRateRange{Min: feePerKw, Max: feePerKw}
+		_, err := proposal.Reconcile(feeRange)
+		if err != nil && !f.acceptCheckFailed(
+			peer, pendingChanID, rejectReasonFeeRateRange, err,
+		) {
+
+			return
+		}
+	}
+
+	// If our policy bounds it, the fee of the commitment transaction
+	// carrying as many HTLCs as the peer allows us to offer must not take
+	// up too large a share of our balance.
+	if f.cfg.MaxCommitFeeAllocation > 0 {
+		reservation := resCtx.reservation
+		maxCommitWeight := lnwallet.MaxCommitWeight(
+			msg, reservation.ChanType(),
+		)
+		maxCommitFee := reservation.CommitFeePerKw().FeeForWeight(
+			maxCommitWeight,
+		)
+		ourBalance := reservation.OurContribution().FundingAmount
+		maxAllowed := btcutil.Amount(
+			float64(ourBalance) * f.cfg.MaxCommitFeeAllocation,
+		)
+		if maxCommitFee > maxAllowed {
+			err := lnwallet.ErrMaxCommitFeeTooHigh(
+				maxCommitFee, maxAllowed,
+			)
+			if !f.acceptCheckFailed(
+				peer, pendingChanID, acceptRejectionReason(err),
+				err,
+			) {
+
+				return
+			}
+		}
+	}
+
+	// All checks of the AcceptChannel passed, so we'll count it by the
+	// capacity tier of the channel.
+	recordAcceptChannel(
+		resCtx.chanAmt, msg.ChannelReserve, msg.MinAcceptDepth,
+	)
 
 	// As they've accepted our channel constraints, we'll regenerate them
 	// here so we can properly commit their accepted constraints to the
 	// reservation.
-	chanReserve := f.cfg.RequiredRemoteChanReserve(resCtx.chanAmt, msg.DustLimit)
+	chanReserve := f.peerChanReserve(
+		peerKey, resCtx.chanAmt, msg.DustLimit,
+	)
 
 	// The remote node has responded with their portion of the channel
 	// contribution. At this point, we can process their contribution which
 	// allows us to construct and sign both the commitment transaction, and
 	// the funding transaction.
+	basePoints := msg.BasePoints()
 	remoteContribution := &lnwallet.ChannelContribution{
-		FirstCommitmentPoint: msg.FirstCommitmentPoint,
+		FirstCommitmentPoint: basePoints.FirstCommit,
 		ChannelConfig: &channeldb.ChannelConfig{
 			ChannelConstraints: channeldb.ChannelConstraints{
 				DustLimit:        msg.DustLimit,
@@ -1599,19 +2495,19 @@ func (f *Manager) handleFundingAccept(peer lnpeer.Peer,
 				CsvDelay:         resCtx.remoteCsvDelay,
 			},
 			MultiSigKey: keychain.KeyDescriptor{
-				PubKey: copyPubKey(msg.FundingKey),
+				PubKey: copyPubKey(basePoints.Funding),
 			},
 			RevocationBasePoint: keychain.KeyDescriptor{
-				PubKey: copyPubKey(msg.RevocationPoint),
+				PubKey: copyPubKey(basePoints.Revocation),
 			},
 			PaymentBasePoint: keychain.KeyDescriptor{
-				PubKey: copyPubKey(msg.PaymentPoint),
+				PubKey: copyPubKey(basePoints.Payment),
 			},
 			DelayBasePoint: keychain.KeyDescriptor{
-				PubKey: copyPubKey(msg.DelayedPaymentPoint),
+				PubKey: copyPubKey(basePoints.DelayedPayment),
 			},
 			HtlcBasePoint: keychain.KeyDescriptor{
-				PubKey: copyPubKey(msg.HtlcPoint),
+				PubKey: copyPubKey(basePoints.Htlc),
 			},
 		},
 		UpfrontShutdown: msg.UpfrontShutdownScript,
@@ -1655,15 +2551,27 @@ func (f *Manager) handleFundingAccept(peer lnpeer.Peer,
 	} else if err != nil {
 		log.Errorf("Unable to process contribution from %v: %v",
 			peerKey, err)
-		f.failFundingFlow(peer, msg.PendingChannelID, err)
+		f.rejectAccept(
+			peer, pendingChanID, acceptRejectionReason(err), err,
+		)
 		return
 	}
 
+	resCtx.setAcceptMsg(msg)
+
 	log.Infof("pendingChan(%x): remote party proposes num_confs=%v, "+
 		"csv_delay=%v", pendingChanID[:], msg.MinAcceptDepth, msg.CsvDelay)
 	log.Debugf("Remote party accepted commitment constraints: %v",
 		spew.Sdump(remoteContribution.ChannelConfig.ChannelConstraints))
 
+	f.cacheAcceptPolicy(peerKey, resCtx.chanAmt, msg)
+
+	f.sendFundingEvent(AcceptChannelProcessedEvent{
+		PendingID: pendingChanID,
+		Peer:      peerKey,
+		Result:    newNegotiationResult(resCtx, msg),
+	})
+
 	// If the user requested funding through a PSBT, we cannot directly
 	// continue now and need to wait for the fully funded and signed PSBT
 	// to arrive. To not block any other channels from opening, we wait in
@@ -1711,8 +2619,20 @@ func (f *Manager) waitForPsbt(intent *chanfunding.PsbtIntent,
 	case err := <-intent.PsbtReady:
 		switch err {
 		// If the user canceled the funding reservation, we need to
-		// inform the other peer about us canceling the reservation.
+		// inform the other peer about us canceling the reservation,
+		// unless the reservation is already gone because the funding
+		// flow was failed, e.g. by aborting the negotiation, which
+		// informed the other peer already.
 		case chanfunding.ErrUserCanceled:
+			if !f.IsPendingChannel(pendingChanID, resCtx.peer) {
+				log.Infof("Reservation canceled, aborting "+
+					"PSBT flow for peer_key=%x, "+
+					"pending_chan_id=%x",
+					peerKey.SerializeCompressed(),
+					pendingChanID)
+				return
+			}
+
 			failFlow("aborting PSBT flow", err)
 			return
 
@@ -1769,24 +2689,34 @@ func (f *Manager) continueFundingAccept(resCtx *reservationWithCtx,
 	outPoint := resCtx.reservation.FundingOutpoint()
 	_, sig := resCtx.reservation.OurSignatures()
 
+	// The next message that advances the funding flow will reference the
+	// channel via its permanent channel ID, so we'll set up this mapping
+	// so we can retrieve the reservation context once we get the
+	// FundingSigned message. Setting it up under the same lock as
+	// AbortFunding checks it ensures that an aborted negotiation never
+	// sends the FundingCreated message, while one that already got here
+	// can no longer be aborted.
+	channelID := lnwire.NewChanIDFromOutPoint(outPoint)
+	f.resMtx.Lock()
+	if resCtx.aborted {
+		f.resMtx.Unlock()
+
+		log.Infof("Funding negotiation for pending_id(%x) was "+
+			"aborted, not sending FundingCreated", pendingChanID[:])
+		return
+	}
+	f.signedReservations[channelID] = pendingChanID
+	f.resMtx.Unlock()
+
 	// A new channel has almost finished the funding process. In order to
 	// properly synchronize with the writeHandler goroutine, we add a new
 	// channel to the barriers map which will be closed once the channel is
 	// fully open.
 	f.barrierMtx.Lock()
-	channelID := lnwire.NewChanIDFromOutPoint(outPoint)
 	log.Debugf("Creating chan barrier for ChanID(%v)", channelID)
 	f.newChanBarriers[channelID] = make(chan struct{})
 	f.barrierMtx.Unlock()
 
-	// The next message that advances the funding flow will reference the
-	// channel via its permanent channel ID, so we'll set up this mapping
-	// so we can retrieve the reservation context once we get the
-	// FundingSigned message.
-	f.resMtx.Lock()
-	f.signedReservations[channelID] = pendingChanID
-	f.resMtx.Unlock()
-
 	log.Infof("Generated ChannelPoint(%v) for pending_id(%x)", outPoint,
 		pendingChanID[:])
 
@@ -1801,6 +2731,11 @@ func (f *Manager) continueFundingAccept(resCtx *reservationWithCtx,
 		f.failFundingFlow(resCtx.peer, pendingChanID, err)
 		return
 	}
+
+	// The funding latency of the peer is measured from here on, which
+	// leaves out the time it took us to fund the channel, e.g. through a
+	// PSBT.
+	resCtx.setFundingCreatedSent(time.Now())
 	if err := resCtx.peer.SendMessage(true, fundingCreated); err != nil {
 		log.Errorf("Unable to send funding complete message: %v", err)
 		f.failFundingFlow(resCtx.peer, pendingChanID, err)
@@ -2027,6 +2962,18 @@ func (f *Manager) handleFundingSigned(peer lnpeer.Peer,
 	// delete it from our set of active reservations.
 	f.deleteReservationCtx(peerKey, pendingChanID)
 
+	// Keep the AcceptChannel and its unknown records around for as long
+	// as the channel is pending, so they can be inspected over RPC.
+	f.storeAcceptMsg(*fundingPoint, resCtx)
+	f.storeAcceptExtraData(*fundingPoint, resCtx)
+
+	// With the FundingSigned received, the remote party completed its
+	// part of the funding flow, so we record how long it took.
+	if err := f.recordFundingLatency(peerKey, resCtx); err != nil {
+		log.Errorf("Unable to record funding latency of peer %x: %v",
+			peerKey.SerializeCompressed(), err)
+	}
+
 	// Broadcast the finalized funding transaction to the network, but only
 	// if we actually have the funding transaction.
 	if completeChan.ChanType.HasFundingTx() {
@@ -2378,6 +3325,11 @@ func (f *Manager) handleFundingConfirmation(
 		return fmt.Errorf("error setting channel pending flag to false: "+
 			"%v", err)
 	}
+	f.deleteAcceptState(fundingPoint)
+
+	// Record all parameters negotiated for the now open channel in a
+	// single line, so they can be audited.
+	logOpenedChannel(log, completeChan)
 
 	// Inform the ChannelNotifier that the channel has transitioned from
 	// pending open to open.
@@ -3088,6 +4040,43 @@ func getUpfrontShutdownScript(enableUpfrontShutdown bool, peer lnpeer.Peer,
 	return getScript()
 }
 
+// localDustLimit returns the dust limit we'll use for our side of a new
+// channel, which depends on the chain that is currently active.
+func (f *Manager) localDustLimit() btcutil.Amount {
+	var ourDustLimit btcutil.Amount
+	switch f.cfg.RegisteredChains.PrimaryChain() {
+	case chainreg.BitcoinChain:
+		ourDustLimit = lnwallet.DefaultDustLimit()
+	case chainreg.LitecoinChain:
+		ourDustLimit = chainreg.DefaultLitecoinDustLimit
+	}
+
+	return ourDustLimit
+}
+
+// initialCommitFeeRate queries the fee estimator for a fee rate that should
+// get the commitment transaction confirmed by the next few blocks (conf target
+// of 3). We target the near blocks here to ensure that we'll be able to
+// execute a timely unilateral channel closure if needed.
+func (f *Manager) initialCommitFeeRate(
+	commitType lnwallet.CommitmentType) (chainfee.SatPerKWeight, error) {
+
+	commitFeePerKw, err := f.cfg.FeeEstimator.EstimateFeePerKW(3)
+	if err != nil {
+		return 0, err
+	}
+
+	// For anchor channels cap the initial commit fee rate at our defined
+	// maximum.
+	if commitType == lnwallet.CommitmentTypeAnchorsZeroFeeHtlcTx &&
+		commitFeePerKw > f.cfg.MaxAnchorsCommitFeeRate {
+
+		commitFeePerKw = f.cfg.MaxAnchorsCommitFeeRate
+	}
+
+	return commitFeePerKw, nil
+}
+
 // handleInitFundingMsg creates a channel reservation within the daemon's
 // wallet, then sends a funding request to the remote peer kicking off the
 // funding workflow.
@@ -3109,13 +4098,7 @@ func (f *Manager) handleInitFundingMsg(msg *InitFundingMsg) {
 	}
 
 	// We'll determine our dust limit depending on which chain is active.
-	var ourDustLimit btcutil.Amount
-	switch f.cfg.RegisteredChains.PrimaryChain() {
-	case chainreg.BitcoinChain:
-		ourDustLimit = lnwallet.DefaultDustLimit()
-	case chainreg.LitecoinChain:
-		ourDustLimit = chainreg.DefaultLitecoinDustLimit
-	}
+	ourDustLimit := f.localDustLimit()
 	log.Infof("Initiating fundingRequest(local_amt=%v "+
 		"(subtract_fees=%v), push_amt=%v, chain_hash=%v, peer=%x, "+
 		"dust_limit=%v, min_confs=%v)", localAmt, msg.SubtractFees,
@@ -3148,6 +4131,13 @@ func (f *Manager) handleInitFundingMsg(msg *InitFundingMsg) {
 		}
 	}
 
+	// Before sending an OpenChannel, ensure that the total number of our
+	// channels stays within our limit.
+	if err := f.checkMaxChannels(); err != nil {
+		msg.Err <- err
+		return
+	}
+
 	// Check whether the peer supports upfront shutdown, and get an address
 	// which should be used (either a user specified address or a new
 	// address from the wallet if our node is configured to set shutdown
@@ -3182,24 +4172,14 @@ func (f *Manager) handleInitFundingMsg(msg *InitFundingMsg) {
 		msg.Peer.LocalFeatures(), msg.Peer.RemoteFeatures(),
 	)
 
-	// First, we'll query the fee estimator for a fee that should get the
-	// commitment transaction confirmed by the next few blocks (conf target
-	// of 3). We target the near blocks here to ensure that we'll be able
-	// to execute a timely unilateral channel closure if needed.
-	commitFeePerKw, err := f.cfg.FeeEstimator.EstimateFeePerKW(3)
+	// First, we'll query the fee estimator for the fee rate of the initial
+	// commitment transaction.
+	commitFeePerKw, err := f.initialCommitFeeRate(commitType)
 	if err != nil {
 		msg.Err <- err
 		return
 	}
 
-	// For anchor channels cap the initial commit fee rate at our defined
-	// maximum.
-	if commitType == lnwallet.CommitmentTypeAnchorsZeroFeeHtlcTx &&
-		commitFeePerKw > f.cfg.MaxAnchorsCommitFeeRate {
-
-		commitFeePerKw = f.cfg.MaxAnchorsCommitFeeRate
-	}
-
 	req := &lnwallet.InitFundingReserveMsg{
 		ChainHash:        &msg.ChainHash,
 		PendingChanID:    chanID,
@@ -3265,13 +4245,18 @@ func (f *Manager) handleInitFundingMsg(msg *InitFundingMsg) {
 		f.activeReservations[peerIDKey] = make(pendingChannels)
 	}
 
+	// Only reservations funded through an external funding shim outlive
+	// the connection they were started on, so those are the only ones
+	// we'll persist to be resumed after a disconnect or restart.
 	resCtx := &reservationWithCtx{
 		chanAmt:        capacity,
+		commitType:     commitType,
 		remoteCsvDelay: remoteCsvDelay,
 		remoteMinHtlc:  minHtlcIn,
 		remoteMaxValue: maxValue,
 		remoteMaxHtlcs: maxHtlcs,
 		maxLocalCsv:    maxCSV,
+		persisted:      reservation.IsCannedShim(),
 		reservation:    reservation,
 		peer:           msg.Peer,
 		updates:        msg.Updates,
@@ -3290,7 +4275,9 @@ func (f *Manager) handleInitFundingMsg(msg *InitFundingMsg) {
 	// Finally, we'll use the current value of the channels and our default
 	// policy to determine of required commitment constraints for the
 	// remote party.
-	chanReserve := f.cfg.RequiredRemoteChanReserve(capacity, ourDustLimit)
+	chanReserve := f.peerChanReserve(
+		msg.Peer.IdentityKey(), capacity, ourDustLimit,
+	)
 
 	log.Infof("Starting funding workflow with %v for pending_id(%x), "+
 		"committype=%v", msg.Peer.Address(), chanID, commitType)
@@ -3316,6 +4303,22 @@ func (f *Manager) handleInitFundingMsg(msg *InitFundingMsg) {
 		ChannelFlags:          channelFlags,
 		UpfrontShutdownScript: shutdown,
 	}
+
+	// Persist the reservation before sending the OpenChannel, such that
+	// we're able to resume it if the AcceptChannel only arrives after a
+	// restart. Failing to do so doesn't prevent the funding flow from
+	// completing without a restart, so we'll only log the error.
+	if resCtx.persisted {
+		err := f.persistReservation(
+			peerKey, &fundingOpen, commitType, msg, maxCSV,
+			reservation,
+		)
+		if err != nil {
+			log.Errorf("Unable to persist reservation for "+
+				"pending_id(%x): %v", chanID[:], err)
+		}
+	}
+
 	if err := msg.Peer.SendMessage(true, &fundingOpen); err != nil {
 		e := fmt.Errorf("unable to send funding request message: %v",
 			err)
@@ -3349,6 +4352,14 @@ func (f *Manager) handleErrorMsg(peer lnpeer.Peer,
 	if err != nil {
 		log.Warnf("Received error for non-existent funding "+
 			"flow: %v (%v)", err, msg.Error())
+
+		// The funding flow may still be persisted if it was lost to a
+		// restart, in which case it must no longer be resumed.
+		err := f.deletePendingReservation(peerKey, chanID)
+		if err != nil {
+			log.Errorf("Unable to delete pending reservation: %v",
+				err)
+		}
 		return
 	}
 
@@ -3444,6 +4455,19 @@ func (f *Manager) cancelReservationCtx(peerKey *btcec.PublicKey,
 			err)
 	}
 
+	// A canceled reservation must not be resumed after a restart.
+	if ctx.persisted {
+		err := f.deletePendingReservation(peerKey, pendingChanID)
+		if err != nil {
+			log.Errorf("Unable to delete pending reservation: %v",
+				err)
+		}
+	}
+
+	// Nor must the AcceptChannel of its channel outlive it, if its
+	// funding outpoint is known already.
+	f.deleteAcceptState(*ctx.reservation.FundingOutpoint())
+
 	delete(nodeReservations, pendingChanID)
 
 	// If this was the last active reservation for this peer, delete the
@@ -4,15 +4,19 @@ package funding
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"math/big"
 	"net"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -20,6 +24,7 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btclog"
 	"github.com/btcsuite/btcutil"
 	"github.com/lightningnetwork/lnd/chainntnfs"
 	"github.com/lightningnetwork/lnd/chainreg"
@@ -27,16 +32,22 @@ import (
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/channelnotifier"
 	"github.com/lightningnetwork/lnd/discovery"
+	"github.com/lightningnetwork/lnd/feature"
 	"github.com/lightningnetwork/lnd/htlcswitch"
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/kvdb"
 	"github.com/lightningnetwork/lnd/lncfg"
 	"github.com/lightningnetwork/lnd/lnpeer"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lntest/mock"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwallet/chanfunding"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -194,10 +205,16 @@ type testNode struct {
 	mockChanEvent   *mockChanEvent
 	testDir         string
 	shutdownChannel chan struct{}
+	localFeatures   []lnwire.FeatureBit
 	remoteFeatures  []lnwire.FeatureBit
 
 	remotePeer  *testNode
 	sendMessage func(lnwire.Message) error
+
+	// acceptChannels holds the AcceptChannel messages sent to the node,
+	// as decoded from their wire encoding.
+	acceptChannels []*lnwire.AcceptChannel
+	acceptMtx      sync.Mutex
 }
 
 var _ lnpeer.Peer = (*testNode)(nil)
@@ -215,9 +232,62 @@ func (n *testNode) PubKey() [33]byte {
 }
 
 func (n *testNode) SendMessage(_ bool, msg ...lnwire.Message) error {
+	if accept, ok := msg[0].(*lnwire.AcceptChannel); ok {
+		if err := n.recvAcceptChannel(accept); err != nil {
+			return err
+		}
+	}
+
 	return n.sendMessage(msg[0])
 }
 
+// recvAcceptChannel decodes the AcceptChannel sent to the node from its wire
+// encoding and validates it like a real peer would, before recording it. This
+// allows tests to make assertions on the fields that actually made it onto
+// the wire, rather than on the message object handed to the node.
+func (n *testNode) recvAcceptChannel(msg *lnwire.AcceptChannel) error {
+	var b bytes.Buffer
+	if _, err := lnwire.WriteMessage(&b, msg, 0); err != nil {
+		return err
+	}
+
+	decoded, err := lnwire.ReadMessage(&b, 0)
+	if err != nil {
+		return err
+	}
+	accept, ok := decoded.(*lnwire.AcceptChannel)
+	if !ok {
+		return fmt.Errorf("expected AcceptChannel, got %T", decoded)
+	}
+
+	if err := accept.ValidatePubKeys(); err != nil {
+		return err
+	}
+
+	wireVersion := lnwire.NegotiatedWireVersion(
+		n.LocalFeatures(), n.RemoteFeatures(),
+	)
+	upfrontShutdown := wireVersion&lnwire.WireVersionUpfrontShutdown != 0
+	if err := accept.ValidateUpfrontShutdown(upfrontShutdown); err != nil {
+		return err
+	}
+
+	n.acceptMtx.Lock()
+	n.acceptChannels = append(n.acceptChannels, accept)
+	n.acceptMtx.Unlock()
+
+	return nil
+}
+
+// receivedAcceptChannels returns the AcceptChannel messages sent to the node
+// so far, in the order they were received.
+func (n *testNode) receivedAcceptChannels() []*lnwire.AcceptChannel {
+	n.acceptMtx.Lock()
+	defer n.acceptMtx.Unlock()
+
+	return append([]*lnwire.AcceptChannel(nil), n.acceptChannels...)
+}
+
 func (n *testNode) SendMessageLazy(sync bool, msgs ...lnwire.Message) error {
 	return n.SendMessage(sync, msgs...)
 }
@@ -228,8 +298,58 @@ func (n *testNode) QuitSignal() <-chan struct{} {
 	return n.shutdownChannel
 }
 
+// basePointKeyRing is a mock.SecretKeyRing that derives a distinct key for
+// each of the channel base points, such that the keys a node sends in its
+// OpenChannel and AcceptChannel messages don't collide. Multi-sig keys are
+// still derived from the root key, which the mock signer signs with.
+type basePointKeyRing struct {
+	*mock.SecretKeyRing
+}
+
+// DeriveNextKey derives a key for the given family.
+//
+// NOTE: This is part of the keychain.KeyRing interface.
+func (b *basePointKeyRing) DeriveNextKey(keyFam keychain.KeyFamily) (
+	keychain.KeyDescriptor, error) {
+
+	if keyFam == keychain.KeyFamilyMultiSig {
+		return b.SecretKeyRing.DeriveNextKey(keyFam)
+	}
+
+	return b.DeriveKey(keychain.KeyLocator{Family: keyFam})
+}
+
+// DeriveKey derives the key with the given locator, matching the keys
+// returned by DeriveNextKey.
+//
+// NOTE: This is part of the keychain.KeyRing interface.
+func (b *basePointKeyRing) DeriveKey(keyLoc keychain.KeyLocator) (
+	keychain.KeyDescriptor, error) {
+
+	keyFam := keyLoc.Family
+	if keyFam == keychain.KeyFamilyMultiSig {
+		return b.SecretKeyRing.DeriveKey(keyLoc)
+	}
+
+	var famBytes [4]byte
+	binary.BigEndian.PutUint32(famBytes[:], uint32(keyFam))
+	seed := sha256.Sum256(append(
+		b.RootKey.Serialize(), famBytes[:]...,
+	))
+	_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), seed[:])
+
+	return keychain.KeyDescriptor{
+		KeyLocator: keychain.KeyLocator{
+			Family: keyFam,
+		},
+		PubKey: pubKey,
+	}, nil
+}
+
 func (n *testNode) LocalFeatures() *lnwire.FeatureVector {
-	return lnwire.NewFeatureVector(nil, nil)
+	return lnwire.NewFeatureVector(
+		lnwire.NewRawFeatureVector(n.localFeatures...), nil,
+	)
 }
 
 func (n *testNode) RemoteFeatures() *lnwire.FeatureVector {
@@ -238,6 +358,20 @@ func (n *testNode) RemoteFeatures() *lnwire.FeatureVector {
 	)
 }
 
+// defaultInitFeatures returns the feature bits lnd advertises in its Init
+// message with the default feature config.
+func defaultInitFeatures(t *testing.T) []lnwire.FeatureBit {
+	featureMgr, err := feature.NewManager(feature.Config{})
+	require.NoError(t, err)
+
+	var features []lnwire.FeatureBit
+	for bit := range featureMgr.Get(feature.SetInit).Features() {
+		features = append(features, bit)
+	}
+
+	return features
+}
+
 func (n *testNode) AddNewChannel(channel *channeldb.OpenChannel,
 	quit <-chan struct{}) error {
 
@@ -334,8 +468,10 @@ func createTestFundingManager(t *testing.T, privKey *btcec.PrivateKey,
 		return nil, err
 	}
 
-	keyRing := &mock.SecretKeyRing{
-		RootKey: alicePrivKey,
+	keyRing := &basePointKeyRing{
+		SecretKeyRing: &mock.SecretKeyRing{
+			RootKey: alicePrivKey,
+		},
 	}
 
 	lnw, err := createTestWallet(
@@ -405,20 +541,10 @@ func createTestFundingManager(t *testing.T, privKey *btcec.PrivateKey,
 		RequiredRemoteDelay: func(amt btcutil.Amount) uint16 {
 			return 4
 		},
-		RequiredRemoteChanReserve: func(chanAmt,
-			dustLimit btcutil.Amount) btcutil.Amount {
-
-			reserve := chanAmt / 100
-			if reserve < dustLimit {
-				reserve = dustLimit
-			}
-
-			return reserve
-		},
-		RequiredRemoteMaxValue: func(chanAmt btcutil.Amount) lnwire.MilliSatoshi {
-			reserve := lnwire.NewMSatFromSatoshis(chanAmt / 100)
-			return lnwire.NewMSatFromSatoshis(chanAmt) - reserve
-		},
+		ReservePolicy: DefaultReservePolicy,
+		RequiredRemoteMaxValue: DefaultMaxValueInFlight(
+			DefaultReservePolicy,
+		),
 		RequiredRemoteMaxHTLCs: func(chanAmt btcutil.Amount) uint16 {
 			return uint16(input.MaxHTLCNumber / 2)
 		},
@@ -536,6 +662,7 @@ func recreateAliceFundingManager(t *testing.T, alice *testNode) {
 		},
 		DefaultMinHtlcIn:       5,
 		RequiredRemoteMaxValue: oldCfg.RequiredRemoteMaxValue,
+		ReservePolicy:          oldCfg.ReservePolicy,
 		PublishTransaction: func(txn *wire.MsgTx, _ string) error {
 			publishChan <- txn
 			return nil
@@ -543,9 +670,11 @@ func recreateAliceFundingManager(t *testing.T, alice *testNode) {
 		UpdateLabel: func(chainhash.Hash, string) error {
 			return nil
 		},
-		ZombieSweeperInterval: oldCfg.ZombieSweeperInterval,
-		ReservationTimeout:    oldCfg.ReservationTimeout,
-		OpenChannelPredicate:  chainedAcceptor,
+		ZombieSweeperInterval:         oldCfg.ZombieSweeperInterval,
+		ReservationTimeout:            oldCfg.ReservationTimeout,
+		OpenChannelPredicate:          chainedAcceptor,
+		WatchNewChannel:               oldCfg.WatchNewChannel,
+		NotifyPendingOpenChannelEvent: oldCfg.NotifyPendingOpenChannelEvent,
 	})
 	if err != nil {
 		t.Fatalf("failed recreating aliceFundingManager: %v", err)
@@ -653,10 +782,12 @@ func openChannel(t *testing.T, alice, bob *testNode, localFundingAmt,
 }
 
 // fundChannel takes the funding process to the point where the funding
-// transaction is confirmed on-chain. Returns the funding tx.
+// transaction is confirmed on-chain. Bob's AcceptChannel is passed to the
+// given functions before Alice processes it. Returns the funding tx.
 func fundChannel(t *testing.T, alice, bob *testNode, localFundingAmt,
 	pushAmt btcutil.Amount, subtractFees bool, numConfs uint32,
-	updateChan chan *lnrpc.OpenStatusUpdate, announceChan bool) *wire.MsgTx {
+	updateChan chan *lnrpc.OpenStatusUpdate, announceChan bool,
+	modifyAccept ...func(*lnwire.AcceptChannel)) *wire.MsgTx {
 
 	// Create a funding request and start the workflow.
 	errChan := make(chan error, 1)
@@ -711,6 +842,9 @@ func fundChannel(t *testing.T, alice, bob *testNode, localFundingAmt,
 	assertNumPendingReservations(t, bob, alicePubKey, 1)
 
 	// Forward the response to Alice.
+	for _, modify := range modifyAccept {
+		modify(acceptChannelResponse)
+	}
 	alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
 
 	// Alice responds with a FundingCreated message.
@@ -1280,6 +1414,107 @@ func TestFundingManagerNormalWorkflow(t *testing.T) {
 	assertNoChannelState(t, alice, bob, fundingOutPoint)
 }
 
+// TestFundingManagerMaxHtlcExpiryDelta asserts that the max HTLC expiry delta
+// Bob requires in his AcceptChannel is persisted with the channel Alice hands
+// to the peer once it is open, such that its link rejects forwards beyond it.
+func TestFundingManagerMaxHtlcExpiryDelta(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	const maxHtlcExpiryDelta = 144
+
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	fundingTx := fundChannel(
+		t, alice, bob, 500000, 0, false, 1, updateChan, true,
+		func(msg *lnwire.AcceptChannel) {
+			err := msg.SetMaxHtlcExpiryDelta(maxHtlcExpiryDelta)
+			require.NoError(t, err)
+		},
+	)
+	fundingOutPoint := &wire.OutPoint{Hash: fundingTx.TxHash()}
+
+	alice.mockNotifier.oneConfChannel <- &chainntnfs.TxConfirmation{
+		Tx: fundingTx,
+	}
+	bob.mockNotifier.oneConfChannel <- &chainntnfs.TxConfirmation{
+		Tx: fundingTx,
+	}
+	assertMarkedOpen(t, alice, bob, fundingOutPoint)
+
+	fundingLockedAlice := assertFundingMsgSent(
+		t, alice.msgChan, "FundingLocked",
+	).(*lnwire.FundingLocked)
+	fundingLockedBob := assertFundingMsgSent(
+		t, bob.msgChan, "FundingLocked",
+	).(*lnwire.FundingLocked)
+	assertChannelAnnouncements(t, alice, bob, 500000, nil, nil)
+	waitForOpenUpdate(t, updateChan)
+
+	alice.fundingMgr.ProcessFundingMsg(fundingLockedBob, bob)
+	bob.fundingMgr.ProcessFundingMsg(fundingLockedAlice, alice)
+
+	// Alice hands her channel to the peer object of Bob, and vice versa.
+	// Only the HTLCs Alice offers are bounded.
+	var channel *channeldb.OpenChannel
+	select {
+	case c := <-bob.newChannels:
+		channel = c.channel
+		close(c.err)
+	case <-time.After(time.Second * 15):
+		t.Fatalf("alice did not send new channel to peer")
+	}
+	require.EqualValues(t, maxHtlcExpiryDelta, channel.MaxHtlcExpiryDelta)
+
+	select {
+	case c := <-alice.newChannels:
+		require.Zero(t, c.channel.MaxHtlcExpiryDelta)
+		close(c.err)
+	case <-time.After(time.Second * 15):
+		t.Fatalf("bob did not send new channel to peer")
+	}
+
+	// Build the link of the channel the way the peer does, and forward
+	// HTLCs expiring at and beyond the bound over it.
+	lnChan, err := lnwallet.NewLightningChannel(
+		alice.fundingMgr.cfg.Wallet.Cfg.Signer, channel, nil,
+	)
+	require.NoError(t, err)
+
+	link := htlcswitch.NewChannelLink(htlcswitch.ChannelLinkConfig{
+		FwrdingPolicy: htlcswitch.ForwardingPolicy{
+			TimeLockDelta: 20,
+			MinHTLCOut:    500,
+			MaxHTLC:       1000,
+			BaseFee:       10,
+		},
+		FetchLastChannelUpdate: func(lnwire.ShortChannelID) (
+			*lnwire.ChannelUpdate, error) {
+
+			return &lnwire.ChannelUpdate{}, nil
+		},
+		MaxOutgoingCltvExpiry: htlcswitch.DefaultMaxOutgoingCltvExpiry,
+		MaxHtlcExpiryDelta:    channel.MaxHtlcExpiryDelta,
+	}, lnChan)
+
+	const heightNow = 100
+	var hash [32]byte
+
+	linkErr := link.CheckHtlcForward(
+		hash, 1500, 1000, heightNow+maxHtlcExpiryDelta+20,
+		heightNow+maxHtlcExpiryDelta, heightNow,
+	)
+	require.Nil(t, linkErr)
+
+	linkErr = link.CheckHtlcForward(
+		hash, 1500, 1000, heightNow+maxHtlcExpiryDelta+21,
+		heightNow+maxHtlcExpiryDelta+1, heightNow,
+	)
+	require.NotNil(t, linkErr)
+	require.IsType(t, &lnwire.FailExpiryTooFar{}, linkErr.WireMessage())
+}
+
 // TestFundingManagerRejectCSV tests checking of local CSV values against our
 // local CSV limit for incoming and outgoing channels.
 func TestFundingManagerRejectCSV(t *testing.T) {
@@ -1384,7 +1619,9 @@ func testLocalCSVLimit(t *testing.T, aliceMaxCSV, bobRequiredCSV uint16) {
 
 	// We do not need to complete the rest of the funding flow (it is
 	// covered in other tests). So now we test that Alice will appropriately
-	// handle incoming channels, opening a channel from Bob->Alice.
+	// handle incoming channels, opening a channel from Bob->Alice. As both
+	// nodes generate the same pending channel IDs, Bob picks his own to
+	// not collide with Alice's channel still in progress.
 	errChan = make(chan error, 1)
 	updateChan = make(chan *lnrpc.OpenStatusUpdate)
 	initReq = &InitFundingMsg{
@@ -1395,6 +1632,7 @@ func testLocalCSVLimit(t *testing.T, aliceMaxCSV, bobRequiredCSV uint16) {
 		FundingFeePerKw: 1000,
 		Updates:         updateChan,
 		Err:             errChan,
+		PendingChanID:   [32]byte{1},
 	}
 
 	bob.fundingMgr.InitFundingWorkflow(initReq)
@@ -3173,158 +3411,237 @@ func TestFundingManagerMaxConfs(t *testing.T) {
 	}
 }
 
-// TestFundingManagerFundAll tests that we can initiate a funding request to
-// use the funds remaining in the wallet. This should produce a funding tx with
-// no change output.
-func TestFundingManagerFundAll(t *testing.T) {
+// TestFundingManagerCustomReservePolicy ensures that a custom ReservePolicy is
+// used to determine the reserve we require from the remote party, both in the
+// OpenChannel and the AcceptChannel message, and that the reserve never dips
+// below the dust limit.
+func TestFundingManagerCustomReservePolicy(t *testing.T) {
 	t.Parallel()
 
-	// We set up our mock wallet to control a list of UTXOs that sum to
-	// less than the max channel size.
-	allCoins := []*lnwallet.Utxo{
-		{
-			AddressType: lnwallet.WitnessPubKey,
-			Value: btcutil.Amount(
-				0.05 * btcutil.SatoshiPerBitcoin,
-			),
-			PkScript: mock.CoinPkScript,
-			OutPoint: wire.OutPoint{
-				Hash:  chainhash.Hash{},
-				Index: 0,
-			},
-		},
-		{
-			AddressType: lnwallet.WitnessPubKey,
-			Value: btcutil.Amount(
-				0.06 * btcutil.SatoshiPerBitcoin,
-			),
-			PkScript: mock.CoinPkScript,
-			OutPoint: wire.OutPoint{
-				Hash:  chainhash.Hash{},
-				Index: 1,
-			},
-		},
-	}
-
 	tests := []struct {
-		spendAmt btcutil.Amount
-		change   bool
+		name        string
+		flatReserve btcutil.Amount
+		belowDust   bool
 	}{
 		{
-			// We will spend all the funds in the wallet, and
-			// expects no change output.
-			spendAmt: btcutil.Amount(
-				0.11 * btcutil.SatoshiPerBitcoin,
-			),
-			change: false,
+			name:        "flat reserve",
+			flatReserve: 50000,
 		},
 		{
-			// We spend a little less than the funds in the wallet,
-			// so a change output should be created.
-			spendAmt: btcutil.Amount(
-				0.10 * btcutil.SatoshiPerBitcoin,
-			),
-			change: true,
+			name:        "flat reserve below dust",
+			flatReserve: 1,
+			belowDust:   true,
 		},
 	}
 
 	for _, test := range tests {
-		alice, bob := setupFundingManagers(t)
-		defer tearDownFundingManagers(t, alice, bob)
+		test := test
 
-		alice.fundingMgr.cfg.Wallet.WalletController.(*mock.WalletController).Utxos = allCoins
+		t.Run(test.name, func(t *testing.T) {
+			flatPolicy := func(cfg *Config) {
+				cfg.ReservePolicy = func(
+					btcutil.Amount) btcutil.Amount {
 
-		// We will consume the channel updates as we go, so no
-		// buffering is needed.
-		updateChan := make(chan *lnrpc.OpenStatusUpdate)
+					return test.flatReserve
+				}
+			}
 
-		// Initiate a fund channel, and inspect the funding tx.
-		pushAmt := btcutil.Amount(0)
-		fundingTx := fundChannel(
-			t, alice, bob, test.spendAmt, pushAmt, true, 1,
-			updateChan, true,
-		)
+			alice, bob := setupFundingManagers(t, flatPolicy)
+			defer tearDownFundingManagers(t, alice, bob)
 
-		// Check whether the expected change output is present.
-		if test.change && len(fundingTx.TxOut) != 2 {
-			t.Fatalf("expected 2 outputs, had %v",
-				len(fundingTx.TxOut))
-		}
+			flow := openUntilAccept(t, alice, bob)
 
-		if !test.change && len(fundingTx.TxOut) != 1 {
-			t.Fatalf("expected 1 output, had %v",
-				len(fundingTx.TxOut))
-		}
+			expOpenReserve := test.flatReserve
+			expAcceptReserve := test.flatReserve
+			if test.belowDust {
+				expOpenReserve = flow.open.DustLimit
+				expAcceptReserve = flow.open.DustLimit
+			}
 
-		// Inputs should be all funds in the wallet.
-		if len(fundingTx.TxIn) != len(allCoins) {
-			t.Fatalf("Had %d inputs, expected %d",
-				len(fundingTx.TxIn), len(allCoins))
-		}
+			require.Equal(t, expOpenReserve, flow.open.ChannelReserve)
+			require.Equal(
+				t, expAcceptReserve, flow.accept.ChannelReserve,
+			)
+		})
+	}
+}
 
-		for i, txIn := range fundingTx.TxIn {
-			if txIn.PreviousOutPoint != allCoins[i].OutPoint {
-				t.Fatalf("expected outpoint to be %v, was %v",
-					allCoins[i].OutPoint,
-					txIn.PreviousOutPoint)
-			}
-		}
+// TestFundingManagerSmallAnchorChannel asserts that two nodes with the default
+// config can open a small anchor channel, even though the default reserve of
+// 1% of the capacity doesn't cover the dust limit and both anchor outputs.
+func TestFundingManagerSmallAnchorChannel(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	features := []lnwire.FeatureBit{
+		lnwire.StaticRemoteKeyOptional,
+		lnwire.AnchorsZeroFeeHtlcTxOptional,
+	}
+	for _, node := range []*testNode{alice, bob} {
+		node.localFeatures = features
+		node.remoteFeatures = features
 	}
+
+	// Bob requires the default reserve of 1000 sat for a 100000 sat
+	// channel, which is below his dust limit plus the anchor outputs.
+	const capacity = btcutil.Amount(100000)
+	var accept *lnwire.AcceptChannel
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	fundChannel(
+		t, alice, bob, capacity, 0, false, 1, updateChan, true,
+		func(msg *lnwire.AcceptChannel) {
+			accept = msg
+		},
+	)
+	require.EqualValues(t, DefaultReservePolicy(capacity),
+		accept.ChannelReserve)
+	require.Error(t, lnwire.ValidateAnchorReserve(accept, true))
+
+	// Alice only warns about the reserve, so the channel is funded.
+	assertErrorNotSent(t, alice.msgChan)
+	assertErrorNotSent(t, bob.msgChan)
+
+	pendingChannels, err := alice.fundingMgr.cfg.Wallet.Cfg.Database.
+		FetchPendingChannels()
+	require.NoError(t, err)
+	require.Len(t, pendingChannels, 1)
+	require.True(t, pendingChannels[0].ChanType.HasAnchors())
 }
 
-// TestGetUpfrontShutdown tests different combinations of inputs for getting a
-// shutdown script. It varies whether the peer has the feature set, whether
-// the user has provided a script and our local configuration to test that
-// GetUpfrontShutdownScript returns the expected outcome.
-func TestGetUpfrontShutdownScript(t *testing.T) {
-	upfrontScript := []byte("upfront script")
-	generatedScript := []byte("generated script")
+// TestDefaultReservePolicy asserts that the default reserve policy requires
+// 1% of the channel capacity.
+func TestDefaultReservePolicy(t *testing.T) {
+	t.Parallel()
 
-	getScript := func() (lnwire.DeliveryAddress, error) {
-		return generatedScript, nil
+	require.Equal(t, btcutil.Amount(0), DefaultReservePolicy(99))
+	require.Equal(t, btcutil.Amount(1), DefaultReservePolicy(100))
+	require.Equal(t, btcutil.Amount(5000), DefaultReservePolicy(500000))
+}
+
+// TestDefaultMaxValueInFlight asserts that the default max value in flight is
+// the channel capacity minus the reserve of the given reserve policy,
+// expressed in milli-satoshis.
+func TestDefaultMaxValueInFlight(t *testing.T) {
+	t.Parallel()
+
+	// tenPercentReserve is a custom reserve policy, which must be left
+	// out instead of the default one.
+	tenPercentReserve := func(capacity btcutil.Amount) btcutil.Amount {
+		return capacity / 10
 	}
 
 	tests := []struct {
-		name           string
-		getScript      func() (lnwire.DeliveryAddress, error)
-		upfrontScript  lnwire.DeliveryAddress
-		peerEnabled    bool
-		localEnabled   bool
-		expectedScript lnwire.DeliveryAddress
-		expectedErr    error
+		policy   ReservePolicy
+		capacity btcutil.Amount
+		expected lnwire.MilliSatoshi
 	}{
 		{
-			name:      "peer disabled, no shutdown",
-			getScript: getScript,
+			policy:   DefaultReservePolicy,
+			capacity: 0,
+			expected: 0,
 		},
 		{
-			name:          "peer disabled, upfront provided",
-			upfrontScript: upfrontScript,
-			expectedErr:   errUpfrontShutdownScriptNotSupported,
+			policy:   DefaultReservePolicy,
+			capacity: 99,
+			expected: 99_000,
 		},
 		{
-			name:           "peer enabled, upfront provided",
-			upfrontScript:  upfrontScript,
-			peerEnabled:    true,
-			expectedScript: upfrontScript,
+			policy:   DefaultReservePolicy,
+			capacity: 100,
+			expected: 99_000,
 		},
 		{
-			name:        "peer enabled, local disabled",
-			peerEnabled: true,
+			policy:   DefaultReservePolicy,
+			capacity: 1_000_000,
+			expected: 990_000_000,
 		},
 		{
-			name:           "local enabled, no upfront script",
-			getScript:      getScript,
-			peerEnabled:    true,
-			localEnabled:   true,
-			expectedScript: generatedScript,
+			policy:   tenPercentReserve,
+			capacity: 1_000_000,
+			expected: 900_000_000,
 		},
-		{
-			name:           "local enabled, upfront script",
-			peerEnabled:    true,
-			upfrontScript:  upfrontScript,
-			localEnabled:   true,
-			expectedScript: upfrontScript,
+	}
+
+	for _, test := range tests {
+		maxValue := DefaultMaxValueInFlight(test.policy)(test.capacity)
+		require.Equal(t, test.expected, maxValue)
+
+		// The result must leave exactly the reserve, converted to
+		// milli-satoshis, out of the capacity.
+		reserve := test.policy(test.capacity)
+		require.Equal(
+			t, lnwire.NewMSatFromSatoshis(test.capacity),
+			maxValue+lnwire.NewMSatFromSatoshis(reserve),
+		)
+	}
+}
+
+// TestFundingManagerAcceptRejectionMetrics asserts that rejecting an
+// AcceptChannel increments the rejection counter with the label matching the
+// reason of the rejection.
+//
+// NOTE: This test must not be run in parallel, since other tests rejecting an
+// AcceptChannel would otherwise increment the same counters.
+func TestFundingManagerAcceptRejectionMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(*lnwire.AcceptChannel)
+		malformed bool
+		reason    string
+	}{
+		{
+			name: "csv delay too large",
+			modify: func(msg *lnwire.AcceptChannel) {
+				msg.CsvDelay = math.MaxUint16
+			},
+			reason: string(lnwallet.ReasonCsvDelayTooLarge),
+		},
+		{
+			name: "reserve below dust",
+			modify: func(msg *lnwire.AcceptChannel) {
+				msg.ChannelReserve = msg.DustLimit - 1
+			},
+			reason: string(lnwallet.ReasonChanReserveTooSmall),
+		},
+		{
+			name: "depth too large",
+			modify: func(msg *lnwire.AcceptChannel) {
+				msg.MinAcceptDepth = chainntnfs.MaxNumConfs + 1
+			},
+			reason: string(lnwallet.ReasonNumConfsTooLarge),
+		},
+		{
+			name: "max htlcs too small",
+			modify: func(msg *lnwire.AcceptChannel) {
+				msg.MaxAcceptedHTLCs = 1
+			},
+			reason: string(lnwallet.ReasonMaxHtlcNumTooSmall),
+		},
+		{
+			name: "duplicate pubkey",
+			modify: func(msg *lnwire.AcceptChannel) {
+				msg.HtlcPoint = msg.FundingKey
+			},
+			reason: rejectReasonDuplicatePubKey,
+		},
+		{
+			name: "dust limit below script",
+			modify: func(msg *lnwire.AcceptChannel) {
+				// A P2PKH script has a dust threshold of 546.
+				msg.UpfrontShutdownScript = append(
+					[]byte{0x76, 0xa9, 0x14},
+					append(make([]byte, 20), 0x88, 0xac)...,
+				)
+				msg.DustLimit = 545
+			},
+			reason: rejectReasonDustLimitBelowScript,
+		},
+		{
+			name:      "malformed",
+			malformed: true,
+			reason:    rejectReasonMalformed,
 		},
 	}
 
@@ -3332,275 +3649,563 @@ func TestGetUpfrontShutdownScript(t *testing.T) {
 		test := test
 
 		t.Run(test.name, func(t *testing.T) {
-			var mockPeer testNode
+			alice, bob := setupFundingManagers(t)
+			defer tearDownFundingManagers(t, alice, bob)
 
-			// If the remote peer in the test should support upfront shutdown,
-			// add the feature bit.
-			if test.peerEnabled {
-				mockPeer.remoteFeatures = []lnwire.FeatureBit{
-					lnwire.UpfrontShutdownScriptOptional,
-				}
-			}
+			flow := openUntilAccept(t, alice, bob)
 
-			addr, err := getUpfrontShutdownScript(
-				test.localEnabled, &mockPeer, test.upfrontScript,
-				test.getScript,
+			counter := acceptChannelRejections.WithLabelValues(
+				test.reason,
 			)
-			if err != test.expectedErr {
-				t.Fatalf("got: %v, expected error: %v", err, test.expectedErr)
-			}
-
-			if !bytes.Equal(addr, test.expectedScript) {
-				t.Fatalf("expected address: %x, got: %x",
-					test.expectedScript, addr)
+			before := testutil.ToFloat64(counter)
+
+			// Since Alice synchronously sends an error to Bob when
+			// processing a malformed message, do so in a
+			// goroutine.
+			if test.malformed {
+				go alice.fundingMgr.ProcessMalformedAccept(
+					flow.accept.PendingChannelID,
+					errors.New("malformed"), bob,
+				)
+			} else {
+				test.modify(flow.accept)
+				alice.fundingMgr.ProcessFundingMsg(
+					flow.accept, bob,
+				)
 			}
 
+			assertFundingMsgSent(t, alice.msgChan, "Error")
+			require.Equal(t, before+1, testutil.ToFloat64(counter))
 		})
 	}
 }
 
-func expectOpenChannelMsg(t *testing.T, msgChan chan lnwire.Message) *lnwire.OpenChannel {
-	var msg lnwire.Message
-	select {
-	case msg = <-msgChan:
-	case <-time.After(time.Second * 5):
-		t.Fatalf("node did not send OpenChannel message")
-	}
+// TestCapacityTier asserts that channels are assigned to the capacity tier
+// their capacity falls into, and that the labels of an accepted AcceptChannel
+// are derived from it.
+func TestCapacityTier(t *testing.T) {
+	t.Parallel()
 
-	openChannelReq, ok := msg.(*lnwire.OpenChannel)
-	if !ok {
-		errorMsg, gotError := msg.(*lnwire.Error)
-		if gotError {
-			t.Fatalf("expected OpenChannel to be sent "+
-				"from bob, instead got error: %v",
-				errorMsg.Error())
-		}
-		t.Fatalf("expected OpenChannel to be sent, instead got %T",
-			msg)
+	tests := []struct {
+		capacity btcutil.Amount
+		tier     string
+	}{
+		{capacity: 20000, tier: "small"},
+		{capacity: mediumCapacityTier - 1, tier: "small"},
+		{capacity: mediumCapacityTier, tier: "medium"},
+		{capacity: largeCapacityTier - 1, tier: "medium"},
+		{capacity: largeCapacityTier, tier: "large"},
+		{capacity: MaxBtcFundingAmountWumbo, tier: "large"},
+	}
+	for _, test := range tests {
+		require.Equalf(
+			t, test.tier, capacityTier(test.capacity),
+			"capacity %v", test.capacity,
+		)
 	}
 
-	return openChannelReq
+	require.Equal(
+		t, []string{"medium", "1", "3"},
+		acceptChannelLabels(2000000, 20000, 3),
+	)
+	require.Equal(
+		t, []string{"small", "0", "1"}, acceptChannelLabels(0, 0, 1),
+	)
 }
 
-func TestMaxChannelSizeConfig(t *testing.T) {
-	t.Parallel()
-
-	// Create a set of funding managers that will reject wumbo
-	// channels but set --maxchansize explicitly lower than soft-limit.
-	// Verify that wumbo rejecting funding managers will respect --maxchansize
-	// below 16777215 satoshi (MaxBtcFundingAmount) limit.
-	alice, bob := setupFundingManagers(t, func(cfg *Config) {
-		cfg.NoWumboChans = true
-		cfg.MaxChanSize = MaxBtcFundingAmount - 1
-	})
+// TestFundingManagerAcceptChannelTierMetric asserts that an accepted
+// AcceptChannel is counted by the capacity tier of the channel.
+func TestFundingManagerAcceptChannelTierMetric(t *testing.T) {
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
 
-	// Attempt to create a channel above the limit
-	// imposed by --maxchansize, which should be rejected.
-	updateChan := make(chan *lnrpc.OpenStatusUpdate)
-	errChan := make(chan error, 1)
-	initReq := &InitFundingMsg{
-		Peer:            bob,
-		TargetPubkey:    bob.privKey.PubKey(),
-		ChainHash:       *fundingNetParams.GenesisHash,
-		LocalFundingAmt: MaxBtcFundingAmount,
-		PushAmt:         lnwire.NewMSatFromSatoshis(0),
-		Private:         false,
-		Updates:         updateChan,
-		Err:             errChan,
-	}
+	flow := openUntilAccept(t, alice, bob)
 
-	// After processing the funding open message, bob should respond with
-	// an error rejecting the channel that exceeds size limit.
-	alice.fundingMgr.InitFundingWorkflow(initReq)
-	openChanMsg := expectOpenChannelMsg(t, alice.msgChan)
-	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
-	assertErrorSent(t, bob.msgChan)
+	labels := acceptChannelLabels(
+		flow.initReq.LocalFundingAmt, flow.accept.ChannelReserve,
+		flow.accept.MinAcceptDepth,
+	)
+	require.Equal(t, "small", labels[0])
 
-	// Create a set of funding managers that will reject wumbo
-	// channels but set --maxchansize explicitly higher than soft-limit
-	// A --maxchansize greater than this limit should have no effect.
-	tearDownFundingManagers(t, alice, bob)
-	alice, bob = setupFundingManagers(t, func(cfg *Config) {
-		cfg.NoWumboChans = true
-		cfg.MaxChanSize = MaxBtcFundingAmount + 1
-	})
+	counter := acceptChannelsByTier.WithLabelValues(labels...)
+	before := testutil.ToFloat64(counter)
 
-	// Reset the Peer to the newly created one.
-	initReq.Peer = bob
+	alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+	assertFundingMsgSent(t, alice.msgChan, "FundingCreated")
+	require.Equal(t, before+1, testutil.ToFloat64(counter))
+}
 
-	// We expect Bob to respond with an Accept channel message.
-	alice.fundingMgr.InitFundingWorkflow(initReq)
-	openChanMsg = expectOpenChannelMsg(t, alice.msgChan)
-	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
-	assertFundingMsgSent(t, bob.msgChan, "AcceptChannel")
+// TestFundingManagerAcceptPolicy asserts that the AcceptChannel of the peer
+// accepting our channel is only accepted if it passes the checks of our
+// policy, and that the AcceptChannel is rejected with an Error describing the
+// failed check otherwise.
+func TestFundingManagerAcceptPolicy(t *testing.T) {
+	t.Parallel()
 
-	// Verify that wumbo accepting funding managers will respect --maxchansize
-	// Create the funding managers, this time allowing
-	// wumbo channels but setting --maxchansize explicitly.
-	tearDownFundingManagers(t, alice, bob)
-	alice, bob = setupFundingManagers(t, func(cfg *Config) {
-		cfg.NoWumboChans = false
-		cfg.MaxChanSize = btcutil.Amount(100000000)
-	})
+	// A P2PKH script has a dust threshold of 546.
+	p2pkh := lnwire.DeliveryAddress(append(
+		[]byte{0x76, 0xa9, 0x14},
+		append(make([]byte, 20), 0x88, 0xac)...,
+	))
+	p2wpkh := lnwire.DeliveryAddress(
+		append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x01}, 20)...),
+	)
+	belowScriptDust := func(_ *testing.T, f *acceptFlow) {
+		f.accept.UpfrontShutdownScript = p2pkh
+		f.accept.DustLimit = 545
+	}
+	duplicatePubKey := func(_ *testing.T, f *acceptFlow) {
+		f.accept.HtlcPoint = f.accept.FundingKey
+	}
 
-	// Reset the Peer to the newly created one.
-	initReq.Peer = bob
+	anchors := func(alice, bob *testNode) {
+		features := []lnwire.FeatureBit{
+			lnwire.StaticRemoteKeyOptional,
+			lnwire.AnchorsZeroFeeHtlcTxOptional,
+		}
+		for _, node := range []*testNode{alice, bob} {
+			node.localFeatures = features
+			node.remoteFeatures = features
+		}
+	}
+	trust := func(key *btcec.PublicKey) func(*btcec.PublicKey) bool {
+		return func(peer *btcec.PublicKey) bool {
+			return peer.IsEqual(key)
+		}
+	}
 
-	// Attempt to create a channel above the limit
-	// imposed by --maxchansize, which should be rejected.
-	initReq.LocalFundingAmt = btcutil.SatoshiPerBitcoin + 1
+	// feeRange makes bob prefer the range of fee rates derived from the
+	// fee rate alice proposed.
+	feeRange := func(rangeFn func(uint32) lnwire.FeeRateRange) func(
+		*testing.T, *acceptFlow) {
 
-	// After processing the funding open message, bob should respond with
-	// an error rejecting the channel that exceeds size limit.
-	alice.fundingMgr.InitFundingWorkflow(initReq)
-	openChanMsg = expectOpenChannelMsg(t, alice.msgChan)
-	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
-	assertErrorSent(t, bob.msgChan)
-}
+		return func(t *testing.T, f *acceptFlow) {
+			err := f.accept.SetFeeRateRange(
+				rangeFn(f.open.FeePerKiloWeight),
+			)
+			require.NoError(t, err)
+		}
+	}
 
-// TestWumboChannelConfig tests that the funding manager will respect the wumbo
-// channel config param when creating or accepting new channels.
-func TestWumboChannelConfig(t *testing.T) {
-	t.Parallel()
+	// For a 500000 sat channel, we require the peer to keep a reserve of
+	// 5000 sat by default.
+	const (
+		capacity      = btcutil.Amount(500000)
+		remoteReserve = capacity / 100
+		anchorReserve = 2 * lnwire.AnchorOutputValue
+		minMaxHtlcs   = 30
 
-	// First we'll create a set of funding managers that will reject wumbo
-	// channels.
-	alice, bob := setupFundingManagers(t, func(cfg *Config) {
-		cfg.NoWumboChans = true
-	})
+		maxCommitFeeReason = string(lnwallet.ReasonMaxCommitFeeTooHigh)
+	)
+	asymmetryPolicy := &lnwire.ReservePolicy{MaxRatio: 2}
+	withReserve := func(reserve btcutil.Amount) func(*testing.T,
+		*acceptFlow) {
 
-	// If we attempt to initiate a new funding open request to Alice,
-	// that's below the wumbo channel mark, we should be able to start the
-	// funding process w/o issue.
-	updateChan := make(chan *lnrpc.OpenStatusUpdate)
-	errChan := make(chan error, 1)
-	initReq := &InitFundingMsg{
-		Peer:            bob,
-		TargetPubkey:    bob.privKey.PubKey(),
-		ChainHash:       *fundingNetParams.GenesisHash,
-		LocalFundingAmt: MaxBtcFundingAmount,
-		PushAmt:         lnwire.NewMSatFromSatoshis(0),
-		Private:         false,
-		Updates:         updateChan,
-		Err:             errChan,
+		return func(_ *testing.T, f *acceptFlow) {
+			f.accept.ChannelReserve = reserve
+		}
 	}
 
-	// We expect Bob to respond with an Accept channel message.
-	alice.fundingMgr.InitFundingWorkflow(initReq)
-	openChanMsg := expectOpenChannelMsg(t, alice.msgChan)
-	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
-	assertFundingMsgSent(t, bob.msgChan, "AcceptChannel")
-
-	// We'll now attempt to create a channel above the wumbo mark, which
-	// should be rejected.
-	initReq.LocalFundingAmt = btcutil.SatoshiPerBitcoin
-
-	// After processing the funding open message, bob should respond with
-	// an error rejecting the channel.
-	alice.fundingMgr.InitFundingWorkflow(initReq)
-	openChanMsg = expectOpenChannelMsg(t, alice.msgChan)
-	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
-	assertErrorSent(t, bob.msgChan)
+	tests := []struct {
+		name string
 
-	// Next, we'll re-create the funding managers, but this time allowing
-	// wumbo channels explicitly.
-	tearDownFundingManagers(t, alice, bob)
-	alice, bob = setupFundingManagers(t, func(cfg *Config) {
-		cfg.NoWumboChans = false
-		cfg.MaxChanSize = MaxBtcFundingAmountWumbo
-	})
+		// cfg modifies the config of both nodes.
+		cfg func(*Config)
 
-	// Reset the Peer to the newly created one.
-	initReq.Peer = bob
+		// setup is called with both nodes before alice starts the
+		// funding flow.
+		setup func(alice, bob *testNode)
 
-	// We should now be able to initiate a wumbo channel funding w/o any
-	// issues.
-	alice.fundingMgr.InitFundingWorkflow(initReq)
-	openChanMsg = expectOpenChannelMsg(t, alice.msgChan)
-	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
-	assertFundingMsgSent(t, bob.msgChan, "AcceptChannel")
-}
+		// initReq modifies the funding request of alice.
+		initReq func(*InitFundingMsg)
 
-// TestFundingManagerUpfrontShutdown asserts that we'll properly fail out if
-// an invalid upfront shutdown script is sent in the open_channel message.
-// Since both the open_channel and accept_message logic validate the script
-// using the same validation function, it suffices to just check the
-// open_channel case.
-func TestFundingManagerUpfrontShutdown(t *testing.T) {
-	t.Parallel()
+		// modify modifies bob's AcceptChannel before alice processes
+		// it.
+		modify func(t *testing.T, f *acceptFlow)
 
-	tests := []struct {
-		name      string
-		pkscript  []byte
-		expectErr bool
+		// expectErr is part of the Error alice sends if she rejects
+		// the AcceptChannel. If empty, she must accept it.
+		expectErr string
 	}{
 		{
-			name: "p2pk script",
-			pkscript: []byte("\x21\x02\xd3\x00\x50\x2f\x61\x15" +
-				"\x0d\x58\x0a\x42\xa0\x99\x63\xe3\x47\xa2" +
-				"\xad\x3c\xe5\x1f\x11\x96\x0d\x35\x8d\xf8" +
-				"\xf3\x94\xf9\x67\x2a\x67\xac"),
-			expectErr: true,
+			name: "unmodified",
 		},
 		{
-			name: "op return script",
-			pkscript: []byte("\x6a\x24\xaa\x21\xa9\xed\x18\xa9" +
-				"\x93\x58\x94\xbd\x48\x9b\xeb\x87\x66\x13" +
-				"\x60\xbc\x80\x92\xab\xf6\xdd\xe9\x1e\x82" +
-				"\x0c\x7d\x91\x89\x9d\x0a\x02\x34\x14\x3f"),
-			expectErr: true,
+			name:      "duplicate pubkey",
+			modify:    duplicatePubKey,
+			expectErr: "accept channel contains duplicate public keys",
 		},
 		{
-			name: "standard (non-p2sh) 2-of-3 multisig",
-			pkscript: []byte("\x51\x41\x04\xcc\x71\xeb\x30\xd6" +
-				"\x53\xc0\xc3\x16\x39\x90\xc4\x7b\x97\x6f" +
-				"\x3f\xb3\xf3\x7c\xcc\xdc\xbe\xdb\x16\x9a" +
-				"\x1d\xfe\xf5\x8b\xbf\xbf\xaf\xf7\xd8\xa4" +
-				"\x73\xe7\xe2\xe6\xd3\x17\xb8\x7b\xaf\xe8" +
-				"\xbd\xe9\x7e\x3c\xf8\xf0\x65\xde\xc0\x22" +
-				"\xb5\x1d\x11\xfc\xdd\x0d\x34\x8a\xc4\x41" +
-				"\x04\x61\xcb\xdc\xc5\x40\x9f\xb4\xb4\xd4" +
-				"\x2b\x51\xd3\x33\x81\x35\x4d\x80\xe5\x50" +
-				"\x07\x8c\xb5\x32\xa3\x4b\xfa\x2f\xcf\xde" +
-				"\xb7\xd7\x65\x19\xae\xcc\x62\x77\x0f\x5b" +
-				"\x0e\x4e\xf8\x55\x19\x46\xd8\xa5\x40\x91" +
-				"\x1a\xbe\x3e\x78\x54\xa2\x6f\x39\xf5\x8b" +
-				"\x25\xc1\x53\x42\xaf\x52\xae"),
-			expectErr: true,
+			name:      "dust limit below script",
+			modify:    belowScriptDust,
+			expectErr: "unacceptable dust limit",
 		},
 		{
-			name:      "nonstandard script",
-			pkscript:  []byte("\x99"),
-			expectErr: true,
+			name: "csv delay too large",
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.CsvDelay = math.MaxUint16
+			},
+			expectErr: "CSV delay too large",
 		},
 		{
-			name: "p2sh script",
-			pkscript: []byte("\xa9\x14\xfe\x44\x10\x65\xb6\x53" +
-				"\x22\x31\xde\x2f\xac\x56\x31\x52\x20\x5e" +
-				"\xc4\xf5\x9c\x74\x87"),
-			expectErr: false,
+			name: "fee rate range contains fee rate",
+			modify: feeRange(func(feePerKw uint32) lnwire.FeeRateRange {
+				return lnwire.FeeRateRange{
+					Min: feePerKw - 1, Max: feePerKw + 1,
+				}
+			}),
 		},
 		{
-			name: "p2pkh script",
-			pkscript: []byte("\x76\xa9\x14\x64\x1a\xd5\x05\x1e" +
-				"\xdd\x97\x02\x9a\x00\x3f\xe9\xef\xb2\x93" +
-				"\x59\xfc\xee\x40\x9d\x88\xac"),
-			expectErr: false,
+			name: "fee rate range bounded by fee rate",
+			modify: feeRange(func(feePerKw uint32) lnwire.FeeRateRange {
+				return lnwire.FeeRateRange{
+					Min: feePerKw, Max: feePerKw,
+				}
+			}),
 		},
 		{
-			name: "p2wpkh script",
-			pkscript: []byte("\x00\x14\x4b\xfe\x98\x3f\x16\xaa" +
-				"\xde\x1b\x1c\xb1\x54\x5a\xa5\xa4\x88\xd5" +
-				"\xe3\x68\xb5\xdc"),
-			expectErr: false,
+			name: "fee rate range above fee rate",
+			modify: feeRange(func(feePerKw uint32) lnwire.FeeRateRange {
+				return lnwire.FeeRateRange{
+					Min: feePerKw + 1, Max: feePerKw * 2,
+				}
+			}),
+			expectErr: "unacceptable fee rate range",
 		},
 		{
-			name: "p2wsh script",
-			pkscript: []byte("\x00\x20\x1d\xd6\x3c\x20\x13\x89" +
-				"\x3a\x8b\x41\x5e\xb2\xe7\x41\x8f\x07\x5d" +
-				"\x4f\x3b\xf1\x81\x34\x99\xef\x31\xfb\xd7" +
-				"\x8c\xa8\xc4\x5d\x8f\xf0"),
-			expectErr: false,
+			name: "fee rate range below fee rate",
+			modify: feeRange(func(feePerKw uint32) lnwire.FeeRateRange {
+				return lnwire.FeeRateRange{
+					Min: feePerKw / 2, Max: feePerKw - 1,
+				}
+			}),
+			expectErr: "unacceptable fee rate range",
+		},
+		{
+			name:  "anchor reserve at minimum",
+			setup: anchors,
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.ChannelReserve = f.accept.DustLimit +
+					anchorReserve
+			},
+		},
+		{
+			name:  "anchor reserve below minimum",
+			setup: anchors,
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.ChannelReserve = f.accept.DustLimit +
+					anchorReserve - 1
+			},
+		},
+		{
+			name: "anchor reserve below minimum rejected",
+			cfg: func(cfg *Config) {
+				cfg.SeverityPolicy = SeverityPolicy{
+					rejectReasonAnchorReserve: SeverityReject,
+				}
+			},
+			setup: anchors,
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.ChannelReserve = f.accept.DustLimit +
+					anchorReserve - 1
+			},
+			expectErr: "unacceptable anchor reserve",
+		},
+		{
+			name: "legacy reserve below anchor minimum",
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.ChannelReserve = f.accept.DustLimit +
+					anchorReserve - 1
+			},
+		},
+		{
+			name:   "reserve asymmetry without policy",
+			modify: withReserve(5 * remoteReserve),
+		},
+		{
+			name: "acceptable reserve asymmetry",
+			cfg: func(cfg *Config) {
+				cfg.ReserveAsymmetryPolicy = asymmetryPolicy
+			},
+			modify: withReserve(2 * remoteReserve),
+		},
+		{
+			name: "unacceptable reserve asymmetry",
+			cfg: func(cfg *Config) {
+				cfg.ReserveAsymmetryPolicy = asymmetryPolicy
+			},
+			modify:    withReserve(2*remoteReserve + 1),
+			expectErr: "unacceptable reserve asymmetry",
+		},
+		{
+			name: "zero reserve between trusted peers",
+			setup: func(alice, bob *testNode) {
+				alice.fundingMgr.cfg.AllowZeroReserve = trust(
+					bobPubKey,
+				)
+				bob.fundingMgr.cfg.AllowZeroReserve = trust(
+					alicePubKey,
+				)
+			},
+			modify: func(t *testing.T, f *acceptFlow) {
+				require.Zero(t, f.open.ChannelReserve)
+				require.Zero(t, f.accept.ChannelReserve)
+			},
+		},
+		{
+			name:      "zero reserve from untrusted peer",
+			modify:    withReserve(0),
+			expectErr: "channel reserve",
+		},
+		{
+			name: "zero reserve only trusted by responder",
+			setup: func(_, bob *testNode) {
+				bob.fundingMgr.cfg.AllowZeroReserve = trust(
+					alicePubKey,
+				)
+			},
+			modify: func(t *testing.T, f *acceptFlow) {
+				require.NotZero(t, f.open.ChannelReserve)
+				require.Zero(t, f.accept.ChannelReserve)
+			},
+			expectErr: "channel reserve",
+		},
+		{
+			name: "reserve equals balance",
+			initReq: func(req *InitFundingMsg) {
+				req.PushAmt = lnwire.NewMSatFromSatoshis(450000)
+			},
+			modify: func(t *testing.T, f *acceptFlow) {
+				f.accept.ChannelReserve = fundingBalance(t, f)
+			},
+		},
+		{
+			name: "reserve exceeds balance",
+			initReq: func(req *InitFundingMsg) {
+				req.PushAmt = lnwire.NewMSatFromSatoshis(450000)
+			},
+			modify: func(t *testing.T, f *acceptFlow) {
+				f.accept.ChannelReserve = fundingBalance(t, f) + 1
+			},
+			expectErr: "exceeds our balance",
+		},
+		{
+			name: "reserve at initiator floor",
+			cfg: func(cfg *Config) {
+				cfg.MinAbsoluteReserve = 10000
+			},
+			modify: func(t *testing.T, f *acceptFlow) {
+				require.EqualValues(
+					t, 10000, f.open.ChannelReserve,
+				)
+				f.accept.ChannelReserve = 10000
+			},
+		},
+		{
+			name: "reserve below initiator floor",
+			cfg: func(cfg *Config) {
+				cfg.MinAbsoluteReserve = 10000
+			},
+			modify:    withReserve(9999),
+			expectErr: "too small",
+		},
+		{
+			name: "severity policy rejects",
+			cfg: func(cfg *Config) {
+				cfg.SeverityPolicy = SeverityPolicy{
+					rejectReasonDustLimitBelowScript: SeverityReject,
+				}
+			},
+			modify:    belowScriptDust,
+			expectErr: "unacceptable dust limit",
+		},
+		{
+			name: "severity policy warns",
+			cfg: func(cfg *Config) {
+				cfg.SeverityPolicy = SeverityPolicy{
+					rejectReasonDustLimitBelowScript: SeverityWarn,
+				}
+			},
+			modify: belowScriptDust,
+		},
+		{
+			name: "severity policy warns on other check",
+			cfg: func(cfg *Config) {
+				cfg.SeverityPolicy = SeverityPolicy{
+					rejectReasonMaxValueInFlight: SeverityWarn,
+				}
+			},
+			modify:    belowScriptDust,
+			expectErr: "unacceptable dust limit",
+		},
+		{
+			name: "severity policy can't warn on safety check",
+			cfg: func(cfg *Config) {
+				cfg.SeverityPolicy = SeverityPolicy{
+					rejectReasonDuplicatePubKey: SeverityWarn,
+				}
+			},
+			modify:    duplicatePubKey,
+			expectErr: "duplicate public keys",
+		},
+		{
+			name: "max htlcs below minimum",
+			cfg: func(cfg *Config) {
+				cfg.MinRemoteMaxHtlcs = minMaxHtlcs
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.MaxAcceptedHTLCs = minMaxHtlcs - 1
+			},
+			expectErr: "below our configured minimum",
+		},
+		{
+			name: "max htlcs at minimum",
+			cfg: func(cfg *Config) {
+				cfg.MinRemoteMaxHtlcs = minMaxHtlcs
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.MaxAcceptedHTLCs = minMaxHtlcs
+			},
+		},
+		{
+			// At the fee rate of the test estimator, the fee of a
+			// commitment carrying 483 HTLCs exceeds the capacity.
+			name: "max commit fee above allocation",
+			cfg: func(cfg *Config) {
+				cfg.MaxCommitFeeAllocation = 0.5
+			},
+			expectErr: "max commitment fee",
+		},
+		{
+			name: "max commit fee within allocation",
+			cfg: func(cfg *Config) {
+				cfg.MaxCommitFeeAllocation = 0.5
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.MaxAcceptedHTLCs = 5
+			},
+		},
+		{
+			name: "severity policy warns on max commit fee",
+			cfg: func(cfg *Config) {
+				cfg.MaxCommitFeeAllocation = 0.5
+				cfg.SeverityPolicy = SeverityPolicy{
+					maxCommitFeeReason: SeverityWarn,
+				}
+			},
+		},
+		{
+			name: "dust limit below minimum",
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.DustLimit = lnwallet.MinDustLimit - 1
+			},
+			expectErr: "dust limit of",
+		},
+		{
+			name: "dust limit at minimum",
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.DustLimit = lnwallet.MinDustLimit
+			},
+		},
+		{
+			name: "upfront shutdown optional without script",
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.UpfrontShutdownScript = nil
+			},
+		},
+		{
+			name: "upfront shutdown required without script",
+			cfg: func(cfg *Config) {
+				cfg.RequireRemoteUpfrontShutdown = true
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.UpfrontShutdownScript = nil
+			},
+			expectErr: "upfront shutdown script required",
+		},
+		{
+			name: "upfront shutdown required with script",
+			cfg: func(cfg *Config) {
+				cfg.RequireRemoteUpfrontShutdown = true
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.UpfrontShutdownScript = p2wpkh
+			},
+		},
+		{
+			name: "excess max value in flight allowed",
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.MaxValueInFlight = math.MaxUint64
+			},
+		},
+		{
+			name: "excess max value in flight rejected",
+			cfg: func(cfg *Config) {
+				cfg.RejectExcessMaxValueInFlight = true
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.MaxValueInFlight =
+					lnwire.NewMSatFromSatoshis(capacity) + 1
+			},
+			expectErr: "unacceptable max value in flight",
+		},
+		{
+			name: "max value in flight at capacity",
+			cfg: func(cfg *Config) {
+				cfg.RejectExcessMaxValueInFlight = true
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.MaxValueInFlight =
+					lnwire.NewMSatFromSatoshis(capacity)
+			},
+		},
+		{
+			name: "identity funding key allowed",
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.FundingKey = f.bob.privKey.PubKey()
+			},
+		},
+		{
+			name: "identity funding key rejected",
+			cfg: func(cfg *Config) {
+				cfg.RejectIdentityFundingKey = true
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.FundingKey = f.bob.privKey.PubKey()
+			},
+			expectErr: lnwire.ErrFundingKeyIsIdentity.Error(),
+		},
+		{
+			name: "zero-conf offered",
+			setup: func(_, bob *testNode) {
+				// Alice's features on her connection to Bob
+				// are those of Bob's mock peer.
+				bob.localFeatures = []lnwire.FeatureBit{
+					lnwire.ZeroConfOptional,
+				}
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.MinAcceptDepth = 0
+			},
+		},
+		{
+			name: "zero-conf not offered",
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.MinAcceptDepth = 0
+			},
+			expectErr: "unexpected zero-conf",
+		},
+		{
+			// lnd doesn't support zero-conf channels, so the
+			// features it advertises never offer them.
+			name: "zero-conf with default features",
+			setup: func(_, bob *testNode) {
+				bob.localFeatures = defaultInitFeatures(t)
+			},
+			modify: func(_ *testing.T, f *acceptFlow) {
+				f.accept.MinAcceptDepth = 0
+			},
+			expectErr: "unexpected zero-conf",
 		},
 	}
 
@@ -3608,69 +4213,3024 @@ func TestFundingManagerUpfrontShutdown(t *testing.T) {
 		test := test
 
 		t.Run(test.name, func(t *testing.T) {
-			testUpfrontFailure(t, test.pkscript, test.expectErr)
+			t.Parallel()
+
+			var cfgs []cfgOption
+			if test.cfg != nil {
+				cfgs = append(cfgs, test.cfg)
+			}
+			alice, bob := setupFundingManagers(t, cfgs...)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			if test.setup != nil {
+				test.setup(alice, bob)
+			}
+
+			var opts []func(*InitFundingMsg)
+			if test.initReq != nil {
+				opts = append(opts, test.initReq)
+			}
+			flow := openUntilAccept(t, alice, bob, opts...)
+
+			if test.modify != nil {
+				test.modify(t, flow)
+			}
+			alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+
+			if test.expectErr == "" {
+				assertFundingMsgSent(
+					t, alice.msgChan, "FundingCreated",
+				)
+				return
+			}
+
+			errMsg := assertFundingMsgSent(
+				t, alice.msgChan, "Error",
+			).(*lnwire.Error)
+			require.Equal(
+				t, lnwire.ChannelID(flow.open.PendingChannelID),
+				errMsg.ChanID,
+			)
+			require.Contains(t, string(errMsg.Data), test.expectErr)
+			assertNumPendingReservations(t, alice, bobPubKey, 0)
 		})
 	}
 }
 
-func testUpfrontFailure(t *testing.T, pkscript []byte, expectErr bool) {
-	alice, bob := setupFundingManagers(t)
-	defer tearDownFundingManagers(t, alice, bob)
+// fundingBalance returns the balance alice contributes to the channel of the
+// given funding flow.
+func fundingBalance(t *testing.T, f *acceptFlow) btcutil.Amount {
+	t.Helper()
 
-	errChan := make(chan error, 1)
-	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	resCtx, err := f.alice.fundingMgr.getReservationCtx(
+		f.bob.privKey.PubKey(), f.open.PendingChannelID,
+	)
+	require.NoError(t, err)
 
-	fundingAmt := btcutil.Amount(500000)
-	pushAmt := lnwire.NewMSatFromSatoshis(btcutil.Amount(0))
+	return resCtx.reservation.OurContribution().FundingAmount
+}
 
-	initReq := &InitFundingMsg{
-		Peer:            alice,
-		TargetPubkey:    alice.privKey.PubKey(),
-		ChainHash:       *fundingNetParams.GenesisHash,
-		SubtractFees:    false,
-		LocalFundingAmt: fundingAmt,
-		PushAmt:         pushAmt,
-		FundingFeePerKw: 1000,
-		Private:         false,
-		Updates:         updateChan,
-		Err:             errChan,
-	}
-	bob.fundingMgr.InitFundingWorkflow(initReq)
+// TestCommitPointCache asserts that the cache detects a first commitment
+// point seen before from the same peer, and evicts the point the peer sent
+// least recently once its LRU is full, without affecting other peers.
+func TestCommitPointCache(t *testing.T) {
+	t.Parallel()
 
-	// Bob should send an open_channel message to Alice.
-	var bobMsg lnwire.Message
-	select {
-	case bobMsg = <-bob.msgChan:
-	case err := <-initReq.Err:
-		t.Fatalf("received unexpected error: %v", err)
-	case <-time.After(time.Second * 5):
-		t.Fatalf("timed out waiting for bob's message")
+	key := func(b byte) *btcec.PublicKey {
+		_, pub := btcec.PrivKeyFromBytes(
+			btcec.S256(), bytes.Repeat([]byte{b}, 32),
+		)
+		return pub
+	}
+	peerA, peerB := key(1), key(2)
+	point1, point2, point3 := key(3), key(4), key(5)
+
+	cache := newCommitPointCache(2)
+	require.False(t, cache.observe(peerA, point1))
+	require.True(t, cache.observe(peerA, point1))
+
+	// The same point sent by another peer isn't a reuse.
+	require.False(t, cache.observe(peerB, point1))
+	require.Equal(t, 1, cache.len(peerA))
+	require.Equal(t, 1, cache.len(peerB))
+
+	// Overflowing the LRU of peerA evicts its point1, but not the one of
+	// peerB.
+	require.False(t, cache.observe(peerA, point2))
+	require.False(t, cache.observe(peerA, point3))
+	require.Equal(t, 2, cache.len(peerA))
+	require.True(t, cache.observe(peerB, point1))
+
+	// Seeing point2 again makes it the most recent point of peerA, so
+	// point3 is evicted once point1 is added again.
+	require.True(t, cache.observe(peerA, point2))
+	require.False(t, cache.observe(peerA, point1))
+	require.True(t, cache.observe(peerA, point2))
+	require.False(t, cache.observe(peerA, point3))
+	require.Equal(t, 2, cache.len(peerA))
+
+	// A disabled cache never reports a point as seen.
+	var disabled *commitPointCache
+	require.Nil(t, newCommitPointCache(0))
+	require.False(t, disabled.observe(peerA, point1))
+	require.False(t, disabled.observe(peerA, point1))
+}
+
+// TestFundingManagerCommitPointReuse asserts that an AcceptChannel reusing
+// the first commitment point of an earlier, failed channel is rejected if
+// reuse is detected, unless the SeverityPolicy downgrades the check to a
+// warning.
+func TestFundingManagerCommitPointReuse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		maxSeen      int
+		policy       SeverityPolicy
+		reuse        bool
+		expectReject bool
+	}{
+		{
+			name:    "fresh point",
+			maxSeen: 1,
+		},
+		{
+			name:         "reused point",
+			maxSeen:      1,
+			reuse:        true,
+			expectReject: true,
+		},
+		{
+			name:    "reused point warned about",
+			maxSeen: 1,
+			policy: SeverityPolicy{
+				rejectReasonCommitPointReuse: SeverityWarn,
+			},
+			reuse: true,
+		},
+		{
+			name:  "reuse detection disabled",
+			reuse: true,
+		},
 	}
 
-	bobOpenChan, ok := bobMsg.(*lnwire.OpenChannel)
-	require.True(t, ok, "did not receive OpenChannel")
+	for _, test := range tests {
+		test := test
 
-	// Set the UpfrontShutdownScript in OpenChannel.
-	bobOpenChan.UpfrontShutdownScript = lnwire.DeliveryAddress(pkscript)
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
 
-	// Send the OpenChannel message to Alice now. If we expected an error,
-	// check that we received it.
-	alice.fundingMgr.ProcessFundingMsg(bobOpenChan, bob)
+			alice, bob := setupFundingManagers(
+				t, func(cfg *Config) {
+					cfg.MaxSeenCommitPoints = test.maxSeen
+					cfg.SeverityPolicy = test.policy
+				},
+			)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			// The first channel fails, as Bob's AcceptChannel
+			// reuses one of its keys.
+			firstAccept := openUntilAccept(t, alice, bob).accept
+			firstPoint := firstAccept.FirstCommitmentPoint
+			firstAccept.HtlcPoint = firstAccept.FundingKey
+			alice.fundingMgr.ProcessFundingMsg(firstAccept, bob)
+
+			errMsg := assertFundingMsgSent(t, alice.msgChan, "Error")
+			bob.fundingMgr.ProcessFundingMsg(errMsg, alice)
+			assertNumPendingReservations(t, alice, bobPubKey, 0)
+
+			// As the keys of the test wallet are deterministic,
+			// Bob's first commitment point has to be replaced
+			// explicitly for the channel not to reuse it.
+			secondAccept := openUntilAccept(t, alice, bob).accept
+			secondAccept.FirstCommitmentPoint = firstPoint
+			if !test.reuse {
+				priv, err := btcec.NewPrivateKey(btcec.S256())
+				require.NoError(t, err)
+
+				freshPoint := priv.PubKey()
+				secondAccept.FirstCommitmentPoint = freshPoint
+			}
+			alice.fundingMgr.ProcessFundingMsg(secondAccept, bob)
 
-	var aliceMsg lnwire.Message
-	select {
-	case aliceMsg = <-alice.msgChan:
-	case <-time.After(time.Second * 5):
-		t.Fatalf("timed out waiting for alice's message")
-	}
+			if !test.expectReject {
+				assertFundingMsgSent(
+					t, alice.msgChan, "FundingCreated",
+				)
+				return
+			}
 
-	if expectErr {
-		// Assert that Error was received.
-		_, ok = aliceMsg.(*lnwire.Error)
-		require.True(t, ok, "did not receive Error")
-	} else {
-		// Assert that AcceptChannel was received.
-		_, ok = aliceMsg.(*lnwire.AcceptChannel)
-		require.True(t, ok, "did not receive AcceptChannel")
+			errMsg = assertFundingMsgSent(
+				t, alice.msgChan, "Error",
+			)
+			require.Contains(
+				t, string(errMsg.(*lnwire.Error).Data),
+				"reused first commitment point",
+			)
+			assertNumPendingReservations(t, alice, bobPubKey, 0)
+		})
 	}
 }
+
+// TestFundingManagerRequiredCommitType asserts that an opening request is only
+// accepted if it results in the commitment type we require.
+func TestFundingManagerRequiredCommitType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		requiredType lnwallet.CommitmentType
+		expectReject bool
+	}{
+		{
+			// Since our test nodes don't signal any features, the
+			// negotiated commitment type is always legacy.
+			name:         "matching type",
+			requiredType: lnwallet.CommitmentTypeLegacy,
+		},
+		{
+			name:         "mismatching type",
+			requiredType: lnwallet.CommitmentTypeAnchorsZeroFeeHtlcTx,
+			expectReject: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			requireType := func(cfg *Config) {
+				requiredType := test.requiredType
+				cfg.RequiredCommitType = &requiredType
+			}
+
+			alice, bob := setupFundingManagers(t, requireType)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			_, openChannelReq := initFunding(t, alice, bob)
+			bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+
+			if !test.expectReject {
+				assertFundingMsgSent(
+					t, bob.msgChan, "AcceptChannel",
+				)
+				return
+			}
+
+			errMsg := assertFundingMsgSent(
+				t, bob.msgChan, "Error",
+			).(*lnwire.Error)
+			require.Contains(
+				t, string(errMsg.Data), "commitment type legacy "+
+					"not allowed",
+			)
+			assertNumPendingReservations(t, bob, alicePubKey, 0)
+		})
+	}
+}
+
+// TestFundingManagerMalformedAccept ensures that the reservation Alice holds is
+// cancelled right away once Bob sends an AcceptChannel she can't decode, and
+// that processing the same malformed message again is a no-op.
+func TestFundingManagerMalformedAccept(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// Alice starts the funding flow, and Bob answers with an
+	// AcceptChannel.
+	flow := openUntilAccept(t, alice, bob)
+	pendingChanID := flow.accept.PendingChannelID
+
+	// At this point, Alice has a reservation holding her coins.
+	assertNumPendingReservations(t, alice, bobPubKey, 1)
+	require.Len(t, alice.fundingMgr.cfg.Wallet.ActiveReservations(), 1)
+
+	// Serialize Bob's response and cut it off in the middle of the first
+	// commitment point, such that it can no longer be decoded.
+	var b bytes.Buffer
+	_, err := lnwire.WriteMessage(&b, flow.accept, 0)
+	require.NoError(t, err)
+
+	rawMsg := b.Bytes()[:b.Len()-20]
+	_, decodeErr := lnwire.ReadMessage(bytes.NewReader(rawMsg), 0)
+	require.Error(t, decodeErr)
+
+	// Hand the decode failure to Alice. Since she sends an error to Bob as
+	// part of failing the flow, we do this in a goroutine.
+	done := make(chan struct{})
+	go func() {
+		alice.fundingMgr.ProcessMalformedAccept(
+			pendingChanID, decodeErr, bob,
+		)
+		close(done)
+	}()
+
+	// Alice should fail the funding flow towards Bob, telling him why, and
+	// report the error to the caller.
+	errMsg := assertFundingMsgSent(
+		t, alice.msgChan, "Error",
+	).(*lnwire.Error)
+	require.Equal(t, lnwire.ChannelID(pendingChanID), errMsg.ChanID)
+	require.Contains(t, string(errMsg.Data), "malformed AcceptChannel")
+	select {
+	case err := <-flow.initReq.Err:
+		require.Contains(t, err.Error(), "malformed AcceptChannel")
+	case <-time.After(time.Second * 5):
+		t.Fatalf("alice did not fail funding request")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("malformed accept not processed")
+	}
+
+	// The reservation must be gone without waiting for the zombie
+	// sweeper, meaning the coins are available again.
+	assertNumPendingReservations(t, alice, bobPubKey, 0)
+	require.Empty(t, alice.fundingMgr.cfg.Wallet.ActiveReservations())
+
+	// Processing the malformed message a second time should be a no-op.
+	alice.fundingMgr.ProcessMalformedAccept(pendingChanID, decodeErr, bob)
+	assertErrorNotSent(t, alice.msgChan)
+}
+
+// TestFundingManagerFundAll tests that we can initiate a funding request to
+// use the funds remaining in the wallet. This should produce a funding tx with
+// no change output.
+func TestFundingManagerFundAll(t *testing.T) {
+	t.Parallel()
+
+	// We set up our mock wallet to control a list of UTXOs that sum to
+	// less than the max channel size.
+	allCoins := []*lnwallet.Utxo{
+		{
+			AddressType: lnwallet.WitnessPubKey,
+			Value: btcutil.Amount(
+				0.05 * btcutil.SatoshiPerBitcoin,
+			),
+			PkScript: mock.CoinPkScript,
+			OutPoint: wire.OutPoint{
+				Hash:  chainhash.Hash{},
+				Index: 0,
+			},
+		},
+		{
+			AddressType: lnwallet.WitnessPubKey,
+			Value: btcutil.Amount(
+				0.06 * btcutil.SatoshiPerBitcoin,
+			),
+			PkScript: mock.CoinPkScript,
+			OutPoint: wire.OutPoint{
+				Hash:  chainhash.Hash{},
+				Index: 1,
+			},
+		},
+	}
+
+	tests := []struct {
+		spendAmt btcutil.Amount
+		change   bool
+	}{
+		{
+			// We will spend all the funds in the wallet, and
+			// expects no change output.
+			spendAmt: btcutil.Amount(
+				0.11 * btcutil.SatoshiPerBitcoin,
+			),
+			change: false,
+		},
+		{
+			// We spend a little less than the funds in the wallet,
+			// so a change output should be created.
+			spendAmt: btcutil.Amount(
+				0.10 * btcutil.SatoshiPerBitcoin,
+			),
+			change: true,
+		},
+	}
+
+	for _, test := range tests {
+		alice, bob := setupFundingManagers(t)
+		defer tearDownFundingManagers(t, alice, bob)
+
+		alice.fundingMgr.cfg.Wallet.WalletController.(*mock.WalletController).Utxos = allCoins
+
+		// We will consume the channel updates as we go, so no
+		// buffering is needed.
+		updateChan := make(chan *lnrpc.OpenStatusUpdate)
+
+		// Initiate a fund channel, and inspect the funding tx.
+		pushAmt := btcutil.Amount(0)
+		fundingTx := fundChannel(
+			t, alice, bob, test.spendAmt, pushAmt, true, 1,
+			updateChan, true,
+		)
+
+		// Check whether the expected change output is present.
+		if test.change && len(fundingTx.TxOut) != 2 {
+			t.Fatalf("expected 2 outputs, had %v",
+				len(fundingTx.TxOut))
+		}
+
+		if !test.change && len(fundingTx.TxOut) != 1 {
+			t.Fatalf("expected 1 output, had %v",
+				len(fundingTx.TxOut))
+		}
+
+		// Inputs should be all funds in the wallet.
+		if len(fundingTx.TxIn) != len(allCoins) {
+			t.Fatalf("Had %d inputs, expected %d",
+				len(fundingTx.TxIn), len(allCoins))
+		}
+
+		for i, txIn := range fundingTx.TxIn {
+			if txIn.PreviousOutPoint != allCoins[i].OutPoint {
+				t.Fatalf("expected outpoint to be %v, was %v",
+					allCoins[i].OutPoint,
+					txIn.PreviousOutPoint)
+			}
+		}
+	}
+}
+
+// TestGetUpfrontShutdown tests different combinations of inputs for getting a
+// shutdown script. It varies whether the peer has the feature set, whether
+// the user has provided a script and our local configuration to test that
+// GetUpfrontShutdownScript returns the expected outcome.
+func TestGetUpfrontShutdownScript(t *testing.T) {
+	upfrontScript := []byte("upfront script")
+	generatedScript := []byte("generated script")
+
+	getScript := func() (lnwire.DeliveryAddress, error) {
+		return generatedScript, nil
+	}
+
+	tests := []struct {
+		name           string
+		getScript      func() (lnwire.DeliveryAddress, error)
+		upfrontScript  lnwire.DeliveryAddress
+		peerEnabled    bool
+		localEnabled   bool
+		expectedScript lnwire.DeliveryAddress
+		expectedErr    error
+	}{
+		{
+			name:      "peer disabled, no shutdown",
+			getScript: getScript,
+		},
+		{
+			name:          "peer disabled, upfront provided",
+			upfrontScript: upfrontScript,
+			expectedErr:   errUpfrontShutdownScriptNotSupported,
+		},
+		{
+			name:           "peer enabled, upfront provided",
+			upfrontScript:  upfrontScript,
+			peerEnabled:    true,
+			expectedScript: upfrontScript,
+		},
+		{
+			name:        "peer enabled, local disabled",
+			peerEnabled: true,
+		},
+		{
+			name:           "local enabled, no upfront script",
+			getScript:      getScript,
+			peerEnabled:    true,
+			localEnabled:   true,
+			expectedScript: generatedScript,
+		},
+		{
+			name:           "local enabled, upfront script",
+			peerEnabled:    true,
+			upfrontScript:  upfrontScript,
+			localEnabled:   true,
+			expectedScript: upfrontScript,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var mockPeer testNode
+
+			// If the remote peer in the test should support upfront shutdown,
+			// add the feature bit.
+			if test.peerEnabled {
+				mockPeer.remoteFeatures = []lnwire.FeatureBit{
+					lnwire.UpfrontShutdownScriptOptional,
+				}
+			}
+
+			addr, err := getUpfrontShutdownScript(
+				test.localEnabled, &mockPeer, test.upfrontScript,
+				test.getScript,
+			)
+			if err != test.expectedErr {
+				t.Fatalf("got: %v, expected error: %v", err, test.expectedErr)
+			}
+
+			if !bytes.Equal(addr, test.expectedScript) {
+				t.Fatalf("expected address: %x, got: %x",
+					test.expectedScript, addr)
+			}
+
+		})
+	}
+}
+
+func expectOpenChannelMsg(t *testing.T, msgChan chan lnwire.Message) *lnwire.OpenChannel {
+	var msg lnwire.Message
+	select {
+	case msg = <-msgChan:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("node did not send OpenChannel message")
+	}
+
+	openChannelReq, ok := msg.(*lnwire.OpenChannel)
+	if !ok {
+		errorMsg, gotError := msg.(*lnwire.Error)
+		if gotError {
+			t.Fatalf("expected OpenChannel to be sent "+
+				"from bob, instead got error: %v",
+				errorMsg.Error())
+		}
+		t.Fatalf("expected OpenChannel to be sent, instead got %T",
+			msg)
+	}
+
+	return openChannelReq
+}
+
+// acceptFlow is a funding flow alice started with bob, up until bob's
+// AcceptChannel, which alice hasn't processed yet.
+type acceptFlow struct {
+	alice, bob *testNode
+
+	// initReq is the funding request alice started the flow with.
+	initReq *InitFundingMsg
+
+	// open is the OpenChannel alice sent.
+	open *lnwire.OpenChannel
+
+	// accept is the AcceptChannel bob responded with.
+	accept *lnwire.AcceptChannel
+}
+
+// initFunding makes alice start a funding flow with bob for a 500000 sat
+// channel, modified by the passed options, and returns the funding request
+// along with the OpenChannel alice sent. Bob hasn't processed it yet.
+func initFunding(t *testing.T, alice, bob *testNode,
+	opts ...func(*InitFundingMsg)) (*InitFundingMsg, *lnwire.OpenChannel) {
+
+	t.Helper()
+
+	initReq := &InitFundingMsg{
+		Peer:            bob,
+		TargetPubkey:    bob.privKey.PubKey(),
+		ChainHash:       *fundingNetParams.GenesisHash,
+		LocalFundingAmt: 500000,
+		FundingFeePerKw: 1000,
+		Updates:         make(chan *lnrpc.OpenStatusUpdate),
+		Err:             make(chan error, 1),
+	}
+	for _, opt := range opts {
+		opt(initReq)
+	}
+	alice.fundingMgr.InitFundingWorkflow(initReq)
+
+	return initReq, expectOpenChannelMsg(t, alice.msgChan)
+}
+
+// openUntilAccept makes alice open a channel to bob as initFunding does, and
+// lets bob process the OpenChannel. The flow is returned along with bob's
+// AcceptChannel, which alice hasn't processed yet.
+func openUntilAccept(t *testing.T, alice, bob *testNode,
+	opts ...func(*InitFundingMsg)) *acceptFlow {
+
+	t.Helper()
+
+	initReq, openChannelReq := initFunding(t, alice, bob, opts...)
+	bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+
+	acceptChannelResponse := assertFundingMsgSent(
+		t, bob.msgChan, "AcceptChannel",
+	).(*lnwire.AcceptChannel)
+
+	return &acceptFlow{
+		alice:   alice,
+		bob:     bob,
+		initReq: initReq,
+		open:    openChannelReq,
+		accept:  acceptChannelResponse,
+	}
+}
+
+func TestMaxChannelSizeConfig(t *testing.T) {
+	t.Parallel()
+
+	// Create a set of funding managers that will reject wumbo
+	// channels but set --maxchansize explicitly lower than soft-limit.
+	// Verify that wumbo rejecting funding managers will respect --maxchansize
+	// below 16777215 satoshi (MaxBtcFundingAmount) limit.
+	alice, bob := setupFundingManagers(t, func(cfg *Config) {
+		cfg.NoWumboChans = true
+		cfg.MaxChanSize = MaxBtcFundingAmount - 1
+	})
+
+	// Attempt to create a channel above the limit
+	// imposed by --maxchansize, which should be rejected.
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	errChan := make(chan error, 1)
+	initReq := &InitFundingMsg{
+		Peer:            bob,
+		TargetPubkey:    bob.privKey.PubKey(),
+		ChainHash:       *fundingNetParams.GenesisHash,
+		LocalFundingAmt: MaxBtcFundingAmount,
+		PushAmt:         lnwire.NewMSatFromSatoshis(0),
+		Private:         false,
+		Updates:         updateChan,
+		Err:             errChan,
+	}
+
+	// After processing the funding open message, bob should respond with
+	// an error rejecting the channel that exceeds size limit.
+	alice.fundingMgr.InitFundingWorkflow(initReq)
+	openChanMsg := expectOpenChannelMsg(t, alice.msgChan)
+	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
+	assertErrorSent(t, bob.msgChan)
+
+	// Create a set of funding managers that will reject wumbo
+	// channels but set --maxchansize explicitly higher than soft-limit
+	// A --maxchansize greater than this limit should have no effect.
+	tearDownFundingManagers(t, alice, bob)
+	alice, bob = setupFundingManagers(t, func(cfg *Config) {
+		cfg.NoWumboChans = true
+		cfg.MaxChanSize = MaxBtcFundingAmount + 1
+	})
+
+	// Reset the Peer to the newly created one.
+	initReq.Peer = bob
+
+	// We expect Bob to respond with an Accept channel message.
+	alice.fundingMgr.InitFundingWorkflow(initReq)
+	openChanMsg = expectOpenChannelMsg(t, alice.msgChan)
+	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
+	assertFundingMsgSent(t, bob.msgChan, "AcceptChannel")
+
+	// Verify that wumbo accepting funding managers will respect --maxchansize
+	// Create the funding managers, this time allowing
+	// wumbo channels but setting --maxchansize explicitly.
+	tearDownFundingManagers(t, alice, bob)
+	alice, bob = setupFundingManagers(t, func(cfg *Config) {
+		cfg.NoWumboChans = false
+		cfg.MaxChanSize = btcutil.Amount(100000000)
+	})
+
+	// Reset the Peer to the newly created one.
+	initReq.Peer = bob
+
+	// Attempt to create a channel above the limit
+	// imposed by --maxchansize, which should be rejected.
+	initReq.LocalFundingAmt = btcutil.SatoshiPerBitcoin + 1
+
+	// After processing the funding open message, bob should respond with
+	// an error rejecting the channel that exceeds size limit.
+	alice.fundingMgr.InitFundingWorkflow(initReq)
+	openChanMsg = expectOpenChannelMsg(t, alice.msgChan)
+	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
+	assertErrorSent(t, bob.msgChan)
+}
+
+// TestMinChannelSizeConfig asserts that channels below --minchansize are
+// rejected with an error sent to the initiator, while channels of exactly the
+// minimum size are accepted.
+func TestMinChannelSizeConfig(t *testing.T) {
+	t.Parallel()
+
+	const minChanSize = btcutil.Amount(100000)
+
+	testCases := []struct {
+		name        string
+		minChanSize btcutil.Amount
+		amt         btcutil.Amount
+		accept      bool
+	}{
+		{
+			name:        "below min chan size",
+			minChanSize: minChanSize,
+			amt:         minChanSize - 1,
+			accept:      false,
+		},
+		{
+			name:        "equal to min chan size",
+			minChanSize: minChanSize,
+			amt:         minChanSize,
+			accept:      true,
+		},
+		{
+			name:        "above min chan size",
+			minChanSize: minChanSize,
+			amt:         minChanSize + 1,
+			accept:      true,
+		},
+		{
+			name:        "no min chan size",
+			minChanSize: 0,
+			amt:         minChanSize - 1,
+			accept:      true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			alice, bob := setupFundingManagers(t, func(cfg *Config) {
+				cfg.MinChanSize = testCase.minChanSize
+			})
+			defer tearDownFundingManagers(t, alice, bob)
+
+			_, openChanMsg := initFunding(
+				t, alice, bob, func(req *InitFundingMsg) {
+					req.LocalFundingAmt = testCase.amt
+				},
+			)
+			bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
+
+			if testCase.accept {
+				assertFundingMsgSent(
+					t, bob.msgChan, "AcceptChannel",
+				)
+				assertNumPendingReservations(
+					t, bob, alicePubKey, 1,
+				)
+				return
+			}
+
+			// Bob should tell Alice why the channel was rejected,
+			// without reserving anything for it.
+			errMsg := assertFundingMsgSent(
+				t, bob.msgChan, "Error",
+			).(*lnwire.Error)
+			expectedErr := lnwallet.ErrChanTooSmall(
+				testCase.amt, testCase.minChanSize,
+			)
+			require.Equal(
+				t, expectedErr.Error(), string(errMsg.Data),
+			)
+			assertNumPendingReservations(t, bob, alicePubKey, 0)
+		})
+	}
+}
+
+// TestWumboChannelConfig tests that the funding manager will respect the wumbo
+// channel config param when creating or accepting new channels.
+func TestWumboChannelConfig(t *testing.T) {
+	t.Parallel()
+
+	// First we'll create a set of funding managers that will reject wumbo
+	// channels.
+	alice, bob := setupFundingManagers(t, func(cfg *Config) {
+		cfg.NoWumboChans = true
+	})
+
+	// If we attempt to initiate a new funding open request to Alice,
+	// that's below the wumbo channel mark, we should be able to start the
+	// funding process w/o issue.
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	errChan := make(chan error, 1)
+	initReq := &InitFundingMsg{
+		Peer:            bob,
+		TargetPubkey:    bob.privKey.PubKey(),
+		ChainHash:       *fundingNetParams.GenesisHash,
+		LocalFundingAmt: MaxBtcFundingAmount,
+		PushAmt:         lnwire.NewMSatFromSatoshis(0),
+		Private:         false,
+		Updates:         updateChan,
+		Err:             errChan,
+	}
+
+	// We expect Bob to respond with an Accept channel message.
+	alice.fundingMgr.InitFundingWorkflow(initReq)
+	openChanMsg := expectOpenChannelMsg(t, alice.msgChan)
+	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
+	assertFundingMsgSent(t, bob.msgChan, "AcceptChannel")
+
+	// We'll now attempt to create a channel above the wumbo mark, which
+	// should be rejected.
+	initReq.LocalFundingAmt = btcutil.SatoshiPerBitcoin
+
+	// After processing the funding open message, bob should respond with
+	// an error rejecting the channel.
+	alice.fundingMgr.InitFundingWorkflow(initReq)
+	openChanMsg = expectOpenChannelMsg(t, alice.msgChan)
+	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
+	assertErrorSent(t, bob.msgChan)
+
+	// Next, we'll re-create the funding managers, but this time allowing
+	// wumbo channels explicitly.
+	tearDownFundingManagers(t, alice, bob)
+	alice, bob = setupFundingManagers(t, func(cfg *Config) {
+		cfg.NoWumboChans = false
+		cfg.MaxChanSize = MaxBtcFundingAmountWumbo
+	})
+
+	// Reset the Peer to the newly created one.
+	initReq.Peer = bob
+
+	// We should now be able to initiate a wumbo channel funding w/o any
+	// issues.
+	alice.fundingMgr.InitFundingWorkflow(initReq)
+	openChanMsg = expectOpenChannelMsg(t, alice.msgChan)
+	bob.fundingMgr.ProcessFundingMsg(openChanMsg, alice)
+	assertFundingMsgSent(t, bob.msgChan, "AcceptChannel")
+}
+
+// TestFundingManagerUpfrontShutdown asserts that we'll properly fail out if
+// an invalid upfront shutdown script is sent in the open_channel message.
+// Since both the open_channel and accept_message logic validate the script
+// using the same validation function, it suffices to just check the
+// open_channel case.
+func TestFundingManagerUpfrontShutdown(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		pkscript  []byte
+		expectErr bool
+	}{
+		{
+			name: "p2pk script",
+			pkscript: []byte("\x21\x02\xd3\x00\x50\x2f\x61\x15" +
+				"\x0d\x58\x0a\x42\xa0\x99\x63\xe3\x47\xa2" +
+				"\xad\x3c\xe5\x1f\x11\x96\x0d\x35\x8d\xf8" +
+				"\xf3\x94\xf9\x67\x2a\x67\xac"),
+			expectErr: true,
+		},
+		{
+			name: "op return script",
+			pkscript: []byte("\x6a\x24\xaa\x21\xa9\xed\x18\xa9" +
+				"\x93\x58\x94\xbd\x48\x9b\xeb\x87\x66\x13" +
+				"\x60\xbc\x80\x92\xab\xf6\xdd\xe9\x1e\x82" +
+				"\x0c\x7d\x91\x89\x9d\x0a\x02\x34\x14\x3f"),
+			expectErr: true,
+		},
+		{
+			name: "standard (non-p2sh) 2-of-3 multisig",
+			pkscript: []byte("\x51\x41\x04\xcc\x71\xeb\x30\xd6" +
+				"\x53\xc0\xc3\x16\x39\x90\xc4\x7b\x97\x6f" +
+				"\x3f\xb3\xf3\x7c\xcc\xdc\xbe\xdb\x16\x9a" +
+				"\x1d\xfe\xf5\x8b\xbf\xbf\xaf\xf7\xd8\xa4" +
+				"\x73\xe7\xe2\xe6\xd3\x17\xb8\x7b\xaf\xe8" +
+				"\xbd\xe9\x7e\x3c\xf8\xf0\x65\xde\xc0\x22" +
+				"\xb5\x1d\x11\xfc\xdd\x0d\x34\x8a\xc4\x41" +
+				"\x04\x61\xcb\xdc\xc5\x40\x9f\xb4\xb4\xd4" +
+				"\x2b\x51\xd3\x33\x81\x35\x4d\x80\xe5\x50" +
+				"\x07\x8c\xb5\x32\xa3\x4b\xfa\x2f\xcf\xde" +
+				"\xb7\xd7\x65\x19\xae\xcc\x62\x77\x0f\x5b" +
+				"\x0e\x4e\xf8\x55\x19\x46\xd8\xa5\x40\x91" +
+				"\x1a\xbe\x3e\x78\x54\xa2\x6f\x39\xf5\x8b" +
+				"\x25\xc1\x53\x42\xaf\x52\xae"),
+			expectErr: true,
+		},
+		{
+			name:      "nonstandard script",
+			pkscript:  []byte("\x99"),
+			expectErr: true,
+		},
+		{
+			name: "p2sh script",
+			pkscript: []byte("\xa9\x14\xfe\x44\x10\x65\xb6\x53" +
+				"\x22\x31\xde\x2f\xac\x56\x31\x52\x20\x5e" +
+				"\xc4\xf5\x9c\x74\x87"),
+			expectErr: false,
+		},
+		{
+			name: "p2pkh script",
+			pkscript: []byte("\x76\xa9\x14\x64\x1a\xd5\x05\x1e" +
+				"\xdd\x97\x02\x9a\x00\x3f\xe9\xef\xb2\x93" +
+				"\x59\xfc\xee\x40\x9d\x88\xac"),
+			expectErr: false,
+		},
+		{
+			name: "p2wpkh script",
+			pkscript: []byte("\x00\x14\x4b\xfe\x98\x3f\x16\xaa" +
+				"\xde\x1b\x1c\xb1\x54\x5a\xa5\xa4\x88\xd5" +
+				"\xe3\x68\xb5\xdc"),
+			expectErr: false,
+		},
+		{
+			name: "p2wsh script",
+			pkscript: []byte("\x00\x20\x1d\xd6\x3c\x20\x13\x89" +
+				"\x3a\x8b\x41\x5e\xb2\xe7\x41\x8f\x07\x5d" +
+				"\x4f\x3b\xf1\x81\x34\x99\xef\x31\xfb\xd7" +
+				"\x8c\xa8\xc4\x5d\x8f\xf0"),
+			expectErr: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			testUpfrontFailure(t, test.pkscript, test.expectErr)
+		})
+	}
+}
+
+func testUpfrontFailure(t *testing.T, pkscript []byte, expectErr bool) {
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	errChan := make(chan error, 1)
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+
+	fundingAmt := btcutil.Amount(500000)
+	pushAmt := lnwire.NewMSatFromSatoshis(btcutil.Amount(0))
+
+	initReq := &InitFundingMsg{
+		Peer:            alice,
+		TargetPubkey:    alice.privKey.PubKey(),
+		ChainHash:       *fundingNetParams.GenesisHash,
+		SubtractFees:    false,
+		LocalFundingAmt: fundingAmt,
+		PushAmt:         pushAmt,
+		FundingFeePerKw: 1000,
+		Private:         false,
+		Updates:         updateChan,
+		Err:             errChan,
+	}
+	bob.fundingMgr.InitFundingWorkflow(initReq)
+
+	// Bob should send an open_channel message to Alice.
+	var bobMsg lnwire.Message
+	select {
+	case bobMsg = <-bob.msgChan:
+	case err := <-initReq.Err:
+		t.Fatalf("received unexpected error: %v", err)
+	case <-time.After(time.Second * 5):
+		t.Fatalf("timed out waiting for bob's message")
+	}
+
+	bobOpenChan, ok := bobMsg.(*lnwire.OpenChannel)
+	require.True(t, ok, "did not receive OpenChannel")
+
+	// Set the UpfrontShutdownScript in OpenChannel.
+	bobOpenChan.UpfrontShutdownScript = lnwire.DeliveryAddress(pkscript)
+
+	// Send the OpenChannel message to Alice now. If we expected an error,
+	// check that we received it.
+	alice.fundingMgr.ProcessFundingMsg(bobOpenChan, bob)
+
+	var aliceMsg lnwire.Message
+	select {
+	case aliceMsg = <-alice.msgChan:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("timed out waiting for alice's message")
+	}
+
+	if expectErr {
+		// Assert that Error was received.
+		_, ok = aliceMsg.(*lnwire.Error)
+		require.True(t, ok, "did not receive Error")
+	} else {
+		// Assert that AcceptChannel was received.
+		_, ok = aliceMsg.(*lnwire.AcceptChannel)
+		require.True(t, ok, "did not receive AcceptChannel")
+	}
+}
+
+// TestFundingManagerSimulateOpenChannel asserts that simulating a channel open
+// returns the reserve, confirmation depth and commitment fee a real open
+// would use, and that balances too small to fund a channel are rejected the
+// same way a reservation would reject them.
+func TestFundingManagerSimulateOpenChannel(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	const (
+		capacity = btcutil.Amount(500000)
+		pushAmt  = lnwire.MilliSatoshi(10000000)
+	)
+
+	sim, err := alice.fundingMgr.SimulateOpenChannel(bob, capacity, pushAmt)
+	require.NoError(t, err)
+
+	// Our test nodes don't signal any features, so the legacy commitment
+	// type is used at the rate of the static fee estimator.
+	commitFee := chainfee.SatPerKWeight(62500).FeeForWeight(
+		input.CommitWeight,
+	)
+	require.EqualValues(t, lnwallet.CommitmentTypeLegacy, sim.CommitType)
+	require.Equal(t, chainfee.SatPerKWeight(62500), sim.CommitFeePerKw)
+	require.Equal(t, commitFee, sim.CommitFee)
+	require.Equal(t, DefaultReservePolicy(capacity), sim.ChanReserve)
+	require.Equal(t, uint16(3), sim.MinAcceptDepth)
+	require.Equal(
+		t, lnwire.NewMSatFromSatoshis(capacity-commitFee)-pushAmt,
+		sim.LocalBalance,
+	)
+	require.Equal(t, pushAmt, sim.RemoteBalance)
+
+	// Nothing should have been sent to the peer, and no reservation
+	// should be pending.
+	assertNumPendingReservations(t, alice, bobPubKey, 0)
+	select {
+	case msg := <-alice.msgChan:
+		t.Fatalf("unexpected message sent to peer: %T", msg)
+	default:
+	}
+
+	// A capacity that doesn't cover the commitment fee should be
+	// rejected.
+	_, err = alice.fundingMgr.SimulateOpenChannel(bob, commitFee, 0)
+	var resErr lnwallet.ReservationError
+	require.ErrorAs(t, err, &resErr)
+	require.Equal(t, lnwallet.ReasonFunderBalanceDust, resErr.Reason())
+
+	// Pushing more than the capacity should be rejected as well.
+	_, err = alice.fundingMgr.SimulateOpenChannel(
+		bob, capacity, lnwire.NewMSatFromSatoshis(capacity+1),
+	)
+	require.Error(t, err)
+
+	// Once Bob accepted a channel, the reserve and confirmation depth he
+	// applied then are previewed instead of our own policy.
+	alice.fundingMgr.cacheAcceptPolicy(bobPubKey, capacity/2,
+		&lnwire.AcceptChannel{
+			DustLimit:      573,
+			ChannelReserve: 20000,
+			MinAcceptDepth: 6,
+		},
+	)
+
+	sim, err = alice.fundingMgr.SimulateOpenChannel(bob, capacity, pushAmt)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(40000), sim.ChanReserve)
+	require.Equal(t, uint16(6), sim.MinAcceptDepth)
+	require.Equal(t, commitFee, sim.CommitFee)
+}
+
+// TestFundingManagerAcceptEvents asserts that handling an AcceptChannel
+// publishes the expected funding events to subscribers.
+func TestFundingManagerAcceptEvents(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		modify func(*lnwire.AcceptChannel)
+		reason string
+	}{
+		{
+			name:   "accepted",
+			modify: func(*lnwire.AcceptChannel) {},
+		},
+		{
+			name: "rejected",
+			modify: func(msg *lnwire.AcceptChannel) {
+				msg.CsvDelay = math.MaxUint16
+			},
+			reason: string(lnwallet.ReasonCsvDelayTooLarge),
+		},
+		{
+			name: "unknown channel type",
+			modify: func(msg *lnwire.AcceptChannel) {
+				chanType := lnwire.ChannelType(
+					*lnwire.NewRawFeatureVector(100),
+				)
+				err := msg.ExtraData.PackRecords(
+					chanType.NewRecord(),
+				)
+				require.NoError(t, err)
+			},
+			reason: rejectReasonUnknownChannelType,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			alice, bob := setupFundingManagers(t)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			client, err := alice.fundingMgr.SubscribeFundingEvents()
+			require.NoError(t, err)
+			defer client.Cancel()
+
+			flow := openUntilAccept(t, alice, bob)
+			pendingID := flow.accept.PendingChannelID
+
+			test.modify(flow.accept)
+			alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+
+			nextEvent := func() interface{} {
+				select {
+				case event := <-client.Updates():
+					return event
+				case <-time.After(time.Second * 5):
+					t.Fatalf("no funding event received")
+					return nil
+				}
+			}
+
+			require.Equal(t, AcceptChannelReceivedEvent{
+				PendingID: pendingID,
+				Peer:      bobPubKey,
+			}, nextEvent())
+
+			if test.reason == "" {
+				event := nextEvent()
+				require.IsType(
+					t, AcceptChannelProcessedEvent{}, event,
+				)
+				processed := event.(AcceptChannelProcessedEvent)
+				require.Equal(t, pendingID, processed.PendingID)
+				require.Equal(t, bobPubKey, processed.Peer)
+				require.NotNil(t, processed.Result)
+				return
+			}
+
+			event, ok := nextEvent().(AcceptChannelRejectedEvent)
+			require.True(t, ok, "expected rejection event")
+			require.Equal(t, pendingID, event.PendingID)
+			require.Equal(t, bobPubKey, event.Peer)
+			require.Equal(t, test.reason, event.Reason)
+			require.Error(t, event.Err)
+		})
+	}
+}
+
+// TestFundingManagerPeerRemoteDelay asserts that the CSV delay we send in
+// AcceptChannel is taken from the per-peer policy if it applies to the peer,
+// and from RequiredRemoteDelay otherwise.
+func TestFundingManagerPeerRemoteDelay(t *testing.T) {
+	t.Parallel()
+
+	const overrideDelay = 1000
+
+	tests := []struct {
+		name          string
+		policy        func(*btcec.PublicKey, btcutil.Amount) (uint16, bool)
+		expectedDelay uint16
+	}{
+		{
+			name:          "no policy",
+			expectedDelay: 4,
+		},
+		{
+			name: "policy for other peer",
+			policy: func(peer *btcec.PublicKey,
+				_ btcutil.Amount) (uint16, bool) {
+
+				return overrideDelay, peer.IsEqual(bobPubKey)
+			},
+			expectedDelay: 4,
+		},
+		{
+			name: "policy for peer",
+			policy: func(peer *btcec.PublicKey,
+				_ btcutil.Amount) (uint16, bool) {
+
+				return overrideDelay, peer.IsEqual(alicePubKey)
+			},
+			expectedDelay: overrideDelay,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			alice, bob := setupFundingManagers(
+				t, func(cfg *Config) {
+					cfg.PeerRemoteDelay = test.policy
+				},
+			)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			flow := openUntilAccept(
+				t, alice, bob, func(req *InitFundingMsg) {
+					req.MaxLocalCsv = overrideDelay
+				},
+			)
+			require.Equal(t, test.expectedDelay, flow.accept.CsvDelay)
+
+			// Alice should accept the delay, as it is within the
+			// maximum she allows for this channel.
+			alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+			assertFundingMsgSent(t, alice.msgChan, "FundingCreated")
+		})
+	}
+}
+
+// TestFundingManagerDepthPolicy asserts that the MinAcceptDepth advertised in
+// the AcceptChannel is taken from the DepthPolicy if one is set, so that
+// trusted peers are required fewer confirmations than unknown ones.
+func TestFundingManagerDepthPolicy(t *testing.T) {
+	t.Parallel()
+
+	const (
+		trustedDepth   = 1
+		untrustedDepth = 6
+	)
+
+	aliceVertex := route.NewVertex(alicePubKey)
+	trustScores := func(scores map[route.Vertex]float64) DepthPolicy {
+		return TrustScoreDepthPolicy(
+			func(peer route.Vertex) float64 {
+				return scores[peer]
+			}, trustedDepth, untrustedDepth,
+		)
+	}
+
+	tests := []struct {
+		name          string
+		policy        DepthPolicy
+		expectedDepth uint32
+	}{
+		{
+			name:          "no policy",
+			expectedDepth: 3,
+		},
+		{
+			name: "trusted peer",
+			policy: trustScores(map[route.Vertex]float64{
+				aliceVertex: 1,
+			}),
+			expectedDepth: trustedDepth,
+		},
+		{
+			name: "partially trusted peer",
+			policy: trustScores(map[route.Vertex]float64{
+				aliceVertex: 0.4,
+			}),
+			expectedDepth: 4,
+		},
+		{
+			name: "untrusted peer",
+			policy: trustScores(map[route.Vertex]float64{
+				route.NewVertex(bobPubKey): 1,
+			}),
+			expectedDepth: untrustedDepth,
+		},
+		{
+			name: "depth above maximum",
+			policy: func(route.Vertex, btcutil.Amount) uint32 {
+				return chainntnfs.MaxNumConfs + 1
+			},
+			expectedDepth: chainntnfs.MaxNumConfs,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			alice, bob := setupFundingManagers(
+				t, func(cfg *Config) {
+					cfg.DepthPolicy = test.policy
+				},
+			)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			flow := openUntilAccept(t, alice, bob)
+			require.Equal(
+				t, test.expectedDepth, flow.accept.MinAcceptDepth,
+			)
+
+			// Alice should accept the depth, as it never exceeds
+			// the maximum she allows.
+			alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+			assertFundingMsgSent(t, alice.msgChan, "FundingCreated")
+		})
+	}
+}
+
+// TestFundingManagerLightClientDepth asserts that a responder running against
+// a light client requires LightClientExtraConfs more confirmations than it
+// otherwise would, without exceeding the maximum.
+func TestFundingManagerLightClientDepth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		policy        DepthPolicy
+		expectedDepth uint32
+	}{
+		{
+			name:          "default depth",
+			expectedDepth: 3 + LightClientExtraConfs,
+		},
+		{
+			name: "depth policy",
+			policy: func(route.Vertex, btcutil.Amount) uint32 {
+				return 1
+			},
+			expectedDepth: 1 + LightClientExtraConfs,
+		},
+		{
+			name: "depth at maximum",
+			policy: func(route.Vertex, btcutil.Amount) uint32 {
+				return chainntnfs.MaxNumConfs
+			},
+			expectedDepth: chainntnfs.MaxNumConfs,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			alice, bob := setupFundingManagers(
+				t, func(cfg *Config) {
+					cfg.LightClient = true
+					cfg.DepthPolicy = test.policy
+				},
+			)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			flow := openUntilAccept(t, alice, bob)
+			require.Equal(
+				t, test.expectedDepth, flow.accept.MinAcceptDepth,
+			)
+
+			alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+			assertFundingMsgSent(t, alice.msgChan, "FundingCreated")
+		})
+	}
+}
+
+// TestFundingManagerNegotiationResult asserts that the NegotiationResult
+// published once an AcceptChannel is processed matches the parameters both
+// parties put forward.
+func TestFundingManagerNegotiationResult(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	client, err := alice.fundingMgr.SubscribeFundingEvents()
+	require.NoError(t, err)
+	defer client.Cancel()
+
+	const capacity = btcutil.Amount(500000)
+	flow := openUntilAccept(t, alice, bob)
+	openChannelReq, acceptChannelResponse := flow.open, flow.accept
+	acceptChannelResponse.UpfrontShutdownScript = lnwire.DeliveryAddress(
+		append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x01}, 20)...),
+	)
+	require.NoError(t, acceptChannelResponse.SetMaxHtlcExpiryDelta(1008))
+	alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+
+	var result *NegotiationResult
+	for result == nil {
+		select {
+		case event := <-client.Updates():
+			switch e := event.(type) {
+			case AcceptChannelProcessedEvent:
+				result = e.Result
+
+			case AcceptChannelRejectedEvent:
+				t.Fatalf("AcceptChannel rejected: %v", e.Err)
+			}
+
+		case <-time.After(time.Second * 5):
+			t.Fatalf("no processed event received")
+		}
+	}
+
+	require.Equal(
+		t, acceptChannelResponse.PendingChannelID, result.PendingChanID,
+	)
+	require.Equal(t, capacity, result.Capacity)
+	require.EqualValues(t, lnwallet.CommitmentTypeLegacy, result.CommitType)
+	require.EqualValues(t, 1008, result.MaxHtlcExpiryDelta)
+	require.Equal(
+		t, acceptChannelResponse.MinAcceptDepth,
+		result.NumConfsRequired,
+	)
+
+	// The constraints we adhere to are the ones Bob sent in his
+	// AcceptChannel.
+	require.Equal(t, channeldb.ChannelConstraints{
+		DustLimit:        acceptChannelResponse.DustLimit,
+		ChanReserve:      acceptChannelResponse.ChannelReserve,
+		MaxPendingAmount: acceptChannelResponse.MaxValueInFlight,
+		MinHTLC:          acceptChannelResponse.HtlcMinimum,
+		MaxAcceptedHtlcs: acceptChannelResponse.MaxAcceptedHTLCs,
+		CsvDelay:         acceptChannelResponse.CsvDelay,
+	}, result.LocalConstraints)
+
+	// The constraints Bob adheres to are the ones Alice sent in her
+	// OpenChannel, with the reserve adjusted to Bob's dust limit.
+	require.Equal(t, channeldb.ChannelConstraints{
+		DustLimit:        acceptChannelResponse.DustLimit,
+		ChanReserve:      openChannelReq.ChannelReserve,
+		MaxPendingAmount: openChannelReq.MaxValueInFlight,
+		MinHTLC:          openChannelReq.HtlcMinimum,
+		MaxAcceptedHtlcs: openChannelReq.MaxAcceptedHTLCs,
+		CsvDelay:         openChannelReq.CsvDelay,
+	}, result.RemoteConstraints)
+
+	require.Equal(
+		t, openChannelReq.UpfrontShutdownScript,
+		result.LocalUpfrontShutdown,
+	)
+	require.Equal(
+		t, acceptChannelResponse.UpfrontShutdownScript,
+		result.RemoteUpfrontShutdown,
+	)
+}
+
+// cannedShimFunding returns an option making alice fund the channel through
+// an external funding shim with the given funding outpoint, after registering
+// the matching shim with bob.
+func cannedShimFunding(t *testing.T, bob *testNode, pendingChanID [32]byte,
+	chanPoint wire.OutPoint) func(*InitFundingMsg) {
+
+	// Both test nodes derive their multi-sig key from the same root key.
+	multiSigKey := &keychain.KeyDescriptor{
+		KeyLocator: keychain.KeyLocator{
+			Family: keychain.KeyFamilyMultiSig,
+		},
+		PubKey: alicePubKey,
+	}
+
+	return func(req *InitFundingMsg) {
+		amt := req.LocalFundingAmt
+		intent, err := chanfunding.NewCannedAssembler(
+			0, chanPoint, amt, multiSigKey, alicePubKey, false,
+		).ProvisionChannel(&chanfunding.Request{RemoteAmt: amt})
+		require.NoError(t, err)
+
+		err = bob.fundingMgr.cfg.Wallet.RegisterFundingIntent(
+			pendingChanID, intent,
+		)
+		require.NoError(t, err)
+
+		req.PendingChanID = pendingChanID
+		req.ChanFunder = chanfunding.NewCannedAssembler(
+			0, chanPoint, amt, multiSigKey, alicePubKey, true,
+		)
+	}
+}
+
+// assertReservationPersisted asserts whether alice has persisted the
+// reservation with the given pending channel ID she initiated with bob.
+func assertReservationPersisted(t *testing.T, alice *testNode,
+	pendingChanID [32]byte, persisted bool) {
+
+	t.Helper()
+
+	_, err := alice.fundingMgr.fetchPendingReservation(
+		bobPubKey, pendingChanID,
+	)
+	if persisted {
+		require.NoError(t, err)
+	} else {
+		require.Equal(t, errPendingReservationNotFound, err)
+	}
+}
+
+// TestFundingManagerResumeReservation checks that an externally funded
+// reservation we initiated survives a restart before the remote party's
+// AcceptChannel is received, unless it expired in the meantime.
+func TestFundingManagerResumeReservation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		timeout time.Duration
+		resumed bool
+	}{
+		{
+			name:    "resumed",
+			timeout: time.Hour,
+			resumed: true,
+		},
+		{
+			name:    "expired",
+			timeout: time.Nanosecond,
+			resumed: false,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			testResumeReservation(t, test.timeout, test.resumed)
+		})
+	}
+}
+
+// TestFundingManagerPersistReservation checks that only externally funded
+// reservations are persisted, and that they're removed once the funding flow
+// is canceled or completes.
+func TestFundingManagerPersistReservation(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t, func(cfg *Config) {
+		cfg.ReservationTimeout = time.Hour
+	})
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// A reservation funded by our wallet is canceled along with the
+	// connection it was started on, so it isn't persisted.
+	flow := openUntilAccept(t, alice, bob)
+	assertReservationPersisted(
+		t, alice, flow.open.PendingChannelID, false,
+	)
+	alice.fundingMgr.CancelPeerReservations(newSerializedKey(bobPubKey))
+	bob.fundingMgr.CancelPeerReservations(newSerializedKey(alicePubKey))
+
+	// An externally funded reservation is persisted, and removed once
+	// it's canceled.
+	pendingChanID := [32]byte{5}
+	chanPoint := wire.OutPoint{Hash: chainhash.Hash{5}}
+	_, openChannelReq := initFunding(
+		t, alice, bob, cannedShimFunding(
+			t, bob, pendingChanID, chanPoint,
+		),
+	)
+	assertReservationPersisted(t, alice, pendingChanID, true)
+
+	bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+	assertFundingMsgSent(t, bob.msgChan, "AcceptChannel")
+
+	_, err := alice.fundingMgr.cancelReservationCtx(
+		bobPubKey, pendingChanID, false,
+	)
+	require.NoError(t, err)
+	assertReservationPersisted(t, alice, pendingChanID, false)
+	bob.fundingMgr.CancelPeerReservations(newSerializedKey(alicePubKey))
+
+	// Another externally funded reservation is removed once the funding
+	// flow completes.
+	pendingChanID = [32]byte{6}
+	chanPoint = wire.OutPoint{Hash: chainhash.Hash{6}}
+	flow = openUntilAccept(
+		t, alice, bob, cannedShimFunding(
+			t, bob, pendingChanID, chanPoint,
+		),
+	)
+	assertReservationPersisted(t, alice, pendingChanID, true)
+
+	alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+	fundingCreated := assertFundingMsgSent(
+		t, alice.msgChan, "FundingCreated",
+	).(*lnwire.FundingCreated)
+	require.Equal(t, chanPoint, fundingCreated.FundingPoint)
+	assertReservationPersisted(t, alice, pendingChanID, false)
+
+	bob.fundingMgr.ProcessFundingMsg(fundingCreated, alice)
+	fundingSigned := assertFundingMsgSent(
+		t, bob.msgChan, "FundingSigned",
+	).(*lnwire.FundingSigned)
+	alice.fundingMgr.ProcessFundingMsg(fundingSigned, bob)
+
+	select {
+	case update := <-flow.initReq.Updates:
+		_, ok := update.Update.(*lnrpc.OpenStatusUpdate_ChanPending)
+		require.True(t, ok)
+	case err := <-flow.initReq.Err:
+		t.Fatalf("unexpected funding error: %v", err)
+	case <-time.After(time.Second * 5):
+		t.Fatalf("alice did not send OpenStatusUpdate_ChanPending")
+	}
+	assertNumPendingReservations(t, alice, bobPubKey, 0)
+	assertReservationPersisted(t, alice, pendingChanID, false)
+}
+
+// TestFundingManagerPruneUnreadableReservation checks that a persisted
+// reservation that can't be read is removed upon restart, without affecting
+// the startup of the funding manager or the other reservations.
+func TestFundingManagerPruneUnreadableReservation(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t, func(cfg *Config) {
+		cfg.ReservationTimeout = time.Hour
+	})
+	defer tearDownFundingManagers(t, alice, bob)
+
+	pendingChanID := [32]byte{7}
+	openUntilAccept(t, alice, bob, cannedShimFunding(
+		t, bob, pendingChanID, wire.OutPoint{Hash: chainhash.Hash{7}},
+	))
+
+	corruptKey := pendingReservationKey(bobPubKey, [32]byte{9})
+	db := alice.fundingMgr.cfg.Wallet.Cfg.Database
+	err := kvdb.Update(db, func(tx kvdb.RwTx) error {
+		bucket := tx.ReadWriteBucket(pendingReservationBucket)
+		return bucket.Put(corruptKey, []byte{1, 2, 3})
+	}, func() {})
+	require.NoError(t, err)
+
+	for _, res := range alice.fundingMgr.cfg.Wallet.ActiveReservations() {
+		require.NoError(t, res.Cancel())
+	}
+	recreateAliceFundingManager(t, alice)
+
+	err = kvdb.View(db, func(tx kvdb.RTx) error {
+		bucket := tx.ReadBucket(pendingReservationBucket)
+		require.Nil(t, bucket.Get(corruptKey))
+		return nil
+	}, func() {})
+	require.NoError(t, err)
+
+	assertReservationPersisted(t, alice, pendingChanID, true)
+}
+
+func testResumeReservation(t *testing.T, timeout time.Duration,
+	resumed bool) {
+
+	alice, bob := setupFundingManagers(t, func(cfg *Config) {
+		cfg.ReservationTimeout = timeout
+	})
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// Alice initiates an externally funded channel, and Bob responds
+	// with an AcceptChannel.
+	pendingChanID := [32]byte{8}
+	chanPoint := wire.OutPoint{Hash: chainhash.Hash{8}}
+	flow := openUntilAccept(t, alice, bob, cannedShimFunding(
+		t, bob, pendingChanID, chanPoint,
+	))
+	acceptChannelResponse := flow.accept
+	assertReservationPersisted(t, alice, pendingChanID, true)
+
+	// Before the AcceptChannel reaches Alice, she restarts, losing all
+	// reservations held in memory by both her funding manager and her
+	// wallet.
+	for _, res := range alice.fundingMgr.cfg.Wallet.ActiveReservations() {
+		require.NoError(t, res.Cancel())
+	}
+	recreateAliceFundingManager(t, alice)
+	assertNumPendingReservations(t, alice, bobPubKey, 0)
+
+	// Expired reservations are pruned upon restart, so the AcceptChannel
+	// is rejected.
+	if !resumed {
+		assertReservationPersisted(t, alice, pendingChanID, false)
+
+		alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+		assertErrorNotSent(t, alice.msgChan)
+		assertNumPendingReservations(t, alice, bobPubKey, 0)
+
+		return
+	}
+
+	// Otherwise, Alice resumes the reservation once she receives the
+	// AcceptChannel, and the funding flow completes with the keys and
+	// funding outpoint she used before the restart.
+	alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+	fundingCreated := assertFundingMsgSent(
+		t, alice.msgChan, "FundingCreated",
+	).(*lnwire.FundingCreated)
+	require.Equal(t, chanPoint, fundingCreated.FundingPoint)
+	assertReservationPersisted(t, alice, pendingChanID, false)
+
+	bob.fundingMgr.ProcessFundingMsg(fundingCreated, alice)
+	fundingSigned := assertFundingMsgSent(
+		t, bob.msgChan, "FundingSigned",
+	).(*lnwire.FundingSigned)
+
+	alice.fundingMgr.ProcessFundingMsg(fundingSigned, bob)
+
+	select {
+	case <-alice.mockChanEvent.pendingOpenEvent:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("alice did not mark the channel as pending")
+	}
+
+	assertNumPendingReservations(t, alice, bobPubKey, 0)
+	assertNumPendingReservations(t, bob, alicePubKey, 0)
+}
+
+// TestFundingManagerMinAbsoluteReserve asserts that the responder rejects an
+// OpenChannel requiring a reserve below its MinAbsoluteReserve. The floor of
+// the initiator is covered by TestFundingManagerAcceptPolicy.
+func TestFundingManagerMinAbsoluteReserve(t *testing.T) {
+	t.Parallel()
+
+	// defaultReserve is the reserve the default policy requires for the
+	// capacity of the channels opened by initFunding.
+	const defaultReserve = btcutil.Amount(500000 / 100)
+
+	tests := []struct {
+		name         string
+		bobFloor     btcutil.Amount
+		expectReject bool
+	}{
+		{
+			name:     "open reserve at responder floor",
+			bobFloor: defaultReserve,
+		},
+		{
+			name:         "open reserve below responder floor",
+			bobFloor:     defaultReserve + 1,
+			expectReject: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			alice, bob := setupFundingManagers(t)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			bob.fundingMgr.cfg.MinAbsoluteReserve = test.bobFloor
+
+			_, openChannelReq := initFunding(t, alice, bob)
+			require.Equal(
+				t, defaultReserve, openChannelReq.ChannelReserve,
+			)
+			bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+
+			if !test.expectReject {
+				assertFundingMsgSent(
+					t, bob.msgChan, "AcceptChannel",
+				)
+				return
+			}
+
+			errMsg := assertFundingMsgSent(
+				t, bob.msgChan, "Error",
+			).(*lnwire.Error)
+			require.Contains(t, string(errMsg.Data), "too small")
+		})
+	}
+}
+
+// TestFundingManagerMockPeerAcceptChannel asserts that the AcceptChannel
+// received by the mock peer carries the CsvDelay negotiated by the responder.
+func TestFundingManagerMockPeerAcceptChannel(t *testing.T) {
+	t.Parallel()
+
+	const csvDelay = 144
+
+	alice, bob := setupFundingManagers(t, func(cfg *Config) {
+		cfg.RequiredRemoteDelay = func(btcutil.Amount) uint16 {
+			return csvDelay
+		}
+	})
+	defer tearDownFundingManagers(t, alice, bob)
+
+	openChannelReq := openUntilAccept(t, alice, bob).open
+
+	// Bob sent his AcceptChannel to Alice, who decoded the CsvDelay Bob
+	// requires her to use.
+	acceptChannels := alice.receivedAcceptChannels()
+	require.Len(t, acceptChannels, 1)
+	require.Equal(
+		t, openChannelReq.PendingChannelID,
+		acceptChannels[0].PendingChannelID,
+	)
+	require.EqualValues(t, csvDelay, acceptChannels[0].CsvDelay)
+}
+
+// TestFundingManagerDiagnoseAcceptChannel asserts that an AcceptChannel is
+// flagged as uneconomical if force closing the channel at the current fee rate
+// would cost more than the reserve we're required to keep.
+func TestFundingManagerDiagnoseAcceptChannel(t *testing.T) {
+	t.Parallel()
+
+	const (
+		capacity    = 500000
+		chanReserve = 5000
+		anchors     = lnwallet.CommitmentTypeAnchorsZeroFeeHtlcTx
+	)
+
+	tests := []struct {
+		name         string
+		feePerKw     chainfee.SatPerKWeight
+		commitType   lnwallet.CommitmentType
+		uneconomical bool
+	}{
+		{
+			name:       "low fee rate",
+			feePerKw:   chainfee.FeePerKwFloor,
+			commitType: lnwallet.CommitmentTypeTweakless,
+		},
+		{
+			name:       "low fee rate anchors",
+			feePerKw:   chainfee.FeePerKwFloor,
+			commitType: anchors,
+		},
+		{
+			name:         "high fee rate",
+			feePerKw:     50000,
+			commitType:   lnwallet.CommitmentTypeTweakless,
+			uneconomical: true,
+		},
+		{
+			name:         "high fee rate anchors",
+			feePerKw:     50000,
+			commitType:   anchors,
+			uneconomical: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			estimator := chainfee.NewStaticEstimator(
+				test.feePerKw, 0,
+			)
+			setEstimator := func(cfg *Config) {
+				cfg.FeeEstimator = estimator
+			}
+			alice, bob := setupFundingManagers(t, setEstimator)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			msg := &lnwire.AcceptChannel{
+				ChannelReserve: chanReserve,
+			}
+			fundingMgr := alice.fundingMgr
+			diagnosis, err := fundingMgr.DiagnoseAcceptChannel(
+				msg, capacity, test.commitType,
+			)
+			require.NoError(t, err)
+
+			require.Equal(t, test.feePerKw, diagnosis.FeePerKw)
+			require.Equal(
+				t, lnwallet.InitiatorCommitFee(
+					test.commitType, test.feePerKw,
+				), diagnosis.CommitFee,
+			)
+			require.Positive(t, int64(diagnosis.SweepFee))
+			require.Equal(
+				t, diagnosis.CommitFee+diagnosis.SweepFee,
+				diagnosis.ForceCloseCost,
+			)
+			require.EqualValues(
+				t, chanReserve, diagnosis.ChanReserve,
+			)
+			require.EqualValues(t, capacity, diagnosis.Capacity)
+			require.Equal(
+				t, test.uneconomical, diagnosis.Uneconomical,
+			)
+		})
+	}
+}
+
+// TestFundingManagerDiagnoseChannel asserts that a channel we initiated is
+// diagnosed based on the AcceptChannel the remote party sent for it, and that
+// other channels can't be diagnosed.
+func TestFundingManagerDiagnoseChannel(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	const capacity = 500000
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	fundingOutPoint, _ := openChannel(
+		t, alice, bob, capacity, 0, 1, updateChan, true,
+	)
+
+	channel, err := alice.fundingMgr.cfg.Wallet.Cfg.Database.FetchChannel(
+		*fundingOutPoint,
+	)
+	require.NoError(t, err)
+
+	diagnosis, err := alice.fundingMgr.DiagnoseChannel(*fundingOutPoint)
+	require.NoError(t, err)
+	require.EqualValues(t, capacity, diagnosis.Capacity)
+	require.Equal(
+		t, channel.LocalChanCfg.ChanReserve, diagnosis.ChanReserve,
+	)
+	require.Equal(
+		t, lnwallet.InitiatorCommitFee(
+			lnwallet.CommitmentTypeOf(channel.ChanType),
+			diagnosis.FeePerKw,
+		), diagnosis.CommitFee,
+	)
+
+	// Bob didn't receive an AcceptChannel for the channel.
+	_, err = bob.fundingMgr.DiagnoseChannel(*fundingOutPoint)
+	require.Equal(t, channeldb.ErrNotChannelInitiator, err)
+
+	_, err = alice.fundingMgr.DiagnoseChannel(wire.OutPoint{Index: 1})
+	require.Equal(t, channeldb.ErrChannelNotFound, err)
+}
+
+// TestFundingManagerAcceptChannelHook asserts that the AcceptChannelHook can
+// add custom records to and adjust the policy of the AcceptChannel we send,
+// and that the funding flow is failed if the hook returns an error or leaves
+// the message invalid.
+func TestFundingManagerAcceptChannelHook(t *testing.T) {
+	t.Parallel()
+
+	const customType = 65539
+	customValue := []byte("custom")
+
+	tests := []struct {
+		name      string
+		hook      func(*lnwire.AcceptChannel) error
+		verify    func(*testing.T, *lnwire.AcceptChannel)
+		expectErr string
+	}{
+		{
+			name: "custom record",
+			hook: func(accept *lnwire.AcceptChannel) error {
+				value := customValue
+				return accept.ExtraData.PackRecords(
+					tlv.MakePrimitiveRecord(
+						customType, &value,
+					),
+				)
+			},
+			verify: func(t *testing.T, a *lnwire.AcceptChannel) {
+				var value []byte
+				typeMap, err := a.ExtraData.ExtractRecords(
+					tlv.MakePrimitiveRecord(
+						customType, &value,
+					),
+				)
+				require.NoError(t, err)
+				require.Contains(
+					t, typeMap, tlv.Type(customType),
+				)
+				require.Equal(t, customValue, value)
+			},
+		},
+		{
+			name: "adjusted policy",
+			hook: func(accept *lnwire.AcceptChannel) error {
+				accept.CsvDelay = 288
+				accept.ChannelReserve = 20000
+				return nil
+			},
+			verify: func(t *testing.T, a *lnwire.AcceptChannel) {
+				require.EqualValues(t, 288, a.CsvDelay)
+				require.EqualValues(t, 20000, a.ChannelReserve)
+			},
+		},
+		{
+			name: "hook error",
+			hook: func(*lnwire.AcceptChannel) error {
+				return errors.New("rejected by plugin")
+			},
+			expectErr: "internal error",
+		},
+		{
+			name: "modified key",
+			hook: func(accept *lnwire.AcceptChannel) error {
+				accept.FundingKey = accept.HtlcPoint
+				return nil
+			},
+			expectErr: "internal error",
+		},
+		{
+			name: "reserve too large",
+			hook: func(accept *lnwire.AcceptChannel) error {
+				accept.ChannelReserve = 500000
+				return nil
+			},
+			expectErr: "channel reserve is too large",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			alice, bob := setupFundingManagers(
+				t, func(cfg *Config) {
+					cfg.AcceptChannelHook = test.hook
+				},
+			)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			_, openChannelReq := initFunding(t, alice, bob)
+			bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+
+			if test.expectErr != "" {
+				errMsg := assertFundingMsgSent(
+					t, bob.msgChan, "Error",
+				).(*lnwire.Error)
+				require.Contains(
+					t, string(errMsg.Data), test.expectErr,
+				)
+				assertNumPendingReservations(
+					t, bob, alicePubKey, 0,
+				)
+				return
+			}
+
+			acceptChannelResponse := assertFundingMsgSent(
+				t, bob.msgChan, "AcceptChannel",
+			).(*lnwire.AcceptChannel)
+
+			// The hook must be applied to the message as sent, and
+			// the reservation must require the same policy of the
+			// initiator.
+			test.verify(t, acceptChannelResponse)
+			received := alice.receivedAcceptChannels()
+			require.Len(t, received, 1)
+			test.verify(t, received[0])
+
+			pendingID := acceptChannelResponse.PendingChannelID
+			resCtx, err := bob.fundingMgr.getReservationCtx(
+				alicePubKey, pendingID,
+			)
+			require.NoError(t, err)
+			theirConfig := resCtx.reservation.TheirContribution()
+			require.Equal(
+				t, acceptChannelResponse.CsvDelay,
+				theirConfig.CsvDelay,
+			)
+			require.Equal(
+				t, acceptChannelResponse.ChannelReserve,
+				theirConfig.ChanReserve,
+			)
+
+			alice.fundingMgr.ProcessFundingMsg(
+				acceptChannelResponse, bob,
+			)
+			assertFundingMsgSent(t, alice.msgChan, "FundingCreated")
+		})
+	}
+}
+
+// TestFundingManagerPendingNegotiations asserts that the pending negotiations
+// reflect the reservations of both parties, including the AcceptChannel once
+// the initiator processed it, and that a negotiation is no longer listed once
+// its reservation is completed.
+func TestFundingManagerPendingNegotiations(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	const capacity = 500000
+	_, openChannelReq := initFunding(
+		t, alice, bob, func(req *InitFundingMsg) {
+			req.Updates = make(chan *lnrpc.OpenStatusUpdate, 1)
+		},
+	)
+
+	// Until the AcceptChannel is processed, the negotiation of the
+	// initiator doesn't carry it.
+	negotiations := alice.fundingMgr.PendingNegotiations()
+	require.Len(t, negotiations, 1)
+	require.Equal(
+		t, openChannelReq.PendingChannelID,
+		negotiations[0].PendingChanID,
+	)
+	require.Equal(t, bobPubKey, negotiations[0].Peer)
+	require.EqualValues(t, capacity, negotiations[0].Capacity)
+	require.Nil(t, negotiations[0].Accept)
+
+	// The responder never carries an AcceptChannel.
+	bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+	acceptChannelResponse := assertFundingMsgSent(
+		t, bob.msgChan, "AcceptChannel",
+	).(*lnwire.AcceptChannel)
+
+	negotiations = bob.fundingMgr.PendingNegotiations()
+	require.Len(t, negotiations, 1)
+	require.Equal(t, alicePubKey, negotiations[0].Peer)
+	require.EqualValues(t, capacity, negotiations[0].Capacity)
+	require.Nil(t, negotiations[0].Accept)
+
+	alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+	fundingCreated := assertFundingMsgSent(
+		t, alice.msgChan, "FundingCreated",
+	).(*lnwire.FundingCreated)
+
+	negotiations = alice.fundingMgr.PendingNegotiations()
+	require.Len(t, negotiations, 1)
+	require.NotNil(t, negotiations[0].Accept)
+	require.True(t, negotiations[0].Accept.Equal(acceptChannelResponse))
+
+	// Once the responder has processed the FundingCreated, its
+	// reservation is completed and the negotiation is no longer listed.
+	bob.fundingMgr.ProcessFundingMsg(fundingCreated, alice)
+	assertFundingMsgSent(t, bob.msgChan, "FundingSigned")
+	require.Empty(t, bob.fundingMgr.PendingNegotiations())
+}
+
+// TestFundingManagerRevalidatePendingChannels asserts that pending channels
+// whose received AcceptChannel fails a validation rule added by an upgrade
+// are flagged, but left pending, and that channels the remote party initiated
+// aren't validated against the AcceptChannel we sent.
+func TestFundingManagerRevalidatePendingChannels(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// Run through the process of opening the channel, up until the funding
+	// transaction is broadcasted, leaving it pending for both.
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	_, _ = openChannel(t, alice, bob, 500000, 0, 1, updateChan, true)
+
+	fetchPending := func(node *testNode) []*channeldb.OpenChannel {
+		db := node.fundingMgr.cfg.Wallet.Cfg.Database
+		pendingChannels, err := db.FetchPendingChannels()
+		require.NoError(t, err)
+		require.Len(t, pendingChannels, 1)
+
+		return pendingChannels
+	}
+	aliceChan := fetchPending(alice)[0]
+	bobChan := fetchPending(bob)[0]
+
+	// Simulate an upgrade requiring a larger depth than the three
+	// confirmations Bob asked for. The rule records the messages it is
+	// called with.
+	var validated []*lnwire.AcceptChannel
+	stricterRule := func(accept *lnwire.AcceptChannel) error {
+		validated = append(validated, accept)
+
+		if err := accept.Validate(); err != nil {
+			return err
+		}
+		if accept.MinAcceptDepth < 6 {
+			return errors.New("min depth too low")
+		}
+
+		return nil
+	}
+	alice.fundingMgr.cfg.RevalidateAcceptChannel = stricterRule
+	bob.fundingMgr.cfg.RevalidateAcceptChannel = stricterRule
+
+	before := testutil.ToFloat64(pendingChannelsInvalid)
+
+	// Alice validates the AcceptChannel she received from Bob, which
+	// carries his keys, and flags the channel without closing it.
+	alice.fundingMgr.revalidatePendingChannel(aliceChan)
+	require.Len(t, validated, 1)
+	require.True(t, validated[0].FundingKey.IsEqual(
+		bobChan.LocalChanCfg.MultiSigKey.PubKey,
+	))
+	require.Equal(t, before+1, testutil.ToFloat64(pendingChannelsInvalid))
+	fetchPending(alice)
+
+	// Bob only sent an AcceptChannel, so there's nothing to validate, and
+	// the channel stays pending as well.
+	bob.fundingMgr.revalidatePendingChannel(bobChan)
+	require.Len(t, validated, 1)
+	require.Equal(t, before+1, testutil.ToFloat64(pendingChannelsInvalid))
+	fetchPending(bob)
+
+	// Under the current rules, the AcceptChannel is valid and the channel
+	// isn't flagged.
+	alice.fundingMgr.cfg.RevalidateAcceptChannel = nil
+	alice.fundingMgr.revalidatePendingChannel(aliceChan)
+	require.Equal(t, before+1, testutil.ToFloat64(pendingChannelsInvalid))
+}
+
+// TestFundingManagerPendingChanIDInUse asserts that funding messages colliding
+// with the pending channel ID of a funding flow already in progress with the
+// same peer fail that flow.
+func TestFundingManagerPendingChanIDInUse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("open", func(t *testing.T) {
+		t.Parallel()
+
+		alice, bob := setupFundingManagers(t)
+		defer tearDownFundingManagers(t, alice, bob)
+
+		openChannelReq := openUntilAccept(t, alice, bob).open
+		assertNumPendingReservations(t, bob, alicePubKey, 1)
+
+		// A second OpenChannel with the same pending channel ID fails
+		// the funding flow in progress.
+		bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+		errMsg := assertFundingMsgSent(
+			t, bob.msgChan, "Error",
+		).(*lnwire.Error)
+		require.Equal(
+			t, lnwire.ChannelID(openChannelReq.PendingChannelID),
+			errMsg.ChanID,
+		)
+		assertNumPendingReservations(t, bob, alicePubKey, 0)
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		t.Parallel()
+
+		alice, bob := setupFundingManagers(t)
+		defer tearDownFundingManagers(t, alice, bob)
+
+		flow := openUntilAccept(t, alice, bob)
+		acceptChannelResponse, errChan := flow.accept, flow.initReq.Err
+		alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+		assertFundingMsgSent(t, alice.msgChan, "FundingCreated")
+
+		// Accepting the same channel again is rejected, failing the
+		// funding flow.
+		alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+		errMsg := assertFundingMsgSent(
+			t, alice.msgChan, "Error",
+		).(*lnwire.Error)
+		require.Contains(
+			t, string(errMsg.Data), "duplicate AcceptChannel",
+		)
+		assertNumPendingReservations(t, alice, bobPubKey, 0)
+
+		select {
+		case err := <-errChan:
+			require.True(t, errors.Is(err, ErrPendingChanIDInUse))
+		case <-time.After(time.Second * 5):
+			t.Fatalf("funding request not failed")
+		}
+	})
+}
+
+// TestFundingManagerCommitTypeDowngrade asserts that a peer accepting our
+// channel with an older commitment type than the one we proposed is only
+// followed if downgrades are allowed, and never for newer commitment types.
+func TestFundingManagerCommitTypeDowngrade(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tweakless = []lnwire.FeatureBit{
+			lnwire.StaticRemoteKeyOptional,
+		}
+		anchors = []lnwire.FeatureBit{
+			lnwire.StaticRemoteKeyOptional,
+			lnwire.AnchorsZeroFeeHtlcTxOptional,
+		}
+	)
+
+	tests := []struct {
+		name          string
+		allow         bool
+		aliceFeatures []lnwire.FeatureBit
+		bobFeatures   []lnwire.FeatureBit
+		chanType      *lnwire.RawFeatureVector
+		expectReject  bool
+	}{
+		{
+			name:          "downgrade accepted",
+			allow:         true,
+			aliceFeatures: anchors,
+			bobFeatures:   tweakless,
+			chanType: lnwire.NewRawFeatureVector(
+				lnwire.StaticRemoteKeyRequired,
+			),
+		},
+		{
+			name:          "downgrade rejected",
+			aliceFeatures: anchors,
+			bobFeatures:   tweakless,
+			chanType: lnwire.NewRawFeatureVector(
+				lnwire.StaticRemoteKeyRequired,
+			),
+			expectReject: true,
+		},
+		{
+			name:          "upgrade rejected",
+			allow:         true,
+			aliceFeatures: tweakless,
+			bobFeatures:   anchors,
+			chanType: lnwire.NewRawFeatureVector(
+				lnwire.StaticRemoteKeyRequired,
+				lnwire.AnchorsZeroFeeHtlcTxRequired,
+			),
+			expectReject: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			alice, bob := setupFundingManagers(t)
+			defer tearDownFundingManagers(t, alice, bob)
+
+			aliceCfg := alice.fundingMgr.cfg
+			aliceCfg.AllowCommitTypeDowngrade = test.allow
+
+			// The commitment type each node uses is derived from
+			// the features of its connection to the other.
+			bob.localFeatures = test.aliceFeatures
+			bob.remoteFeatures = test.aliceFeatures
+			alice.localFeatures = test.bobFeatures
+			alice.remoteFeatures = test.bobFeatures
+
+			// Bob echoes the channel type he accepted the channel
+			// with.
+			flow := openUntilAccept(t, alice, bob)
+			openChannelReq := flow.open
+			acceptChannelResponse := flow.accept
+			chanType := lnwire.ChannelType(*test.chanType)
+			err := acceptChannelResponse.ExtraData.PackRecords(
+				chanType.NewRecord(),
+			)
+			require.NoError(t, err)
+			alice.fundingMgr.ProcessFundingMsg(
+				acceptChannelResponse, bob,
+			)
+
+			if test.expectReject {
+				errMsg := assertFundingMsgSent(
+					t, alice.msgChan, "Error",
+				).(*lnwire.Error)
+				require.Contains(
+					t, string(errMsg.Data), "accepted",
+				)
+				return
+			}
+
+			// Once downgraded, Bob must be able to verify Alice's
+			// signature for his commitment.
+			fundingCreated := assertFundingMsgSent(
+				t, alice.msgChan, "FundingCreated",
+			).(*lnwire.FundingCreated)
+
+			resCtx, err := alice.fundingMgr.getReservationCtx(
+				bobPubKey, openChannelReq.PendingChannelID,
+			)
+			require.NoError(t, err)
+			require.EqualValues(
+				t, lnwallet.CommitmentTypeTweakless,
+				resCtx.reservation.CommitType(),
+			)
+
+			bob.fundingMgr.ProcessFundingMsg(fundingCreated, alice)
+			assertFundingMsgSent(t, bob.msgChan, "FundingSigned")
+		})
+	}
+}
+
+// TestFundingManagerAcceptExtraData tests that the AcceptChannel and the
+// records of it we don't know about are kept while the channel is pending, and
+// forgotten once it is open.
+func TestFundingManagerAcceptExtraData(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// Bob sends an unknown odd record along with a known one, which Alice
+	// must ignore when processing the message.
+	flow := openUntilAccept(t, alice, bob)
+	updateChan, acceptChannelResponse := flow.initReq.Updates, flow.accept
+	err := acceptChannelResponse.SetMaxHtlcExpiryDelta(2016)
+	require.NoError(t, err)
+
+	unknown := lnwire.ExtraOpaqueData{
+		0xfe, 0x00, 0x01, 0x00, 0x03, 0x02, 0xab, 0xcd,
+	}
+	acceptChannelResponse.ExtraData, err = lnwire.MergeExtraData(
+		acceptChannelResponse.ExtraData, unknown,
+	)
+	require.NoError(t, err)
+	alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+
+	fundingCreated := assertFundingMsgSent(
+		t, alice.msgChan, "FundingCreated",
+	).(*lnwire.FundingCreated)
+	bob.fundingMgr.ProcessFundingMsg(fundingCreated, alice)
+
+	fundingSigned := assertFundingMsgSent(
+		t, bob.msgChan, "FundingSigned",
+	).(*lnwire.FundingSigned)
+	alice.fundingMgr.ProcessFundingMsg(fundingSigned, bob)
+
+	var pendingUpdate *lnrpc.OpenStatusUpdate
+	select {
+	case pendingUpdate = <-updateChan:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("alice did not send OpenStatusUpdate_ChanPending")
+	}
+	_, ok := pendingUpdate.Update.(*lnrpc.OpenStatusUpdate_ChanPending)
+	require.True(t, ok)
+
+	var fundingTx *wire.MsgTx
+	select {
+	case fundingTx = <-alice.publTxChan:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("alice did not publish funding tx")
+	}
+	fundingOutPoint := fundingCreated.FundingPoint
+
+	// Only the unknown record is exposed for the pending channel, and only
+	// by Alice, who received the AcceptChannel.
+	extraData, ok := alice.fundingMgr.AcceptExtraData(fundingOutPoint)
+	require.True(t, ok)
+	require.Equal(t, unknown, extraData)
+
+	_, ok = bob.fundingMgr.AcceptExtraData(fundingOutPoint)
+	require.False(t, ok)
+
+	accept, ok := alice.fundingMgr.AcceptMsg(fundingOutPoint)
+	require.True(t, ok)
+	require.Equal(t, acceptChannelResponse, accept)
+
+	_, ok = bob.fundingMgr.AcceptMsg(fundingOutPoint)
+	require.False(t, ok)
+
+	// Once the channel is open, the records are forgotten.
+	alice.mockNotifier.oneConfChannel <- &chainntnfs.TxConfirmation{
+		Tx: fundingTx,
+	}
+	bob.mockNotifier.oneConfChannel <- &chainntnfs.TxConfirmation{
+		Tx: fundingTx,
+	}
+	assertMarkedOpen(t, alice, bob, &fundingOutPoint)
+
+	_, ok = alice.fundingMgr.AcceptExtraData(fundingOutPoint)
+	require.False(t, ok)
+
+	_, ok = alice.fundingMgr.AcceptMsg(fundingOutPoint)
+	require.False(t, ok)
+}
+
+// TestFundingManagerAcceptStateForgotten asserts that the AcceptChannel and
+// its unknown records are forgotten when the reservation of the channel is
+// canceled, and when the pending channel is abandoned.
+func TestFundingManagerAcceptStateForgotten(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	assertForgotten := func(chanPoint wire.OutPoint) {
+		t.Helper()
+
+		_, ok := alice.fundingMgr.AcceptMsg(chanPoint)
+		require.False(t, ok)
+
+		_, ok = alice.fundingMgr.AcceptExtraData(chanPoint)
+		require.False(t, ok)
+	}
+
+	// The state is only kept once FundingSigned is received, so it is
+	// stored explicitly to cancel a reservation after FundingCreated.
+	flow := openUntilAccept(t, alice, bob)
+	unknown := lnwire.ExtraOpaqueData{0xfe, 0x00, 0x01, 0x00, 0x03, 0x00}
+	var err error
+	flow.accept.ExtraData, err = lnwire.MergeExtraData(
+		flow.accept.ExtraData, unknown,
+	)
+	require.NoError(t, err)
+	alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+
+	fundingCreated := assertFundingMsgSent(
+		t, alice.msgChan, "FundingCreated",
+	).(*lnwire.FundingCreated)
+	chanPoint := fundingCreated.FundingPoint
+
+	resCtx, err := alice.fundingMgr.getReservationCtx(
+		bobPubKey, flow.accept.PendingChannelID,
+	)
+	require.NoError(t, err)
+	alice.fundingMgr.storeAcceptMsg(chanPoint, resCtx)
+	alice.fundingMgr.storeAcceptExtraData(chanPoint, resCtx)
+
+	_, ok := alice.fundingMgr.AcceptMsg(chanPoint)
+	require.True(t, ok)
+
+	_, err = alice.fundingMgr.cancelReservationCtx(
+		bobPubKey, flow.accept.PendingChannelID, false,
+	)
+	require.NoError(t, err)
+	assertForgotten(chanPoint)
+
+	_, err = bob.fundingMgr.cancelReservationCtx(
+		alicePubKey, flow.accept.PendingChannelID, true,
+	)
+	require.NoError(t, err)
+
+	// A pending channel that is abandoned never opens, so its state is
+	// forgotten when the funding manager is told about the abandonment.
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	fundingTx := fundChannel(
+		t, alice, bob, 500000, 0, false, 1, updateChan, true,
+	)
+	chanPoint = wire.OutPoint{Hash: fundingTx.TxHash()}
+
+	_, ok = alice.fundingMgr.AcceptMsg(chanPoint)
+	require.True(t, ok)
+
+	alice.fundingMgr.ForgetAbandonedChannel(chanPoint)
+	assertForgotten(chanPoint)
+}
+
+// TestFundingManagerLogOpenedChannel asserts that the parameters negotiated
+// for a channel are logged in a single line once it opened.
+func TestFundingManagerLogOpenedChannel(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	fundingOutPoint, fundingTx := openChannel(
+		t, alice, bob, 500000, 100000, 1, updateChan, true,
+	)
+
+	alice.mockNotifier.oneConfChannel <- &chainntnfs.TxConfirmation{
+		Tx: fundingTx,
+	}
+	bob.mockNotifier.oneConfChannel <- &chainntnfs.TxConfirmation{
+		Tx: fundingTx,
+	}
+	assertMarkedOpen(t, alice, bob, fundingOutPoint)
+
+	db := alice.fundingMgr.cfg.Wallet.Cfg.Database
+	channels, err := db.FetchOpenChannels(bobPubKey)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	channel := channels[0]
+
+	var buf bytes.Buffer
+	logger := btclog.NewBackend(&buf).Logger(Subsystem)
+	logger.SetLevel(btclog.LevelInfo)
+	logOpenedChannel(logger, channel)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	line := lines[0]
+
+	localCfg := channel.LocalChanCfg
+	remoteCfg := channel.RemoteChanCfg
+	expectedFields := []string{
+		fmt.Sprintf("chan_point=%v", fundingOutPoint),
+		fmt.Sprintf("peer=%x", bobPubKey.SerializeCompressed()),
+		"initiator=true",
+		"capacity=500000",
+		"commit_type=legacy",
+		fmt.Sprintf("num_confs=%d", channel.NumConfsRequired),
+		fmt.Sprintf("fee_per_kw=%d", channel.LocalCommitment.FeePerKw),
+		"remote_balance_msat=100000000",
+		fmt.Sprintf("local_dust_limit=%d", localCfg.DustLimit),
+		fmt.Sprintf("local_chan_reserve=%d", localCfg.ChanReserve),
+		fmt.Sprintf("local_csv_delay=%d", localCfg.CsvDelay),
+		fmt.Sprintf("local_max_accepted_htlcs=%d",
+			localCfg.MaxAcceptedHtlcs),
+		fmt.Sprintf("remote_dust_limit=%d", remoteCfg.DustLimit),
+		fmt.Sprintf("remote_chan_reserve=%d", remoteCfg.ChanReserve),
+		fmt.Sprintf("remote_min_htlc_msat=%d", remoteCfg.MinHTLC),
+		fmt.Sprintf("remote_max_pending_msat=%d",
+			remoteCfg.MaxPendingAmount),
+		fmt.Sprintf("remote_csv_delay=%d", remoteCfg.CsvDelay),
+	}
+	for _, field := range expectedFields {
+		require.Contains(t, line, field)
+	}
+}
+
+// TestFundingManagerHtlcMinAsymmetry asserts that an AcceptChannel with an
+// HtlcMinimum far apart from ours is flagged, but doesn't fail the funding
+// flow.
+func TestFundingManagerHtlcMinAsymmetry(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// Bob requires a minimum far above the one Alice requires from him.
+	flow := openUntilAccept(t, alice, bob)
+	openChannelReq, acceptChannelResponse := flow.open, flow.accept
+	acceptChannelResponse.HtlcMinimum = lnwire.NewMSatFromSatoshis(10000)
+
+	resCtx, err := alice.fundingMgr.getReservationCtx(
+		bobPubKey, openChannelReq.PendingChannelID,
+	)
+	require.NoError(t, err)
+	localMin := resCtx.remoteMinHtlc
+
+	err = alice.fundingMgr.checkHtlcMinAsymmetry(
+		openChannelReq.PendingChannelID, localMin,
+		acceptChannelResponse.HtlcMinimum,
+	)
+	var asymmetryErr *lnwire.ErrHtlcMinAsymmetry
+	require.True(t, errors.As(err, &asymmetryErr))
+
+	// Our own minimum is never flagged.
+	err = alice.fundingMgr.checkHtlcMinAsymmetry(
+		openChannelReq.PendingChannelID, localMin, localMin,
+	)
+	require.NoError(t, err)
+
+	// The asymmetry is only a warning, so Alice continues the flow.
+	alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+	assertFundingMsgSent(t, alice.msgChan, "FundingCreated")
+}
+
+// TestFundingManagerAbortFunding asserts that a funding negotiation can only be
+// aborted between the AcceptChannel and the FundingCreated message, and that
+// aborting it cancels the reservation and notifies the remote peer.
+func TestFundingManagerAbortFunding(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// Alice funds the channel through a PSBT, so she waits for it after
+	// processing Bob's AcceptChannel, before sending FundingCreated.
+	localAmt := btcutil.Amount(500000)
+	pendingChanID := [32]byte{2}
+	initReq, openChannelReq := initFunding(
+		t, alice, bob, func(req *InitFundingMsg) {
+			req.LocalFundingAmt = localAmt
+			req.Updates = make(chan *lnrpc.OpenStatusUpdate, 1)
+			req.PendingChanID = pendingChanID
+			req.ChanFunder = chanfunding.NewPsbtAssembler(
+				localAmt, nil, fundingNetParams.Params, false,
+			)
+		},
+	)
+	updateChan, errChan := initReq.Updates, initReq.Err
+
+	err := alice.fundingMgr.AbortFunding([32]byte{3})
+	require.Equal(t, ErrUnknownPendingFunding, err)
+
+	// The negotiation can't be aborted before Bob accepted the channel.
+	err = alice.fundingMgr.AbortFunding(pendingChanID)
+	require.Equal(t, ErrAcceptChannelPending, err)
+
+	bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+	acceptChannelResponse := assertFundingMsgSent(
+		t, bob.msgChan, "AcceptChannel",
+	).(*lnwire.AcceptChannel)
+	alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+
+	select {
+	case update := <-updateChan:
+		require.NotNil(t, update.GetPsbtFund())
+	case <-time.After(time.Second * 5):
+		t.Fatalf("alice did not wait for a PSBT")
+	}
+
+	// Alice sends the Error message to Bob while aborting, so we abort in
+	// a goroutine to receive it.
+	abortErr := make(chan error, 1)
+	go func() {
+		abortErr <- alice.fundingMgr.AbortFunding(pendingChanID)
+	}()
+
+	// Bob is notified of the abort exactly once, and Alice's reservation
+	// is gone.
+	errMsg := assertFundingMsgSent(
+		t, alice.msgChan, "Error",
+	).(*lnwire.Error)
+	require.Equal(t, fundingAbortedError{}.Error(), string(errMsg.Data))
+	require.NoError(t, <-abortErr)
+	assertErrorNotSent(t, alice.msgChan)
+	bob.fundingMgr.ProcessFundingMsg(errMsg, alice)
+	assertNumPendingReservations(t, alice, bobPubKey, 0)
+
+	select {
+	case err := <-errChan:
+		require.Equal(t, fundingAbortedError{}, err)
+	case <-time.After(time.Second * 5):
+		t.Fatalf("funding flow was not failed")
+	}
+
+	err = alice.fundingMgr.AbortFunding(pendingChanID)
+	require.Equal(t, ErrUnknownPendingFunding, err)
+
+	// Once Alice sent FundingCreated for a channel funded by her wallet,
+	// it can't be aborted anymore.
+	flow := openUntilAccept(t, alice, bob, func(req *InitFundingMsg) {
+		req.PendingChanID = [32]byte{4}
+	})
+	alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+	assertFundingMsgSent(t, alice.msgChan, "FundingCreated")
+
+	err = alice.fundingMgr.AbortFunding(flow.initReq.PendingChanID)
+	require.Equal(t, ErrFundingCreatedSent, err)
+	assertNumPendingReservations(t, alice, bobPubKey, 1)
+}
+
+// TestFundingManagerAbortFundingBeforeFundingCreated asserts that once a
+// funding negotiation is marked as aborted, the FundingCreated message isn't
+// sent, even if the funding flow continues before the abort fails it.
+func TestFundingManagerAbortFundingBeforeFundingCreated(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	flow := openUntilAccept(t, alice, bob)
+	pendingChanID := flow.accept.PendingChannelID
+
+	resCtx, err := alice.fundingMgr.getReservationCtx(
+		bobPubKey, pendingChanID,
+	)
+	require.NoError(t, err)
+
+	// Simulate AbortFunding deciding to abort the negotiation while
+	// Alice is still processing Bob's AcceptChannel.
+	alice.fundingMgr.resMtx.Lock()
+	resCtx.aborted = true
+	alice.fundingMgr.resMtx.Unlock()
+
+	alice.fundingMgr.ProcessFundingMsg(flow.accept, bob)
+	assertErrorNotSent(t, alice.msgChan)
+
+	// As the FundingCreated message wasn't sent, the negotiation isn't
+	// tracked as signed, so the abort completes and notifies Bob.
+	abortErr := make(chan error, 1)
+	go func() {
+		abortErr <- alice.fundingMgr.AbortFunding(pendingChanID)
+	}()
+
+	assertFundingMsgSent(t, alice.msgChan, "Error")
+	require.NoError(t, <-abortErr)
+	assertNumPendingReservations(t, alice, bobPubKey, 0)
+}
+
+// TestFundingManagerAcceptPolicyCache asserts that the accept policy of a peer
+// is cached for each channel it accepts, and can be used to preview the
+// parameters of a channel of a different size.
+func TestFundingManagerAcceptPolicyCache(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// Bob must accept a second channel while the first is still pending.
+	bob.fundingMgr.cfg.MaxPendingChannels = 2
+
+	_, ok := alice.fundingMgr.AcceptPolicy(bobPubKey)
+	require.False(t, ok)
+
+	acceptChannel := func(capacity btcutil.Amount,
+		pendingChanID [32]byte) *lnwire.AcceptChannel {
+
+		flow := openUntilAccept(
+			t, alice, bob, func(req *InitFundingMsg) {
+				req.LocalFundingAmt = capacity
+				req.PendingChanID = pendingChanID
+			},
+		)
+		acceptChannelResponse := flow.accept
+		alice.fundingMgr.ProcessFundingMsg(acceptChannelResponse, bob)
+		assertFundingMsgSent(t, alice.msgChan, "FundingCreated")
+
+		return acceptChannelResponse
+	}
+
+	// Once Bob accepted the first channel, his policy is cached.
+	firstAccept := acceptChannel(500000, [32]byte{1})
+
+	policy, ok := alice.fundingMgr.AcceptPolicy(bobPubKey)
+	require.True(t, ok)
+	require.Equal(t, firstAccept.ToOpenParams(), policy.Params)
+	require.Equal(t, firstAccept.MinAcceptDepth, policy.MinAcceptDepth)
+	require.Equal(t, btcutil.Amount(500000), policy.Capacity)
+	require.EqualValues(t, 1, policy.NumAccepted)
+
+	// The cached policy previews the reserve and the maximum value in
+	// flight Bob requires for a channel twice the size.
+	preview := policy.PreviewAccept(1000000)
+	secondAccept := acceptChannel(1000000, [32]byte{2})
+	require.Equal(t, secondAccept.ChannelReserve, preview.ChannelReserve)
+	require.Equal(
+		t, secondAccept.MaxValueInFlight, preview.MaxValueInFlight,
+	)
+	require.Equal(t, secondAccept.DustLimit, preview.DustLimit)
+	require.Equal(t, secondAccept.CsvDelay, preview.CsvDelay)
+
+	// The second channel replaced the cached policy.
+	policy, ok = alice.fundingMgr.AcceptPolicy(bobPubKey)
+	require.True(t, ok)
+	require.Equal(t, secondAccept.ToOpenParams(), policy.Params)
+	require.Equal(t, btcutil.Amount(1000000), policy.Capacity)
+	require.EqualValues(t, 2, policy.NumAccepted)
+
+	// Bob never opened a channel to Alice, so he has no policy for her.
+	_, ok = bob.fundingMgr.AcceptPolicy(alicePubKey)
+	require.False(t, ok)
+}
+
+// TestAcceptPolicyPreviewAccept asserts that previewing an AcceptChannel
+// scales the reserve and the maximum value in flight with the capacity, bound
+// by the dust limit and the capacity respectively.
+func TestAcceptPolicyPreviewAccept(t *testing.T) {
+	t.Parallel()
+
+	policy := &AcceptPolicy{
+		Params: lnwire.OpenChannelParams{
+			DustLimit:        573,
+			ChannelReserve:   10000,
+			MaxValueInFlight: 990000000,
+			HtlcMinimum:      1000,
+			CsvDelay:         144,
+			MaxAcceptedHTLCs: 483,
+		},
+		MinAcceptDepth: 3,
+		Capacity:       1000000,
+	}
+
+	preview := policy.PreviewAccept(2000000)
+	require.Equal(t, &lnwire.AcceptChannel{
+		DustLimit:        573,
+		MaxValueInFlight: 1980000000,
+		ChannelReserve:   20000,
+		HtlcMinimum:      1000,
+		MinAcceptDepth:   3,
+		CsvDelay:         144,
+		MaxAcceptedHTLCs: 483,
+	}, preview)
+
+	// The reserve of a small channel doesn't drop below the dust limit.
+	preview = policy.PreviewAccept(20000)
+	require.Equal(t, btcutil.Amount(573), preview.ChannelReserve)
+	require.Equal(
+		t, lnwire.MilliSatoshi(19800000), preview.MaxValueInFlight,
+	)
+
+	// A zero reserve stays zero.
+	policy.Params.ChannelReserve = 0
+	preview = policy.PreviewAccept(2000000)
+	require.Zero(t, preview.ChannelReserve)
+
+	// The maximum value in flight never exceeds the capacity.
+	policy.Params.MaxValueInFlight = 1500000000
+	preview = policy.PreviewAccept(1000000)
+	require.Equal(
+		t, lnwire.MilliSatoshi(1000000000), preview.MaxValueInFlight,
+	)
+}
+
+// TestFundingManagerMaxChannels asserts that the total number of channels
+// across all peers is capped by MaxChannels, for both the channels we open and
+// the ones opened to us, and that the funding flows in progress count towards
+// the cap.
+func TestFundingManagerMaxChannels(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t, func(cfg *Config) {
+		cfg.MaxChannels = 1
+		cfg.MaxPendingChannels = 3
+	})
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// The first channel is within the cap of both nodes.
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	_, _ = openChannel(t, alice, bob, 500000, 0, 1, updateChan, true)
+
+	// Alice can't open another channel, as the pending one reached her
+	// cap. She doesn't even send an OpenChannel.
+	initReq := &InitFundingMsg{
+		Peer:            bob,
+		TargetPubkey:    bob.privKey.PubKey(),
+		ChainHash:       *fundingNetParams.GenesisHash,
+		LocalFundingAmt: 500000,
+		Updates:         make(chan *lnrpc.OpenStatusUpdate),
+		Err:             make(chan error, 1),
+	}
+	alice.fundingMgr.InitFundingWorkflow(initReq)
+	select {
+	case err := <-initReq.Err:
+		require.Equal(t, lnwire.ErrMaxChannels, err)
+	case <-time.After(time.Second * 5):
+		t.Fatalf("alice did not fail the funding flow")
+	}
+	assertErrorNotSent(t, alice.msgChan)
+
+	// Without a cap on Alice's side, Bob rejects the channel instead, as
+	// it would exceed his cap.
+	alice.fundingMgr.cfg.MaxChannels = 0
+
+	initReq, openChannelReq := initFunding(t, alice, bob)
+	bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+
+	errMsg := assertFundingMsgSent(t, bob.msgChan, "Error").(*lnwire.Error)
+	require.Equal(t, lnwire.ErrMaxChannels.Error(), string(errMsg.Data))
+	assertNumPendingReservations(t, bob, alicePubKey, 0)
+
+	alice.fundingMgr.ProcessFundingMsg(errMsg, bob)
+	select {
+	case <-initReq.Err:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("alice did not fail the funding flow")
+	}
+	assertNumPendingReservations(t, alice, bobPubKey, 0)
+
+	// With a cap of two, Bob accepts one more channel. The reservation of
+	// the channel counts towards his cap, so he rejects another one while
+	// the first is still being negotiated.
+	bob.fundingMgr.cfg.MaxChannels = 2
+
+	openUntilAccept(t, alice, bob)
+
+	_, openChannelReq = initFunding(t, alice, bob)
+	bob.fundingMgr.ProcessFundingMsg(openChannelReq, alice)
+
+	errMsg = assertFundingMsgSent(t, bob.msgChan, "Error").(*lnwire.Error)
+	require.Equal(t, lnwire.ErrMaxChannels.Error(), string(errMsg.Data))
+	assertNumPendingReservations(t, bob, alicePubKey, 1)
+}
+
+// TestFundingManagerFundingLatency asserts that the time a peer takes to
+// complete the funding after we sent our FundingCreated is recorded for each
+// channel we open with it, and persisted across restarts.
+func TestFundingManagerFundingLatency(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// Bob must accept a second channel while the first is still pending.
+	bob.fundingMgr.cfg.MaxPendingChannels = 2
+
+	latency, err := alice.fundingMgr.FundingLatency(bobPubKey)
+	require.NoError(t, err)
+	require.Nil(t, latency)
+
+	// Once the first channel is funded, its latency is recorded. It can't
+	// exceed the time the whole funding flow took.
+	start := time.Now()
+	updateChan := make(chan *lnrpc.OpenStatusUpdate)
+	openChannel(t, alice, bob, 500000, 0, 1, updateChan, true)
+	elapsed := time.Since(start)
+
+	latency, err = alice.fundingMgr.FundingLatency(bobPubKey)
+	require.NoError(t, err)
+	require.NotNil(t, latency)
+	require.EqualValues(t, 1, latency.NumFundings)
+	require.Greater(t, int64(latency.Last), int64(0))
+	require.LessOrEqual(t, int64(latency.Last), int64(elapsed))
+	require.Equal(t, latency.Last, latency.Min)
+	require.Equal(t, latency.Last, latency.Max)
+	require.Equal(t, latency.Last, latency.Average())
+	firstLatency := latency.Last
+
+	// The second channel adds to the latency of the first one.
+	updateChan = make(chan *lnrpc.OpenStatusUpdate)
+	openChannel(t, alice, bob, 500000, 0, 1, updateChan, true)
+
+	latency, err = alice.fundingMgr.FundingLatency(bobPubKey)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, latency.NumFundings)
+	require.Equal(t, firstLatency+latency.Last, latency.Total)
+	require.LessOrEqual(t, int64(latency.Min), int64(latency.Max))
+	require.Equal(t, latency.Total/2, latency.Average())
+
+	// The latency survives a restart.
+	recreateAliceFundingManager(t, alice)
+
+	restored, err := alice.fundingMgr.FundingLatency(bobPubKey)
+	require.NoError(t, err)
+	require.Equal(t, latency.NumFundings, restored.NumFundings)
+	require.Equal(t, latency.Total, restored.Total)
+	require.True(t, latency.LastRecorded.Equal(restored.LastRecorded))
+
+	// Bob never opened a channel to Alice, so no latency is recorded for
+	// her.
+	latency, err = bob.fundingMgr.FundingLatency(alicePubKey)
+	require.NoError(t, err)
+	require.Nil(t, latency)
+}
+
+// TestRecordFundingLatency asserts that the funding latency is measured from
+// sending the FundingCreated message, so the time it took us to fund the
+// channel after receiving the AcceptChannel isn't included, and that nothing
+// is recorded if we never sent one.
+func TestRecordFundingLatency(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	// A reservation of a channel Bob opened to Alice carries no
+	// FundingCreated we sent.
+	resCtx := &reservationWithCtx{}
+	require.NoError(t, alice.fundingMgr.recordFundingLatency(
+		bobPubKey, resCtx,
+	))
+
+	latency, err := alice.fundingMgr.FundingLatency(bobPubKey)
+	require.NoError(t, err)
+	require.Nil(t, latency)
+
+	// The AcceptChannel was processed long before FundingCreated was
+	// sent, e.g. while waiting for a PSBT, which doesn't count.
+	resCtx.setAcceptMsg(&lnwire.AcceptChannel{})
+	sentAt := time.Now().Add(-time.Second)
+	resCtx.setFundingCreatedSent(sentAt)
+
+	start := time.Now()
+	require.NoError(t, alice.fundingMgr.recordFundingLatency(
+		bobPubKey, resCtx,
+	))
+	elapsed := time.Since(sentAt)
+
+	latency, err = alice.fundingMgr.FundingLatency(bobPubKey)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, latency.NumFundings)
+	require.GreaterOrEqual(t, int64(latency.Last), int64(start.Sub(sentAt)))
+	require.LessOrEqual(t, int64(latency.Last), int64(elapsed))
+}
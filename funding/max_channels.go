@@ -0,0 +1,56 @@
+package funding
+
+import (
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// numChannels returns the total number of our channels across all peers: the
+// channels open or pending open in the database, and the reservations of the
+// funding flows still in progress. Channels waiting for a closing transaction
+// to confirm aren't counted, as they're on their way out.
+func (f *Manager) numChannels() (int, error) {
+	db := f.cfg.Wallet.Cfg.Database
+
+	openChannels, err := db.FetchAllOpenChannels()
+	if err != nil {
+		return 0, err
+	}
+
+	pendingChannels, err := db.FetchPendingChannels()
+	if err != nil {
+		return 0, err
+	}
+
+	numChannels := len(openChannels) + len(pendingChannels)
+
+	f.resMtx.RLock()
+	for _, reservations := range f.activeReservations {
+		numChannels += len(reservations)
+	}
+	f.resMtx.RUnlock()
+
+	return numChannels, nil
+}
+
+// checkMaxChannels returns lnwire.ErrMaxChannels if another channel would
+// exceed the MaxChannels limit of our config, counting both the channels we
+// have and the funding flows in progress.
+func (f *Manager) checkMaxChannels() error {
+	if f.cfg.MaxChannels == 0 {
+		return nil
+	}
+
+	numChannels, err := f.numChannels()
+	if err != nil {
+		return err
+	}
+
+	if numChannels >= f.cfg.MaxChannels {
+		log.Warnf("Rejecting channel, as we have %v channels, and at "+
+			"most %v are allowed", numChannels, f.cfg.MaxChannels)
+
+		return lnwire.ErrMaxChannels
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package funding
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// rejectReasonMalformed is the reason label used for AcceptChannel
+	// messages that couldn't be decoded.
+	rejectReasonMalformed = "malformed"
+
+	// rejectReasonDuplicatePubKey is the reason label used for
+	// AcceptChannel messages that reuse one of their public keys.
+	rejectReasonDuplicatePubKey = "duplicate_pubkey"
+
+	// rejectReasonFundingKeyIsIdentity is the reason label used for
+	// AcceptChannel messages whose funding key is the node identity key
+	// of the peer.
+	rejectReasonFundingKeyIsIdentity = "funding_key_is_identity"
+
+	// rejectReasonUpfrontShutdownAbsent is the reason label used for
+	// AcceptChannel messages missing the upfront shutdown script record
+	// although the feature was negotiated.
+	rejectReasonUpfrontShutdownAbsent = "upfront_shutdown_absent"
+
+	// rejectReasonUnknownChannelType is the reason label used for
+	// AcceptChannel messages whose channel type requires features we
+	// don't know of.
+	rejectReasonUnknownChannelType = "unknown_channel_type"
+
+	// rejectReasonDustLimitBelowScript is the reason label used for
+	// AcceptChannel messages whose dust limit is below the dust threshold
+	// of their upfront shutdown script.
+	rejectReasonDustLimitBelowScript = "dust_limit_below_script"
+
+	// rejectReasonMaxValueInFlight is the reason label used for
+	// AcceptChannel messages whose max value in flight is below their
+	// HTLC minimum or exceeds the channel capacity.
+	rejectReasonMaxValueInFlight = "max_value_in_flight"
+
+	// rejectReasonFeeRateRange is the reason label used for AcceptChannel
+	// messages whose preferred commitment fee rate range doesn't contain
+	// the fee rate we proposed.
+	rejectReasonFeeRateRange = "fee_rate_range"
+
+	// rejectReasonAnchorReserve is the reason label used for AcceptChannel
+	// messages for anchor channels whose channel reserve doesn't cover the
+	// dust limit on top of the anchor outputs.
+	rejectReasonAnchorReserve = "anchor_reserve"
+
+	// rejectReasonReserveAsymmetry is the reason label used for
+	// AcceptChannel messages requiring us to keep a reserve much larger
+	// than the one we require from the peer.
+	rejectReasonReserveAsymmetry = "reserve_asymmetry"
+
+	// rejectReasonCommitPointReuse is the reason label used for
+	// AcceptChannel messages reusing a first commitment point the peer
+	// already sent us.
+	rejectReasonCommitPointReuse = "commit_point_reuse"
+
+	// rejectReasonPendingChanIDInUse is the reason label used for
+	// AcceptChannel messages for a reservation that was already accepted.
+	rejectReasonPendingChanIDInUse = "pending_chan_id_in_use"
+
+	// rejectReasonUnexpectedZeroConf is the reason label used for
+	// AcceptChannel messages with a MinAcceptDepth of zero while we didn't
+	// offer zero-conf.
+	rejectReasonUnexpectedZeroConf = "unexpected_zero_conf"
+
+	// rejectReasonOther is the reason label used for AcceptChannel
+	// messages rejected for a reason without a dedicated label.
+	rejectReasonOther = "other"
+)
+
+const (
+	// mediumCapacityTier and largeCapacityTier are the smallest channel
+	// capacities of the medium and large capacity tiers. Channels below
+	// the medium tier are in the small tier.
+	mediumCapacityTier btcutil.Amount = 1000000
+	largeCapacityTier  btcutil.Amount = 10000000
+)
+
+// capacityTier returns the label of the capacity tier a channel of the given
+// capacity falls into.
+func capacityTier(capacity btcutil.Amount) string {
+	switch {
+	case capacity >= largeCapacityTier:
+		return "large"
+
+	case capacity >= mediumCapacityTier:
+		return "medium"
+
+	default:
+		return "small"
+	}
+}
+
+// acceptChannelsByTier counts the AcceptChannel messages we accepted, by the
+// capacity tier of the channel, the reserve we're required to maintain as a
+// percentage of the capacity, and the required depth.
+var acceptChannelsByTier = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "lnd",
+		Subsystem: "funding",
+		Name:      "accept_channel_accepted_total",
+		Help: "Number of AcceptChannel messages accepted, by " +
+			"capacity tier, reserve percentage and depth.",
+	},
+	[]string{"tier", "reserve_pct", "min_depth"},
+)
+
+// acceptChannelRejections counts the AcceptChannel messages we rejected, by
+// the reason they were rejected for.
+var acceptChannelRejections = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "lnd",
+		Subsystem: "funding",
+		Name:      "accept_channel_rejected_total",
+		Help: "Number of AcceptChannel messages rejected, by " +
+			"reason.",
+	},
+	[]string{"reason"},
+)
+
+// pendingChannelsInvalid counts the channels found pending on startup whose
+// received AcceptChannel no longer passes our validation.
+var pendingChannelsInvalid = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "lnd",
+		Subsystem: "funding",
+		Name:      "pending_channel_invalid_total",
+		Help: "Number of pending channels whose AcceptChannel failed " +
+			"validation on startup.",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(acceptChannelRejections)
+	prometheus.MustRegister(acceptChannelsByTier)
+	prometheus.MustRegister(pendingChannelsInvalid)
+}
+
+// acceptRejectionReason returns the reason label for an AcceptChannel
+// rejected with the given error. Reservation errors are labeled with their
+// reason, any other error is labeled as rejectReasonOther.
+func acceptRejectionReason(err error) string {
+	var resErr lnwallet.ReservationError
+	if errors.As(err, &resErr) && resErr.Reason() != "" {
+		return string(resErr.Reason())
+	}
+
+	return rejectReasonOther
+}
+
+// recordAcceptRejection increments the rejection counter for the given
+// reason label.
+func recordAcceptRejection(reason string) {
+	acceptChannelRejections.WithLabelValues(reason).Inc()
+}
+
+// acceptChannelLabels returns the labels an AcceptChannel for a channel of
+// the given capacity, requiring the given reserve and depth, is counted with.
+// The reserve is expressed as a whole percentage of the capacity, which keeps
+// the number of distinct labels small.
+func acceptChannelLabels(capacity, reserve btcutil.Amount,
+	minDepth uint32) []string {
+
+	var reservePct int64
+	if capacity > 0 {
+		reservePct = int64(reserve * 100 / capacity)
+	}
+
+	return []string{
+		capacityTier(capacity),
+		strconv.FormatInt(reservePct, 10),
+		strconv.FormatUint(uint64(minDepth), 10),
+	}
+}
+
+// recordAcceptChannel increments the counter of accepted AcceptChannel
+// messages for a channel of the given capacity, requiring the given reserve
+// and depth.
+func recordAcceptChannel(capacity, reserve btcutil.Amount, minDepth uint32) {
+	labels := acceptChannelLabels(capacity, reserve, minDepth)
+	acceptChannelsByTier.WithLabelValues(labels...).Inc()
+}
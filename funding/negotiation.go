@@ -0,0 +1,144 @@
+package funding
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// NegotiationResult captures the channel parameters agreed on by both parties
+// once we've processed the AcceptChannel of a channel we initiated, such that
+// higher layers don't need to re-derive them from the exchanged messages.
+type NegotiationResult struct {
+	// PendingChanID is the pending channel ID of the negotiated channel.
+	PendingChanID [32]byte
+
+	// Capacity is the capacity of the channel.
+	Capacity btcutil.Amount
+
+	// CommitType is the commitment type negotiated for the channel.
+	CommitType lnwallet.CommitmentType
+
+	// NumConfsRequired is the number of confirmations the remote party
+	// requires before the channel can be used.
+	NumConfsRequired uint32
+
+	// LocalConstraints are the constraints the remote party requires us
+	// to adhere to in their commitment transactions.
+	LocalConstraints channeldb.ChannelConstraints
+
+	// RemoteConstraints are the constraints we require the remote party
+	// to adhere to in our commitment transactions.
+	RemoteConstraints channeldb.ChannelConstraints
+
+	// LocalUpfrontShutdown is our upfront shutdown script, if any.
+	LocalUpfrontShutdown lnwire.DeliveryAddress
+
+	// RemoteUpfrontShutdown is the upfront shutdown script of the remote
+	// party, if any.
+	RemoteUpfrontShutdown lnwire.DeliveryAddress
+
+	// MaxHtlcExpiryDelta is the maximum number of blocks the expiry of the
+	// HTLCs we offer may lie beyond the current height, as required by the
+	// remote party. It is zero if the remote party didn't bound it.
+	MaxHtlcExpiryDelta uint32
+}
+
+// newNegotiationResult assembles the NegotiationResult of a reservation for
+// which the contribution carried in the given AcceptChannel was processed.
+func newNegotiationResult(resCtx *reservationWithCtx,
+	msg *lnwire.AcceptChannel) *NegotiationResult {
+
+	ourContribution := resCtx.reservation.OurContribution()
+	theirContribution := resCtx.reservation.TheirContribution()
+
+	return &NegotiationResult{
+		PendingChanID:         msg.PendingChannelID,
+		Capacity:              resCtx.chanAmt,
+		CommitType:            resCtx.commitType,
+		NumConfsRequired:      msg.MinAcceptDepth,
+		LocalConstraints:      ourContribution.ChannelConstraints,
+		RemoteConstraints:     theirContribution.ChannelConstraints,
+		LocalUpfrontShutdown:  ourContribution.UpfrontShutdown,
+		RemoteUpfrontShutdown: theirContribution.UpfrontShutdown,
+		MaxHtlcExpiryDelta:    resCtx.maxHtlcExpiryDelta,
+	}
+}
+
+// PendingNegotiation describes a funding negotiation that is still in
+// progress, meaning the reservation for the channel is active and the funding
+// transaction hasn't been broadcast yet.
+type PendingNegotiation struct {
+	// PendingChanID is the pending channel ID of the negotiation.
+	PendingChanID [32]byte
+
+	// Peer is the identity key of the peer the channel is negotiated
+	// with.
+	Peer *btcec.PublicKey
+
+	// Capacity is the capacity of the channel.
+	Capacity btcutil.Amount
+
+	// Accept is a copy of the AcceptChannel the peer responded to our
+	// OpenChannel with. It is nil until a valid AcceptChannel was
+	// processed, and for channels initiated by the peer.
+	Accept *lnwire.AcceptChannel
+
+	// LastUpdated is the time the negotiation last progressed. It is the
+	// zero time for negotiations that are waiting for the user, such as
+	// PSBT funding flows, which don't time out.
+	LastUpdated time.Time
+}
+
+// PendingNegotiations returns a snapshot of all funding negotiations in
+// progress, sorted by peer and pending channel ID. As opposed to the pending
+// channels of the database, these are the channels for which no funding
+// transaction exists yet.
+func (f *Manager) PendingNegotiations() []PendingNegotiation {
+	f.resMtx.RLock()
+	defer f.resMtx.RUnlock()
+
+	var negotiations []PendingNegotiation
+	for _, reservations := range f.activeReservations {
+		for pendingChanID, resCtx := range reservations {
+			negotiation := PendingNegotiation{
+				PendingChanID: pendingChanID,
+				Peer:          resCtx.peer.IdentityKey(),
+				Capacity:      resCtx.chanAmt,
+			}
+
+			resCtx.updateMtx.RLock()
+			if resCtx.acceptMsg != nil {
+				accept := *resCtx.acceptMsg
+				negotiation.Accept = &accept
+			}
+			negotiation.LastUpdated = resCtx.lastUpdated
+			resCtx.updateMtx.RUnlock()
+
+			negotiations = append(negotiations, negotiation)
+		}
+	}
+
+	sort.Slice(negotiations, func(i, j int) bool {
+		a, b := negotiations[i], negotiations[j]
+		cmp := bytes.Compare(
+			a.Peer.SerializeCompressed(),
+			b.Peer.SerializeCompressed(),
+		)
+		if cmp != 0 {
+			return cmp < 0
+		}
+
+		return bytes.Compare(
+			a.PendingChanID[:], b.PendingChanID[:],
+		) < 0
+	})
+
+	return negotiations
+}
@@ -0,0 +1,60 @@
+package funding
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwallet"
+)
+
+// openedChannelParams formats the parameters negotiated for the given channel
+// through the OpenChannel and AcceptChannel messages as a single line of
+// key=value pairs. The constraints of both sides are prefixed with local_ and
+// remote_, and refer to the constraints the respective side must adhere to in
+// the commitment transaction of its counterparty.
+func openedChannelParams(channel *channeldb.OpenChannel) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "chan_point=%v peer=%x initiator=%v capacity=%d "+
+		"commit_type=%v num_confs=%d channel_flags=%d fee_per_kw=%d "+
+		"local_balance_msat=%d remote_balance_msat=%d",
+		channel.FundingOutpoint,
+		channel.IdentityPub.SerializeCompressed(), channel.IsInitiator,
+		int64(channel.Capacity),
+		lnwallet.CommitmentTypeOf(channel.ChanType),
+		channel.NumConfsRequired, channel.ChannelFlags,
+		int64(channel.LocalCommitment.FeePerKw),
+		uint64(channel.LocalCommitment.LocalBalance),
+		uint64(channel.LocalCommitment.RemoteBalance))
+
+	sides := []struct {
+		prefix   string
+		cfg      *channeldb.ChannelConfig
+		shutdown []byte
+	}{
+		{"local", &channel.LocalChanCfg, channel.LocalShutdownScript},
+		{"remote", &channel.RemoteChanCfg, channel.RemoteShutdownScript},
+	}
+	for _, side := range sides {
+		fmt.Fprintf(&b, " %[1]s_dust_limit=%[2]d "+
+			"%[1]s_chan_reserve=%[3]d %[1]s_max_pending_msat=%[4]d "+
+			"%[1]s_min_htlc_msat=%[5]d %[1]s_max_accepted_htlcs=%[6]d "+
+			"%[1]s_csv_delay=%[7]d %[1]s_upfront_shutdown=%[8]x",
+			side.prefix, int64(side.cfg.DustLimit),
+			int64(side.cfg.ChanReserve),
+			uint64(side.cfg.MaxPendingAmount),
+			uint64(side.cfg.MinHTLC), side.cfg.MaxAcceptedHtlcs,
+			side.cfg.CsvDelay, side.shutdown)
+	}
+
+	return b.String()
+}
+
+// logOpenedChannel logs the parameters negotiated for the given channel, which
+// just opened, as a single line to the passed logger, to allow auditing them
+// later on.
+func logOpenedChannel(logger btclog.Logger, channel *channeldb.OpenChannel) {
+	logger.Infof("Opened channel with negotiated parameters: %v",
+		openedChannelParams(channel))
+}
@@ -0,0 +1,71 @@
+package funding
+
+import (
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+// ReservePolicy is a function closure that, given the capacity of a proposed
+// channel, returns the channel reserve we'll require the remote party to
+// maintain at all times.
+type ReservePolicy func(capacity btcutil.Amount) btcutil.Amount
+
+// DefaultReservePolicy is the ReservePolicy used unless the operator provides
+// their own. It requires the remote peer to maintain at least 1% of the total
+// channel capacity.
+func DefaultReservePolicy(capacity btcutil.Amount) btcutil.Amount {
+	return capacity / 100
+}
+
+// DefaultMaxValueInFlight returns the closure computing the maximum value in
+// flight we allow the remote peer by default, which is the full capacity of
+// the channel minus the reserve required by the given ReservePolicy. The
+// policy should be the one the funding manager is configured with, so the
+// two stay consistent. The reserve is subtracted in satoshis, and only the
+// result is converted to milli-satoshis, so the two units are never mixed.
+func DefaultMaxValueInFlight(
+	reservePolicy ReservePolicy) func(btcutil.Amount) lnwire.MilliSatoshi {
+
+	return func(capacity btcutil.Amount) lnwire.MilliSatoshi {
+		reserve := reservePolicy(capacity)
+		return lnwire.NewMSatFromSatoshis(capacity - reserve)
+	}
+}
+
+// DepthPolicy is a function closure that, given the remote peer and the
+// capacity of a channel it proposes to us, returns the number of
+// confirmations we'll require before the channel is considered open.
+type DepthPolicy func(peer route.Vertex, capacity btcutil.Amount) uint32
+
+// TrustScoreDepthPolicy returns a DepthPolicy that scales the required depth
+// with the trust score of the peer. Scores are clamped to the range [0, 1]: a
+// fully trusted peer with a score of 1 is required minDepth confirmations,
+// while a peer with a score of 0, such as one we know nothing about, is
+// required maxDepth confirmations. The depth of the scores in between is
+// interpolated linearly.
+func TrustScoreDepthPolicy(score func(route.Vertex) float64, minDepth,
+	maxDepth uint32) DepthPolicy {
+
+	return func(peer route.Vertex, _ btcutil.Amount) uint32 {
+		trust := score(peer)
+		switch {
+		case trust <= 0:
+			return maxDepth
+		case trust >= 1:
+			return minDepth
+		}
+
+		span := float64(maxDepth) - float64(minDepth)
+		return uint32(float64(maxDepth) - trust*span + 0.5)
+	}
+}
+
+// DefaultHtlcMinPolicy is the lnwire.HtlcMinPolicy used to warn about a peer
+// accepting our channel with an HtlcMinimum far apart from ours, unless the
+// operator provides their own. A minimum may exceed the other by up to ten
+// satoshis, or a hundred times the other, whichever is larger.
+var DefaultHtlcMinPolicy = lnwire.HtlcMinPolicy{
+	MaxRatio:  100,
+	Tolerance: lnwire.NewMSatFromSatoshis(10),
+}
@@ -0,0 +1,226 @@
+package funding
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// fundingRecordingPath is the path of the example recording of a
+	// funding negotiation between Alice and Bob.
+	fundingRecordingPath = "testdata/funding_negotiation.json"
+
+	// replayInitiator and replayResponder are the senders of the messages
+	// of a recorded funding negotiation.
+	replayInitiator = "alice"
+	replayResponder = "bob"
+)
+
+// recordedMessage is a single message of a recorded funding negotiation, as
+// stored on disk.
+type recordedMessage struct {
+	// Sender is the party that sent the message, either alice or bob.
+	Sender string `json:"sender"`
+
+	// Type is the name of the type of the message, e.g. AcceptChannel.
+	Type string `json:"type"`
+
+	// Data is the hex encoded wire serialization of the message.
+	Data string `json:"data"`
+}
+
+// fundingRecording is a recorded funding negotiation, as stored on disk.
+type fundingRecording struct {
+	// Description describes the negotiation that was recorded.
+	Description string `json:"description"`
+
+	// FundingFeePerKw is the fee rate the initiator funded the channel
+	// with. It is only needed to replay the recording as the initiator.
+	FundingFeePerKw uint64 `json:"funding_fee_per_kw"`
+
+	// Messages are the messages exchanged, in order.
+	Messages []recordedMessage `json:"messages"`
+}
+
+// replayStep is a decoded message of a recorded funding negotiation.
+type replayStep struct {
+	sender string
+	msg    lnwire.Message
+}
+
+// fundingReplay is a decoded funding negotiation that can be replayed through
+// a funding manager.
+type fundingReplay struct {
+	description     string
+	fundingFeePerKw chainfee.SatPerKWeight
+	steps           []replayStep
+}
+
+// loadFundingRecording loads the recorded funding negotiation at the given
+// path, and decodes each of its messages.
+func loadFundingRecording(path string) (*fundingReplay, error) {
+	recordingJSON, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var recording fundingRecording
+	if err := json.Unmarshal(recordingJSON, &recording); err != nil {
+		return nil, err
+	}
+
+	fundingFeePerKw := chainfee.SatPerKWeight(recording.FundingFeePerKw)
+	replay := &fundingReplay{
+		description:     recording.Description,
+		fundingFeePerKw: fundingFeePerKw,
+	}
+	for i, recorded := range recording.Messages {
+		if recorded.Sender != replayInitiator &&
+			recorded.Sender != replayResponder {
+
+			return nil, fmt.Errorf("message %d has unknown "+
+				"sender %q", i, recorded.Sender)
+		}
+
+		data, err := hex.DecodeString(recorded.Data)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %v", i, err)
+		}
+
+		msg, err := lnwire.ReadMessage(bytes.NewReader(data), 0)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %v", i, err)
+		}
+
+		msgType := strings.TrimPrefix(msg.MsgType().String(), "Msg")
+		if msgType != recorded.Type {
+			return nil, fmt.Errorf("message %d is a %v, recorded "+
+				"as %v", i, msgType, recorded.Type)
+		}
+
+		replay.steps = append(replay.steps, replayStep{
+			sender: recorded.Sender,
+			msg:    msg,
+		})
+	}
+
+	return replay, nil
+}
+
+// replayFunding replays the recorded negotiation through the funding manager
+// of the given party. The messages of the other party are fed into the
+// manager, while the ones of the given party are asserted to match the ones
+// the manager sends. Finally, the channel is asserted to be pending.
+func replayFunding(t *testing.T, replay *fundingReplay, local string) {
+	t.Helper()
+
+	t.Logf("Replaying as %v: %v", local, replay.description)
+
+	alice, bob := setupFundingManagers(t)
+	defer tearDownFundingManagers(t, alice, bob)
+
+	localNode, remoteNode := bob, alice
+	if local == replayInitiator {
+		localNode, remoteNode = alice, bob
+	}
+
+	var fundingCreated *lnwire.FundingCreated
+	updateChan := make(chan *lnrpc.OpenStatusUpdate, 2)
+	for i, step := range replay.steps {
+		if created, ok := step.msg.(*lnwire.FundingCreated); ok {
+			fundingCreated = created
+		}
+
+		if step.sender != local {
+			localNode.fundingMgr.ProcessFundingMsg(
+				step.msg, remoteNode,
+			)
+			continue
+		}
+
+		// As the initiator, the recorded OpenChannel tells us which
+		// channel to open.
+		if open, ok := step.msg.(*lnwire.OpenChannel); ok {
+			announce := open.ChannelFlags & lnwire.FFAnnounceChannel
+			initReq := &InitFundingMsg{
+				Peer:            remoteNode,
+				TargetPubkey:    remoteNode.privKey.PubKey(),
+				ChainHash:       open.ChainHash,
+				LocalFundingAmt: open.FundingAmount,
+				PushAmt:         open.PushAmount,
+				FundingFeePerKw: replay.fundingFeePerKw,
+				Private:         announce == 0,
+				Updates:         updateChan,
+				Err:             make(chan error, 1),
+			}
+			localNode.fundingMgr.InitFundingWorkflow(initReq)
+		}
+
+		var sent lnwire.Message
+		select {
+		case sent = <-localNode.msgChan:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("step %d: %v not sent", i, step.msg.MsgType())
+		}
+
+		var expected, actual bytes.Buffer
+		_, err := lnwire.WriteMessage(&expected, step.msg, 0)
+		require.NoError(t, err)
+		_, err = lnwire.WriteMessage(&actual, sent, 0)
+		require.NoError(t, err)
+		require.Equalf(
+			t, expected.Bytes(), actual.Bytes(),
+			"step %d: unexpected %v", i, sent.MsgType(),
+		)
+	}
+
+	require.NotNil(t, fundingCreated, "no FundingCreated recorded")
+	fundingPoint := fundingCreated.FundingPoint
+
+	// The initiator publishes the funding transaction once the negotiation
+	// completed.
+	if local == replayInitiator {
+		select {
+		case tx := <-localNode.publTxChan:
+			require.Equal(t, fundingPoint.Hash, tx.TxHash())
+		case <-time.After(time.Second * 5):
+			t.Fatalf("funding transaction not published")
+		}
+	}
+
+	assertNumPendingChannelsBecomes(t, localNode, 1)
+	pendingChannels, err := localNode.fundingMgr.cfg.Wallet.Cfg.Database.
+		FetchPendingChannels()
+	require.NoError(t, err)
+	require.Equal(t, fundingPoint, pendingChannels[0].FundingOutpoint)
+}
+
+// TestFundingManagerReplay replays the example recording of a funding
+// negotiation through the funding manager of each party.
+func TestFundingManagerReplay(t *testing.T) {
+	t.Parallel()
+
+	replay, err := loadFundingRecording(fundingRecordingPath)
+	require.NoError(t, err)
+
+	for _, local := range []string{replayInitiator, replayResponder} {
+		local := local
+
+		t.Run(local, func(t *testing.T) {
+			t.Parallel()
+
+			replayFunding(t, replay, local)
+		})
+	}
+}
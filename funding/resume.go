@@ -0,0 +1,439 @@
+package funding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnpeer"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwallet/chanfunding"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+var (
+	// pendingReservationBucket is the database bucket used to store the
+	// externally funded reservations we initiated that are still waiting
+	// for the remote party's AcceptChannel. Each entry is keyed by the
+	// identity key of the remote party followed by the pending channel ID.
+	pendingReservationBucket = []byte("pendingReservations")
+
+	// errPendingReservationNotFound is returned when no reservation for
+	// the given peer and pending channel ID has been persisted.
+	errPendingReservationNotFound = errors.New("pending reservation not " +
+		"found")
+)
+
+// pendingReservation is the persisted state of a reservation we initiated
+// that is still waiting for the remote party's AcceptChannel. It holds enough
+// information to re-create the reservation with the exact same parameters
+// and keys we sent to the remote party, should we restart before the
+// AcceptChannel arrives.
+//
+// Only reservations funded through an external funding shim are persisted.
+// Their funding output was agreed upon with the remote party beforehand and
+// none of our coins are locked for them, so the negotiation isn't tied to
+// the connection it was started on. Any other reservation is canceled by
+// both sides once the connection drops, so there would be nothing to resume.
+type pendingReservation struct {
+	// openMsg is the OpenChannel message we sent to the remote party.
+	openMsg *lnwire.OpenChannel
+
+	// commitType is the commitment type negotiated for the channel.
+	commitType lnwallet.CommitmentType
+
+	// fundingFeePerKw is the fee rate used for the funding transaction.
+	fundingFeePerKw chainfee.SatPerKWeight
+
+	// minConfs is the minimum number of confirmations of the coins used
+	// to fund the channel.
+	minConfs int32
+
+	// maxLocalCsv is the maximum csv we will accept from the remote.
+	maxLocalCsv uint16
+
+	// keys are the locators of the keys we sent in openMsg.
+	keys lnwallet.ReservationKeys
+
+	// chanPoint is the funding outpoint of the external funding shim.
+	chanPoint wire.OutPoint
+
+	// thawHeight is the thaw height of the external funding shim.
+	thawHeight uint32
+
+	// localFundingKey is our multi-sig key of the external funding shim.
+	localFundingKey keychain.KeyDescriptor
+
+	// remoteFundingKey is the remote party's multi-sig key of the
+	// external funding shim.
+	remoteFundingKey *btcec.PublicKey
+
+	// createdAt is the time the reservation was persisted at.
+	createdAt time.Time
+}
+
+// pendingReservationKey returns the database key of the reservation with the
+// given peer and pending channel ID.
+func pendingReservationKey(peerKey *btcec.PublicKey,
+	pendingChanID [32]byte) []byte {
+
+	var key [33 + 32]byte
+	copy(key[:33], peerKey.SerializeCompressed())
+	copy(key[33:], pendingChanID[:])
+
+	return key[:]
+}
+
+// serialize writes the pending reservation to the given buffer.
+func (p *pendingReservation) serialize(w *bytes.Buffer) error {
+	return channeldb.WriteElements(w,
+		p.openMsg, uint64(p.commitType), uint64(p.fundingFeePerKw),
+		p.minConfs, p.maxLocalCsv,
+		keychain.KeyDescriptor{KeyLocator: p.keys.MultiSigKey},
+		keychain.KeyDescriptor{KeyLocator: p.keys.RevocationBasePoint},
+		keychain.KeyDescriptor{KeyLocator: p.keys.HtlcBasePoint},
+		keychain.KeyDescriptor{KeyLocator: p.keys.PaymentBasePoint},
+		keychain.KeyDescriptor{KeyLocator: p.keys.DelayBasePoint},
+		keychain.KeyDescriptor{KeyLocator: p.keys.RevocationRoot},
+		p.chanPoint, p.thawHeight, p.localFundingKey,
+		p.remoteFundingKey, uint64(p.createdAt.Unix()),
+	)
+}
+
+// deserializePendingReservation reads a pending reservation written by
+// serialize.
+func deserializePendingReservation(r *bytes.Reader) (*pendingReservation,
+	error) {
+
+	var (
+		msg             lnwire.Message
+		commitType      uint64
+		fundingFeePerKw uint64
+		keys            [6]keychain.KeyDescriptor
+		createdAt       uint64
+		p               pendingReservation
+	)
+	err := channeldb.ReadElements(r,
+		&msg, &commitType, &fundingFeePerKw, &p.minConfs,
+		&p.maxLocalCsv, &keys[0], &keys[1], &keys[2], &keys[3],
+		&keys[4], &keys[5], &p.chanPoint, &p.thawHeight,
+		&p.localFundingKey, &p.remoteFundingKey, &createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	openMsg, ok := msg.(*lnwire.OpenChannel)
+	if !ok {
+		return nil, fmt.Errorf("expected OpenChannel, got %T", msg)
+	}
+
+	p.openMsg = openMsg
+	p.commitType = lnwallet.CommitmentType(commitType)
+	p.fundingFeePerKw = chainfee.SatPerKWeight(fundingFeePerKw)
+	p.keys = lnwallet.ReservationKeys{
+		MultiSigKey:         keys[0].KeyLocator,
+		RevocationBasePoint: keys[1].KeyLocator,
+		HtlcBasePoint:       keys[2].KeyLocator,
+		PaymentBasePoint:    keys[3].KeyLocator,
+		DelayBasePoint:      keys[4].KeyLocator,
+		RevocationRoot:      keys[5].KeyLocator,
+	}
+	p.createdAt = time.Unix(int64(createdAt), 0)
+
+	return &p, nil
+}
+
+// persistReservation persists the externally funded reservation we initiated
+// with the given peer, along with the OpenChannel we're about to send for it.
+func (f *Manager) persistReservation(peerKey *btcec.PublicKey,
+	openMsg *lnwire.OpenChannel, commitType lnwallet.CommitmentType,
+	msg *InitFundingMsg, maxLocalCsv uint16,
+	reservation *lnwallet.ChannelReservation) error {
+
+	shim := reservation.CannedShimIntent()
+	if shim == nil {
+		return fmt.Errorf("reservation isn't funded through an " +
+			"external funding shim")
+	}
+
+	chanPoint, err := shim.ChanPoint()
+	if err != nil {
+		return err
+	}
+	fundingKeys, err := shim.MultiSigKeys()
+	if err != nil {
+		return err
+	}
+
+	return f.savePendingReservation(peerKey, &pendingReservation{
+		openMsg:          openMsg,
+		commitType:       commitType,
+		fundingFeePerKw:  msg.FundingFeePerKw,
+		minConfs:         msg.MinConfs,
+		maxLocalCsv:      maxLocalCsv,
+		keys:             *reservation.Keys(),
+		chanPoint:        *chanPoint,
+		thawHeight:       shim.ThawHeight(),
+		localFundingKey:  *fundingKeys.LocalKey,
+		remoteFundingKey: fundingKeys.RemoteKey,
+		createdAt:        time.Now(),
+	})
+}
+
+// savePendingReservation persists the given reservation we initiated with
+// the given peer, allowing it to be resumed should we restart before
+// receiving the peer's AcceptChannel.
+func (f *Manager) savePendingReservation(peerKey *btcec.PublicKey,
+	res *pendingReservation) error {
+
+	var b bytes.Buffer
+	if err := res.serialize(&b); err != nil {
+		return err
+	}
+
+	return kvdb.Update(f.cfg.Wallet.Cfg.Database, func(tx kvdb.RwTx) error {
+		bucket, err := tx.CreateTopLevelBucket(pendingReservationBucket)
+		if err != nil {
+			return err
+		}
+
+		key := pendingReservationKey(
+			peerKey, res.openMsg.PendingChannelID,
+		)
+		return bucket.Put(key, b.Bytes())
+	}, func() {})
+}
+
+// fetchPendingReservation fetches the persisted reservation with the given
+// peer and pending channel ID, or returns errPendingReservationNotFound if
+// none exists.
+func (f *Manager) fetchPendingReservation(peerKey *btcec.PublicKey,
+	pendingChanID [32]byte) (*pendingReservation, error) {
+
+	var res *pendingReservation
+	err := kvdb.View(f.cfg.Wallet.Cfg.Database, func(tx kvdb.RTx) error {
+		bucket := tx.ReadBucket(pendingReservationBucket)
+		if bucket == nil {
+			return errPendingReservationNotFound
+		}
+
+		key := pendingReservationKey(peerKey, pendingChanID)
+		value := bucket.Get(key)
+		if value == nil {
+			return errPendingReservationNotFound
+		}
+
+		var err error
+		res, err = deserializePendingReservation(bytes.NewReader(value))
+		return err
+	}, func() {
+		res = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// deletePendingReservation removes the persisted reservation with the given
+// peer and pending channel ID, if any.
+func (f *Manager) deletePendingReservation(peerKey *btcec.PublicKey,
+	pendingChanID [32]byte) error {
+
+	return kvdb.Update(f.cfg.Wallet.Cfg.Database, func(tx kvdb.RwTx) error {
+		bucket := tx.ReadWriteBucket(pendingReservationBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		key := pendingReservationKey(peerKey, pendingChanID)
+		return bucket.Delete(key)
+	}, func() {})
+}
+
+// pruneExpiredPendingReservations removes all persisted reservations that
+// are older than the ReservationTimeout, as the remote party is unlikely to
+// still be waiting on them, along with the ones that can't be read.
+func (f *Manager) pruneExpiredPendingReservations() error {
+	return kvdb.Update(f.cfg.Wallet.Cfg.Database, func(tx kvdb.RwTx) error {
+		bucket := tx.ReadWriteBucket(pendingReservationBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		var expired [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			// A record we fail to read can't be resumed, so it
+			// is removed rather than failing the startup of the
+			// funding manager.
+			res, err := deserializePendingReservation(
+				bytes.NewReader(v),
+			)
+			if err != nil {
+				log.Warnf("Removing unreadable pending "+
+					"reservation %x: %v", k, err)
+				expired = append(expired, k)
+				return nil
+			}
+
+			if f.pendingReservationExpired(res) {
+				expired = append(expired, k)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func() {})
+}
+
+// pendingReservationExpired returns whether the persisted reservation is
+// older than the ReservationTimeout.
+func (f *Manager) pendingReservationExpired(res *pendingReservation) bool {
+	return time.Since(res.createdAt) > f.cfg.ReservationTimeout
+}
+
+// resumeReservation re-creates the reservation with the given pending channel
+// ID we initiated with the given peer from its persisted state, and adds it
+// to our set of active reservations. This allows an AcceptChannel for a
+// reservation lost to a restart to still be processed. If no such
+// reservation was persisted, errPendingReservationNotFound is returned.
+func (f *Manager) resumeReservation(peer lnpeer.Peer,
+	pendingChanID [32]byte) (*reservationWithCtx, error) {
+
+	peerKey := peer.IdentityKey()
+	res, err := f.fetchPendingReservation(peerKey, pendingChanID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whether or not we manage to resume it, the reservation won't need
+	// to be resumed again.
+	err = f.deletePendingReservation(peerKey, pendingChanID)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.pendingReservationExpired(res) {
+		return nil, fmt.Errorf("reservation expired at %v",
+			res.createdAt.Add(f.cfg.ReservationTimeout))
+	}
+
+	log.Infof("Resuming funding reservation for pending_id(%x) with "+
+		"peer %x", pendingChanID[:], peerKey.SerializeCompressed())
+
+	// The funding output was created outside of lnd, so we'll use the
+	// same external funding shim to fund the resumed reservation.
+	openMsg := res.openMsg
+	chanFunder := chanfunding.NewCannedAssembler(
+		res.thawHeight, res.chanPoint, openMsg.FundingAmount,
+		&res.localFundingKey, res.remoteFundingKey, true,
+	)
+	req := &lnwallet.InitFundingReserveMsg{
+		ChainHash:        &openMsg.ChainHash,
+		PendingChanID:    pendingChanID,
+		NodeID:           peerKey,
+		NodeAddr:         peer.Address(),
+		LocalFundingAmt:  openMsg.FundingAmount,
+		RemoteFundingAmt: 0,
+		CommitFeePerKw: chainfee.SatPerKWeight(
+			openMsg.FeePerKiloWeight,
+		),
+		FundingFeePerKw: res.fundingFeePerKw,
+		PushMSat:        openMsg.PushAmount,
+		Flags:           openMsg.ChannelFlags,
+		MinConfs:        res.minConfs,
+		CommitType:      res.commitType,
+		ChanFunder:      chanFunder,
+		ResumeKeys:      &res.keys,
+	}
+	reservation, err := f.cfg.Wallet.InitChannelReservation(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the resumed reservation is the one the remote party
+	// knows about, as it would otherwise be unable to verify our
+	// signatures.
+	ourContribution := reservation.OurContribution()
+	switch {
+	case reservation.Capacity() != openMsg.FundingAmount:
+		err = fmt.Errorf("resumed capacity %v doesn't match %v",
+			reservation.Capacity(), openMsg.FundingAmount)
+
+	case !ourContribution.MultiSigKey.PubKey.IsEqual(openMsg.FundingKey),
+		!ourContribution.RevocationBasePoint.PubKey.IsEqual(
+			openMsg.RevocationPoint,
+		),
+		!ourContribution.PaymentBasePoint.PubKey.IsEqual(
+			openMsg.PaymentPoint,
+		),
+		!ourContribution.DelayBasePoint.PubKey.IsEqual(
+			openMsg.DelayedPaymentPoint,
+		),
+		!ourContribution.HtlcBasePoint.PubKey.IsEqual(
+			openMsg.HtlcPoint,
+		),
+		!ourContribution.FirstCommitmentPoint.IsEqual(
+			openMsg.FirstCommitmentPoint,
+		):
+
+		err = errors.New("resumed keys don't match the keys sent to " +
+			"the peer")
+	}
+	if err != nil {
+		if cancelErr := reservation.Cancel(); cancelErr != nil {
+			log.Errorf("Unable to cancel resumed reservation: %v",
+				cancelErr)
+		}
+
+		return nil, err
+	}
+
+	reservation.SetOurUpfrontShutdown(openMsg.UpfrontShutdownScript)
+
+	// As the original caller of the funding flow is gone, nobody will be
+	// listening for updates, so we'll buffer the few the flow sends.
+	resCtx := &reservationWithCtx{
+		reservation:    reservation,
+		peer:           peer,
+		chanAmt:        openMsg.FundingAmount,
+		commitType:     res.commitType,
+		remoteCsvDelay: openMsg.CsvDelay,
+		remoteMinHtlc:  openMsg.HtlcMinimum,
+		remoteMaxValue: openMsg.MaxValueInFlight,
+		remoteMaxHtlcs: openMsg.MaxAcceptedHTLCs,
+		maxLocalCsv:    res.maxLocalCsv,
+		updates:        make(chan *lnrpc.OpenStatusUpdate, 2),
+		err:            make(chan error, 1),
+	}
+
+	peerIDKey := newSerializedKey(peerKey)
+	f.resMtx.Lock()
+	if _, ok := f.activeReservations[peerIDKey]; !ok {
+		f.activeReservations[peerIDKey] = make(pendingChannels)
+	}
+	f.activeReservations[peerIDKey][pendingChanID] = resCtx
+	f.resMtx.Unlock()
+
+	return resCtx, nil
+}
@@ -0,0 +1,40 @@
+package funding
+
+import (
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// revalidatePendingChannel validates the AcceptChannel we received for the
+// given pending channel again, as validation rules introduced since it was
+// accepted may reject it. A channel failing validation is only flagged, by
+// logging an error and incrementing the pendingChannelsInvalid counter. Its
+// funding flow is resumed nonetheless, as the funding transaction may already
+// be published, leaving it to the operator to abandon the channel.
+//
+// Channels the remote party initiated are skipped, as the only AcceptChannel
+// of those is the one we sent.
+func (f *Manager) revalidatePendingChannel(channel *channeldb.OpenChannel) {
+	if !channel.IsInitiator {
+		return
+	}
+
+	accept, err := channel.RebuildReceivedAcceptChannel()
+	if err != nil {
+		log.Errorf("Unable to rebuild AcceptChannel of pending "+
+			"ChannelPoint(%v): %v", channel.FundingOutpoint, err)
+		return
+	}
+
+	validate := f.cfg.RevalidateAcceptChannel
+	if validate == nil {
+		validate = (*lnwire.AcceptChannel).Validate
+	}
+
+	if err := validate(accept); err != nil {
+		log.Errorf("AcceptChannel received for pending ChannelPoint(%v) "+
+			"is no longer valid, the channel may have to be "+
+			"abandoned: %v", channel.FundingOutpoint, err)
+		pendingChannelsInvalid.Inc()
+	}
+}
@@ -0,0 +1,110 @@
+package funding
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/lnpeer"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// OpenChannelSimulation is the outcome of a dry-run of the funding flow. It
+// describes the channel that would result from funding it with the given
+// parameters, without reserving any coins or contacting the peer.
+type OpenChannelSimulation struct {
+	// CommitType is the commitment type that would be negotiated with the
+	// peer given the features both sides currently signal.
+	CommitType lnwallet.CommitmentType
+
+	// CommitFeePerKw is the fee rate of the initial commitment
+	// transaction.
+	CommitFeePerKw chainfee.SatPerKWeight
+
+	// CommitFee is the total amount the initiator pays for the initial
+	// commitment transaction, including the value of any anchor outputs.
+	CommitFee btcutil.Amount
+
+	// ChanReserve is the channel reserve each side is expected to
+	// maintain. If the peer accepted one of our channels since we
+	// started, it is previewed from the policy it applied then.
+	// Otherwise, it is estimated using our own ReservePolicy.
+	ChanReserve btcutil.Amount
+
+	// MinAcceptDepth is the number of confirmations expected before the
+	// channel can be used. As with the reserve, it is taken from the
+	// peer's accept policy if known, and estimated using our own
+	// NumRequiredConfs policy otherwise.
+	MinAcceptDepth uint16
+
+	// LocalBalance is our balance in the initial commitment state.
+	LocalBalance lnwire.MilliSatoshi
+
+	// RemoteBalance is the peer's balance in the initial commitment state.
+	RemoteBalance lnwire.MilliSatoshi
+}
+
+// SimulateOpenChannel previews the outcome of funding a single funder channel
+// of localAmt with the given peer, pushing pushAmt to them. Nothing is
+// broadcast or reserved, and no messages are sent to the peer. The same
+// validation applied to the initial balances of a real reservation is
+// applied here, such that a simulation that succeeds describes a channel
+// that can actually be funded at the current fee rate.
+func (f *Manager) SimulateOpenChannel(peer lnpeer.Peer,
+	localAmt btcutil.Amount,
+	pushAmt lnwire.MilliSatoshi) (*OpenChannelSimulation, error) {
+
+	capacityMSat := lnwire.NewMSatFromSatoshis(localAmt)
+	if pushAmt > capacityMSat {
+		return nil, fmt.Errorf("push amount %v exceeds channel "+
+			"capacity %v", pushAmt, capacityMSat)
+	}
+
+	commitType := commitmentType(
+		peer.LocalFeatures(), peer.RemoteFeatures(),
+	)
+
+	commitFeePerKw, err := f.initialCommitFeeRate(commitType)
+	if err != nil {
+		return nil, err
+	}
+
+	// As the initiator, we pay for the commitment transaction in addition
+	// to the two anchor outputs, if any.
+	commitFee := lnwallet.InitiatorCommitFee(commitType, commitFeePerKw)
+	feeMSat := lnwire.NewMSatFromSatoshis(commitFee)
+
+	// Just like a real reservation, we'll reject the channel if our
+	// starting balance after paying the fees and the push amount would be
+	// below 2x the dust limit.
+	dustLimit := f.localDustLimit()
+	localBalance := int64(capacityMSat) - int64(feeMSat) - int64(pushAmt)
+	if localBalance/1000 <= int64(2*dustLimit) {
+		return nil, lnwallet.ErrFunderBalanceDust(
+			int64(commitFee), localBalance/1000,
+			int64(2*dustLimit),
+		)
+	}
+
+	chanReserve := f.requiredRemoteChanReserve(localAmt, dustLimit)
+	minAcceptDepth := f.cfg.NumRequiredConfs(localAmt, pushAmt)
+
+	// If the peer accepted one of our channels before, the constraints it
+	// applied then are a better estimate than our own policy.
+	if policy, ok := f.AcceptPolicy(peer.IdentityKey()); ok {
+		preview := policy.PreviewAccept(localAmt)
+		chanReserve = preview.ChannelReserve
+		minAcceptDepth = uint16(preview.MinAcceptDepth)
+	}
+
+	return &OpenChannelSimulation{
+		CommitType:     commitType,
+		CommitFeePerKw: commitFeePerKw,
+		CommitFee:      commitFee,
+		ChanReserve:    chanReserve,
+		MinAcceptDepth: minAcceptDepth,
+		LocalBalance:   lnwire.MilliSatoshi(localBalance),
+		RemoteBalance:  pushAmt,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package funding
+
+import (
+	"github.com/lightningnetwork/lnd/lnpeer"
+	"github.com/lightningnetwork/lnd/lnwallet"
+)
+
+// Severity is the severity of a failed AcceptChannel check, which determines
+// whether the funding flow continues.
+type Severity uint8
+
+const (
+	// SeverityReject causes the AcceptChannel to be rejected.
+	SeverityReject Severity = iota
+
+	// SeverityWarn causes the failed check to be logged, while the funding
+	// flow continues.
+	SeverityWarn
+)
+
+// String returns a human readable representation of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityReject:
+		return "reject"
+
+	case SeverityWarn:
+		return "warn"
+
+	default:
+		return "unknown"
+	}
+}
+
+// ValidationResult is the outcome of a failed AcceptChannel check.
+type ValidationResult struct {
+	// Severity is the severity the policy assigned to the failed check.
+	Severity Severity
+
+	// Reason is the reason label of the failed check.
+	Reason string
+
+	// Err is the error the check failed with.
+	Err error
+}
+
+// SeverityPolicy maps the reason labels of failed AcceptChannel checks to
+// the severity they are treated with. Checks whose reason isn't mapped are
+// treated with their default severity, which is SeverityReject unless set in
+// defaultSeverities.
+//
+// NOTE: Only the checks of our own policy may be downgraded, see
+// warnableReasons. Checks guarding the safety of the channel always reject
+// the AcceptChannel.
+type SeverityPolicy map[string]Severity
+
+// warnableReasons is the set of reason labels of the checks a SeverityPolicy
+// may downgrade to a warning. These enforce our own preferences, rather than
+// rules of the protocol or the safety of the channel.
+var warnableReasons = map[string]struct{}{
+	string(lnwallet.ReasonUpfrontShutdownRequired): {},
+	string(lnwallet.ReasonMaxHtlcsTooLow):          {},
+	string(lnwallet.ReasonMaxCommitFeeTooHigh):     {},
+	rejectReasonDustLimitBelowScript:               {},
+	rejectReasonMaxValueInFlight:                   {},
+	rejectReasonFeeRateRange:                       {},
+	rejectReasonReserveAsymmetry:                   {},
+	rejectReasonCommitPointReuse:                   {},
+	rejectReasonAnchorReserve:                      {},
+}
+
+// defaultSeverities holds the severity of the warnable checks that are only
+// warned about unless a SeverityPolicy maps them. The anchor reserve check
+// is among them, as lnd itself requires a reserve of 1% of the capacity,
+// which doesn't cover the dust limit and both anchor outputs for small
+// channels.
+var defaultSeverities = map[string]Severity{
+	rejectReasonAnchorReserve: SeverityWarn,
+}
+
+// Evaluate returns the result of a check that failed with the given reason
+// and error.
+func (p SeverityPolicy) Evaluate(reason string, err error) ValidationResult {
+	severity := SeverityReject
+	if _, ok := warnableReasons[reason]; ok {
+		if s, ok := defaultSeverities[reason]; ok {
+			severity = s
+		}
+		if s, ok := p[reason]; ok {
+			severity = s
+		}
+	}
+
+	return ValidationResult{
+		Severity: severity,
+		Reason:   reason,
+		Err:      err,
+	}
+}
+
+// acceptCheckFailed handles an AcceptChannel check that failed with the given
+// reason and error, according to our SeverityPolicy. If the failure is only
+// warned about, true is returned and the funding flow may continue.
+// Otherwise, the AcceptChannel is rejected and false is returned.
+func (f *Manager) acceptCheckFailed(peer lnpeer.Peer, pendingChanID [32]byte,
+	reason string, err error) bool {
+
+	result := f.cfg.SeverityPolicy.Evaluate(reason, err)
+	if result.Severity == SeverityWarn {
+		log.Warnf("Accepting AcceptChannel for pending_id(%x) "+
+			"despite failed check (%v): %v", pendingChanID[:],
+			result.Reason, result.Err)
+		return true
+	}
+
+	log.Warnf("Unacceptable AcceptChannel for pending_id(%x): %v",
+		pendingChanID[:], result.Err)
+	f.rejectAccept(peer, pendingChanID, result.Reason, result.Err)
+
+	return false
+}
@@ -267,6 +267,13 @@ type ChannelLinkConfig struct {
 	// current block height.
 	MaxOutgoingCltvExpiry uint32
 
+	// MaxHtlcExpiryDelta is the maximum number of blocks the expiry of an
+	// HTLC offered on this channel may lie beyond the current block
+	// height, as negotiated with the remote party during funding. A value
+	// of zero means the channel doesn't bound the expiry beyond
+	// MaxOutgoingCltvExpiry.
+	MaxHtlcExpiryDelta uint32
+
 	// MaxFeeAllocation is the highest allocation we'll allow a channel's
 	// commitment fee to be of its balance. This only applies to the
 	// initiator of the channel.
@@ -2328,6 +2335,26 @@ func (l *channelLink) canSendHtlc(policy ForwardingPolicy,
 		return NewDetailedLinkError(failure, OutgoingFailureHTLCExceedsMax)
 	}
 
+	// Ensure that the HTLC won't push the value we have in flight past the
+	// maximum our peer allows, as negotiated during funding. Checking this
+	// here lets us fail the HTLC right away, instead of only once the
+	// channel state machine refuses to add it.
+	availableInFlight := l.channel.AvailableInFlight()
+	if amt > availableInFlight {
+		l.log.Warnf("outgoing htlc(%x) exceeds max value in flight: "+
+			"available=%v, htlc_value=%v", payHash[:],
+			availableInFlight, amt)
+
+		failure := l.createFailureWithUpdate(
+			func(upd *lnwire.ChannelUpdate) lnwire.FailureMessage {
+				return lnwire.NewTemporaryChannelFailure(upd)
+			},
+		)
+		return NewDetailedLinkError(
+			failure, OutgoingFailureInsufficientBalance,
+		)
+	}
+
 	// We want to avoid offering an HTLC which will expire in the near
 	// future, so we'll reject an HTLC if the outgoing expiration time is
 	// too close to the current height.
@@ -2352,6 +2379,17 @@ func (l *channelLink) canSendHtlc(policy ForwardingPolicy,
 		return NewLinkError(&lnwire.FailExpiryTooFar{})
 	}
 
+	// Check the max delta negotiated with the remote party, which bounds
+	// how long a force close can keep the HTLC locked up.
+	maxDelta := l.cfg.MaxHtlcExpiryDelta
+	if maxDelta != 0 && timeout > maxDelta+heightNow {
+		l.log.Warnf("outgoing htlc(%x) exceeds the negotiated max "+
+			"expiry delta: got %v, but maximum is %v", payHash[:],
+			timeout-heightNow, maxDelta)
+
+		return NewLinkError(&lnwire.FailExpiryTooFar{})
+	}
+
 	// Check to see if there is enough balance in this channel.
 	if amt > l.Bandwidth() {
 		l.log.Warnf("insufficient bandwidth to route htlc: %v is "+
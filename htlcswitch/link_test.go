@@ -5807,6 +5807,150 @@ func TestCheckHtlcForward(t *testing.T) {
 	})
 }
 
+// TestCheckHtlcForwardMaxValueInFlight tests that the link refuses to forward
+// an HTLC that would push the value in flight past the maximum negotiated with
+// the remote party.
+func TestCheckHtlcForwardMaxValueInFlight(t *testing.T) {
+	fetchLastChannelUpdate := func(lnwire.ShortChannelID) (
+		*lnwire.ChannelUpdate, error) {
+
+		return &lnwire.ChannelUpdate{}, nil
+	}
+
+	testChannel, _, fCleanUp, err := createTestChannel(
+		alicePrivKey, bobPrivKey, 100000, 100000,
+		1000, 1000, lnwire.ShortChannelID{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fCleanUp()
+
+	// Lower the max value in flight the remote party allows us, such that
+	// only a couple of HTLCs fit.
+	const maxInFlight = lnwire.MilliSatoshi(2000)
+	testChannel.channel.State().LocalChanCfg.MaxPendingAmount = maxInFlight
+
+	link := channelLink{
+		cfg: ChannelLinkConfig{
+			FwrdingPolicy: ForwardingPolicy{
+				TimeLockDelta: 20,
+				MinHTLCOut:    500,
+				MaxHTLC:       1000,
+				BaseFee:       10,
+			},
+			FetchLastChannelUpdate: fetchLastChannelUpdate,
+			MaxOutgoingCltvExpiry:  DefaultMaxOutgoingCltvExpiry,
+			HtlcNotifier:           &mockHTLCNotifier{},
+		},
+		log:     log,
+		channel: testChannel.channel,
+	}
+
+	var hash [32]byte
+
+	// With nothing in flight, an HTLC within the limit is accepted.
+	result := link.CheckHtlcForward(hash, 1500, 1000, 200, 150, 0)
+	if result != nil {
+		t.Fatalf("expected policy to be satisfied, got: %v", result)
+	}
+
+	// Add an HTLC to the channel, leaving less in flight capacity than
+	// the next HTLC requires.
+	htlc := &lnwire.UpdateAddHTLC{
+		Amount: 1500,
+		Expiry: 200,
+	}
+	if _, err := testChannel.channel.AddHTLC(htlc, nil); err != nil {
+		t.Fatalf("unable to add htlc: %v", err)
+	}
+
+	if avail := testChannel.channel.AvailableInFlight(); avail != 500 {
+		t.Fatalf("expected 500 msat available in flight, got %v",
+			avail)
+	}
+
+	result = link.CheckHtlcForward(hash, 1500, 1000, 200, 150, 0)
+	if result == nil {
+		t.Fatalf("expected htlc to exceed max value in flight")
+	}
+	if _, ok := result.WireMessage().(*lnwire.FailTemporaryChannelFailure); !ok {
+		t.Fatalf("expected FailTemporaryChannelFailure failure code")
+	}
+	if result.FailureDetail != OutgoingFailureInsufficientBalance {
+		t.Fatalf("expected insufficient balance detail, got: %v",
+			result.FailureDetail)
+	}
+
+	// A smaller HTLC still fits within the limit.
+	result = link.CheckHtlcForward(hash, 1010, 500, 200, 150, 0)
+	if result != nil {
+		t.Fatalf("expected policy to be satisfied, got: %v", result)
+	}
+}
+
+// TestCheckHtlcForwardMaxHtlcExpiryDelta tests that the link refuses to
+// forward an HTLC whose expiry lies further in the future than the max expiry
+// delta negotiated with the remote party.
+func TestCheckHtlcForwardMaxHtlcExpiryDelta(t *testing.T) {
+	fetchLastChannelUpdate := func(lnwire.ShortChannelID) (
+		*lnwire.ChannelUpdate, error) {
+
+		return &lnwire.ChannelUpdate{}, nil
+	}
+
+	testChannel, _, fCleanUp, err := createTestChannel(
+		alicePrivKey, bobPrivKey, 100000, 100000,
+		1000, 1000, lnwire.ShortChannelID{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fCleanUp()
+
+	link := channelLink{
+		cfg: ChannelLinkConfig{
+			FwrdingPolicy: ForwardingPolicy{
+				TimeLockDelta: 20,
+				MinHTLCOut:    500,
+				MaxHTLC:       1000,
+				BaseFee:       10,
+			},
+			FetchLastChannelUpdate: fetchLastChannelUpdate,
+			MaxOutgoingCltvExpiry:  DefaultMaxOutgoingCltvExpiry,
+			MaxHtlcExpiryDelta:     150,
+			HtlcNotifier:           &mockHTLCNotifier{},
+		},
+		log:     log,
+		channel: testChannel.channel,
+	}
+
+	var hash [32]byte
+
+	// An HTLC expiring exactly at the bound is accepted.
+	result := link.CheckHtlcForward(hash, 1500, 1000, 270, 250, 100)
+	if result != nil {
+		t.Fatalf("expected policy to be satisfied, got: %v", result)
+	}
+
+	// An HTLC expiring a block beyond the bound is failed, although it
+	// is well within the MaxOutgoingCltvExpiry.
+	result = link.CheckHtlcForward(hash, 1500, 1000, 271, 251, 100)
+	if result == nil {
+		t.Fatalf("expected htlc to exceed max expiry delta")
+	}
+	if _, ok := result.WireMessage().(*lnwire.FailExpiryTooFar); !ok {
+		t.Fatalf("expected FailExpiryTooFar failure code")
+	}
+
+	// Without a negotiated bound, the same HTLC is accepted.
+	link.cfg.MaxHtlcExpiryDelta = 0
+	result = link.CheckHtlcForward(hash, 1500, 1000, 271, 251, 100)
+	if result != nil {
+		t.Fatalf("expected policy to be satisfied, got: %v", result)
+	}
+}
+
 // TestChannelLinkCanceledInvoice in this test checks the interaction
 // between Alice and Bob for a canceled invoice.
 func TestChannelLinkCanceledInvoice(t *testing.T) {
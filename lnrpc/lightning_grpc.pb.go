@@ -154,6 +154,32 @@ type LightningClient interface {
 	//funding for partially complete funding transactions.
 	FundingStateStep(ctx context.Context, in *FundingTransitionMsg, opts ...grpc.CallOption) (*FundingStateStepResp, error)
 	//
+	//AbortFunding aborts a pending funding negotiation with a remote peer that
+	//has accepted the channel, but for which the FundingCreated message hasn't
+	//been sent yet. The reservation of the channel is canceled, the coins locked
+	//for it are released, and the remote peer is notified of the cancellation.
+	AbortFunding(ctx context.Context, in *AbortFundingRequest, opts ...grpc.CallOption) (*AbortFundingResponse, error)
+	//
+	//SimulateOpenChannel previews the channel that would result from opening a
+	//channel with a connected peer, without broadcasting anything, reserving any
+	//coins or sending any messages to the peer. It returns the negotiated
+	//commitment type, the commitment fee, the expected channel reserve and
+	//number of confirmations, and the initial balances of both sides.
+	SimulateOpenChannel(ctx context.Context, in *SimulateOpenChannelRequest, opts ...grpc.CallOption) (*SimulateOpenChannelResponse, error)
+	//
+	//lncli: `diagnoseacceptchannel`
+	//DiagnoseAcceptChannel estimates the cost of force closing a channel we
+	//initiated at the current fee rate, based on the parameters the remote peer
+	//set in its AcceptChannel message, and reports whether that cost exceeds the
+	//channel reserve the peer requires us to keep.
+	DiagnoseAcceptChannel(ctx context.Context, in *DiagnoseAcceptChannelRequest, opts ...grpc.CallOption) (*DiagnoseAcceptChannelResponse, error)
+	//
+	//lncli: `listpendingnegotiations`
+	//ListPendingNegotiations returns the funding negotiations that are still in
+	//progress. As opposed to the channels returned by PendingChannels, no
+	//funding transaction exists yet for these.
+	ListPendingNegotiations(ctx context.Context, in *ListPendingNegotiationsRequest, opts ...grpc.CallOption) (*ListPendingNegotiationsResponse, error)
+	//
 	//ChannelAcceptor dispatches a bi-directional streaming RPC in which
 	//OpenChannel requests are sent to the client and the client responds with
 	//a boolean that tells LND whether or not to accept the channel. This allows
@@ -690,6 +716,42 @@ func (c *lightningClient) FundingStateStep(ctx context.Context, in *FundingTrans
 	return out, nil
 }
 
+func (c *lightningClient) AbortFunding(ctx context.Context, in *AbortFundingRequest, opts ...grpc.CallOption) (*AbortFundingResponse, error) {
+	out := new(AbortFundingResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/AbortFunding", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) SimulateOpenChannel(ctx context.Context, in *SimulateOpenChannelRequest, opts ...grpc.CallOption) (*SimulateOpenChannelResponse, error) {
+	out := new(SimulateOpenChannelResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/SimulateOpenChannel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) DiagnoseAcceptChannel(ctx context.Context, in *DiagnoseAcceptChannelRequest, opts ...grpc.CallOption) (*DiagnoseAcceptChannelResponse, error) {
+	out := new(DiagnoseAcceptChannelResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/DiagnoseAcceptChannel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) ListPendingNegotiations(ctx context.Context, in *ListPendingNegotiationsRequest, opts ...grpc.CallOption) (*ListPendingNegotiationsResponse, error) {
+	out := new(ListPendingNegotiationsResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/ListPendingNegotiations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lightningClient) ChannelAcceptor(ctx context.Context, opts ...grpc.CallOption) (Lightning_ChannelAcceptorClient, error) {
 	stream, err := c.cc.NewStream(ctx, &Lightning_ServiceDesc.Streams[4], "/lnrpc.Lightning/ChannelAcceptor", opts...)
 	if err != nil {
@@ -1305,6 +1367,32 @@ type LightningServer interface {
 	//funding for partially complete funding transactions.
 	FundingStateStep(context.Context, *FundingTransitionMsg) (*FundingStateStepResp, error)
 	//
+	//AbortFunding aborts a pending funding negotiation with a remote peer that
+	//has accepted the channel, but for which the FundingCreated message hasn't
+	//been sent yet. The reservation of the channel is canceled, the coins locked
+	//for it are released, and the remote peer is notified of the cancellation.
+	AbortFunding(context.Context, *AbortFundingRequest) (*AbortFundingResponse, error)
+	//
+	//SimulateOpenChannel previews the channel that would result from opening a
+	//channel with a connected peer, without broadcasting anything, reserving any
+	//coins or sending any messages to the peer. It returns the negotiated
+	//commitment type, the commitment fee, the expected channel reserve and
+	//number of confirmations, and the initial balances of both sides.
+	SimulateOpenChannel(context.Context, *SimulateOpenChannelRequest) (*SimulateOpenChannelResponse, error)
+	//
+	//lncli: `diagnoseacceptchannel`
+	//DiagnoseAcceptChannel estimates the cost of force closing a channel we
+	//initiated at the current fee rate, based on the parameters the remote peer
+	//set in its AcceptChannel message, and reports whether that cost exceeds the
+	//channel reserve the peer requires us to keep.
+	DiagnoseAcceptChannel(context.Context, *DiagnoseAcceptChannelRequest) (*DiagnoseAcceptChannelResponse, error)
+	//
+	//lncli: `listpendingnegotiations`
+	//ListPendingNegotiations returns the funding negotiations that are still in
+	//progress. As opposed to the channels returned by PendingChannels, no
+	//funding transaction exists yet for these.
+	ListPendingNegotiations(context.Context, *ListPendingNegotiationsRequest) (*ListPendingNegotiationsResponse, error)
+	//
 	//ChannelAcceptor dispatches a bi-directional streaming RPC in which
 	//OpenChannel requests are sent to the client and the client responds with
 	//a boolean that tells LND whether or not to accept the channel. This allows
@@ -1602,6 +1690,18 @@ func (UnimplementedLightningServer) OpenChannel(*OpenChannelRequest, Lightning_O
 func (UnimplementedLightningServer) FundingStateStep(context.Context, *FundingTransitionMsg) (*FundingStateStepResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FundingStateStep not implemented")
 }
+func (UnimplementedLightningServer) AbortFunding(context.Context, *AbortFundingRequest) (*AbortFundingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortFunding not implemented")
+}
+func (UnimplementedLightningServer) SimulateOpenChannel(context.Context, *SimulateOpenChannelRequest) (*SimulateOpenChannelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SimulateOpenChannel not implemented")
+}
+func (UnimplementedLightningServer) DiagnoseAcceptChannel(context.Context, *DiagnoseAcceptChannelRequest) (*DiagnoseAcceptChannelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiagnoseAcceptChannel not implemented")
+}
+func (UnimplementedLightningServer) ListPendingNegotiations(context.Context, *ListPendingNegotiationsRequest) (*ListPendingNegotiationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPendingNegotiations not implemented")
+}
 func (UnimplementedLightningServer) ChannelAcceptor(Lightning_ChannelAcceptorServer) error {
 	return status.Errorf(codes.Unimplemented, "method ChannelAcceptor not implemented")
 }
@@ -2164,6 +2264,78 @@ func _Lightning_FundingStateStep_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Lightning_AbortFunding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortFundingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).AbortFunding(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/AbortFunding",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).AbortFunding(ctx, req.(*AbortFundingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_SimulateOpenChannel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimulateOpenChannelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).SimulateOpenChannel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/SimulateOpenChannel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).SimulateOpenChannel(ctx, req.(*SimulateOpenChannelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_DiagnoseAcceptChannel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiagnoseAcceptChannelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).DiagnoseAcceptChannel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/DiagnoseAcceptChannel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).DiagnoseAcceptChannel(ctx, req.(*DiagnoseAcceptChannelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_ListPendingNegotiations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingNegotiationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).ListPendingNegotiations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/ListPendingNegotiations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).ListPendingNegotiations(ctx, req.(*ListPendingNegotiationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Lightning_ChannelAcceptor_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(LightningServer).ChannelAcceptor(&lightningChannelAcceptorServer{stream})
 }
@@ -2917,6 +3089,22 @@ var Lightning_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "FundingStateStep",
 			Handler:    _Lightning_FundingStateStep_Handler,
 		},
+		{
+			MethodName: "AbortFunding",
+			Handler:    _Lightning_AbortFunding_Handler,
+		},
+		{
+			MethodName: "SimulateOpenChannel",
+			Handler:    _Lightning_SimulateOpenChannel_Handler,
+		},
+		{
+			MethodName: "DiagnoseAcceptChannel",
+			Handler:    _Lightning_DiagnoseAcceptChannel_Handler,
+		},
+		{
+			MethodName: "ListPendingNegotiations",
+			Handler:    _Lightning_ListPendingNegotiations_Handler,
+		},
 		{
 			MethodName: "AbandonChannel",
 			Handler:    _Lightning_AbandonChannel_Handler,
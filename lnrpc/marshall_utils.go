@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"errors"
 	fmt "fmt"
+	"math"
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
@@ -60,6 +61,48 @@ func UnmarshallAmt(amtSat, amtMsat int64) (lnwire.MilliSatoshi, error) {
 	return lnwire.MilliSatoshi(amtMsat), nil
 }
 
+// MarshalAcceptChannelParams translates the parameters of an AcceptChannel
+// message into an *AcceptChannelParams.
+func MarshalAcceptChannelParams(
+	msg *lnwire.AcceptChannel) *AcceptChannelParams {
+
+	return &AcceptChannelParams{
+		ChanReserveSat:   uint64(msg.ChannelReserve),
+		CsvDelay:         uint32(msg.CsvDelay),
+		DustLimitSat:     uint64(msg.DustLimit),
+		MinHtlcMsat:      uint64(msg.HtlcMinimum),
+		MaxAcceptedHtlcs: uint32(msg.MaxAcceptedHTLCs),
+		MinAcceptDepth:   msg.MinAcceptDepth,
+	}
+}
+
+// UnmarshalAcceptChannelParams translates an *AcceptChannelParams into an
+// AcceptChannel message that carries only the parameters, and none of the
+// keys or other fields of the message.
+func UnmarshalAcceptChannelParams(
+	params *AcceptChannelParams) (*lnwire.AcceptChannel, error) {
+
+	if params.CsvDelay > math.MaxUint16 {
+		return nil, fmt.Errorf("csv delay of %v exceeds maximum of %v",
+			params.CsvDelay, math.MaxUint16)
+	}
+
+	if params.MaxAcceptedHtlcs > math.MaxUint16 {
+		return nil, fmt.Errorf("max accepted htlcs of %v exceeds "+
+			"maximum of %v", params.MaxAcceptedHtlcs,
+			math.MaxUint16)
+	}
+
+	return &lnwire.AcceptChannel{
+		ChannelReserve:   btcutil.Amount(params.ChanReserveSat),
+		CsvDelay:         uint16(params.CsvDelay),
+		DustLimit:        btcutil.Amount(params.DustLimitSat),
+		HtlcMinimum:      lnwire.MilliSatoshi(params.MinHtlcMsat),
+		MaxAcceptedHTLCs: uint16(params.MaxAcceptedHtlcs),
+		MinAcceptDepth:   params.MinAcceptDepth,
+	}, nil
+}
+
 // ParseConfs validates the minimum and maximum confirmation arguments of a
 // ListUnspent request.
 func ParseConfs(min, max int32) (int32, int32, error) {
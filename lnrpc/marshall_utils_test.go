@@ -0,0 +1,63 @@
+package lnrpc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptChannelParamsRoundTrip asserts that the parameters of an
+// AcceptChannel survive the mapping to and from the rpc message unchanged.
+// As every parameter is set to a distinct non-zero value, a field added to the
+// rpc message without being mapped is caught as well.
+func TestAcceptChannelParamsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	msg := &lnwire.AcceptChannel{
+		ChannelReserve:   10000,
+		CsvDelay:         144,
+		DustLimit:        573,
+		HtlcMinimum:      1001,
+		MaxAcceptedHTLCs: 483,
+		MinAcceptDepth:   3,
+	}
+
+	params := MarshalAcceptChannelParams(msg)
+
+	fields := params.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		require.Truef(
+			t, params.ProtoReflect().Has(field),
+			"field %v not mapped", field.Name(),
+		)
+	}
+
+	decoded, err := UnmarshalAcceptChannelParams(params)
+	require.NoError(t, err)
+	require.Equal(t, msg, decoded)
+}
+
+// TestUnmarshalAcceptChannelParamsOverflow asserts that rpc values that don't
+// fit into the fields of an AcceptChannel are rejected rather than truncated.
+func TestUnmarshalAcceptChannelParamsOverflow(t *testing.T) {
+	t.Parallel()
+
+	_, err := UnmarshalAcceptChannelParams(&AcceptChannelParams{
+		CsvDelay: math.MaxUint16 + 1,
+	})
+	require.Error(t, err)
+
+	_, err = UnmarshalAcceptChannelParams(&AcceptChannelParams{
+		MaxAcceptedHtlcs: math.MaxUint16 + 1,
+	})
+	require.Error(t, err)
+
+	_, err = UnmarshalAcceptChannelParams(&AcceptChannelParams{
+		CsvDelay:         math.MaxUint16,
+		MaxAcceptedHtlcs: math.MaxUint16,
+	})
+	require.NoError(t, err)
+}
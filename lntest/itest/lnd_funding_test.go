@@ -375,6 +375,164 @@ func testUnconfirmedChannelFunding(net *lntest.NetworkHarness, t *harnessTest) {
 	closeChannelAndAssert(t, net, carol, chanPoint, false)
 }
 
+// testSimulateOpenChannel tests that simulating a channel open previews the
+// channel that results from actually opening it, without leaving a pending
+// channel behind.
+func testSimulateOpenChannel(net *lntest.NetworkHarness, t *harnessTest) {
+	ctxb := context.Background()
+
+	const (
+		chanAmt = funding.MaxBtcFundingAmount
+		pushAmt = btcutil.Amount(100000)
+	)
+
+	carol := net.NewNode(t.t, "Carol", nil)
+	defer shutdownAndAssert(net, t, carol)
+
+	ctxt, cancel := context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	net.SendCoins(ctxt, t.t, btcutil.SatoshiPerBitcoin, carol)
+
+	dave := net.NewNode(t.t, "Dave", nil)
+	defer shutdownAndAssert(net, t, dave)
+
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	net.ConnectNodes(ctxt, t.t, carol, dave)
+
+	// Carol simulates opening the channel with Dave first.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	sim, err := carol.SimulateOpenChannel(
+		ctxt, &lnrpc.SimulateOpenChannelRequest{
+			NodePubkey:         dave.PubKey[:],
+			LocalFundingAmount: int64(chanAmt),
+			PushSat:            int64(pushAmt),
+		},
+	)
+	require.NoError(t.t, err, "unable to simulate channel open")
+	require.EqualValues(t.t, pushAmt, sim.RemoteBalance)
+	require.EqualValues(
+		t.t, chanAmt-pushAmt-btcutil.Amount(sim.CommitFee),
+		sim.LocalBalance,
+	)
+
+	// Nothing is reserved by the simulation, so no pending channel shows
+	// up on either side.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	assertNumOpenChannelsPending(ctxt, t, carol, dave, 0)
+
+	// A simulation with a peer that isn't connected fails.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	_, err = carol.SimulateOpenChannel(
+		ctxt, &lnrpc.SimulateOpenChannelRequest{
+			NodePubkey:         net.Bob.PubKey[:],
+			LocalFundingAmount: int64(chanAmt),
+		},
+	)
+	require.Error(t.t, err)
+
+	// Now Carol actually opens the channel, which should match the
+	// simulation.
+	ctxt, cancel = context.WithTimeout(ctxb, channelOpenTimeout)
+	defer cancel()
+	chanPoint := openChannelAndAssert(
+		ctxt, t, net, carol, dave,
+		lntest.OpenChannelParams{
+			Amt:     chanAmt,
+			PushAmt: pushAmt,
+		},
+	)
+
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	channel, err := getChanInfo(ctxt, carol)
+	require.NoError(t.t, err, "unable to get channel")
+
+	// The commit fee of the simulation includes the value of any anchor
+	// outputs, so it is checked through the balances.
+	require.Equal(t.t, sim.CommitmentType, channel.CommitmentType)
+	require.Equal(t.t, sim.FeePerKw, channel.FeePerKw)
+	require.Equal(t.t, sim.LocalBalance, channel.LocalBalance)
+	require.Equal(t.t, sim.RemoteBalance, channel.RemoteBalance)
+	require.Equal(
+		t.t, sim.ChanReserveSat,
+		channel.RemoteConstraints.ChanReserveSat,
+	)
+
+	closeChannelAndAssert(t, net, carol, chanPoint, false)
+}
+
+// testDiagnoseAcceptChannel tests that the initiator of a channel can diagnose
+// the cost of force closing it, while the responder can't.
+func testDiagnoseAcceptChannel(net *lntest.NetworkHarness, t *harnessTest) {
+	ctxb := context.Background()
+
+	const chanAmt = funding.MaxBtcFundingAmount
+
+	carol := net.NewNode(t.t, "Carol", nil)
+	defer shutdownAndAssert(net, t, carol)
+
+	ctxt, cancel := context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	net.SendCoins(ctxt, t.t, btcutil.SatoshiPerBitcoin, carol)
+
+	dave := net.NewNode(t.t, "Dave", nil)
+	defer shutdownAndAssert(net, t, dave)
+
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	net.ConnectNodes(ctxt, t.t, carol, dave)
+
+	ctxt, cancel = context.WithTimeout(ctxb, channelOpenTimeout)
+	defer cancel()
+	chanPoint := openChannelAndAssert(
+		ctxt, t, net, carol, dave,
+		lntest.OpenChannelParams{
+			Amt: chanAmt,
+		},
+	)
+
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	channel, err := getChanInfo(ctxt, carol)
+	require.NoError(t.t, err, "unable to get channel")
+
+	// Carol initiated the channel, so she can diagnose it based on the
+	// AcceptChannel Dave sent.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	diag, err := carol.DiagnoseAcceptChannel(
+		ctxt, &lnrpc.DiagnoseAcceptChannelRequest{
+			ChannelPoint: chanPoint,
+		},
+	)
+	require.NoError(t.t, err, "unable to diagnose channel")
+	require.EqualValues(t.t, chanAmt, diag.Capacity)
+	require.EqualValues(
+		t.t, channel.LocalConstraints.ChanReserveSat,
+		diag.ChanReserveSat,
+	)
+	require.Equal(
+		t.t, diag.CommitFee+diag.SweepFee, diag.ForceCloseCost,
+	)
+
+	// Dave didn't receive an AcceptChannel for the channel, so he can't
+	// diagnose it.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	_, err = dave.DiagnoseAcceptChannel(
+		ctxt, &lnrpc.DiagnoseAcceptChannelRequest{
+			ChannelPoint: chanPoint,
+		},
+	)
+	require.Error(t.t, err)
+
+	closeChannelAndAssert(t, net, carol, chanPoint, false)
+}
+
 // testexternalfundingchanpoint tests that we're able to carry out a normal
 // channel funding workflow given a channel point that was constructed outside
 // the main daemon.
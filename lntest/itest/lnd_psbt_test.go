@@ -12,6 +12,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
 	"github.com/lightningnetwork/lnd/lntest"
+	"github.com/lightningnetwork/lnd/lntest/wait"
 	"github.com/stretchr/testify/require"
 )
 
@@ -249,6 +250,177 @@ func testPsbtChanFunding(net *lntest.NetworkHarness, t *harnessTest) {
 	closeChannelAndAssert(t, net, carol, chanPoint, false)
 }
 
+// testAbortFunding makes sure a PSBT funding flow that is waiting for the PSBT
+// after the remote peer accepted the channel can be aborted, which fails the
+// flow on both sides.
+func testAbortFunding(net *lntest.NetworkHarness, t *harnessTest) {
+	ctxb := context.Background()
+	const chanSize = funding.MaxBtcFundingAmount
+
+	carol := net.NewNode(t.t, "carol", nil)
+	defer shutdownAndAssert(net, t, carol)
+
+	dave := net.NewNode(t.t, "dave", nil)
+	defer shutdownAndAssert(net, t, dave)
+
+	ctxt, cancel := context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	net.EnsureConnected(ctxt, t.t, carol, dave)
+
+	var pendingChanID [32]byte
+	_, err := rand.Read(pendingChanID[:])
+	require.NoError(t.t, err)
+
+	// Carol opens the channel through a PSBT, so once Dave accepted the
+	// channel, the flow waits for her to fund the PSBT.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	chanUpdates, _, err := openChannelPsbt(
+		ctxt, carol, dave, lntest.OpenChannelParams{
+			Amt: chanSize,
+			FundingShim: &lnrpc.FundingShim{
+				Shim: &lnrpc.FundingShim_PsbtShim{
+					PsbtShim: &lnrpc.PsbtShim{
+						PendingChanId: pendingChanID[:],
+					},
+				},
+			},
+		},
+	)
+	require.NoError(t.t, err)
+
+	// Instead, Carol aborts the funding negotiation, which fails her
+	// OpenChannel stream.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	_, err = carol.AbortFunding(ctxt, &lnrpc.AbortFundingRequest{
+		PendingChanId: pendingChanID[:],
+	})
+	require.NoError(t.t, err)
+
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	_, err = receiveChanUpdate(ctxt, chanUpdates)
+	require.Error(t.t, err)
+	require.Contains(t.t, err.Error(), "funding negotiation aborted")
+
+	// The negotiation is gone, so it can't be aborted twice.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	_, err = carol.AbortFunding(ctxt, &lnrpc.AbortFundingRequest{
+		PendingChanId: pendingChanID[:],
+	})
+	require.Error(t.t, err)
+
+	// Neither side is left with a pending channel.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	assertNumOpenChannelsPending(ctxt, t, carol, dave, 0)
+}
+
+// testListPendingNegotiations makes sure a funding negotiation that doesn't
+// have a funding transaction yet is listed on both sides until it is aborted.
+func testListPendingNegotiations(net *lntest.NetworkHarness, t *harnessTest) {
+	ctxb := context.Background()
+	const chanSize = funding.MaxBtcFundingAmount
+
+	carol := net.NewNode(t.t, "carol", nil)
+	defer shutdownAndAssert(net, t, carol)
+
+	dave := net.NewNode(t.t, "dave", nil)
+	defer shutdownAndAssert(net, t, dave)
+
+	ctxt, cancel := context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	net.EnsureConnected(ctxt, t.t, carol, dave)
+
+	var pendingChanID [32]byte
+	_, err := rand.Read(pendingChanID[:])
+	require.NoError(t.t, err)
+
+	// Carol opens the channel through a PSBT, so the negotiation stays
+	// pending while the flow waits for her to fund the PSBT.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	_, _, err = openChannelPsbt(
+		ctxt, carol, dave, lntest.OpenChannelParams{
+			Amt: chanSize,
+			FundingShim: &lnrpc.FundingShim{
+				Shim: &lnrpc.FundingShim_PsbtShim{
+					PsbtShim: &lnrpc.PsbtShim{
+						PendingChanId: pendingChanID[:],
+					},
+				},
+			},
+		},
+	)
+	require.NoError(t.t, err)
+
+	// Carol's negotiation carries the parameters of Dave's AcceptChannel,
+	// and isn't timed out while waiting for her.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	resp, err := carol.ListPendingNegotiations(
+		ctxt, &lnrpc.ListPendingNegotiationsRequest{},
+	)
+	require.NoError(t.t, err)
+	require.Len(t.t, resp.Negotiations, 1)
+
+	negotiation := resp.Negotiations[0]
+	require.Equal(t.t, pendingChanID[:], negotiation.PendingChanId)
+	require.Equal(t.t, dave.PubKeyStr, negotiation.RemoteNodePub)
+	require.EqualValues(t.t, chanSize, negotiation.Capacity)
+	require.True(t.t, negotiation.Accepted)
+	require.NotZero(t.t, negotiation.CsvDelay)
+	require.NotZero(t.t, negotiation.ChanReserveSat)
+	require.Zero(t.t, negotiation.LastUpdated)
+
+	// Dave didn't initiate the channel, so he didn't receive an
+	// AcceptChannel for it.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	resp, err = dave.ListPendingNegotiations(
+		ctxt, &lnrpc.ListPendingNegotiationsRequest{},
+	)
+	require.NoError(t.t, err)
+	require.Len(t.t, resp.Negotiations, 1)
+
+	negotiation = resp.Negotiations[0]
+	require.Equal(t.t, pendingChanID[:], negotiation.PendingChanId)
+	require.Equal(t.t, carol.PubKeyStr, negotiation.RemoteNodePub)
+	require.False(t.t, negotiation.Accepted)
+
+	// Once Carol aborts the negotiation, it is gone on both sides.
+	ctxt, cancel = context.WithTimeout(ctxb, defaultTimeout)
+	defer cancel()
+	_, err = carol.AbortFunding(ctxt, &lnrpc.AbortFundingRequest{
+		PendingChanId: pendingChanID[:],
+	})
+	require.NoError(t.t, err)
+
+	for _, node := range []*lntest.HarnessNode{carol, dave} {
+		err := wait.NoError(func() error {
+			ctxt, cancel := context.WithTimeout(ctxb, defaultTimeout)
+			defer cancel()
+
+			resp, err := node.ListPendingNegotiations(
+				ctxt, &lnrpc.ListPendingNegotiationsRequest{},
+			)
+			if err != nil {
+				return err
+			}
+			if len(resp.Negotiations) != 0 {
+				return fmt.Errorf("%s has %d pending "+
+					"negotiations", node.Name(),
+					len(resp.Negotiations))
+			}
+
+			return nil
+		}, defaultTimeout)
+		require.NoError(t.t, err)
+	}
+}
+
 // openChannelPsbt attempts to open a channel between srcNode and destNode with
 // the passed channel funding parameters. If the passed context has a timeout,
 // then if the timeout is reached before the channel pending notification is
@@ -27,6 +27,14 @@ var allTestCases = []*testCase{
 		name: "unconfirmed channel funding",
 		test: testUnconfirmedChannelFunding,
 	},
+	{
+		name: "simulate channel open",
+		test: testSimulateOpenChannel,
+	},
+	{
+		name: "diagnose accept channel",
+		test: testDiagnoseAcceptChannel,
+	},
 	{
 		name: "update channel policy",
 		test: testUpdateChannelPolicy,
@@ -274,6 +282,14 @@ var allTestCases = []*testCase{
 		name: "psbt channel funding",
 		test: testPsbtChanFunding,
 	},
+	{
+		name: "abort funding",
+		test: testAbortFunding,
+	},
+	{
+		name: "list pending negotiations",
+		test: testListPendingNegotiations,
+	},
 	{
 		name: "sendtoroute multi path payment",
 		test: testSendToRouteMultiPath,
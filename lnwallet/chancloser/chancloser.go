@@ -35,6 +35,12 @@ var (
 	// shutdown script previously set for that party.
 	ErrUpfrontShutdownScriptMismatch = fmt.Errorf("shutdown script does not " +
 		"match upfront shutdown script")
+
+	// ErrShutdownScriptIsRemoteUpfront is returned when an end user
+	// requests a cooperative close to the upfront shutdown script of the
+	// remote party, which would pay our balance out to them.
+	ErrShutdownScriptIsRemoteUpfront = fmt.Errorf("shutdown script is " +
+		"the upfront shutdown script of the remote party")
 )
 
 // closeState represents all the possible states the channel closer state
@@ -336,6 +342,38 @@ func maybeMatchScript(disconnect func() error, upfrontScript,
 	return nil
 }
 
+// CanCoopClose checks whether we can initiate a cooperative close to the
+// requested script given the upfront shutdown scripts negotiated for the
+// channel. A requested script is optional, and if one is provided while we
+// committed to a local upfront script, it must match that script. A requested
+// script must also never be the upfront script of the remote party, as that
+// would pay our balance out to them.
+func CanCoopClose(localUpfront, remoteUpfront,
+	requestedScript lnwire.DeliveryAddress) error {
+
+	// Without a requested script, we'll close out to our upfront script
+	// or a fresh one, both of which are fine.
+	if len(requestedScript) == 0 {
+		return nil
+	}
+
+	// If we committed to an upfront shutdown script, closing out to any
+	// other script would violate upfront shutdown.
+	if len(localUpfront) != 0 &&
+		!bytes.Equal(localUpfront, requestedScript) {
+
+		return ErrUpfrontShutdownScriptMismatch
+	}
+
+	if len(remoteUpfront) != 0 &&
+		bytes.Equal(remoteUpfront, requestedScript) {
+
+		return ErrShutdownScriptIsRemoteUpfront
+	}
+
+	return nil
+}
+
 // ProcessCloseMsg attempts to process the next message in the closing series.
 // This method will update the state accordingly and return two primary values:
 // the next set of messages to be sent, and a bool indicating if the fee
@@ -20,6 +20,106 @@ func randDeliveryAddress(t *testing.T) lnwire.DeliveryAddress {
 	return da
 }
 
+// TestCanCoopClose tests that CanCoopClose only accepts a requested script
+// that is compatible with the upfront shutdown scripts of both parties.
+func TestCanCoopClose(t *testing.T) {
+	local := randDeliveryAddress(t)
+	remote := randDeliveryAddress(t)
+	other := randDeliveryAddress(t)
+
+	tests := []struct {
+		name          string
+		localUpfront  lnwire.DeliveryAddress
+		remoteUpfront lnwire.DeliveryAddress
+		requested     lnwire.DeliveryAddress
+		expectedErr   error
+	}{
+		{
+			name: "no scripts",
+		},
+		{
+			name:      "no upfront scripts, script requested",
+			requested: other,
+		},
+		{
+			name:         "local upfront, no script requested",
+			localUpfront: local,
+		},
+		{
+			name:         "local upfront, matching script",
+			localUpfront: local,
+			requested:    local,
+		},
+		{
+			name:         "local upfront, other script requested",
+			localUpfront: local,
+			requested:    other,
+			expectedErr:  ErrUpfrontShutdownScriptMismatch,
+		},
+		{
+			name:          "remote upfront, no script requested",
+			remoteUpfront: remote,
+		},
+		{
+			name:          "remote upfront, other script requested",
+			remoteUpfront: remote,
+			requested:     other,
+		},
+		{
+			name:          "remote upfront, remote script",
+			remoteUpfront: remote,
+			requested:     remote,
+			expectedErr:   ErrShutdownScriptIsRemoteUpfront,
+		},
+		{
+			name:          "both upfront, no script requested",
+			localUpfront:  local,
+			remoteUpfront: remote,
+		},
+		{
+			name:          "both upfront, local script requested",
+			localUpfront:  local,
+			remoteUpfront: remote,
+			requested:     local,
+		},
+		{
+			name:          "both upfront, other script requested",
+			localUpfront:  local,
+			remoteUpfront: remote,
+			requested:     other,
+			expectedErr:   ErrUpfrontShutdownScriptMismatch,
+		},
+		{
+			name:          "both upfront, remote script requested",
+			localUpfront:  local,
+			remoteUpfront: remote,
+			requested:     remote,
+			expectedErr:   ErrUpfrontShutdownScriptMismatch,
+		},
+		{
+			name:          "empty upfront scripts",
+			localUpfront:  []byte{},
+			remoteUpfront: []byte{},
+			requested:     other,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := CanCoopClose(
+				test.localUpfront, test.remoteUpfront,
+				test.requested,
+			)
+			if err != test.expectedErr {
+				t.Fatalf("Error: %v, expected error: %v", err,
+					test.expectedErr)
+			}
+		})
+	}
+}
+
 // TestMaybeMatchScript tests that the maybeMatchScript errors appropriately
 // when an upfront shutdown script is set and the script provided does not
 // match, and does not error in any other case.
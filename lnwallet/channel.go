@@ -6557,6 +6557,58 @@ func (lc *LightningChannel) AvailableBalance() lnwire.MilliSatoshi {
 	return bal
 }
 
+// AvailableInFlight returns the value we may still add in outgoing HTLCs
+// without exceeding the maximum value in flight the remote party allows us to
+// have pending, as negotiated in its OpenChannel or AcceptChannel message.
+// Both commitment chains are examined, and the lowest value is returned.
+func (lc *LightningChannel) AvailableInFlight() lnwire.MilliSatoshi {
+	lc.RLock()
+	defer lc.RUnlock()
+
+	maxInFlight := lc.channelState.LocalChanCfg.MaxPendingAmount
+
+	// outgoingInFlight sums up the value of all our HTLCs that are
+	// present on the given commitment chain.
+	outgoingInFlight := func(theirLogIndex uint64,
+		remoteChain bool) lnwire.MilliSatoshi {
+
+		view := lc.fetchHTLCView(
+			theirLogIndex, lc.localUpdateLog.logIndex,
+		)
+		_, _, _, filteredView, err := lc.computeView(
+			view, remoteChain, false,
+		)
+		if err != nil {
+			lc.log.Errorf("Unable to compute in flight value: %v",
+				err)
+			return maxInFlight
+		}
+
+		var amtInFlight lnwire.MilliSatoshi
+		for _, entry := range filteredView.ourUpdates {
+			if entry.EntryType == Add {
+				amtInFlight += entry.Amount
+			}
+		}
+
+		return amtInFlight
+	}
+
+	remoteACKedIndex := lc.localCommitChain.tail().theirMessageIndex
+	amtInFlight := outgoingInFlight(remoteACKedIndex, true)
+
+	localInFlight := outgoingInFlight(lc.remoteUpdateLog.logIndex, false)
+	if localInFlight > amtInFlight {
+		amtInFlight = localInFlight
+	}
+
+	if amtInFlight >= maxInFlight {
+		return 0
+	}
+
+	return maxInFlight - amtInFlight
+}
+
 // availableBalance is the private, non mutexed version of AvailableBalance.
 // This method is provided so methods that already hold the lock can access
 // this method. Additionally, the total weight of the next to be created
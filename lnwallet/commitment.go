@@ -278,6 +278,18 @@ func CommitWeight(chanType channeldb.ChannelType) int64 {
 	return input.CommitWeight
 }
 
+// MaxCommitWeight returns the weight of a commitment transaction of the given
+// type that carries as many outgoing HTLCs as the sender of the passed
+// AcceptChannel is willing to accept. This is the heaviest commitment we can
+// create for the peer, and can be used to bound the commitment fee.
+func MaxCommitWeight(accept *lnwire.AcceptChannel,
+	chanType channeldb.ChannelType) int64 {
+
+	htlcWeight := int64(accept.MaxAcceptedHTLCs) * input.HTLCWeight
+
+	return CommitWeight(chanType) + htlcWeight
+}
+
 // HtlcTimeoutFee returns the fee in satoshis required for an HTLC timeout
 // transaction based on the current fee rate.
 func HtlcTimeoutFee(chanType channeldb.ChannelType,
@@ -0,0 +1,63 @@
+package lnwallet
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMaxCommitWeight asserts that the max commitment weight accounts for the
+// base weight of the channel type, and for every HTLC the remote accepts.
+func TestMaxCommitWeight(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		chanType channeldb.ChannelType
+		maxHtlcs uint16
+		expected int64
+	}{
+		{
+			name:     "legacy no htlcs",
+			chanType: channeldb.SingleFunderBit,
+			maxHtlcs: 0,
+			expected: 724,
+		},
+		{
+			name:     "legacy max htlcs",
+			chanType: channeldb.SingleFunderTweaklessBit,
+			maxHtlcs: 483,
+			expected: 724 + 483*172,
+		},
+		{
+			name: "anchors no htlcs",
+			chanType: channeldb.SingleFunderTweaklessBit |
+				channeldb.AnchorOutputsBit,
+			maxHtlcs: 0,
+			expected: 1124,
+		},
+		{
+			name: "anchors max htlcs",
+			chanType: channeldb.SingleFunderTweaklessBit |
+				channeldb.AnchorOutputsBit |
+				channeldb.ZeroHtlcTxFeeBit,
+			maxHtlcs: 483,
+			expected: 1124 + 483*172,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			accept := &lnwire.AcceptChannel{
+				MaxAcceptedHTLCs: test.maxHtlcs,
+			}
+
+			weight := MaxCommitWeight(accept, test.chanType)
+			require.Equal(t, test.expected, weight)
+		})
+	}
+}
@@ -15,8 +15,107 @@ import (
 // reservation, as they may contain private information.
 type ReservationError struct {
 	error
+
+	// reason identifies the kind of the error.
+	reason ReservationErrorReason
+}
+
+// Reason returns the kind of the error, which can be used to distinguish
+// reservation errors without inspecting their message.
+func (e ReservationError) Reason() ReservationErrorReason {
+	return e.reason
 }
 
+// ReservationErrorReason is a short, stable identifier for the kind of a
+// ReservationError, suitable for use as a metric label.
+type ReservationErrorReason string
+
+const (
+	// ReasonZeroCapacity is the reason of the errors returned by
+	// ErrZeroCapacity.
+	ReasonZeroCapacity ReservationErrorReason = "zero_capacity"
+
+	// ReasonChainMismatch is the reason of the errors returned by
+	// ErrChainMismatch.
+	ReasonChainMismatch ReservationErrorReason = "chain_mismatch"
+
+	// ReasonFunderBalanceDust is the reason of the errors returned by
+	// ErrFunderBalanceDust.
+	ReasonFunderBalanceDust ReservationErrorReason = "funder_balance_dust"
+
+	// ReasonCsvDelayTooLarge is the reason of the errors returned by
+	// ErrCsvDelayTooLarge.
+	ReasonCsvDelayTooLarge ReservationErrorReason = "csv_delay_too_large"
+
+	// ReasonDustLimitTooLow is the reason of the errors returned by
+	// ErrDustLimitTooLow.
+	ReasonDustLimitTooLow ReservationErrorReason = "dust_limit_too_low"
+
+	// ReasonChanReserveTooSmall is the reason of the errors returned by
+	// ErrChanReserveTooSmall.
+	ReasonChanReserveTooSmall ReservationErrorReason = "chan_reserve_too_small"
+
+	// ReasonChanReserveTooLarge is the reason of the errors returned by
+	// ErrChanReserveTooLarge.
+	ReasonChanReserveTooLarge ReservationErrorReason = "chan_reserve_too_large"
+
+	// ReasonChanReserveExceedsBalance is the reason of the errors returned
+	// by ErrChanReserveExceedsBalance.
+	ReasonChanReserveExceedsBalance ReservationErrorReason = "chan_reserve_exceeds_balance"
+
+	// ReasonNonZeroPushAmount is the reason of the errors returned by
+	// ErrNonZeroPushAmount.
+	ReasonNonZeroPushAmount ReservationErrorReason = "non_zero_push_amount"
+
+	// ReasonMinHtlcTooLarge is the reason of the errors returned by
+	// ErrMinHtlcTooLarge.
+	ReasonMinHtlcTooLarge ReservationErrorReason = "min_htlc_too_large"
+
+	// ReasonMaxHtlcNumTooLarge is the reason of the errors returned by
+	// ErrMaxHtlcNumTooLarge.
+	ReasonMaxHtlcNumTooLarge ReservationErrorReason = "max_htlc_num_too_large"
+
+	// ReasonMaxHtlcNumTooSmall is the reason of the errors returned by
+	// ErrMaxHtlcNumTooSmall.
+	ReasonMaxHtlcNumTooSmall ReservationErrorReason = "max_htlc_num_too_small"
+
+	// ReasonMaxHtlcsTooLow is the reason of the errors returned by
+	// ErrMaxHtlcsTooLow.
+	ReasonMaxHtlcsTooLow ReservationErrorReason = "max_htlcs_too_low"
+
+	// ReasonMaxCommitFeeTooHigh is the reason of the errors returned by
+	// ErrMaxCommitFeeTooHigh.
+	ReasonMaxCommitFeeTooHigh ReservationErrorReason = "max_commit_fee_too_high"
+
+	// ReasonMaxValueInFlightTooSmall is the reason of the errors returned
+	// by ErrMaxValueInFlightTooSmall.
+	ReasonMaxValueInFlightTooSmall ReservationErrorReason = "max_value_in_flight_too_small"
+
+	// ReasonNumConfsTooLarge is the reason of the errors returned by
+	// ErrNumConfsTooLarge.
+	ReasonNumConfsTooLarge ReservationErrorReason = "num_confs_too_large"
+
+	// ReasonChanTooSmall is the reason of the errors returned by
+	// ErrChanTooSmall.
+	ReasonChanTooSmall ReservationErrorReason = "chan_too_small"
+
+	// ReasonChanTooLarge is the reason of the errors returned by
+	// ErrChanTooLarge.
+	ReasonChanTooLarge ReservationErrorReason = "chan_too_large"
+
+	// ReasonCommitTypeNotAllowed is the reason of the errors returned by
+	// ErrCommitTypeNotAllowed.
+	ReasonCommitTypeNotAllowed ReservationErrorReason = "commit_type_not_allowed"
+
+	// ReasonUpfrontShutdownRequired is the reason of the errors returned
+	// by ErrUpfrontShutdownRequired.
+	ReasonUpfrontShutdownRequired ReservationErrorReason = "upfront_shutdown_required"
+
+	// ReasonCommitTypeMismatch is the reason of the errors returned by
+	// ErrCommitTypeMismatch.
+	ReasonCommitTypeMismatch ReservationErrorReason = "commit_type_mismatch"
+)
+
 // A compile time check to ensure ReservationError implements the error
 // interface.
 var _ error = (*ReservationError)(nil)
@@ -26,6 +125,7 @@ var _ error = (*ReservationError)(nil)
 func ErrZeroCapacity() ReservationError {
 	return ReservationError{
 		errors.New("zero channel funds"),
+		ReasonZeroCapacity,
 	}
 }
 
@@ -36,6 +136,7 @@ func ErrChainMismatch(knownChain,
 	return ReservationError{
 		fmt.Errorf("unknown chain=%v, supported chain=%v",
 			unknownChain, knownChain),
+		ReasonChainMismatch,
 	}
 }
 
@@ -47,6 +148,7 @@ func ErrFunderBalanceDust(commitFee, funderBalance,
 		fmt.Errorf("funder balance too small (%v) with fee=%v sat, "+
 			"minimum=%v sat required", funderBalance,
 			commitFee, minBalance),
+		ReasonFunderBalanceDust,
 	}
 }
 
@@ -56,6 +158,17 @@ func ErrCsvDelayTooLarge(remoteDelay, maxDelay uint16) ReservationError {
 	return ReservationError{
 		fmt.Errorf("CSV delay too large: %v, max is %v",
 			remoteDelay, maxDelay),
+		ReasonCsvDelayTooLarge,
+	}
+}
+
+// ErrDustLimitTooLow returns an error indicating that the dust limit the
+// remote party set is below the protocol minimum.
+func ErrDustLimitTooLow(dustLimit, minDustLimit btcutil.Amount) ReservationError {
+	return ReservationError{
+		fmt.Errorf("dust limit of %v sat is too low, min is %v sat",
+			int64(dustLimit), int64(minDustLimit)),
+		ReasonDustLimitTooLow,
 	}
 }
 
@@ -65,6 +178,7 @@ func ErrChanReserveTooSmall(reserve, dustLimit btcutil.Amount) ReservationError
 	return ReservationError{
 		fmt.Errorf("channel reserve of %v sat is too small, min is %v "+
 			"sat", int64(reserve), int64(dustLimit)),
+		ReasonChanReserveTooSmall,
 	}
 }
 
@@ -75,6 +189,19 @@ func ErrChanReserveTooLarge(reserve,
 	return ReservationError{
 		fmt.Errorf("channel reserve is too large: %v sat, max "+
 			"is %v sat", int64(reserve), int64(maxReserve)),
+		ReasonChanReserveTooLarge,
+	}
+}
+
+// ErrChanReserveExceedsBalance returns an error indicating that the channel
+// reserve the remote is requiring is larger than our initial balance, which
+// would leave us below reserve as soon as the channel is open.
+func ErrChanReserveExceedsBalance(reserve,
+	balance btcutil.Amount) ReservationError {
+	return ReservationError{
+		fmt.Errorf("channel reserve of %v sat exceeds our balance of "+
+			"%v sat", int64(reserve), int64(balance)),
+		ReasonChanReserveExceedsBalance,
 	}
 }
 
@@ -82,7 +209,10 @@ func ErrChanReserveTooLarge(reserve,
 // FundingOpen request for a channel with non-zero push amount while
 // they have 'rejectpush' enabled.
 func ErrNonZeroPushAmount() ReservationError {
-	return ReservationError{errors.New("non-zero push amounts are disabled")}
+	return ReservationError{
+		errors.New("non-zero push amounts are disabled"),
+		ReasonNonZeroPushAmount,
+	}
 }
 
 // ErrMinHtlcTooLarge returns an error indicating that the MinHTLC value the
@@ -92,6 +222,7 @@ func ErrMinHtlcTooLarge(minHtlc,
 	return ReservationError{
 		fmt.Errorf("minimum HTLC value is too large: %v, max is %v",
 			minHtlc, maxMinHtlc),
+		ReasonMinHtlcTooLarge,
 	}
 }
 
@@ -101,6 +232,7 @@ func ErrMaxHtlcNumTooLarge(maxHtlc, maxMaxHtlc uint16) ReservationError {
 	return ReservationError{
 		fmt.Errorf("maxHtlcs is too large: %d, max is %d",
 			maxHtlc, maxMaxHtlc),
+		ReasonMaxHtlcNumTooLarge,
 	}
 }
 
@@ -110,6 +242,30 @@ func ErrMaxHtlcNumTooSmall(maxHtlc, minMaxHtlc uint16) ReservationError {
 	return ReservationError{
 		fmt.Errorf("maxHtlcs is too small: %d, min is %d",
 			maxHtlc, minMaxHtlc),
+		ReasonMaxHtlcNumTooSmall,
+	}
+}
+
+// ErrMaxHtlcsTooLow returns an error indicating that the 'max HTLCs in flight'
+// value the remote required is below the minimum we're configured to accept.
+func ErrMaxHtlcsTooLow(maxHtlcs, minMaxHtlcs uint16) ReservationError {
+	return ReservationError{
+		fmt.Errorf("maxHtlcs %d is below our configured minimum of %d",
+			maxHtlcs, minMaxHtlcs),
+		ReasonMaxHtlcsTooLow,
+	}
+}
+
+// ErrMaxCommitFeeTooHigh returns an error indicating that the fee of the
+// heaviest commitment transaction the remote party allows exceeds the share of
+// our balance we're willing to spend on it.
+func ErrMaxCommitFeeTooHigh(maxCommitFee,
+	maxAllowed btcutil.Amount) ReservationError {
+
+	return ReservationError{
+		fmt.Errorf("max commitment fee %v exceeds our configured "+
+			"maximum of %v", maxCommitFee, maxAllowed),
+		ReasonMaxCommitFeeTooHigh,
 	}
 }
 
@@ -120,6 +276,7 @@ func ErrMaxValueInFlightTooSmall(maxValInFlight,
 	return ReservationError{
 		fmt.Errorf("maxValueInFlight too small: %v, min is %v",
 			maxValInFlight, minMaxValInFlight),
+		ReasonMaxValueInFlightTooSmall,
 	}
 }
 
@@ -129,6 +286,7 @@ func ErrNumConfsTooLarge(numConfs, maxNumConfs uint32) error {
 	return ReservationError{
 		fmt.Errorf("minimum depth of %d is too large, max is %d",
 			numConfs, maxNumConfs),
+		ReasonNumConfsTooLarge,
 	}
 }
 
@@ -139,6 +297,7 @@ func ErrChanTooSmall(chanSize, minChanSize btcutil.Amount) ReservationError {
 	return ReservationError{
 		fmt.Errorf("chan size of %v is below min chan size of %v",
 			chanSize, minChanSize),
+		ReasonChanTooSmall,
 	}
 }
 
@@ -149,6 +308,39 @@ func ErrChanTooLarge(chanSize, maxChanSize btcutil.Amount) ReservationError {
 	return ReservationError{
 		fmt.Errorf("chan size of %v exceeds maximum chan size of %v",
 			chanSize, maxChanSize),
+		ReasonChanTooLarge,
+	}
+}
+
+// ErrCommitTypeNotAllowed returns an error indicating that an incoming channel
+// request would result in a commitment type other than the one we require.
+func ErrCommitTypeNotAllowed(commitType,
+	requiredType CommitmentType) ReservationError {
+	return ReservationError{
+		fmt.Errorf("commitment type %v not allowed, %v required",
+			commitType, requiredType),
+		ReasonCommitTypeNotAllowed,
+	}
+}
+
+// ErrCommitTypeMismatch returns an error indicating that the remote party
+// accepted a channel with a commitment type other than the one we proposed,
+// which we don't agree to.
+func ErrCommitTypeMismatch(proposedType,
+	acceptedType CommitmentType) ReservationError {
+	return ReservationError{
+		fmt.Errorf("commitment type %v accepted, %v proposed",
+			acceptedType, proposedType),
+		ReasonCommitTypeMismatch,
+	}
+}
+
+// ErrUpfrontShutdownRequired returns an error indicating that the remote
+// party didn't commit to an upfront shutdown script although we require it.
+func ErrUpfrontShutdownRequired() ReservationError {
+	return ReservationError{
+		errors.New("upfront shutdown script required"),
+		ReasonUpfrontShutdownRequired,
 	}
 }
 
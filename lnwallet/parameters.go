@@ -6,6 +6,11 @@ import (
 	"github.com/lightningnetwork/lnd/input"
 )
 
+// MinDustLimit is the smallest dust limit BOLT #2 allows either party of a
+// channel to set. Any output below it would be non-standard for one of the
+// output types the commitment transaction may contain.
+const MinDustLimit = btcutil.Amount(354)
+
 // DefaultDustLimit is used to calculate the dust HTLC amount which will be
 // send to other node during funding process.
 func DefaultDustLimit() btcutil.Amount {
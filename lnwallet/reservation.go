@@ -1,6 +1,8 @@
 package lnwallet
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"sync"
 
@@ -166,6 +168,85 @@ type ChannelReservation struct {
 	// nextRevocationKeyLoc stores the key locator information for this
 	// channel.
 	nextRevocationKeyLoc keychain.KeyLocator
+
+	// zeroReserveAllowed is set if the remote party may require us to
+	// maintain no channel reserve at all.
+	zeroReserveAllowed bool
+}
+
+// ReservationKeys holds the locators of the keys derived for our contribution
+// to a channel reservation. They allow a reservation to be re-created with the
+// exact same keys, for instance to resume a funding flow that was interrupted
+// by a restart after our keys were already sent to the remote party.
+type ReservationKeys struct {
+	// MultiSigKey is the locator of our multi-sig funding key.
+	MultiSigKey keychain.KeyLocator
+
+	// RevocationBasePoint is the locator of our revocation base point.
+	RevocationBasePoint keychain.KeyLocator
+
+	// HtlcBasePoint is the locator of our HTLC base point.
+	HtlcBasePoint keychain.KeyLocator
+
+	// PaymentBasePoint is the locator of our payment base point.
+	PaymentBasePoint keychain.KeyLocator
+
+	// DelayBasePoint is the locator of our delay base point.
+	DelayBasePoint keychain.KeyLocator
+
+	// RevocationRoot is the locator of the key our revocation producer,
+	// and therefore our per-commitment points, are derived from.
+	RevocationRoot keychain.KeyLocator
+}
+
+// locator returns the locator of the key of the given family, if any.
+func (k *ReservationKeys) locator(
+	keyFam keychain.KeyFamily) (keychain.KeyLocator, bool) {
+
+	switch keyFam {
+	case keychain.KeyFamilyMultiSig:
+		return k.MultiSigKey, true
+	case keychain.KeyFamilyRevocationBase:
+		return k.RevocationBasePoint, true
+	case keychain.KeyFamilyHtlcBase:
+		return k.HtlcBasePoint, true
+	case keychain.KeyFamilyPaymentBase:
+		return k.PaymentBasePoint, true
+	case keychain.KeyFamilyDelayBase:
+		return k.DelayBasePoint, true
+	case keychain.KeyFamilyRevocationRoot:
+		return k.RevocationRoot, true
+	default:
+		return keychain.KeyLocator{}, false
+	}
+}
+
+// initialCommitFee returns the fee of the initial commitment transaction of a
+// channel of the given commitment type at the given fee rate.
+func initialCommitFee(commitType CommitmentType,
+	feePerKw chainfee.SatPerKWeight) btcutil.Amount {
+
+	commitWeight := int64(input.CommitWeight)
+	if commitType == CommitmentTypeAnchorsZeroFeeHtlcTx {
+		commitWeight = input.AnchorCommitWeight
+	}
+
+	return feePerKw.FeeForWeight(commitWeight)
+}
+
+// InitiatorCommitFee returns the total amount the initiator of a channel of
+// the given commitment type pays for the initial commitment transaction at the
+// given fee rate. This is the commitment fee in addition to the value of the
+// two anchor outputs, if any.
+func InitiatorCommitFee(commitType CommitmentType,
+	feePerKw chainfee.SatPerKWeight) btcutil.Amount {
+
+	fee := initialCommitFee(commitType, feePerKw)
+	if commitType == CommitmentTypeAnchorsZeroFeeHtlcTx {
+		fee += 2 * anchorSize
+	}
+
+	return fee
 }
 
 // NewChannelReservation creates a new channel reservation. This function is
@@ -187,11 +268,7 @@ func NewChannelReservation(capacity, localFundingAmt btcutil.Amount,
 
 	// Based on the channel type, we determine the initial commit weight
 	// and fee.
-	commitWeight := int64(input.CommitWeight)
-	if commitType == CommitmentTypeAnchorsZeroFeeHtlcTx {
-		commitWeight = input.AnchorCommitWeight
-	}
-	commitFee := commitFeePerKw.FeeForWeight(commitWeight)
+	commitFee := initialCommitFee(commitType, commitFeePerKw)
 
 	localFundingMSat := lnwire.NewMSatFromSatoshis(localFundingAmt)
 	// TODO(halseth): make method take remote funding amount directly
@@ -200,10 +277,9 @@ func NewChannelReservation(capacity, localFundingAmt btcutil.Amount,
 
 	// The total fee paid by the initiator will be the commitment fee in
 	// addition to the two anchor outputs.
-	feeMSat := lnwire.NewMSatFromSatoshis(commitFee)
-	if commitType == CommitmentTypeAnchorsZeroFeeHtlcTx {
-		feeMSat += 2 * lnwire.NewMSatFromSatoshis(anchorSize)
-	}
+	feeMSat := lnwire.NewMSatFromSatoshis(
+		InitiatorCommitFee(commitType, commitFeePerKw),
+	)
 
 	// If we're the responder to a single-funder reservation, then we have
 	// no initial balance in the channel unless the remote party is pushing
@@ -384,6 +460,113 @@ func (r *ChannelReservation) SetNumConfsRequired(numConfs uint16) {
 	r.partialState.NumConfsRequired = numConfs
 }
 
+// SetMaxHtlcExpiryDelta sets the maximum number of blocks the expiry of the
+// HTLCs we offer on the channel may lie beyond the current block height, as
+// required by the remote party. It is persisted along with the channel, such
+// that the link can enforce it.
+func (r *ChannelReservation) SetMaxHtlcExpiryDelta(delta uint32) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.partialState.MaxHtlcExpiryDelta = delta
+}
+
+// AllowZeroReserve allows the remote party to require us to maintain no
+// channel reserve at all, which CommitConstraints would otherwise reject as
+// being below the dust limit. As a zero reserve leaves the remote party
+// without any penalty for broadcasting a revoked state, this must only be
+// allowed for trusted peers.
+func (r *ChannelReservation) AllowZeroReserve() {
+	r.Lock()
+	defer r.Unlock()
+
+	r.zeroReserveAllowed = true
+}
+
+// CommitType returns the commitment type of the channel.
+func (r *ChannelReservation) CommitType() CommitmentType {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.commitType()
+}
+
+// commitType returns the commitment type of the channel, as derived from its
+// channel type.
+//
+// NOTE: The reservation's mutex must be held when calling this method.
+func (r *ChannelReservation) commitType() CommitmentType {
+	return CommitmentTypeOf(r.partialState.ChanType)
+}
+
+// CommitmentTypeOf returns the commitment type of a channel of the given
+// channel type.
+func CommitmentTypeOf(chanType channeldb.ChannelType) CommitmentType {
+	switch {
+	case chanType.HasAnchors():
+		return CommitmentTypeAnchorsZeroFeeHtlcTx
+
+	case chanType.IsTweakless():
+		return CommitmentTypeTweakless
+
+	default:
+		return CommitmentTypeLegacy
+	}
+}
+
+// DowngradeCommitType switches the channel to the given commitment type, which
+// must predate the one the reservation was created with. This allows the
+// initiator of a single funder channel to agree to the commitment type the
+// remote party accepted the channel with, if it doesn't support the one we
+// proposed. The initial balances are recomputed with the commitment fee of the
+// new type. It must be called before the remote party's contribution is
+// processed.
+func (r *ChannelReservation) DowngradeCommitType(
+	commitType CommitmentType) error {
+
+	r.Lock()
+	defer r.Unlock()
+
+	state := r.partialState
+	if !state.IsInitiator || !state.ChanType.IsSingleFunder() {
+		return errors.New("only the initiator of a single funder " +
+			"channel can downgrade its commitment type")
+	}
+
+	current := r.commitType()
+	if commitType >= current {
+		return fmt.Errorf("commitment type %v isn't a downgrade of %v",
+			commitType, current)
+	}
+
+	// All commitment types are tweakless, apart from the legacy one, and
+	// only the newest one has anchors.
+	chanType := state.ChanType &^ (channeldb.AnchorOutputsBit |
+		channeldb.ZeroHtlcTxFeeBit)
+	if commitType == CommitmentTypeLegacy {
+		chanType &^= channeldb.SingleFunderTweaklessBit
+	}
+
+	// As the initiator pays the commitment fee, and older commitment
+	// types are never more expensive, our balance can only increase.
+	feePerKw := chainfee.SatPerKWeight(state.LocalCommitment.FeePerKw)
+	commitFee := initialCommitFee(commitType, feePerKw)
+	feeMSat := lnwire.NewMSatFromSatoshis(
+		InitiatorCommitFee(commitType, feePerKw),
+	)
+	capacityMSat := lnwire.NewMSatFromSatoshis(state.Capacity)
+	ourBalance := capacityMSat - feeMSat - r.pushMSat
+
+	state.ChanType = chanType
+	state.LocalCommitment.LocalBalance = ourBalance
+	state.LocalCommitment.CommitFee = commitFee
+	state.RemoteCommitment.LocalBalance = ourBalance
+	state.RemoteCommitment.CommitFee = commitFee
+	r.ourContribution.FundingAmount = ourBalance.ToSatoshis()
+
+	return nil
+}
+
 // CommitConstraints takes the constraints that the remote party specifies for
 // the type of commitments that we can generate for them. These constraints
 // include several parameters that serve as flow control restricting the amount
@@ -400,9 +583,17 @@ func (r *ChannelReservation) CommitConstraints(c *channeldb.ChannelConstraints,
 		return ErrCsvDelayTooLarge(c.CsvDelay, maxLocalCSVDelay)
 	}
 
+	// Fail if the dust limit is below the protocol minimum, as some of
+	// the outputs of the commitment transaction wouldn't be standard.
+	if c.DustLimit < MinDustLimit {
+		return ErrDustLimitTooLow(c.DustLimit, MinDustLimit)
+	}
+
 	// The channel reserve should always be greater or equal to the dust
-	// limit. The reservation request should be denied if otherwise.
-	if c.DustLimit > c.ChanReserve {
+	// limit, unless a zero reserve was allowed. The reservation request
+	// should be denied if otherwise.
+	zeroReserve := c.ChanReserve == 0 && r.zeroReserveAllowed
+	if c.DustLimit > c.ChanReserve && !zeroReserve {
 		return ErrChanReserveTooSmall(c.ChanReserve, c.DustLimit)
 	}
 
@@ -413,6 +604,17 @@ func (r *ChannelReservation) CommitConstraints(c *channeldb.ChannelConstraints,
 		return ErrChanReserveTooLarge(c.ChanReserve, maxChanReserve)
 	}
 
+	// If we're funding the channel, fail if the reserve is larger than
+	// our initial balance, as we would start out below reserve. The
+	// responder is allowed to start out below its reserve, since it
+	// usually doesn't contribute any funds.
+	ourBalance := r.partialState.LocalCommitment.LocalBalance.ToSatoshis()
+	if r.partialState.IsInitiator &&
+		ReserveExceedsBalance(c.ChanReserve, ourBalance) {
+
+		return ErrChanReserveExceedsBalance(c.ChanReserve, ourBalance)
+	}
+
 	// Fail if the minimum HTLC value is too large. If this is too large,
 	// the channel won't be useful for sending small payments. This limit
 	// is currently set to maxValueInFlight, effectively letting the remote
@@ -445,8 +647,8 @@ func (r *ChannelReservation) CommitConstraints(c *channeldb.ChannelConstraints,
 	}
 
 	// Our dust limit should always be less than or equal to our proposed
-	// channel reserve.
-	if r.ourContribution.DustLimit > c.ChanReserve {
+	// channel reserve, unless that reserve was waived altogether.
+	if r.ourContribution.DustLimit > c.ChanReserve && !zeroReserve {
 		r.ourContribution.DustLimit = c.ChanReserve
 	}
 
@@ -459,6 +661,13 @@ func (r *ChannelReservation) CommitConstraints(c *channeldb.ChannelConstraints,
 	return nil
 }
 
+// ReserveExceedsBalance returns true if the given channel reserve can't be
+// met by the given local balance, meaning the channel would be below reserve
+// right away.
+func ReserveExceedsBalance(reserve, localBalance btcutil.Amount) bool {
+	return reserve > localBalance
+}
+
 // OurContribution returns the wallet's fully populated contribution to the
 // pending payment channel. See 'ChannelContribution' for further details
 // regarding the contents of a contribution.
@@ -472,6 +681,23 @@ func (r *ChannelReservation) OurContribution() *ChannelContribution {
 	return r.ourContribution
 }
 
+// Keys returns the locators of the keys derived for our contribution to the
+// reservation.
+func (r *ChannelReservation) Keys() *ReservationKeys {
+	r.RLock()
+	defer r.RUnlock()
+
+	cfg := r.ourContribution.ChannelConfig
+	return &ReservationKeys{
+		MultiSigKey:         cfg.MultiSigKey.KeyLocator,
+		RevocationBasePoint: cfg.RevocationBasePoint.KeyLocator,
+		HtlcBasePoint:       cfg.HtlcBasePoint.KeyLocator,
+		PaymentBasePoint:    cfg.PaymentBasePoint.KeyLocator,
+		DelayBasePoint:      cfg.DelayBasePoint.KeyLocator,
+		RevocationRoot:      r.nextRevocationKeyLoc,
+	}
+}
+
 // ProcessContribution verifies the counterparty's contribution to the pending
 // payment channel. As a result of this incoming message, lnwallet is able to
 // build the funding transaction, and both commitment transactions. Once this
@@ -505,6 +731,13 @@ func (r *ChannelReservation) IsCannedShim() bool {
 	return ok
 }
 
+// CannedShimIntent returns the canned shim funding intent mapped to this
+// reservation, or nil if there is none.
+func (r *ChannelReservation) CannedShimIntent() *chanfunding.ShimIntent {
+	intent, _ := r.fundingIntent.(*chanfunding.ShimIntent)
+	return intent
+}
+
 // ProcessPsbt continues a previously paused funding flow that involves PSBT to
 // construct the funding transaction. This method can be called once the PSBT is
 // finalized and the signed transaction is available.
@@ -684,6 +917,21 @@ func (r *ChannelReservation) Capacity() btcutil.Amount {
 	return r.partialState.Capacity
 }
 
+// CommitFeePerKw returns the fee rate of the initial commitment transactions
+// of this reservation.
+func (r *ChannelReservation) CommitFeePerKw() chainfee.SatPerKWeight {
+	r.RLock()
+	defer r.RUnlock()
+	return chainfee.SatPerKWeight(r.partialState.LocalCommitment.FeePerKw)
+}
+
+// ChanType returns the channel type of this reservation.
+func (r *ChannelReservation) ChanType() channeldb.ChannelType {
+	r.RLock()
+	defer r.RUnlock()
+	return r.partialState.ChanType
+}
+
 // Cancel abandons this channel reservation. This method should be called in
 // the scenario that communications with the counterparty break down. Upon
 // cancellation, all resources previously reserved for this pending payment
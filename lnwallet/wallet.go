@@ -153,6 +153,12 @@ type InitFundingReserveMsg struct {
 	// used.
 	ChanFunder chanfunding.Assembler
 
+	// ResumeKeys, if set, are the keys of a previous reservation with the
+	// same pending channel ID. They're re-derived instead of deriving
+	// fresh keys, allowing a reservation whose keys were already sent to
+	// the remote party to be resumed.
+	ResumeKeys *ReservationKeys
+
 	// err is a channel in which all errors will be sent across. Will be
 	// nil if this initial set is successful.
 	//
@@ -786,6 +792,15 @@ func (l *LightningWallet) handleFundingReserveRequest(req *InitFundingReserveMsg
 		thawHeight = shimIntent.ThawHeight()
 	}
 
+	// If we're resuming a previous reservation, then we'll re-derive the
+	// keys it used rather than deriving new ones.
+	if req.ResumeKeys != nil {
+		keyRing = &resumeKeyRing{
+			KeyRing: keyRing,
+			keys:    req.ResumeKeys,
+		}
+	}
+
 	// Now that we have a funding intent, we'll check whether funding a
 	// channel using it would violate our reserved value for anchor channel
 	// fee bumping.
@@ -2147,6 +2162,28 @@ func (s *shimKeyRing) DeriveNextKey(keyFam keychain.KeyFamily) (keychain.KeyDesc
 	return *fundingKeys.LocalKey, nil
 }
 
+// resumeKeyRing is a wrapper struct that's used to re-derive the keys of a
+// previous reservation that is being resumed.
+type resumeKeyRing struct {
+	keychain.KeyRing
+
+	keys *ReservationKeys
+}
+
+// DeriveNextKey intercepts the normal DeriveNextKey call to a keychain.KeyRing
+// instance, and derives the key the resumed reservation used for the given
+// family instead of the next unused one.
+func (r *resumeKeyRing) DeriveNextKey(keyFam keychain.KeyFamily) (
+	keychain.KeyDescriptor, error) {
+
+	keyLoc, ok := r.keys.locator(keyFam)
+	if !ok {
+		return r.KeyRing.DeriveNextKey(keyFam)
+	}
+
+	return r.KeyRing.DeriveKey(keyLoc)
+}
+
 // validateUpfrontShutdown checks whether the provided upfront_shutdown_script
 // is of a valid type that we accept.
 func validateUpfrontShutdown(shutdown lnwire.DeliveryAddress,
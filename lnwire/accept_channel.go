@@ -2,13 +2,172 @@ package lnwire
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/tlv"
 )
 
+// ErrDuplicateShutdownScript is returned when encoding an OpenChannel or
+// AcceptChannel message whose ExtraData already contains a record of the
+// upfront shutdown script type. Packing the script in front of it would
+// otherwise produce a TLV stream with a duplicate type.
+var ErrDuplicateShutdownScript = errors.New("extra data contains an " +
+	"upfront shutdown script record")
+
+// ErrDuplicatePubKey is returned when validating an AcceptChannel message in
+// which two of the public keys are equal.
+var ErrDuplicatePubKey = errors.New("accept channel contains duplicate " +
+	"public keys")
+
+// errShutdownScriptAbsent is returned when decoding an OpenChannel or
+// AcceptChannel message whose non-empty TLV data lacks the upfront shutdown
+// script record.
+var errShutdownScriptAbsent = errors.New("no shutdown script in non-empty " +
+	"data blob")
+
+// ErrFundingKeyIsIdentity is returned when validating an AcceptChannel message
+// whose funding key is the node identity key of its sender.
+var ErrFundingKeyIsIdentity = errors.New("accept channel funding key is " +
+	"the node identity key")
+
+// ErrUpfrontShutdownAbsent is returned when validating a message for which the
+// upfront shutdown script feature was negotiated, but that doesn't carry the
+// upfront shutdown script record at all. A sender not wishing to commit to a
+// script must send a zero-length script instead.
+type ErrUpfrontShutdownAbsent struct {
+	msgType MessageType
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrUpfrontShutdownAbsent) Error() string {
+	return fmt.Sprintf("%v is missing the upfront shutdown script "+
+		"required by the negotiated features", e.msgType)
+}
+
+// ErrUnexpectedZeroConf is returned when validating a message that requires
+// zero confirmations of the funding transaction, while we didn't offer the
+// zero-conf feature to its sender.
+var ErrUnexpectedZeroConf = errors.New("accept channel has a min accept " +
+	"depth of zero, but zero-conf wasn't offered")
+
+// ErrPushExceedsCapacity is returned when validating a push amount that is
+// larger than the capacity of the channel.
+var ErrPushExceedsCapacity = errors.New("push amount exceeds channel " +
+	"capacity")
+
+// ErrPushViolatesReserve is returned when validating a push amount that would
+// leave the funder of a channel with a balance below the channel reserve the
+// responder requires in its AcceptChannel.
+type ErrPushViolatesReserve struct {
+	// FunderBalance is the balance of the funder after the push.
+	FunderBalance MilliSatoshi
+
+	// ChannelReserve is the reserve the responder requires the funder to
+	// keep.
+	ChannelReserve btcutil.Amount
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrPushViolatesReserve) Error() string {
+	return fmt.Sprintf("funder balance of %v after push is below the "+
+		"channel reserve of %v", e.FunderBalance, e.ChannelReserve)
+}
+
+// ErrMaxValueInFlightBelowMin is returned when validating an AcceptChannel
+// message whose MaxValueInFlight is below its HtlcMinimum, such that the
+// channel can't carry a single HTLC.
+type ErrMaxValueInFlightBelowMin struct {
+	// MaxValueInFlight is the max value in flight of the message.
+	MaxValueInFlight MilliSatoshi
+
+	// HtlcMinimum is the minimum HTLC value of the message.
+	HtlcMinimum MilliSatoshi
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrMaxValueInFlightBelowMin) Error() string {
+	return fmt.Sprintf("max value in flight of %v is below the htlc "+
+		"minimum of %v", e.MaxValueInFlight, e.HtlcMinimum)
+}
+
+// ErrMaxValueInFlightExceedsCapacity is returned when validating an
+// AcceptChannel message whose MaxValueInFlight exceeds the capacity of the
+// channel, which makes the limit meaningless.
+type ErrMaxValueInFlightExceedsCapacity struct {
+	// MaxValueInFlight is the max value in flight of the message.
+	MaxValueInFlight MilliSatoshi
+
+	// Capacity is the capacity of the channel.
+	Capacity btcutil.Amount
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrMaxValueInFlightExceedsCapacity) Error() string {
+	return fmt.Sprintf("max value in flight of %v exceeds the channel "+
+		"capacity of %v", e.MaxValueInFlight, e.Capacity)
+}
+
+// ErrDustLimitBelowScript is returned when validating an AcceptChannel message
+// whose dust limit is below the dust threshold of its upfront shutdown script.
+type ErrDustLimitBelowScript struct {
+	// DustLimit is the dust limit of the message.
+	DustLimit btcutil.Amount
+
+	// ScriptDustLimit is the dust threshold of the upfront shutdown
+	// script of the message.
+	ScriptDustLimit btcutil.Amount
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrDustLimitBelowScript) Error() string {
+	return fmt.Sprintf("dust limit of %v is below the dust threshold of "+
+		"%v for the upfront shutdown script", e.DustLimit,
+		e.ScriptDustLimit)
+}
+
+// AnchorOutputValue is the value of each of the two anchor outputs of the
+// commitment transactions of anchor channels.
+const AnchorOutputValue = btcutil.Amount(330)
+
+// ErrAnchorReserveBelowDust is returned when validating an AcceptChannel
+// message for an anchor channel whose channel reserve doesn't cover the dust
+// limit on top of the anchor outputs the initiator funds.
+type ErrAnchorReserveBelowDust struct {
+	// ChannelReserve is the channel reserve of the message.
+	ChannelReserve btcutil.Amount
+
+	// DustLimit is the dust limit of the message.
+	DustLimit btcutil.Amount
+
+	// AnchorReserve is the value of both anchor outputs.
+	AnchorReserve btcutil.Amount
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrAnchorReserveBelowDust) Error() string {
+	return fmt.Sprintf("channel reserve of %v is below the dust limit of "+
+		"%v plus the anchor reserve of %v", e.ChannelReserve,
+		e.DustLimit, e.AnchorReserve)
+}
+
 // AcceptChannel is the message Bob sends to Alice after she initiates the
 // single funder channel workflow via an AcceptChannel message. Once Alice
 // receives Bob's response, then she has all the items necessary to construct
@@ -43,8 +202,11 @@ type AcceptChannel struct {
 	// channel should wait before considering the channel open.
 	MinAcceptDepth uint32
 
-	// CsvDelay is the number of blocks to use for the relative time lock
-	// in the pay-to-self output of both commitment transactions.
+	// CsvDelay is the number of blocks the sender of this message, the
+	// responder, requires the to_self output of the initiator to be
+	// delayed by in the commitment transactions of the initiator. It
+	// doesn't apply to the to_self output of the responder, whose delay
+	// is the CsvDelay of the OpenChannel. See LocalToSelfDelay.
 	CsvDelay uint16
 
 	// MaxAcceptedHTLCs is the total number of incoming HTLC's that the
@@ -117,10 +279,17 @@ var _ Message = (*AcceptChannel)(nil)
 //
 // This is part of the lnwire.Message interface.
 func (a *AcceptChannel) Encode(w *bytes.Buffer, pver uint32) error {
+	// Add the records of any TLV writers registered by extensions to the
+	// ExtraData.
+	extraData, err := appendRegisteredTLVs(a.MsgType(), a.ExtraData)
+	if err != nil {
+		return err
+	}
+
 	// Since the upfront script is encoded as a TLV record, concatenate it
 	// with the ExtraData, and write them as one.
 	tlvRecords, err := packShutdownScript(
-		a.UpfrontShutdownScript, a.ExtraData,
+		a.UpfrontShutdownScript, extraData,
 	)
 	if err != nil {
 		return err
@@ -191,47 +360,491 @@ func (a *AcceptChannel) Encode(w *bytes.Buffer, pver uint32) error {
 //
 // This is part of the lnwire.Message interface.
 func (a *AcceptChannel) Decode(r io.Reader, pver uint32) error {
-	// Read all the mandatory fields in the accept message.
-	err := ReadElements(r,
-		a.PendingChannelID[:],
-		&a.DustLimit,
-		&a.MaxValueInFlight,
-		&a.ChannelReserve,
-		&a.HtlcMinimum,
-		&a.MinAcceptDepth,
-		&a.CsvDelay,
-		&a.MaxAcceptedHTLCs,
-		&a.FundingKey,
-		&a.RevocationPoint,
-		&a.PaymentPoint,
-		&a.DelayedPaymentPoint,
-		&a.HtlcPoint,
-		&a.FirstCommitmentPoint,
-	)
-	if err != nil {
-		return err
+	return a.decode(r, pver, nil, nil)
+}
+
+// DecodeWithBudget is like Decode, but limits the total number of bytes
+// allocated for the variable length fields of the message, such as the
+// ExtraData and the upfront shutdown script, to the given budget. If the
+// message would exceed it, the returned error wraps an
+// *ErrAllocBudgetExceeded, and no more than the budget is read into memory.
+func (a *AcceptChannel) DecodeWithBudget(r io.Reader, pver uint32,
+	budget int) error {
+
+	return a.decode(r, pver, newAllocBudget(budget), nil)
+}
+
+// decode deserializes the AcceptChannel, charging the allocations of its
+// variable length fields to the given budget, which may be nil. The given
+// quirks are used to recover a mis-encoded upfront shutdown script.
+func (a *AcceptChannel) decode(r io.Reader, pver uint32, budget *allocBudget,
+	quirks []ShutdownScriptQuirk) error {
+
+	// Read all the mandatory fields in the accept message, keeping track
+	// of the offset so a failure can be pinpointed.
+	reader := &offsetReader{r: r}
+	fields := []struct {
+		name    string
+		element interface{}
+	}{
+		{"PendingChannelID", a.PendingChannelID[:]},
+		{"DustLimit", &a.DustLimit},
+		{"MaxValueInFlight", &a.MaxValueInFlight},
+		{"ChannelReserve", &a.ChannelReserve},
+		{"HtlcMinimum", &a.HtlcMinimum},
+		{"MinAcceptDepth", &a.MinAcceptDepth},
+		{"CsvDelay", &a.CsvDelay},
+		{"MaxAcceptedHTLCs", &a.MaxAcceptedHTLCs},
+		{"FundingKey", &a.FundingKey},
+		{"RevocationPoint", &a.RevocationPoint},
+		{"PaymentPoint", &a.PaymentPoint},
+		{"DelayedPaymentPoint", &a.DelayedPaymentPoint},
+		{"HtlcPoint", &a.HtlcPoint},
+		{"FirstCommitmentPoint", &a.FirstCommitmentPoint},
+	}
+	for _, field := range fields {
+		err := reader.readField(field.name, field.element)
+		if err != nil {
+			return err
+		}
 	}
 
 	// For backwards compatibility, the optional extra data blob for
 	// AcceptChannel must contain an entry for the upfront shutdown script.
 	// We'll read it out and attempt to parse it.
+	// The blob is read with a limit, so that a message exceeding the
+	// budget doesn't get read into memory in its entirety.
+	tlvOffset := reader.offset
+	var tlvReader io.Reader = reader
+	if limit := budget.readLimit(); limit >= 0 {
+		tlvReader = io.LimitReader(reader, limit)
+	}
+
 	var tlvRecords ExtraOpaqueData
-	if err := ReadElements(r, &tlvRecords); err != nil {
-		return err
+	if err := ReadElement(tlvReader, &tlvRecords); err != nil {
+		return &DecodeError{
+			Offset: tlvOffset,
+			Field:  "ExtraData",
+			Err:    err,
+		}
+	}
+	err := budget.charge("ExtraData", len(tlvRecords))
+	if err != nil {
+		return &DecodeError{
+			Offset: tlvOffset,
+			Field:  "ExtraData",
+			Err:    err,
+		}
 	}
 
 	a.UpfrontShutdownScript, a.ExtraData, err = parseShutdownScript(
-		tlvRecords,
+		tlvRecords, quirks,
 	)
+	if err == nil {
+		err = budget.charge(
+			"UpfrontShutdownScript", len(a.UpfrontShutdownScript),
+		)
+	}
 	if err != nil {
+		return &DecodeError{
+			Offset: tlvOffset,
+			Field:  "UpfrontShutdownScript",
+			Err:    err,
+		}
+	}
+
+	return nil
+}
+
+// DecodeCtx is like Decode, but aborts reading from r once the passed context
+// is cancelled, in which case the returned error wraps the error of the
+// context. This prevents a peer that stops sending mid-message from blocking
+// the caller indefinitely. After a cancellation, r may still be read from by
+// an abandoned read, so it must be discarded. Any shutdown script quirks
+// attached to the context with WithShutdownScriptQuirks are used to recover a
+// mis-encoded upfront shutdown script.
+func (a *AcceptChannel) DecodeCtx(ctx context.Context, r io.Reader,
+	pver uint32) error {
+
+	return a.decode(
+		&contextReader{ctx: ctx, r: r}, pver, nil,
+		shutdownScriptQuirksFromContext(ctx),
+	)
+}
+
+// ValidateUpfrontShutdown ensures the message carries an upfront shutdown
+// script record if the upfront shutdown script feature was negotiated with its
+// sender, returning an *ErrUpfrontShutdownAbsent otherwise. Decode leaves
+// UpfrontShutdownScript nil if the record is absent entirely, while a
+// zero-length script, which is valid, is decoded as an empty non-nil script.
+func (a *AcceptChannel) ValidateUpfrontShutdown(negotiated bool) error {
+	if negotiated && a.UpfrontShutdownScript == nil {
+		return &ErrUpfrontShutdownAbsent{msgType: a.MsgType()}
+	}
+
+	return nil
+}
+
+// ValidateMinAcceptDepth ensures the message only requires zero confirmations
+// of the funding transaction if we offered the zero-conf feature to its
+// sender, returning ErrUnexpectedZeroConf otherwise. A non-zero depth is
+// always valid, as the sender may require confirmations even if zero-conf was
+// offered.
+func (a *AcceptChannel) ValidateMinAcceptDepth(zeroConfOffered bool) error {
+	if a.MinAcceptDepth == 0 && !zeroConfOffered {
+		return ErrUnexpectedZeroConf
+	}
+
+	return nil
+}
+
+// ValidateDustLimit ensures that the DustLimit of the message is at least the
+// dust threshold of its UpfrontShutdownScript, as returned by
+// DustLimitForScript, returning an *ErrDustLimitBelowScript otherwise. A
+// message without an upfront shutdown script always passes.
+func (a *AcceptChannel) ValidateDustLimit() error {
+	scriptDustLimit := DustLimitForScript(a.UpfrontShutdownScript)
+	if a.DustLimit < scriptDustLimit {
+		return &ErrDustLimitBelowScript{
+			DustLimit:       a.DustLimit,
+			ScriptDustLimit: scriptDustLimit,
+		}
+	}
+
+	return nil
+}
+
+// ValidateAnchorReserve ensures that the ChannelReserve of the AcceptChannel
+// the responder of an anchor channel sent is at least its DustLimit plus the
+// value of both anchor outputs, returning an *ErrAnchorReserveBelowDust
+// otherwise. As the initiator pays for the anchors out of its balance, a
+// smaller reserve could leave its output at the reserve trimmed as dust,
+// making the reserve worthless as an incentive. Channels without anchors
+// always pass, the legacy dust check being done by CommitConstraints.
+func ValidateAnchorReserve(accept *AcceptChannel, anchors bool) error {
+	if !anchors {
+		return nil
+	}
+
+	anchorReserve := 2 * AnchorOutputValue
+	if accept.ChannelReserve < accept.DustLimit+anchorReserve {
+		return &ErrAnchorReserveBelowDust{
+			ChannelReserve: accept.ChannelReserve,
+			DustLimit:      accept.DustLimit,
+			AnchorReserve:  anchorReserve,
+		}
+	}
+
+	return nil
+}
+
+// ValidatePubKeys ensures that the funding key and the five base points of the
+// message are pairwise distinct. A peer reusing keys across these fields would
+// reduce the entropy of the keys derived from them, so ErrDuplicatePubKey is
+// returned in that case. See BasePoints.ValidateDistinct.
+func (a *AcceptChannel) ValidatePubKeys() error {
+	basePoints := a.BasePoints()
+	return basePoints.ValidateDistinct()
+}
+
+// ValidateFundingKey ensures that the funding key of the message isn't the
+// given node identity key of its sender, returning ErrFundingKeyIsIdentity
+// otherwise, as reusing the identity key links the channel to the node
+// on-chain.
+func (a *AcceptChannel) ValidateFundingKey(
+	identityKey *btcec.PublicKey) error {
+
+	if a.FundingKey != nil && identityKey != nil &&
+		a.FundingKey.IsEqual(identityKey) {
+
+		return ErrFundingKeyIsIdentity
+	}
+
+	return nil
+}
+
+// Validate runs the checks of the message that don't depend on the context it
+// was sent in: ValidatePubKeys, ValidateChannelType, ValidateDustLimit and
+// ValidateReserveMsat. The error of the first failing check is returned. As
+// the set of checks grows with the protocol, a message that passed Validate in
+// an earlier version may fail it in a later one.
+func (a *AcceptChannel) Validate() error {
+	if err := a.ValidatePubKeys(); err != nil {
 		return err
 	}
 
+	if err := a.ValidateChannelType(); err != nil {
+		return err
+	}
+
+	if err := a.ValidateDustLimit(); err != nil {
+		return err
+	}
+
+	return a.ValidateReserveMsat()
+}
+
+// knownAcceptChannelTypes is the set of TLV record types of the ExtraData of
+// an AcceptChannel that are interpreted by lnd.
+var knownAcceptChannelTypes = map[tlv.Type]struct{}{
+	ChannelTypeRecordType:  {},
+	MaxHtlcExpiryDeltaType: {},
+	FeeRateRangeType:       {},
+	ReserveMsatType:        {},
+}
+
+// UnknownExtraData returns the records of the ExtraData of the message that
+// are left after extracting the records known to lnd, packed as a TLV stream
+// sorted by type. A nil blob is returned if all records are known. This is
+// meant for debugging experimental features of peers, as unknown odd records
+// are otherwise silently ignored.
+func (a *AcceptChannel) UnknownExtraData() (ExtraOpaqueData, error) {
+	if len(a.ExtraData) == 0 {
+		return nil, nil
+	}
+
+	typeMap, err := a.ExtraData.ExtractRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	tlvMap := make(map[uint64][]byte)
+	for typ, value := range typeMap {
+		if _, ok := knownAcceptChannelTypes[typ]; ok {
+			continue
+		}
+		tlvMap[uint64(typ)] = value
+	}
+
+	if len(tlvMap) == 0 {
+		return nil, nil
+	}
+
+	records := tlv.MapToRecords(tlvMap)
+	tlv.SortRecords(records)
+
+	var unknown ExtraOpaqueData
+	if err := unknown.PackRecords(records...); err != nil {
+		return nil, err
+	}
+
+	return unknown, nil
+}
+
+// LocalToSelfDelay returns the number of blocks the receiver of the
+// AcceptChannel, the initiator of the channel, must delay the to_self output
+// of its own commitment transactions by, which is the CsvDelay the responder
+// requires. The delay of the to_self output of the responder is negotiated
+// separately, through the CsvDelay of the OpenChannel. The second-level HTLC
+// outputs of the initiator use the same delay.
+func (a *AcceptChannel) LocalToSelfDelay() uint16 {
+	return a.CsvDelay
+}
+
+// ValidatePush ensures that pushing pushAmt to the responder of a channel of
+// the given capacity results in balances that respect the reserves of the
+// AcceptChannel the responder sent. The funder must keep at least the
+// ChannelReserve after the push, otherwise an *ErrPushViolatesReserve is
+// returned. The responder, as in the spec, is allowed to start out below the
+// reserve the funder requires, since that reserve isn't part of the
+// AcceptChannel. The commitment fee isn't accounted for, as it depends on the
+// fee rate of the initial commitment.
+func ValidatePush(pushAmt MilliSatoshi, accept *AcceptChannel,
+	capacity btcutil.Amount) error {
+
+	capacityMSat := NewMSatFromSatoshis(capacity)
+	if pushAmt > capacityMSat {
+		return ErrPushExceedsCapacity
+	}
+
+	funderBalance := capacityMSat - pushAmt
+	if funderBalance < NewMSatFromSatoshis(accept.ChannelReserve) {
+		return &ErrPushViolatesReserve{
+			FunderBalance:  funderBalance,
+			ChannelReserve: accept.ChannelReserve,
+		}
+	}
+
 	return nil
 }
 
+// ValidateMaxValueInFlight ensures that the MaxValueInFlight of the
+// AcceptChannel the responder of a channel of the given capacity sent is at
+// least its HtlcMinimum, returning an *ErrMaxValueInFlightBelowMin otherwise,
+// and that it doesn't exceed the capacity, returning an
+// *ErrMaxValueInFlightExceedsCapacity otherwise.
+func ValidateMaxValueInFlight(accept *AcceptChannel,
+	capacity btcutil.Amount) error {
+
+	if accept.MaxValueInFlight < accept.HtlcMinimum {
+		return &ErrMaxValueInFlightBelowMin{
+			MaxValueInFlight: accept.MaxValueInFlight,
+			HtlcMinimum:      accept.HtlcMinimum,
+		}
+	}
+
+	if accept.MaxValueInFlight > NewMSatFromSatoshis(capacity) {
+		return &ErrMaxValueInFlightExceedsCapacity{
+			MaxValueInFlight: accept.MaxValueInFlight,
+			Capacity:         capacity,
+		}
+	}
+
+	return nil
+}
+
+// ImpliedMaxHtlc returns the largest single HTLC the funder of a channel of
+// the given capacity can offer under the constraints of the AcceptChannel the
+// responder sent. The HTLC is bound by the MaxValueInFlight, and by the part
+// of the capacity that exceeds the ChannelReserve the funder must keep. Zero is
+// returned if no HTLC of at least HtlcMinimum fits within these bounds. As
+// with the reserve, the commitment fee isn't accounted for.
+func ImpliedMaxHtlc(accept *AcceptChannel,
+	capacity btcutil.Amount) MilliSatoshi {
+
+	if accept.ChannelReserve >= capacity {
+		return 0
+	}
+
+	maxHtlc := NewMSatFromSatoshis(capacity - accept.ChannelReserve)
+	if accept.MaxValueInFlight < maxHtlc {
+		maxHtlc = accept.MaxValueInFlight
+	}
+
+	if maxHtlc < accept.HtlcMinimum {
+		return 0
+	}
+
+	return maxHtlc
+}
+
+// SortFundingKeys returns the given funding keys ordered lexicographically by
+// their compressed serialization, which is the order they appear in within
+// the funding script of a channel. Equal keys are returned in the order they
+// were passed in. Both keys must be non-nil.
+func SortFundingKeys(a, b *btcec.PublicKey) (first,
+	second *btcec.PublicKey) {
+
+	if bytes.Compare(a.SerializeCompressed(), b.SerializeCompressed()) > 0 {
+		return b, a
+	}
+
+	return a, b
+}
+
+// FundingScript returns the 2-of-2 multisig witness script of the funding
+// output of a channel between our localKey and the FundingKey the remote party
+// sent in its AcceptChannel or OpenChannel, along with the p2wsh output script
+// paying to it. The keys are ordered lexicographically by their compressed
+// serialization, so both parties arrive at the same script regardless of which
+// one is local.
+func FundingScript(localKey, remoteFundingKey *btcec.PublicKey) ([]byte,
+	[]byte, error) {
+
+	if localKey == nil || remoteFundingKey == nil {
+		return nil, nil, errors.New("funding keys must be set")
+	}
+
+	first, second := SortFundingKeys(localKey, remoteFundingKey)
+	witnessScript, err := input.GenMultiSigScript(
+		first.SerializeCompressed(), second.SerializeCompressed(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pkScript, err := input.WitnessScriptHash(witnessScript)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return witnessScript, pkScript, nil
+}
+
+// Equal returns true if both messages carry the same field values. As opposed
+// to reflect.DeepEqual, public keys are compared by value rather than by
+// pointer, a nil and an empty upfront shutdown script are considered equal, and
+// ExtraData is compared by its parsed TLV records rather than byte by byte.
+func (a *AcceptChannel) Equal(b *AcceptChannel) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if a.PendingChannelID != b.PendingChannelID ||
+		a.DustLimit != b.DustLimit ||
+		a.MaxValueInFlight != b.MaxValueInFlight ||
+		a.ChannelReserve != b.ChannelReserve ||
+		a.HtlcMinimum != b.HtlcMinimum ||
+		a.MinAcceptDepth != b.MinAcceptDepth ||
+		a.CsvDelay != b.CsvDelay ||
+		a.MaxAcceptedHTLCs != b.MaxAcceptedHTLCs {
+
+		return false
+	}
+
+	keyPairs := [][2]*btcec.PublicKey{
+		{a.FundingKey, b.FundingKey},
+		{a.RevocationPoint, b.RevocationPoint},
+		{a.PaymentPoint, b.PaymentPoint},
+		{a.DelayedPaymentPoint, b.DelayedPaymentPoint},
+		{a.HtlcPoint, b.HtlcPoint},
+		{a.FirstCommitmentPoint, b.FirstCommitmentPoint},
+	}
+	for _, keys := range keyPairs {
+		if !pubKeysEqual(keys[0], keys[1]) {
+			return false
+		}
+	}
+
+	if !bytes.Equal(a.UpfrontShutdownScript, b.UpfrontShutdownScript) {
+		return false
+	}
+
+	return extraDataEqual(a.ExtraData, b.ExtraData)
+}
+
+// pubKeysEqual returns true if both keys are nil, or both are set to the same
+// key.
+func pubKeysEqual(a, b *btcec.PublicKey) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.IsEqual(b)
+}
+
+// extraDataEqual returns true if both blobs contain the same set of TLV
+// records. If either of them can't be parsed as a TLV stream, the raw bytes
+// are compared instead.
+func extraDataEqual(a, b ExtraOpaqueData) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+
+	aTypes, errA := a.ExtractRecords()
+	bTypes, errB := b.ExtractRecords()
+	if errA != nil || errB != nil {
+		return bytes.Equal(a, b)
+	}
+
+	if len(aTypes) != len(bTypes) {
+		return false
+	}
+	for typ, aVal := range aTypes {
+		bVal, ok := bTypes[typ]
+		if !ok || !bytes.Equal(aVal, bVal) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // packShutdownScript takes an upfront shutdown script and an opaque data blob
-// and concatenates them.
+// and concatenates them. As required by BOLT #2, the shutdown script record is
+// always emitted first. If the data blob is a TLV stream, it must not contain
+// a shutdown script record itself, otherwise ErrDuplicateShutdownScript is
+// returned. A data blob that isn't a TLV stream is appended as is.
 func packShutdownScript(addr DeliveryAddress, extraData ExtraOpaqueData) (
 	ExtraOpaqueData, error) {
 
@@ -246,9 +859,25 @@ func packShutdownScript(addr DeliveryAddress, extraData ExtraOpaqueData) (
 			"script as TLV record: %v", err)
 	}
 
-	// Concatenate the remaining blob with the shutdown script record.
-	tlvRecords = append(tlvRecords, extraData...)
-	return tlvRecords, nil
+	// Extra data that isn't a valid TLV stream can't be merged, so it is
+	// appended to the shutdown script record as is.
+	if _, err := extraData.ExtractRecords(); err != nil {
+		tlvRecords = append(tlvRecords, extraData...)
+		return tlvRecords, nil
+	}
+
+	// Otherwise, merge the remaining blob with the shutdown script record.
+	// Since the shutdown script has the lowest type, it always ends up as
+	// the first record. Merging also asserts that the extra data doesn't
+	// contain a shutdown script record itself.
+	tlvRecords, err = MergeExtraData(tlvRecords, extraData)
+	if dupErr, ok := err.(*ErrDuplicateTLVType); ok &&
+		dupErr.Type == DeliveryAddrType {
+
+		return nil, ErrDuplicateShutdownScript
+	}
+
+	return tlvRecords, err
 }
 
 // parseShutdownScript reads and extract the upfront shutdown script from the
@@ -257,37 +886,101 @@ func packShutdownScript(addr DeliveryAddress, extraData ExtraOpaqueData) (
 //
 // This can be used to parse extra data for the OpenChannel and AcceptChannel
 // messages, where the shutdown script is mandatory if extra TLV data is
-// present.
-func parseShutdownScript(tlvRecords ExtraOpaqueData) (DeliveryAddress,
-	ExtraOpaqueData, error) {
+// present. If the data blob can't be parsed, the given quirks, which may be
+// nil, are tried in order to recover the script.
+func parseShutdownScript(tlvRecords ExtraOpaqueData,
+	quirks []ShutdownScriptQuirk) (DeliveryAddress, ExtraOpaqueData, error) {
 
 	// If no TLV data is present there can't be any script available.
 	if len(tlvRecords) == 0 {
 		return nil, tlvRecords, nil
 	}
 
-	// Otherwise the shutdown script MUST be present.
+	// Refuse to parse more records than we're willing to iterate over.
+	if err := tlvRecords.checkNumRecords(MaxTLVRecords); err != nil {
+		return nil, nil, err
+	}
+
+	// Otherwise the shutdown script MUST be present. If the data can't be
+	// parsed, it may have been mis-encoded by a known-buggy peer, in which
+	// case one of the given quirks may still recover the script.
 	var addr DeliveryAddress
 	tlvs, err := tlvRecords.ExtractRecords(addr.NewRecord())
 	if err != nil {
-		return nil, nil, err
+		addr, rest, ok := recoverShutdownScript(quirks, tlvRecords)
+		if !ok {
+			return nil, nil, err
+		}
+
+		return addr, rest, nil
 	}
 
 	// Not among TLV records, this means the data was invalid.
 	if _, ok := tlvs[DeliveryAddrType]; !ok {
-		return nil, nil, fmt.Errorf("no shutdown script in non-empty " +
-			"data blob")
+		return nil, nil, errShutdownScriptAbsent
+	}
+
+	// A zero-length script is returned as an empty, non-nil script, such
+	// that callers can tell it apart from an absent record.
+	if addr == nil {
+		addr = DeliveryAddress{}
 	}
 
 	// Now that we have retrieved the address (which can be zero-length),
 	// we'll remove the bytes encoding it from the TLV data before
-	// returning it.
-	addrLen := len(addr)
-	tlvRecords = tlvRecords[addrLen+2:]
+	// returning it. Both the type and the length of the record are
+	// encoded as BigSize varints, so the length prefix of a script longer
+	// than 252 bytes takes up more than a single byte.
+	addrLen := uint64(len(addr))
+	recordLen := tlv.VarIntSize(DeliveryAddrType) +
+		tlv.VarIntSize(addrLen) + addrLen
+	tlvRecords = tlvRecords[recordLen:]
 
 	return addr, tlvRecords, nil
 }
 
+// OpenChannelParams holds the channel constraints of an AcceptChannel that
+// can be reused when re-opening a channel with the same peer.
+type OpenChannelParams struct {
+	// DustLimit is the dust limit the peer enforced on its commitment
+	// transaction.
+	DustLimit btcutil.Amount
+
+	// ChannelReserve is the reserve the peer required us to maintain. As
+	// AcceptChannel doesn't carry the channel capacity, this is the
+	// absolute amount rather than a ratio of the capacity.
+	ChannelReserve btcutil.Amount
+
+	// MaxValueInFlight is the maximum value the peer allowed us to have
+	// pending in the channel at once.
+	MaxValueInFlight MilliSatoshi
+
+	// HtlcMinimum is the smallest HTLC the peer accepted.
+	HtlcMinimum MilliSatoshi
+
+	// CsvDelay is the CSV delay the peer required on our outputs.
+	CsvDelay uint16
+
+	// MaxAcceptedHTLCs is the maximum number of HTLCs the peer accepted
+	// from us at once.
+	MaxAcceptedHTLCs uint16
+}
+
+// ToOpenParams extracts the constraints of the AcceptChannel that can be
+// reused for a fresh open with identical parameters. Fields that are specific
+// to the original channel, such as the keys and the pending channel ID, are
+// omitted.
+func (a *AcceptChannel) ToOpenParams() OpenChannelParams {
+	return OpenChannelParams{
+		DustLimit:        a.DustLimit,
+		ChannelReserve:   a.ChannelReserve,
+		MaxValueInFlight: a.MaxValueInFlight,
+		HtlcMinimum:      a.HtlcMinimum,
+		CsvDelay:         a.CsvDelay,
+		MaxAcceptedHTLCs: a.MaxAcceptedHTLCs,
+	}
+}
+
 // MsgType returns the MessageType code which uniquely identifies this message
 // as an AcceptChannel on the wire.
 //
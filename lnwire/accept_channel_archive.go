@@ -0,0 +1,240 @@
+package lnwire
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+// AcceptChannelArchiveVersion is the version of the archival format written
+// by MarshalArchive.
+const AcceptChannelArchiveVersion = 1
+
+// acceptChannelArchive is the archival representation of an AcceptChannel.
+// Contrary to the wire format, it is self-describing, and new fields can be
+// added to it as long as the version is bumped for changes that older
+// readers can't safely ignore.
+type acceptChannelArchive struct {
+	Version               uint32              `json:"version"`
+	PendingChannelID      string              `json:"pending_channel_id"`
+	DustLimit             int64               `json:"dust_limit_sat"`
+	MaxValueInFlight      uint64              `json:"max_value_in_flight_msat"`
+	ChannelReserve        int64               `json:"channel_reserve_sat"`
+	HtlcMinimum           uint64              `json:"htlc_minimum_msat"`
+	MinAcceptDepth        uint32              `json:"min_accept_depth"`
+	CsvDelay              uint16              `json:"csv_delay"`
+	MaxAcceptedHTLCs      uint16              `json:"max_accepted_htlcs"`
+	FundingKey            string              `json:"funding_key"`
+	RevocationPoint       string              `json:"revocation_point"`
+	PaymentPoint          string              `json:"payment_point"`
+	DelayedPaymentPoint   string              `json:"delayed_payment_point"`
+	HtlcPoint             string              `json:"htlc_point"`
+	FirstCommitmentPoint  string              `json:"first_commitment_point"`
+	UpfrontShutdownScript string              `json:"upfront_shutdown_script,omitempty"`
+	TLVRecords            []archivedTLVRecord `json:"tlv_records,omitempty"`
+	ExtraData             string              `json:"extra_data,omitempty"`
+}
+
+// archivedTLVRecord is the archival representation of a single TLV record
+// carried in the ExtraData of a message.
+type archivedTLVRecord struct {
+	Type  uint64 `json:"type"`
+	Value string `json:"value"`
+}
+
+// MarshalArchive serializes the AcceptChannel into a versioned JSON document
+// suitable for long-term storage. As opposed to the wire format, the document
+// is self-describing: keys and scripts are hex encoded, and the ExtraData is
+// split into its TLV records. If the ExtraData isn't a valid TLV stream, it is
+// stored as a raw hex blob instead.
+func (a *AcceptChannel) MarshalArchive() ([]byte, error) {
+	archive := acceptChannelArchive{
+		Version:          AcceptChannelArchiveVersion,
+		PendingChannelID: hex.EncodeToString(a.PendingChannelID[:]),
+		DustLimit:        int64(a.DustLimit),
+		MaxValueInFlight: uint64(a.MaxValueInFlight),
+		ChannelReserve:   int64(a.ChannelReserve),
+		HtlcMinimum:      uint64(a.HtlcMinimum),
+		MinAcceptDepth:   a.MinAcceptDepth,
+		CsvDelay:         a.CsvDelay,
+		MaxAcceptedHTLCs: a.MaxAcceptedHTLCs,
+		FundingKey:       archivePubKey(a.FundingKey),
+		RevocationPoint:  archivePubKey(a.RevocationPoint),
+		PaymentPoint:     archivePubKey(a.PaymentPoint),
+		DelayedPaymentPoint: archivePubKey(
+			a.DelayedPaymentPoint,
+		),
+		HtlcPoint: archivePubKey(a.HtlcPoint),
+		FirstCommitmentPoint: archivePubKey(
+			a.FirstCommitmentPoint,
+		),
+		UpfrontShutdownScript: hex.EncodeToString(
+			a.UpfrontShutdownScript,
+		),
+	}
+
+	if len(a.ExtraData) > 0 {
+		typeMap, err := a.ExtraData.ExtractRecords()
+		if err != nil {
+			archive.ExtraData = hex.EncodeToString(a.ExtraData)
+		} else {
+			archive.TLVRecords = archiveTLVRecords(typeMap)
+		}
+	}
+
+	return json.Marshal(&archive)
+}
+
+// UnmarshalArchive deserializes an AcceptChannel from a document created by
+// MarshalArchive. Documents of a version newer than
+// AcceptChannelArchiveVersion are rejected, since they may carry data this
+// version doesn't know how to interpret.
+func (a *AcceptChannel) UnmarshalArchive(b []byte) error {
+	var archive acceptChannelArchive
+	if err := json.Unmarshal(b, &archive); err != nil {
+		return err
+	}
+
+	if archive.Version == 0 ||
+		archive.Version > AcceptChannelArchiveVersion {
+
+		return fmt.Errorf("unknown AcceptChannel archive version %d",
+			archive.Version)
+	}
+
+	pendingChanID, err := hex.DecodeString(archive.PendingChannelID)
+	if err != nil {
+		return fmt.Errorf("invalid pending channel id: %v", err)
+	}
+	if len(pendingChanID) != len(a.PendingChannelID) {
+		return fmt.Errorf("invalid pending channel id length %d",
+			len(pendingChanID))
+	}
+
+	var msg AcceptChannel
+	copy(msg.PendingChannelID[:], pendingChanID)
+	msg.DustLimit = btcutil.Amount(archive.DustLimit)
+	msg.MaxValueInFlight = MilliSatoshi(archive.MaxValueInFlight)
+	msg.ChannelReserve = btcutil.Amount(archive.ChannelReserve)
+	msg.HtlcMinimum = MilliSatoshi(archive.HtlcMinimum)
+	msg.MinAcceptDepth = archive.MinAcceptDepth
+	msg.CsvDelay = archive.CsvDelay
+	msg.MaxAcceptedHTLCs = archive.MaxAcceptedHTLCs
+
+	keys := []struct {
+		name    string
+		encoded string
+		key     **btcec.PublicKey
+	}{
+		{"funding_key", archive.FundingKey, &msg.FundingKey},
+		{"revocation_point", archive.RevocationPoint,
+			&msg.RevocationPoint},
+		{"payment_point", archive.PaymentPoint, &msg.PaymentPoint},
+		{"delayed_payment_point", archive.DelayedPaymentPoint,
+			&msg.DelayedPaymentPoint},
+		{"htlc_point", archive.HtlcPoint, &msg.HtlcPoint},
+		{"first_commitment_point", archive.FirstCommitmentPoint,
+			&msg.FirstCommitmentPoint},
+	}
+	for _, k := range keys {
+		*k.key, err = unarchivePubKey(k.encoded)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %v", k.name, err)
+		}
+	}
+
+	if archive.UpfrontShutdownScript != "" {
+		msg.UpfrontShutdownScript, err = hex.DecodeString(
+			archive.UpfrontShutdownScript,
+		)
+		if err != nil {
+			return fmt.Errorf("invalid upfront shutdown script: "+
+				"%v", err)
+		}
+	}
+
+	switch {
+	case archive.ExtraData != "" && len(archive.TLVRecords) > 0:
+		return fmt.Errorf("archive contains both raw extra data and " +
+			"tlv records")
+
+	case archive.ExtraData != "":
+		msg.ExtraData, err = hex.DecodeString(archive.ExtraData)
+		if err != nil {
+			return fmt.Errorf("invalid extra data: %v", err)
+		}
+
+	case len(archive.TLVRecords) > 0:
+		tlvMap := make(map[uint64][]byte, len(archive.TLVRecords))
+		for _, record := range archive.TLVRecords {
+			if _, ok := tlvMap[record.Type]; ok {
+				return fmt.Errorf("duplicate tlv record type "+
+					"%d", record.Type)
+			}
+
+			value, err := hex.DecodeString(record.Value)
+			if err != nil {
+				return fmt.Errorf("invalid value of tlv "+
+					"record type %d: %v", record.Type, err)
+			}
+			tlvMap[record.Type] = value
+		}
+
+		records := tlv.MapToRecords(tlvMap)
+		tlv.SortRecords(records)
+		if err := msg.ExtraData.PackRecords(records...); err != nil {
+			return err
+		}
+	}
+
+	*a = msg
+
+	return nil
+}
+
+// archiveTLVRecords returns the archival representation of the parsed TLV
+// records, sorted by type.
+func archiveTLVRecords(typeMap tlv.TypeMap) []archivedTLVRecord {
+	records := make([]archivedTLVRecord, 0, len(typeMap))
+	for typ, value := range typeMap {
+		records = append(records, archivedTLVRecord{
+			Type:  uint64(typ),
+			Value: hex.EncodeToString(value),
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Type < records[j].Type
+	})
+
+	return records
+}
+
+// archivePubKey returns the hex encoding of the compressed public key, or an
+// empty string if the key isn't set.
+func archivePubKey(key *btcec.PublicKey) string {
+	if key == nil {
+		return ""
+	}
+
+	return hex.EncodeToString(key.SerializeCompressed())
+}
+
+// unarchivePubKey parses a public key encoded by archivePubKey.
+func unarchivePubKey(encoded string) (*btcec.PublicKey, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	keyBytes, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return btcec.ParsePubKey(keyBytes, btcec.S256())
+}
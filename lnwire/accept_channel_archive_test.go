@@ -0,0 +1,126 @@
+package lnwire
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/stretchr/testify/require"
+)
+
+// newArchiveTestAcceptChannel returns an AcceptChannel with all fields set
+// and distinct keys, carrying the given extra data.
+func newArchiveTestAcceptChannel(t *testing.T,
+	extraData ExtraOpaqueData) *AcceptChannel {
+
+	keys := make([]*btcec.PublicKey, 6)
+	for i := range keys {
+		priv, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		keys[i] = priv.PubKey()
+	}
+
+	return &AcceptChannel{
+		PendingChannelID:      [32]byte{1, 2, 3},
+		DustLimit:             573,
+		MaxValueInFlight:      990000000,
+		ChannelReserve:        10000,
+		HtlcMinimum:           1000,
+		MinAcceptDepth:        3,
+		CsvDelay:              144,
+		MaxAcceptedHTLCs:      483,
+		FundingKey:            keys[0],
+		RevocationPoint:       keys[1],
+		PaymentPoint:          keys[2],
+		DelayedPaymentPoint:   keys[3],
+		HtlcPoint:             keys[4],
+		FirstCommitmentPoint:  keys[5],
+		UpfrontShutdownScript: []byte("shutdown script"),
+		ExtraData:             extraData,
+	}
+}
+
+// TestAcceptChannelArchiveRoundTrip asserts that an AcceptChannel survives a
+// round trip through its archival format, including its TLV records.
+func TestAcceptChannelArchiveRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var tlvData ExtraOpaqueData
+	first, second := []byte{0x01, 0x02}, []byte("value")
+	require.NoError(t, tlvData.PackRecords(
+		tlv.MakePrimitiveRecord(1, &first),
+		tlv.MakePrimitiveRecord(65537, &second),
+	))
+
+	tests := []struct {
+		name      string
+		extraData ExtraOpaqueData
+	}{
+		{
+			name: "no extra data",
+		},
+		{
+			name:      "tlv records",
+			extraData: tlvData,
+		},
+		{
+			name:      "non tlv extra data",
+			extraData: ExtraOpaqueData{0xff},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			msg := newArchiveTestAcceptChannel(t, test.extraData)
+
+			archive, err := msg.MarshalArchive()
+			require.NoError(t, err)
+
+			var decoded AcceptChannel
+			require.NoError(t, decoded.UnmarshalArchive(archive))
+			require.True(t, msg.Equal(&decoded))
+			require.True(
+				t, bytes.Equal(msg.ExtraData, decoded.ExtraData),
+			)
+		})
+	}
+}
+
+// TestAcceptChannelArchiveFormat asserts that the archival format is
+// versioned and describes the TLV records individually.
+func TestAcceptChannelArchiveFormat(t *testing.T) {
+	t.Parallel()
+
+	var tlvData ExtraOpaqueData
+	value := []byte{0xab, 0xcd}
+	require.NoError(t, tlvData.PackRecords(
+		tlv.MakePrimitiveRecord(3, &value),
+	))
+	msg := newArchiveTestAcceptChannel(t, tlvData)
+
+	archive, err := msg.MarshalArchive()
+	require.NoError(t, err)
+
+	var document map[string]interface{}
+	require.NoError(t, json.Unmarshal(archive, &document))
+	require.EqualValues(
+		t, AcceptChannelArchiveVersion, document["version"],
+	)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"type": 3.0, "value": "abcd"},
+	}, document["tlv_records"])
+
+	// Documents of a newer version must be rejected.
+	document["version"] = AcceptChannelArchiveVersion + 1
+	newer, err := json.Marshal(document)
+	require.NoError(t, err)
+
+	var decoded AcceptChannel
+	require.Error(t, decoded.UnmarshalArchive(newer))
+}
@@ -0,0 +1,148 @@
+package lnwire
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+)
+
+// ErrMissingAcceptChannelKey is returned when constructing an AcceptChannel
+// message without one of its public keys.
+type ErrMissingAcceptChannelKey struct {
+	// Name is the name of the missing key.
+	Name string
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrMissingAcceptChannelKey) Error() string {
+	return fmt.Sprintf("accept channel is missing its %v", e.Name)
+}
+
+// AcceptChannelAmounts groups the amounts of an AcceptChannel message that
+// are denominated in satoshis.
+type AcceptChannelAmounts struct {
+	// DustLimit is the threshold below which no HTLC output should be
+	// generated for the responder's commitment transaction.
+	DustLimit btcutil.Amount
+
+	// ChannelReserve is the amount that the initiator must keep in the
+	// channel at all times.
+	ChannelReserve btcutil.Amount
+}
+
+// AcceptChannelMSatAmounts groups the amounts of an AcceptChannel message
+// that are denominated in milli-satoshis.
+type AcceptChannelMSatAmounts struct {
+	// MaxValueInFlight is the maximum amount of coins that can be pending
+	// within the channel at any given time.
+	MaxValueInFlight MilliSatoshi
+
+	// HtlcMinimum is the smallest HTLC that the responder will accept.
+	HtlcMinimum MilliSatoshi
+}
+
+// AcceptChannelKeys groups the public keys of an AcceptChannel message, all
+// of which must be set.
+type AcceptChannelKeys struct {
+	// FundingKey is the key that should be used on behalf of the
+	// responder within the 2-of-2 multi-sig output.
+	FundingKey *btcec.PublicKey
+
+	// RevocationPoint is the base revocation point of the responder.
+	RevocationPoint *btcec.PublicKey
+
+	// PaymentPoint is the base payment point of the responder.
+	PaymentPoint *btcec.PublicKey
+
+	// DelayedPaymentPoint is the delay point of the responder.
+	DelayedPaymentPoint *btcec.PublicKey
+
+	// HtlcPoint is the base point used to derive the set of keys for this
+	// channel that will be used within the HTLC public key scripts.
+	HtlcPoint *btcec.PublicKey
+
+	// FirstCommitmentPoint is the first commitment point for the
+	// responder's commitment transaction.
+	FirstCommitmentPoint *btcec.PublicKey
+}
+
+// AcceptChannelParams is the set of parameters used to construct an
+// AcceptChannel message with NewAcceptChannel. The amounts are grouped by
+// their unit, so that a satoshi amount can't end up in a milli-satoshi field
+// by mistake: assigning one group to the other doesn't compile.
+type AcceptChannelParams struct {
+	// PendingChannelID serves to uniquely identify the future channel
+	// created by the initiated single funder workflow.
+	PendingChannelID [32]byte
+
+	// Amounts are the amounts of the message denominated in satoshis.
+	Amounts AcceptChannelAmounts
+
+	// MSatAmounts are the amounts of the message denominated in
+	// milli-satoshis.
+	MSatAmounts AcceptChannelMSatAmounts
+
+	// MinAcceptDepth is the minimum depth that the initiator of the
+	// channel should wait before considering the channel open.
+	MinAcceptDepth uint32
+
+	// CsvDelay is the number of blocks to use for the relative time lock
+	// in the pay-to-self output of the initiator's commitment transaction.
+	CsvDelay uint16
+
+	// MaxAcceptedHTLCs is the total number of incoming HTLCs the responder
+	// will accept.
+	MaxAcceptedHTLCs uint16
+
+	// Keys are the public keys of the responder.
+	Keys AcceptChannelKeys
+
+	// UpfrontShutdownScript is the script to which the channel funds
+	// should be paid when mutually closing the channel. It may be empty.
+	UpfrontShutdownScript DeliveryAddress
+}
+
+// NewAcceptChannel constructs an AcceptChannel message from the given
+// parameters. An error is returned if any of the public keys is missing.
+func NewAcceptChannel(params AcceptChannelParams) (*AcceptChannel, error) {
+	keys := params.Keys
+	namedKeys := []struct {
+		name string
+		key  *btcec.PublicKey
+	}{
+		{"funding key", keys.FundingKey},
+		{"revocation point", keys.RevocationPoint},
+		{"payment point", keys.PaymentPoint},
+		{"delayed payment point", keys.DelayedPaymentPoint},
+		{"htlc point", keys.HtlcPoint},
+		{"first commitment point", keys.FirstCommitmentPoint},
+	}
+	for _, namedKey := range namedKeys {
+		if namedKey.key == nil {
+			return nil, &ErrMissingAcceptChannelKey{
+				Name: namedKey.name,
+			}
+		}
+	}
+
+	return &AcceptChannel{
+		PendingChannelID:      params.PendingChannelID,
+		DustLimit:             params.Amounts.DustLimit,
+		MaxValueInFlight:      params.MSatAmounts.MaxValueInFlight,
+		ChannelReserve:        params.Amounts.ChannelReserve,
+		HtlcMinimum:           params.MSatAmounts.HtlcMinimum,
+		MinAcceptDepth:        params.MinAcceptDepth,
+		CsvDelay:              params.CsvDelay,
+		MaxAcceptedHTLCs:      params.MaxAcceptedHTLCs,
+		FundingKey:            keys.FundingKey,
+		RevocationPoint:       keys.RevocationPoint,
+		PaymentPoint:          keys.PaymentPoint,
+		DelayedPaymentPoint:   keys.DelayedPaymentPoint,
+		HtlcPoint:             keys.HtlcPoint,
+		FirstCommitmentPoint:  keys.FirstCommitmentPoint,
+		UpfrontShutdownScript: params.UpfrontShutdownScript,
+	}, nil
+}
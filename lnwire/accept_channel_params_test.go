@@ -0,0 +1,132 @@
+package lnwire
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// testAcceptChannelParams returns a set of valid parameters for
+// NewAcceptChannel.
+func testAcceptChannelParams() AcceptChannelParams {
+	_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{1})
+
+	return AcceptChannelParams{
+		PendingChannelID: [32]byte{1},
+		Amounts: AcceptChannelAmounts{
+			DustLimit:      354,
+			ChannelReserve: 5000,
+		},
+		MSatAmounts: AcceptChannelMSatAmounts{
+			MaxValueInFlight: 100000000,
+			HtlcMinimum:      1000,
+		},
+		MinAcceptDepth:   3,
+		CsvDelay:         144,
+		MaxAcceptedHTLCs: 483,
+		Keys: AcceptChannelKeys{
+			FundingKey:           pubKey,
+			RevocationPoint:      pubKey,
+			PaymentPoint:         pubKey,
+			DelayedPaymentPoint:  pubKey,
+			HtlcPoint:            pubKey,
+			FirstCommitmentPoint: pubKey,
+		},
+		UpfrontShutdownScript: DeliveryAddress{0x00, 0x14},
+	}
+}
+
+// TestNewAcceptChannel asserts that NewAcceptChannel takes over every
+// parameter into the right field of the message.
+func TestNewAcceptChannel(t *testing.T) {
+	t.Parallel()
+
+	params := testAcceptChannelParams()
+	accept, err := NewAcceptChannel(params)
+	require.NoError(t, err)
+
+	keys := params.Keys
+	require.Equal(t, &AcceptChannel{
+		PendingChannelID:      params.PendingChannelID,
+		DustLimit:             params.Amounts.DustLimit,
+		MaxValueInFlight:      params.MSatAmounts.MaxValueInFlight,
+		ChannelReserve:        params.Amounts.ChannelReserve,
+		HtlcMinimum:           params.MSatAmounts.HtlcMinimum,
+		MinAcceptDepth:        params.MinAcceptDepth,
+		CsvDelay:              params.CsvDelay,
+		MaxAcceptedHTLCs:      params.MaxAcceptedHTLCs,
+		FundingKey:            keys.FundingKey,
+		RevocationPoint:       keys.RevocationPoint,
+		PaymentPoint:          keys.PaymentPoint,
+		DelayedPaymentPoint:   keys.DelayedPaymentPoint,
+		HtlcPoint:             keys.HtlcPoint,
+		FirstCommitmentPoint:  keys.FirstCommitmentPoint,
+		UpfrontShutdownScript: params.UpfrontShutdownScript,
+	}, accept)
+}
+
+// TestNewAcceptChannelMissingKey asserts that NewAcceptChannel fails if any
+// of the public keys is missing.
+func TestNewAcceptChannelMissingKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		clear func(*AcceptChannelKeys)
+	}{
+		{
+			name: "funding key",
+			clear: func(k *AcceptChannelKeys) {
+				k.FundingKey = nil
+			},
+		},
+		{
+			name: "revocation point",
+			clear: func(k *AcceptChannelKeys) {
+				k.RevocationPoint = nil
+			},
+		},
+		{
+			name: "payment point",
+			clear: func(k *AcceptChannelKeys) {
+				k.PaymentPoint = nil
+			},
+		},
+		{
+			name: "delayed payment point",
+			clear: func(k *AcceptChannelKeys) {
+				k.DelayedPaymentPoint = nil
+			},
+		},
+		{
+			name: "htlc point",
+			clear: func(k *AcceptChannelKeys) {
+				k.HtlcPoint = nil
+			},
+		},
+		{
+			name: "first commitment point",
+			clear: func(k *AcceptChannelKeys) {
+				k.FirstCommitmentPoint = nil
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			params := testAcceptChannelParams()
+			test.clear(&params.Keys)
+
+			_, err := NewAcceptChannel(params)
+			require.Equal(
+				t, &ErrMissingAcceptChannelKey{Name: test.name},
+				err,
+			)
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package lnwire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+// acceptRawField describes a fixed size field of a serialized AcceptChannel.
+type acceptRawField struct {
+	name   string
+	offset int
+	size   int
+	pubKey bool
+}
+
+// acceptRawFields are the fixed size fields of a serialized AcceptChannel in
+// the order they are decoded in.
+var acceptRawFields = [...]acceptRawField{
+	{"PendingChannelID", acceptPendingChanIDOffset, 32, false},
+	{"DustLimit", acceptDustLimitOffset, 8, false},
+	{"MaxValueInFlight", acceptMaxValueInFlightOffset, 8, false},
+	{"ChannelReserve", acceptChannelReserveOffset, 8, false},
+	{"HtlcMinimum", acceptHtlcMinimumOffset, 8, false},
+	{"MinAcceptDepth", acceptMinAcceptDepthOffset, 4, false},
+	{"CsvDelay", acceptCsvDelayOffset, 2, false},
+	{"MaxAcceptedHTLCs", acceptMaxAcceptedHTLCsOffset, 2, false},
+	{"FundingKey", acceptFundingKeyOffset, 33, true},
+	{"RevocationPoint", acceptRevocationPointOffset, 33, true},
+	{"PaymentPoint", acceptPaymentPointOffset, 33, true},
+	{"DelayedPaymentPoint", acceptDelayedPaymentOffset, 33, true},
+	{"HtlcPoint", acceptHtlcPointOffset, 33, true},
+	{"FirstCommitmentPoint", acceptFirstCommitPointOffset, 33, true},
+}
+
+// ValidateRaw checks that the serialized AcceptChannel payload, which
+// shouldn't include the message type, decodes and passes Validate, without
+// building the message. Each field is bounds-checked in place, and the first
+// violation is returned, which is the same error Decode or Validate would
+// return. Besides parsing the public keys, which is needed to check that they
+// lie on the curve, nothing is allocated unless the message is invalid or its
+// upfront shutdown script has to be recovered by a ShutdownScriptQuirk.
+func ValidateRaw(data []byte, pver uint32) error {
+	for _, field := range acceptRawFields {
+		end := field.offset + field.size
+		if len(data) < end {
+			err := io.ErrUnexpectedEOF
+			if len(data) <= field.offset {
+				err = io.EOF
+			}
+
+			return &DecodeError{
+				Offset: field.offset,
+				Field:  field.name,
+				Err:    err,
+			}
+		}
+
+		if !field.pubKey {
+			continue
+		}
+
+		if _, err := parsePubKey(data[field.offset:end]); err != nil {
+			return &DecodeError{
+				Offset: field.offset,
+				Field:  field.name,
+				Err:    err,
+			}
+		}
+	}
+
+	script, extraData, err := parseRawShutdownScript(data[acceptTLVOffset:])
+	if err != nil {
+		return &DecodeError{
+			Offset: acceptTLVOffset,
+			Field:  "UpfrontShutdownScript",
+			Err:    err,
+		}
+	}
+
+	// With the message decoded, we run the checks of Validate in the same
+	// order.
+	if err := validateRawPubKeys(data); err != nil {
+		return err
+	}
+
+	if err := validateRawChannelType(extraData); err != nil {
+		return err
+	}
+
+	dustLimit := btcutil.Amount(
+		binary.BigEndian.Uint64(data[acceptDustLimitOffset:]),
+	)
+	scriptDustLimit := DustLimitForScript(script)
+	if dustLimit < scriptDustLimit {
+		return &ErrDustLimitBelowScript{
+			DustLimit:       dustLimit,
+			ScriptDustLimit: scriptDustLimit,
+		}
+	}
+
+	channelReserve := btcutil.Amount(
+		binary.BigEndian.Uint64(data[acceptChannelReserveOffset:]),
+	)
+	return validateRawReserveMsat(channelReserve, extraData)
+}
+
+// parseRawShutdownScript is like parseShutdownScript without any quirks, but
+// returns the script and the remaining extra data as sub-slices of the passed
+// TLV data.
+func parseRawShutdownScript(tlvRecords []byte) (DeliveryAddress,
+	ExtraOpaqueData, error) {
+
+	if len(tlvRecords) == 0 {
+		return nil, tlvRecords, nil
+	}
+
+	if countRawTLVRecords(tlvRecords, MaxTLVRecords) > MaxTLVRecords {
+		return nil, nil, ErrTooManyTLVRecords
+	}
+
+	// As the types of the stream are strictly increasing, the shutdown
+	// script can only be the first record.
+	var (
+		script DeliveryAddress
+		rest   ExtraOpaqueData
+		found  bool
+	)
+	err := walkRawTLVStream(tlvRecords, func(typ tlv.Type, value,
+		remaining []byte) error {
+
+		if typ == DeliveryAddrType {
+			script, rest, found = value, remaining, true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !found {
+		return nil, nil, errShutdownScriptAbsent
+	}
+
+	return script, rest, nil
+}
+
+// validateRawPubKeys implements ValidatePubKeys for a serialized
+// AcceptChannel. As the keys were parsed successfully, they are in their
+// compressed serialization, which can be compared in place.
+func validateRawPubKeys(data []byte) error {
+	const (
+		firstKey = acceptFundingKeyOffset
+		keyLen   = btcec.PubKeyBytesLenCompressed
+		numKeys  = (acceptTLVOffset - firstKey) / keyLen
+	)
+
+	for i := 0; i < numKeys; i++ {
+		a := data[firstKey+i*keyLen : firstKey+(i+1)*keyLen]
+		for j := i + 1; j < numKeys; j++ {
+			b := data[firstKey+j*keyLen : firstKey+(j+1)*keyLen]
+			if bytes.Equal(a, b) {
+				return ErrDuplicatePubKey
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRawChannelType implements ValidateChannelType for the extra data of
+// a serialized AcceptChannel, reading the feature bits of the channel type in
+// place.
+func validateRawChannelType(extraData []byte) error {
+	var (
+		chanType []byte
+		found    bool
+	)
+	err := walkRawTLVStream(extraData, func(typ tlv.Type, value,
+		_ []byte) error {
+
+		if typ == ChannelTypeRecordType {
+			chanType, found = value, true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	// The bits are read in increasing order, so the unknown ones don't
+	// need to be sorted.
+	var unknown []FeatureBit
+	for i := 0; i < len(chanType)*8; i++ {
+		bit := FeatureBit(i)
+		if bit%2 != 0 || !rawFeatureBitSet(chanType, bit) {
+			continue
+		}
+
+		if _, ok := Features[bit]; !ok {
+			unknown = append(unknown, bit)
+		}
+	}
+	if len(unknown) > 0 {
+		return &ErrUnknownChannelType{Unknown: unknown}
+	}
+
+	return validateChannelTypeBits(func(bit FeatureBit) bool {
+		return rawFeatureBitSet(chanType, bit)
+	})
+}
+
+// validateRawReserveMsat implements ValidateReserveMsat for the extra data of
+// a serialized AcceptChannel with the given channel reserve.
+func validateRawReserveMsat(channelReserve btcutil.Amount,
+	extraData []byte) error {
+
+	var (
+		reserve MilliSatoshi
+		found   bool
+	)
+	err := walkRawTLVStream(extraData, func(typ tlv.Type, value,
+		_ []byte) error {
+
+		if typ != ReserveMsatType {
+			return nil
+		}
+
+		// The record is decoded as a primitive uint64, which must
+		// take up exactly 8 bytes.
+		if len(value) != 8 {
+			var v uint64
+			return tlv.NewTypeForDecodingErr(
+				&v, "uint64", uint64(len(value)), 8,
+			)
+		}
+
+		reserve = MilliSatoshi(binary.BigEndian.Uint64(value))
+		found = true
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	return validateReserveMsat(channelReserve, reserve)
+}
+
+// rawFeatureBitSet returns whether the given bit is set in the base256
+// encoding of a feature vector.
+func rawFeatureBitSet(features []byte, bit FeatureBit) bool {
+	byteIndex := int(bit / 8)
+	if byteIndex >= len(features) {
+		return false
+	}
+
+	return (features[len(features)-byteIndex-1]>>(bit%8))&1 == 1
+}
+
+// readRawVarInt is like tlv.ReadVarInt, but reads the varint from the start of
+// b, returning its value along with the number of bytes it takes up.
+func readRawVarInt(b []byte) (uint64, int, error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+
+	var size int
+	switch discriminant := b[0]; {
+	case discriminant < 0xfd:
+		return uint64(discriminant), 1, nil
+
+	case discriminant == 0xfd:
+		size = 3
+
+	case discriminant == 0xfe:
+		size = 5
+
+	default:
+		size = 9
+	}
+
+	if len(b) < size {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+
+	// The encoding is not canonical if the value could have been encoded
+	// using fewer bytes.
+	var rv, min uint64
+	switch size {
+	case 3:
+		rv, min = uint64(binary.BigEndian.Uint16(b[1:])), 0xfd
+
+	case 5:
+		rv, min = uint64(binary.BigEndian.Uint32(b[1:])), 0x10000
+
+	default:
+		rv, min = binary.BigEndian.Uint64(b[1:]), 0x100000000
+	}
+	if rv < min {
+		return 0, 0, tlv.ErrVarIntNotCanonical
+	}
+
+	return rv, size, nil
+}
+
+// walkRawTLVStream calls cb with the type and value of each record of the
+// passed TLV stream, along with the bytes following the record, performing
+// the same checks as decoding the stream with a tlv.Stream. The values are
+// sub-slices of the stream. The walk is aborted with the error cb returns, if
+// any.
+func walkRawTLVStream(stream []byte, cb func(typ tlv.Type, value,
+	remaining []byte) error) error {
+
+	var (
+		min      tlv.Type
+		overflow bool
+	)
+	for len(stream) > 0 {
+		t, n, err := readRawVarInt(stream)
+		if err != nil {
+			return err
+		}
+		stream = stream[n:]
+
+		typ := tlv.Type(t)
+		if overflow || typ < min {
+			return tlv.ErrStreamNotCanonical
+		}
+
+		length, n, err := readRawVarInt(stream)
+		switch {
+		case err == io.EOF:
+			return io.ErrUnexpectedEOF
+
+		case err != nil:
+			return err
+		}
+		stream = stream[n:]
+
+		if length > tlv.MaxRecordSize {
+			return tlv.ErrRecordTooLarge
+		}
+		if length > uint64(len(stream)) {
+			return io.ErrUnexpectedEOF
+		}
+
+		value, remaining := stream[:length], stream[length:]
+		if err := cb(typ, value, remaining); err != nil {
+			return err
+		}
+		stream = remaining
+
+		if typ == math.MaxUint64 {
+			overflow = true
+		}
+		min = typ + 1
+	}
+
+	return nil
+}
+
+// countRawTLVRecords is like ExtraOpaqueData.checkNumRecords, but returns the
+// number of records of the stream it counted, stopping once the count exceeds
+// maxRecords.
+func countRawTLVRecords(stream []byte, maxRecords int) int {
+	var records int
+	for len(stream) > 0 && records <= maxRecords {
+		_, n, err := readRawVarInt(stream)
+		if err != nil {
+			return records
+		}
+		stream = stream[n:]
+
+		length, n, err := readRawVarInt(stream)
+		if err != nil || length > uint64(len(stream)-n) {
+			return records
+		}
+
+		records++
+		stream = stream[n+int(length):]
+	}
+
+	return records
+}
@@ -0,0 +1,365 @@
+package lnwire
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeAndValidate returns the verdict of a full decode of the serialized
+// AcceptChannel payload, followed by Validate.
+func decodeAndValidate(data []byte) error {
+	var msg AcceptChannel
+	if err := msg.Decode(bytes.NewReader(data), 0); err != nil {
+		return err
+	}
+
+	return msg.Validate()
+}
+
+// requireSameVerdict asserts that ValidateRaw returns the same error for the
+// serialized AcceptChannel payload as a full decode followed by Validate.
+func requireSameVerdict(t *testing.T, data []byte) {
+	t.Helper()
+
+	expected := decodeAndValidate(data)
+	err := ValidateRaw(data, 0)
+	if expected == nil {
+		require.NoError(t, err)
+		return
+	}
+
+	require.Error(t, err, "expected %v", expected)
+	require.Equal(t, expected.Error(), err.Error())
+}
+
+// newRawTestAccept returns a valid AcceptChannel, modified by the passed
+// function, serialized without its message type.
+func newRawTestAccept(t *testing.T, modify func(*AcceptChannel)) []byte {
+	t.Helper()
+
+	newKey := func() *btcec.PublicKey {
+		priv, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		return priv.PubKey()
+	}
+
+	msg := &AcceptChannel{
+		PendingChannelID:     [32]byte{1, 2, 3},
+		DustLimit:            573,
+		MaxValueInFlight:     100000000,
+		ChannelReserve:       10000,
+		HtlcMinimum:          1000,
+		MinAcceptDepth:       3,
+		CsvDelay:             144,
+		MaxAcceptedHTLCs:     483,
+		FundingKey:           newKey(),
+		RevocationPoint:      newKey(),
+		PaymentPoint:         newKey(),
+		DelayedPaymentPoint:  newKey(),
+		HtlcPoint:            newKey(),
+		FirstCommitmentPoint: newKey(),
+	}
+	if modify != nil {
+		modify(msg)
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, msg.Encode(&b, 0))
+
+	return b.Bytes()
+}
+
+// TestValidateRaw asserts that ValidateRaw reaches the same verdict as a full
+// decode followed by Validate for valid messages and for messages violating
+// each of the checks.
+func TestValidateRaw(t *testing.T) {
+	t.Parallel()
+
+	p2wsh := append([]byte{0x00, 0x20}, bytes.Repeat([]byte{1}, 32)...)
+
+	packChanType := func(t *testing.T, msg *AcceptChannel,
+		bits ...FeatureBit) {
+
+		chanType := ChannelType(*NewRawFeatureVector(bits...))
+		require.NoError(t, msg.ExtraData.PackRecords(
+			chanType.NewRecord(),
+		))
+	}
+
+	// withTLV replaces the TLV data of the serialized message.
+	withTLV := func(data []byte, tlvData ...byte) []byte {
+		return append(data[:acceptTLVOffset], tlvData...)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, msg *AcceptChannel)
+		raw    func(data []byte) []byte
+		valid  bool
+	}{
+		{
+			name: "no tlv data",
+			raw: func(data []byte) []byte {
+				return data[:acceptTLVOffset]
+			},
+			valid: true,
+		},
+		{
+			name: "empty upfront shutdown script",
+			modify: func(t *testing.T, msg *AcceptChannel) {
+				msg.UpfrontShutdownScript = DeliveryAddress{}
+			},
+			valid: true,
+		},
+		{
+			name: "upfront shutdown script",
+			modify: func(t *testing.T, msg *AcceptChannel) {
+				msg.UpfrontShutdownScript = p2wsh
+			},
+			valid: true,
+		},
+		{
+			name: "channel type and reserve msat",
+			modify: func(t *testing.T, msg *AcceptChannel) {
+				msg.UpfrontShutdownScript = p2wsh
+				packChanType(
+					t, msg, StaticRemoteKeyRequired,
+					AnchorsZeroFeeHtlcTxRequired,
+				)
+				require.NoError(t, msg.SetReserveMsat(9999500))
+			},
+			valid: true,
+		},
+		{
+			name: "unknown odd record",
+			raw: func(data []byte) []byte {
+				return withTLV(
+					data, 0x00, 0x00, 0x07, 0x01, 0x01,
+				)
+			},
+			valid: true,
+		},
+		{
+			name: "truncated fixed fields",
+			raw: func(data []byte) []byte {
+				return data[:acceptHtlcPointOffset+10]
+			},
+		},
+		{
+			name: "invalid public key",
+			raw: func(data []byte) []byte {
+				data[acceptPaymentPointOffset] = 0x05
+				return data
+			},
+		},
+		{
+			name: "duplicate public keys",
+			modify: func(t *testing.T, msg *AcceptChannel) {
+				msg.HtlcPoint = msg.RevocationPoint
+			},
+		},
+		{
+			name: "shutdown script absent",
+			raw: func(data []byte) []byte {
+				return withTLV(data, 0x01, 0x00)
+			},
+		},
+		{
+			name: "non-canonical varint",
+			raw: func(data []byte) []byte {
+				return withTLV(data, 0xfd, 0x00, 0x01, 0x00)
+			},
+		},
+		{
+			name: "unsorted records",
+			raw: func(data []byte) []byte {
+				return withTLV(
+					data, 0x00, 0x00, 0x03, 0x00,
+					0x01, 0x00,
+				)
+			},
+		},
+		{
+			name: "record too large",
+			raw: func(data []byte) []byte {
+				return withTLV(
+					data, 0x00, 0xfe, 0x00, 0x01,
+					0x00, 0x00,
+				)
+			},
+		},
+		{
+			name: "truncated record",
+			raw: func(data []byte) []byte {
+				return withTLV(data, 0x00, 0x05, 0x01)
+			},
+		},
+		{
+			name: "truncated length",
+			raw: func(data []byte) []byte {
+				return withTLV(data, 0x00, 0x00, 0x03)
+			},
+		},
+		{
+			name: "too many records",
+			raw: func(data []byte) []byte {
+				data = withTLV(data, 0x00, 0x00)
+				for i := 0; i < MaxTLVRecords; i++ {
+					data = append(
+						data, 0xfd, 0x01, byte(i), 0x00,
+					)
+				}
+				return data
+			},
+		},
+		{
+			name: "unknown channel type",
+			modify: func(t *testing.T, msg *AcceptChannel) {
+				msg.UpfrontShutdownScript = DeliveryAddress{}
+				packChanType(t, msg, 100, 102, 13)
+			},
+		},
+		{
+			name: "invalid channel type combination",
+			modify: func(t *testing.T, msg *AcceptChannel) {
+				msg.UpfrontShutdownScript = DeliveryAddress{}
+				packChanType(t, msg, AnchorsRequired)
+			},
+		},
+		{
+			name: "dust limit below script",
+			modify: func(t *testing.T, msg *AcceptChannel) {
+				msg.UpfrontShutdownScript = p2wsh
+				msg.DustLimit = p2wshDustLimit - 1
+			},
+		},
+		{
+			name: "reserve msat mismatch",
+			modify: func(t *testing.T, msg *AcceptChannel) {
+				msg.UpfrontShutdownScript = DeliveryAddress{}
+				require.NoError(t, msg.SetReserveMsat(12000000))
+			},
+		},
+		{
+			name: "reserve msat of wrong length",
+			raw: func(data []byte) []byte {
+				return withTLV(
+					data, 0x00, 0x00, 0xfe, 0x00,
+					0x01, 0x00, 0x0d, 0x01, 0x01,
+				)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := newRawTestAccept(t, func(msg *AcceptChannel) {
+				if test.modify != nil {
+					test.modify(t, msg)
+				}
+			})
+			if test.raw != nil {
+				data = test.raw(data)
+			}
+
+			requireSameVerdict(t, data)
+			if test.valid {
+				require.NoError(t, ValidateRaw(data, 0))
+			} else {
+				require.Error(t, ValidateRaw(data, 0))
+			}
+		})
+	}
+}
+
+// TestValidateRawTruncated asserts that ValidateRaw reaches the same verdict
+// as a full decode followed by Validate for every truncation of a message.
+func TestValidateRawTruncated(t *testing.T) {
+	t.Parallel()
+
+	data := newRawTestAccept(t, func(msg *AcceptChannel) {
+		msg.UpfrontShutdownScript = DeliveryAddress{0x00, 0x14}
+		require.NoError(t, msg.SetReserveMsat(10000000))
+	})
+
+	for i := 0; i <= len(data); i++ {
+		requireSameVerdict(t, data[:i])
+	}
+}
+
+// TestValidateRawMutated asserts that ValidateRaw reaches the same verdict as
+// a full decode followed by Validate for messages with random bytes mutated.
+func TestValidateRawMutated(t *testing.T) {
+	t.Parallel()
+
+	var extraData ExtraOpaqueData
+	chanType := ChannelType(*NewRawFeatureVector(StaticRemoteKeyRequired))
+	reserve := ReserveMsat(10000000)
+	require.NoError(t, extraData.PackRecords(
+		chanType.NewRecord(), reserve.NewRecord(),
+	))
+
+	base := newRawTestAccept(t, func(msg *AcceptChannel) {
+		msg.UpfrontShutdownScript = append(
+			[]byte{0x00, 0x14}, bytes.Repeat([]byte{1}, 20)...,
+		)
+		msg.ExtraData = extraData
+	})
+	require.NoError(t, ValidateRaw(base, 0))
+
+	// The mutations are focused on the fields that are checked, as
+	// mutating the amounts doesn't change the verdict.
+	offsets := []int{acceptDustLimitOffset + 7, acceptChannelReserveOffset}
+	for i := acceptFundingKeyOffset; i < len(base); i++ {
+		offsets = append(offsets, i)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		data := append([]byte(nil), base...)
+		for j := 0; j < 1+rng.Intn(3); j++ {
+			offset := offsets[rng.Intn(len(offsets))]
+			data[offset] = byte(rng.Intn(256))
+		}
+
+		requireSameVerdict(t, data)
+	}
+}
+
+// TestValidateRawTLVRecords asserts that walkRawTLVStream reports the same
+// records as decoding the stream with a tlv.Stream.
+func TestValidateRawTLVRecords(t *testing.T) {
+	t.Parallel()
+
+	var (
+		extraData ExtraOpaqueData
+		val1      uint8 = 1
+		val2            = []byte("value")
+	)
+	require.NoError(t, extraData.PackRecords(
+		tlv.MakePrimitiveRecord(tlv.Type(1), &val1),
+		tlv.MakePrimitiveRecord(tlv.Type(300), &val2),
+	))
+
+	expected, err := extraData.ExtractRecords()
+	require.NoError(t, err)
+
+	records := make(tlv.TypeMap)
+	err = walkRawTLVStream(extraData, func(typ tlv.Type, value,
+		_ []byte) error {
+
+		records[typ] = value
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, records)
+}
@@ -0,0 +1,105 @@
+package lnwire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// AcceptChannelIterator reads the AcceptChannel messages from a stream of
+// framed messages, such as the decrypted payloads of a packet capture. Each
+// message in the stream is prefixed with its length as a big-endian uint16,
+// followed by the message type and payload. Messages of any other type are
+// skipped without being decoded.
+type AcceptChannelIterator struct {
+	r    io.Reader
+	pver uint32
+
+	msg *AcceptChannel
+	err error
+}
+
+// NewAcceptChannelIterator returns an iterator over the AcceptChannel messages
+// of the framed message stream read from r, decoded using the given protocol
+// version.
+func NewAcceptChannelIterator(r io.Reader,
+	pver uint32) *AcceptChannelIterator {
+
+	return &AcceptChannelIterator{
+		r:    r,
+		pver: pver,
+	}
+}
+
+// Next advances the iterator to the next AcceptChannel of the stream, which is
+// then available through AcceptChannel. It returns false once the end of the
+// stream is reached, or a frame couldn't be read or decoded, in which case Err
+// returns the error.
+func (i *AcceptChannelIterator) Next() bool {
+	i.msg = nil
+	if i.err != nil {
+		return false
+	}
+
+	for {
+		frame, err := i.readFrame()
+		switch {
+		// The stream ended cleanly at a frame boundary.
+		case err == io.EOF:
+			return false
+
+		case err != nil:
+			i.err = err
+			return false
+		}
+
+		if len(frame) < 2 {
+			i.err = fmt.Errorf("frame of %d bytes too short for "+
+				"message type", len(frame))
+			return false
+		}
+
+		msgType := MessageType(binary.BigEndian.Uint16(frame[:2]))
+		if msgType != MsgAcceptChannel {
+			continue
+		}
+
+		msg, err := ReadMessage(bytes.NewReader(frame), i.pver)
+		if err != nil {
+			i.err = err
+			return false
+		}
+
+		i.msg = msg.(*AcceptChannel)
+		return true
+	}
+}
+
+// readFrame reads the next length prefixed frame from the stream. io.EOF is
+// only returned if the stream ends before the length prefix.
+func (i *AcceptChannelIterator) readFrame() ([]byte, error) {
+	var lenBytes [2]byte
+	if _, err := io.ReadFull(i.r, lenBytes[:]); err != nil {
+		return nil, err
+	}
+
+	frame := make([]byte, binary.BigEndian.Uint16(lenBytes[:]))
+	if _, err := io.ReadFull(i.r, frame); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return frame, nil
+}
+
+// AcceptChannel returns the AcceptChannel the iterator was advanced to by the
+// last call to Next, or nil if Next returned false.
+func (i *AcceptChannelIterator) AcceptChannel() *AcceptChannel {
+	return i.msg
+}
+
+// Err returns the error that stopped the iteration, or nil if the end of the
+// stream was reached.
+func (i *AcceptChannelIterator) Err() error {
+	return i.err
+}
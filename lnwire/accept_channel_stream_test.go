@@ -0,0 +1,86 @@
+package lnwire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestFrame writes the given message to w, prefixed with its length.
+func writeTestFrame(t *testing.T, w *bytes.Buffer, msg Message) {
+	t.Helper()
+
+	var b bytes.Buffer
+	_, err := WriteMessage(&b, msg, 0)
+	require.NoError(t, err)
+
+	var lenBytes [2]byte
+	binary.BigEndian.PutUint16(lenBytes[:], uint16(b.Len()))
+	w.Write(lenBytes[:])
+	w.Write(b.Bytes())
+}
+
+// TestAcceptChannelIterator asserts that the iterator yields the
+// AcceptChannel messages of an interleaved stream of framed messages in
+// order, skipping all other messages.
+func TestAcceptChannelIterator(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	newAccept := func(id byte) *AcceptChannel {
+		return &AcceptChannel{
+			PendingChannelID:      [32]byte{id},
+			DustLimit:             573,
+			CsvDelay:              144,
+			FundingKey:            pk,
+			RevocationPoint:       pk,
+			PaymentPoint:          pk,
+			DelayedPaymentPoint:   pk,
+			HtlcPoint:             pk,
+			FirstCommitmentPoint:  pk,
+			UpfrontShutdownScript: []byte{},
+		}
+	}
+
+	first, second := newAccept(1), newAccept(2)
+
+	var stream bytes.Buffer
+	writeTestFrame(t, &stream, NewPing(10))
+	writeTestFrame(t, &stream, first)
+	writeTestFrame(t, &stream, &Error{Data: []byte("error")})
+	writeTestFrame(t, &stream, NewPong([]byte{1, 2}))
+	writeTestFrame(t, &stream, second)
+	writeTestFrame(t, &stream, NewPing(20))
+
+	iter := NewAcceptChannelIterator(bytes.NewReader(stream.Bytes()), 0)
+
+	var msgs []*AcceptChannel
+	for iter.Next() {
+		msgs = append(msgs, iter.AcceptChannel())
+	}
+	require.NoError(t, iter.Err())
+	require.Nil(t, iter.AcceptChannel())
+
+	require.Len(t, msgs, 2)
+	require.True(t, first.Equal(msgs[0]))
+	require.True(t, second.Equal(msgs[1]))
+
+	// A stream truncated within a frame yields the messages before the
+	// truncation, followed by an error.
+	truncated := stream.Bytes()[:stream.Len()-3]
+	iter = NewAcceptChannelIterator(bytes.NewReader(truncated), 0)
+
+	msgs = nil
+	for iter.Next() {
+		msgs = append(msgs, iter.AcceptChannel())
+	}
+	require.Equal(t, io.ErrUnexpectedEOF, iter.Err())
+	require.Len(t, msgs, 2)
+}
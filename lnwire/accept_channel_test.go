@@ -2,9 +2,20 @@ package lnwire
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/lightningnetwork/lnd/tlv"
 )
 
 // TestDecodeAcceptChannel tests decoding of an accept channel wire message with
@@ -69,3 +80,1783 @@ func TestDecodeAcceptChannel(t *testing.T) {
 		})
 	}
 }
+
+// TestDecodeLegacyAcceptChannel asserts that an AcceptChannel sent by a legacy
+// peer, that ends right after the FirstCommitmentPoint without even a
+// zero-length shutdown script record, can be decoded.
+func TestDecodeLegacyAcceptChannel(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		PendingChannelID:     [32]byte{1, 2, 3},
+		DustLimit:            573,
+		MaxValueInFlight:     990000000,
+		ChannelReserve:       10000,
+		HtlcMinimum:          1000,
+		MinAcceptDepth:       3,
+		CsvDelay:             144,
+		MaxAcceptedHTLCs:     483,
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+
+	var b bytes.Buffer
+	if _, err := WriteMessage(&b, msg, 0); err != nil {
+		t.Fatalf("cannot write message: %v", err)
+	}
+
+	// We always write a zero-length shutdown script record, which takes
+	// two bytes following the fixed size fields and the message type.
+	// Strip it to get the message a legacy peer would send.
+	const msgTypeLen = 2
+	legacyLen := msgTypeLen + acceptTLVOffset
+	if b.Len() != legacyLen+2 {
+		t.Fatalf("expected encoding of %d bytes, got %d",
+			legacyLen+2, b.Len())
+	}
+	legacy := b.Bytes()[:legacyLen]
+
+	decodedMsg, err := ReadMessage(bytes.NewReader(legacy), 0)
+	if err != nil {
+		t.Fatalf("cannot read legacy message: %v", err)
+	}
+	decoded := decodedMsg.(*AcceptChannel)
+
+	if !msg.Equal(decoded) {
+		t.Fatalf("decoded message %v does not equal encoded message %v",
+			spew.Sdump(decoded), spew.Sdump(msg))
+	}
+	if decoded.UpfrontShutdownScript != nil {
+		t.Fatalf("expected no shutdown script, got: %x",
+			decoded.UpfrontShutdownScript)
+	}
+	if len(decoded.ExtraData) != 0 {
+		t.Fatalf("expected no extra data, got: %x", decoded.ExtraData)
+	}
+
+	// As the message doesn't carry the shutdown script record at all, it
+	// is only acceptable if the feature wasn't negotiated.
+	if err := decoded.ValidateUpfrontShutdown(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := decoded.ValidateUpfrontShutdown(true); err == nil {
+		t.Fatalf("expected missing shutdown script to be rejected")
+	}
+}
+
+// TestAcceptChannelAmountEncoding asserts that the amount fields of an
+// AcceptChannel are encoded as big-endian 64-bit integers at their offsets, as
+// required by BOLT #1, and are decoded from that encoding.
+func TestAcceptChannelAmountEncoding(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		DustLimit:            546,
+		MaxValueInFlight:     0x0123456789abcdef,
+		ChannelReserve:       10000,
+		HtlcMinimum:          0xfedcba98,
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+
+	var b bytes.Buffer
+	if err := msg.Encode(&b, 0); err != nil {
+		t.Fatalf("cannot encode message: %v", err)
+	}
+	encoded := b.Bytes()
+
+	var decoded AcceptChannel
+	if err := decoded.Decode(bytes.NewReader(encoded), 0); err != nil {
+		t.Fatalf("cannot decode message: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		offset  int
+		expHex  string
+		decoded uint64
+	}{
+		{
+			name:    "DustLimit",
+			offset:  acceptDustLimitOffset,
+			expHex:  "0000000000000222",
+			decoded: uint64(decoded.DustLimit),
+		},
+		{
+			name:    "MaxValueInFlight",
+			offset:  acceptMaxValueInFlightOffset,
+			expHex:  "0123456789abcdef",
+			decoded: uint64(decoded.MaxValueInFlight),
+		},
+		{
+			name:    "ChannelReserve",
+			offset:  acceptChannelReserveOffset,
+			expHex:  "0000000000002710",
+			decoded: uint64(decoded.ChannelReserve),
+		},
+		{
+			name:    "HtlcMinimum",
+			offset:  acceptHtlcMinimumOffset,
+			expHex:  "00000000fedcba98",
+			decoded: uint64(decoded.HtlcMinimum),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			field := encoded[test.offset : test.offset+8]
+			if hex.EncodeToString(field) != test.expHex {
+				t.Fatalf("expected %v encoded as %v, got %x",
+					test.name, test.expHex, field)
+			}
+
+			expValue, err := strconv.ParseUint(test.expHex, 16, 64)
+			if err != nil {
+				t.Fatalf("cannot parse expected value: %v", err)
+			}
+			if test.decoded != expValue {
+				t.Fatalf("expected %v decoded as %d, got %d",
+					test.name, expValue, test.decoded)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelDuplicateShutdownType asserts that we refuse to encode an
+// AcceptChannel whose ExtraData contains a record of the same type as the
+// upfront shutdown script, as this would result in a duplicate TLV type.
+func TestAcceptChannelDuplicateShutdownType(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	// Pack a delivery address record into the extra data, independent of
+	// the UpfrontShutdownScript field.
+	var extraData ExtraOpaqueData
+	addr := DeliveryAddress([]byte("example"))
+	if err := extraData.PackRecords(addr.NewRecord()); err != nil {
+		t.Fatalf("cannot pack records: %v", err)
+	}
+
+	msg := &AcceptChannel{
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+		ExtraData:            extraData,
+	}
+
+	var b bytes.Buffer
+	if err := msg.Encode(&b, 0); err != ErrDuplicateShutdownScript {
+		t.Fatalf("expected ErrDuplicateShutdownScript, got: %v", err)
+	}
+}
+
+// TestAcceptChannelTLVOrdering asserts that the upfront shutdown script record
+// is always encoded as the first TLV record, and that ExtraData that would
+// break the canonical ordering of the stream is rejected.
+func TestAcceptChannelTLVOrdering(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	var (
+		val1 uint8 = 1
+		val3 uint8 = 3
+	)
+	var higherTypes ExtraOpaqueData
+	err = higherTypes.PackRecords(
+		tlv.MakePrimitiveRecord(tlv.Type(1), &val1),
+		tlv.MakePrimitiveRecord(tlv.Type(3), &val3),
+	)
+	if err != nil {
+		t.Fatalf("cannot pack records: %v", err)
+	}
+
+	var typeZero ExtraOpaqueData
+	addr := DeliveryAddress([]byte("example"))
+	if err := typeZero.PackRecords(addr.NewRecord()); err != nil {
+		t.Fatalf("cannot pack records: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		extraData ExtraOpaqueData
+		opaque    bool
+		expErr    error
+	}{
+		{
+			name:      "higher types",
+			extraData: higherTypes,
+		},
+		{
+			name:      "type zero conflict",
+			extraData: typeZero,
+			expErr:    ErrDuplicateShutdownScript,
+		},
+		{
+			// Types 3 and 1, each with a one byte value, in
+			// descending order. As this isn't a valid TLV stream,
+			// it is appended to the shutdown script as is.
+			name: "unsorted types",
+			extraData: ExtraOpaqueData{
+				0x03, 0x01, 0x03, 0x01, 0x01, 0x01,
+			},
+			opaque: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			msg := &AcceptChannel{
+				FundingKey:            pk,
+				RevocationPoint:       pk,
+				PaymentPoint:          pk,
+				DelayedPaymentPoint:   pk,
+				HtlcPoint:             pk,
+				FirstCommitmentPoint:  pk,
+				UpfrontShutdownScript: []byte("script"),
+				ExtraData:             test.extraData,
+			}
+
+			var b bytes.Buffer
+			err := msg.Encode(&b, 0)
+			if err != test.expErr {
+				t.Fatalf("expected error %v, got: %v",
+					test.expErr, err)
+			}
+			if test.expErr != nil {
+				return
+			}
+
+			// The TLV stream follows the fixed size fields, and
+			// must start with the shutdown script record.
+			encoded := b.Bytes()
+			if encoded[acceptTLVOffset] != DeliveryAddrType {
+				t.Fatalf("expected first tlv type %d, got %d",
+					DeliveryAddrType, encoded[acceptTLVOffset])
+			}
+
+			// Opaque extra data can be encoded, but not decoded.
+			if test.opaque {
+				if !bytes.HasSuffix(encoded, test.extraData) {
+					t.Fatalf("expected extra data %x to be "+
+						"appended", test.extraData)
+				}
+				return
+			}
+
+			var decoded AcceptChannel
+			err = decoded.Decode(bytes.NewReader(encoded), 0)
+			if err != nil {
+				t.Fatalf("cannot decode message: %v", err)
+			}
+			if !bytes.Equal(decoded.ExtraData, test.extraData) {
+				t.Fatalf("expected extra data %x, got %x",
+					test.extraData, decoded.ExtraData)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelLongShutdownScript asserts that the shutdown script record
+// is stripped correctly from the TLV stream regardless of the size of its
+// BigSize length prefix, which takes up more than a single byte for scripts
+// longer than 252 bytes.
+func TestAcceptChannelLongShutdownScript(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	var val uint8 = 1
+	var extraData ExtraOpaqueData
+	err = extraData.PackRecords(tlv.MakePrimitiveRecord(1, &val))
+	if err != nil {
+		t.Fatalf("cannot pack records: %v", err)
+	}
+
+	for _, scriptLen := range []int{0, 34, 252, 253, 300} {
+		scriptLen := scriptLen
+
+		t.Run(strconv.Itoa(scriptLen), func(t *testing.T) {
+			script := DeliveryAddress(
+				bytes.Repeat([]byte{0xaa}, scriptLen),
+			)
+
+			// The remainder of the parsed blob must be exactly the
+			// extra data packed after the script.
+			tlvRecords, err := packShutdownScript(script, extraData)
+			if err != nil {
+				t.Fatalf("cannot pack shutdown script: %v", err)
+			}
+			addr, rest, err := parseShutdownScript(tlvRecords, nil)
+			if err != nil {
+				t.Fatalf("cannot parse shutdown script: %v",
+					err)
+			}
+			if !bytes.Equal(addr, script) {
+				t.Fatalf("expected script of %d bytes, got %d",
+					scriptLen, len(addr))
+			}
+			if !bytes.Equal(rest, extraData) {
+				t.Fatalf("expected remainder %x, got %x",
+					extraData, rest)
+			}
+
+			// The same must hold for a full round trip of the
+			// message.
+			msg := &AcceptChannel{
+				FundingKey:            pk,
+				RevocationPoint:       pk,
+				PaymentPoint:          pk,
+				DelayedPaymentPoint:   pk,
+				HtlcPoint:             pk,
+				FirstCommitmentPoint:  pk,
+				UpfrontShutdownScript: script,
+				ExtraData:             extraData,
+			}
+
+			var b bytes.Buffer
+			if err := msg.Encode(&b, 0); err != nil {
+				t.Fatalf("cannot encode message: %v", err)
+			}
+
+			var decoded AcceptChannel
+			if err := decoded.Decode(&b, 0); err != nil {
+				t.Fatalf("cannot decode message: %v", err)
+			}
+			decodedScript := decoded.UpfrontShutdownScript
+			if !bytes.Equal(decodedScript, script) {
+				t.Fatalf("expected script of %d bytes, got %d",
+					scriptLen, len(decodedScript))
+			}
+			if !bytes.Equal(decoded.ExtraData, extraData) {
+				t.Fatalf("expected extra data %x, got %x",
+					extraData, decoded.ExtraData)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelDecodeErrorOffset asserts that a failure to decode an
+// AcceptChannel reports the field that couldn't be decoded, along with the
+// offset at which it starts.
+func TestAcceptChannelDecodeErrorOffset(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+
+	var b bytes.Buffer
+	if err := msg.Encode(&b, 0); err != nil {
+		t.Fatalf("cannot encode message: %v", err)
+	}
+	encoded := b.Bytes()
+
+	// corrupt returns a copy of the encoded message, with the byte at the
+	// given offset replaced.
+	corrupt := func(offset int, val byte) []byte {
+		c := make([]byte, len(encoded))
+		copy(c, encoded)
+		c[offset] = val
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		input     []byte
+		expField  string
+		expOffset int
+	}{
+		{
+			name:      "truncated pending channel id",
+			input:     encoded[:10],
+			expField:  "PendingChannelID",
+			expOffset: acceptPendingChanIDOffset,
+		},
+		{
+			name:      "truncated at dust limit",
+			input:     encoded[:acceptDustLimitOffset],
+			expField:  "DustLimit",
+			expOffset: acceptDustLimitOffset,
+		},
+		{
+			name:      "truncated csv delay",
+			input:     encoded[:acceptCsvDelayOffset+1],
+			expField:  "CsvDelay",
+			expOffset: acceptCsvDelayOffset,
+		},
+		{
+			name:      "truncated funding key",
+			input:     encoded[:acceptFundingKeyOffset+20],
+			expField:  "FundingKey",
+			expOffset: acceptFundingKeyOffset,
+		},
+		{
+			name:      "invalid revocation point",
+			input:     corrupt(acceptRevocationPointOffset, 0x05),
+			expField:  "RevocationPoint",
+			expOffset: acceptRevocationPointOffset,
+		},
+		{
+			name:      "invalid shutdown script record",
+			input:     encoded[:acceptTLVOffset+1],
+			expField:  "UpfrontShutdownScript",
+			expOffset: acceptTLVOffset,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var decoded AcceptChannel
+			err := decoded.Decode(bytes.NewReader(test.input), 0)
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected DecodeError, got: %v", err)
+			}
+			if decodeErr.Field != test.expField {
+				t.Fatalf("expected field %v, got %v",
+					test.expField, decodeErr.Field)
+			}
+			if decodeErr.Offset != test.expOffset {
+				t.Fatalf("expected offset %d, got %d",
+					test.expOffset, decodeErr.Offset)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelValidatePubKeys asserts that an AcceptChannel is only valid
+// if all of its public keys are distinct.
+func TestAcceptChannelValidatePubKeys(t *testing.T) {
+	newKey := func() *btcec.PublicKey {
+		priv, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatalf("cannot create privkey: %v", err)
+		}
+		return priv.PubKey()
+	}
+
+	msg := &AcceptChannel{
+		FundingKey:           newKey(),
+		RevocationPoint:      newKey(),
+		PaymentPoint:         newKey(),
+		DelayedPaymentPoint:  newKey(),
+		HtlcPoint:            newKey(),
+		FirstCommitmentPoint: newKey(),
+	}
+	if err := msg.ValidatePubKeys(); err != nil {
+		t.Fatalf("expected distinct keys to be valid, got: %v", err)
+	}
+
+	// Reuse the funding key as payment point, through a distinct pointer
+	// to make sure keys are compared by value.
+	paymentPoint, err := btcec.ParsePubKey(
+		msg.FundingKey.SerializeCompressed(), btcec.S256(),
+	)
+	if err != nil {
+		t.Fatalf("cannot parse pubkey: %v", err)
+	}
+	msg.PaymentPoint = paymentPoint
+
+	if err := msg.ValidatePubKeys(); err != ErrDuplicatePubKey {
+		t.Fatalf("expected ErrDuplicatePubKey, got: %v", err)
+	}
+}
+
+// TestAcceptChannelValidateFundingKey asserts that a funding key colliding
+// with the node identity key of the sender is rejected.
+func TestAcceptChannelValidateFundingKey(t *testing.T) {
+	newKey := func() *btcec.PublicKey {
+		priv, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatalf("cannot create privkey: %v", err)
+		}
+		return priv.PubKey()
+	}
+
+	identityKey := newKey()
+	msg := &AcceptChannel{
+		FundingKey: newKey(),
+	}
+	if err := msg.ValidateFundingKey(identityKey); err != nil {
+		t.Fatalf("expected distinct key to be valid, got: %v", err)
+	}
+
+	// Use the identity key as funding key, through a distinct pointer to
+	// make sure keys are compared by value.
+	fundingKey, err := btcec.ParsePubKey(
+		identityKey.SerializeCompressed(), btcec.S256(),
+	)
+	if err != nil {
+		t.Fatalf("cannot parse pubkey: %v", err)
+	}
+	msg.FundingKey = fundingKey
+
+	err = msg.ValidateFundingKey(identityKey)
+	if err != ErrFundingKeyIsIdentity {
+		t.Fatalf("expected ErrFundingKeyIsIdentity, got: %v", err)
+	}
+}
+
+// TestValidatePush asserts that a push amount is only valid if it leaves the
+// funder with at least the channel reserve required in the AcceptChannel.
+func TestValidatePush(t *testing.T) {
+	const capacity = btcutil.Amount(100000)
+
+	accept := &AcceptChannel{
+		ChannelReserve: 1000,
+	}
+
+	tests := []struct {
+		name       string
+		pushAmt    MilliSatoshi
+		expErr     error
+		expReserve bool
+	}{
+		{
+			name:    "no push",
+			pushAmt: 0,
+		},
+		{
+			name:    "push of half the capacity",
+			pushAmt: NewMSatFromSatoshis(50000),
+		},
+		{
+			name:    "push leaves exactly the reserve",
+			pushAmt: NewMSatFromSatoshis(capacity - 1000),
+		},
+		{
+			name:       "push leaves one msat below the reserve",
+			pushAmt:    NewMSatFromSatoshis(capacity-1000) + 1,
+			expReserve: true,
+		},
+		{
+			name:       "push of the full capacity",
+			pushAmt:    NewMSatFromSatoshis(capacity),
+			expReserve: true,
+		},
+		{
+			name:    "push exceeds capacity",
+			pushAmt: NewMSatFromSatoshis(capacity) + 1,
+			expErr:  ErrPushExceedsCapacity,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidatePush(test.pushAmt, accept, capacity)
+
+			if !test.expReserve {
+				if err != test.expErr {
+					t.Fatalf("expected error %v, got: %v",
+						test.expErr, err)
+				}
+				return
+			}
+
+			var reserveErr *ErrPushViolatesReserve
+			if !errors.As(err, &reserveErr) {
+				t.Fatalf("expected ErrPushViolatesReserve, "+
+					"got: %v", err)
+			}
+			capacityMSat := NewMSatFromSatoshis(capacity)
+			expBalance := capacityMSat - test.pushAmt
+			if reserveErr.FunderBalance != expBalance {
+				t.Fatalf("expected funder balance %v, got %v",
+					expBalance, reserveErr.FunderBalance)
+			}
+			if reserveErr.ChannelReserve != accept.ChannelReserve {
+				t.Fatalf("expected reserve %v, got %v",
+					accept.ChannelReserve,
+					reserveErr.ChannelReserve)
+			}
+		})
+	}
+}
+
+// TestValidateMaxValueInFlight tests that a max value in flight below the
+// HTLC minimum or above the channel capacity is rejected, while the boundary
+// values are accepted.
+func TestValidateMaxValueInFlight(t *testing.T) {
+	tests := []struct {
+		name             string
+		capacity         btcutil.Amount
+		maxValueInFlight MilliSatoshi
+		htlcMinimum      MilliSatoshi
+		expBelowMin      bool
+		expExceeds       bool
+	}{
+		{
+			name:             "within bounds",
+			capacity:         100000,
+			maxValueInFlight: 50000000,
+			htlcMinimum:      1000,
+		},
+		{
+			name:             "equal to htlc minimum",
+			capacity:         100000,
+			maxValueInFlight: 1000,
+			htlcMinimum:      1000,
+		},
+		{
+			name:             "below htlc minimum",
+			capacity:         100000,
+			maxValueInFlight: 999,
+			htlcMinimum:      1000,
+			expBelowMin:      true,
+		},
+		{
+			name:             "equal to capacity",
+			capacity:         100000,
+			maxValueInFlight: 100000000,
+			htlcMinimum:      1000,
+		},
+		{
+			name:             "exceeds capacity",
+			capacity:         100000,
+			maxValueInFlight: 100000001,
+			htlcMinimum:      1000,
+			expExceeds:       true,
+		},
+		{
+			name:             "htlc minimum exceeds capacity",
+			capacity:         100000,
+			maxValueInFlight: 100000001,
+			htlcMinimum:      200000000,
+			expBelowMin:      true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			accept := &AcceptChannel{
+				MaxValueInFlight: test.maxValueInFlight,
+				HtlcMinimum:      test.htlcMinimum,
+			}
+
+			err := ValidateMaxValueInFlight(accept, test.capacity)
+
+			var (
+				belowMinErr *ErrMaxValueInFlightBelowMin
+				exceedsErr  *ErrMaxValueInFlightExceedsCapacity
+			)
+			switch {
+			case test.expBelowMin:
+				if !errors.As(err, &belowMinErr) {
+					t.Fatalf("expected "+
+						"ErrMaxValueInFlightBelowMin, "+
+						"got %v", err)
+				}
+
+			case test.expExceeds:
+				if !errors.As(err, &exceedsErr) {
+					t.Fatalf("expected ErrMaxValueInFlight"+
+						"ExceedsCapacity, got %v", err)
+				}
+
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestValidateAnchorReserve asserts that the reserve of an anchor channel must
+// cover the dust limit plus both anchor outputs, while channels without
+// anchors aren't subject to the check.
+func TestValidateAnchorReserve(t *testing.T) {
+	const dustLimit = btcutil.Amount(354)
+	minReserve := dustLimit + 2*AnchorOutputValue
+
+	tests := []struct {
+		name      string
+		anchors   bool
+		reserve   btcutil.Amount
+		expectErr bool
+	}{
+		{
+			name:    "anchors above minimum",
+			anchors: true,
+			reserve: minReserve + 1,
+		},
+		{
+			name:    "anchors at minimum",
+			anchors: true,
+			reserve: minReserve,
+		},
+		{
+			name:      "anchors below minimum",
+			anchors:   true,
+			reserve:   minReserve - 1,
+			expectErr: true,
+		},
+		{
+			name:      "anchors at dust limit",
+			anchors:   true,
+			reserve:   dustLimit,
+			expectErr: true,
+		},
+		{
+			name:    "no anchors at dust limit",
+			reserve: dustLimit,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			accept := &AcceptChannel{
+				DustLimit:      dustLimit,
+				ChannelReserve: test.reserve,
+			}
+
+			err := ValidateAnchorReserve(accept, test.anchors)
+			if !test.expectErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var reserveErr *ErrAnchorReserveBelowDust
+			if !errors.As(err, &reserveErr) {
+				t.Fatalf("expected ErrAnchorReserveBelowDust, "+
+					"got %v", err)
+			}
+			if reserveErr.AnchorReserve != 2*AnchorOutputValue {
+				t.Fatalf("unexpected anchor reserve: %v",
+					reserveErr.AnchorReserve)
+			}
+		})
+	}
+}
+
+// TestImpliedMaxHtlc asserts that the largest HTLC implied by an
+// AcceptChannel is bound by both the MaxValueInFlight and the reserve.
+func TestImpliedMaxHtlc(t *testing.T) {
+	tests := []struct {
+		name             string
+		capacity         btcutil.Amount
+		maxValueInFlight MilliSatoshi
+		chanReserve      btcutil.Amount
+		htlcMinimum      MilliSatoshi
+		expMaxHtlc       MilliSatoshi
+	}{
+		{
+			name:             "bound by in flight cap",
+			capacity:         1000000,
+			maxValueInFlight: 50000000,
+			chanReserve:      10000,
+			expMaxHtlc:       50000000,
+		},
+		{
+			name:             "bound by reserve",
+			capacity:         100000,
+			maxValueInFlight: 990000000,
+			chanReserve:      1000,
+			expMaxHtlc:       99000000,
+		},
+		{
+			name:             "in flight cap equals spendable",
+			capacity:         100000,
+			maxValueInFlight: 99000000,
+			chanReserve:      1000,
+			expMaxHtlc:       99000000,
+		},
+		{
+			name:             "no reserve",
+			capacity:         20000,
+			maxValueInFlight: 990000000,
+			expMaxHtlc:       20000000,
+		},
+		{
+			name:             "reserve equals capacity",
+			capacity:         20000,
+			maxValueInFlight: 990000000,
+			chanReserve:      20000,
+			expMaxHtlc:       0,
+		},
+		{
+			name:             "below htlc minimum",
+			capacity:         100000,
+			maxValueInFlight: 5000,
+			chanReserve:      1000,
+			htlcMinimum:      10000,
+			expMaxHtlc:       0,
+		},
+		{
+			name:             "equal to htlc minimum",
+			capacity:         100000,
+			maxValueInFlight: 10000,
+			chanReserve:      1000,
+			htlcMinimum:      10000,
+			expMaxHtlc:       10000,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			accept := &AcceptChannel{
+				MaxValueInFlight: test.maxValueInFlight,
+				ChannelReserve:   test.chanReserve,
+				HtlcMinimum:      test.htlcMinimum,
+			}
+
+			maxHtlc := ImpliedMaxHtlc(accept, test.capacity)
+			if maxHtlc != test.expMaxHtlc {
+				t.Fatalf("expected max htlc %v, got %v",
+					test.expMaxHtlc, maxHtlc)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelEqual asserts that AcceptChannel messages are compared by
+// the values of their fields.
+func TestAcceptChannelEqual(t *testing.T) {
+	newMsg := func() *AcceptChannel {
+		_, pub := btcec.PrivKeyFromBytes(
+			btcec.S256(), bytes.Repeat([]byte{0x01}, 32),
+		)
+
+		// Parse a fresh copy of the key for every field, such that
+		// no two messages share a pointer.
+		key := func() *btcec.PublicKey {
+			pk, err := btcec.ParsePubKey(
+				pub.SerializeCompressed(), btcec.S256(),
+			)
+			if err != nil {
+				t.Fatalf("cannot parse pubkey: %v", err)
+			}
+			return pk
+		}
+
+		return &AcceptChannel{
+			PendingChannelID:     [32]byte{1},
+			DustLimit:            573,
+			MaxValueInFlight:     1000000,
+			ChannelReserve:       10000,
+			HtlcMinimum:          1000,
+			MinAcceptDepth:       3,
+			CsvDelay:             144,
+			MaxAcceptedHTLCs:     483,
+			FundingKey:           key(),
+			RevocationPoint:      key(),
+			PaymentPoint:         key(),
+			DelayedPaymentPoint:  key(),
+			HtlcPoint:            key(),
+			FirstCommitmentPoint: key(),
+		}
+	}
+
+	var (
+		val1 uint8 = 1
+		val2 uint8 = 2
+	)
+	var extraData ExtraOpaqueData
+	err := extraData.PackRecords(
+		tlv.MakePrimitiveRecord(tlv.Type(1), &val1),
+	)
+	if err != nil {
+		t.Fatalf("cannot pack records: %v", err)
+	}
+	var otherExtraData ExtraOpaqueData
+	err = otherExtraData.PackRecords(
+		tlv.MakePrimitiveRecord(tlv.Type(1), &val2),
+	)
+	if err != nil {
+		t.Fatalf("cannot pack records: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(a, b *AcceptChannel)
+		equal  bool
+	}{
+		{
+			name:   "distinct key pointers",
+			modify: func(a, b *AcceptChannel) {},
+			equal:  true,
+		},
+		{
+			name: "nil and empty script",
+			modify: func(a, b *AcceptChannel) {
+				a.UpfrontShutdownScript = nil
+				b.UpfrontShutdownScript = []byte{}
+			},
+			equal: true,
+		},
+		{
+			name: "nil and empty extra data",
+			modify: func(a, b *AcceptChannel) {
+				a.ExtraData = nil
+				b.ExtraData = make([]byte, 0)
+			},
+			equal: true,
+		},
+		{
+			name: "same extra data records",
+			modify: func(a, b *AcceptChannel) {
+				a.ExtraData = extraData
+				b.ExtraData = append(
+					ExtraOpaqueData{}, extraData...,
+				)
+			},
+			equal: true,
+		},
+		{
+			name: "different extra data records",
+			modify: func(a, b *AcceptChannel) {
+				a.ExtraData = extraData
+				b.ExtraData = otherExtraData
+			},
+			equal: false,
+		},
+		{
+			name: "different csv delay",
+			modify: func(a, b *AcceptChannel) {
+				b.CsvDelay++
+			},
+			equal: false,
+		},
+		{
+			name: "different key",
+			modify: func(a, b *AcceptChannel) {
+				b.HtlcPoint = nil
+			},
+			equal: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			a, b := newMsg(), newMsg()
+			test.modify(a, b)
+
+			if a.Equal(b) != test.equal {
+				t.Fatalf("expected equal=%v", test.equal)
+			}
+			if b.Equal(a) != test.equal {
+				t.Fatalf("expected symmetric equal=%v",
+					test.equal)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelDecodeSlowReader asserts that an AcceptChannel is decoded
+// correctly when the underlying reader returns fewer bytes than requested on
+// every call.
+func TestAcceptChannelDecodeSlowReader(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	var (
+		extraData ExtraOpaqueData
+		extraVal  uint8 = 1
+	)
+	err = extraData.PackRecords(
+		tlv.MakePrimitiveRecord(tlv.Type(1), &extraVal),
+	)
+	if err != nil {
+		t.Fatalf("cannot pack records: %v", err)
+	}
+
+	msg := &AcceptChannel{
+		PendingChannelID:      [32]byte{1, 2, 3},
+		DustLimit:             573,
+		MaxValueInFlight:      1000000,
+		ChannelReserve:        10000,
+		HtlcMinimum:           1000,
+		MinAcceptDepth:        3,
+		CsvDelay:              144,
+		MaxAcceptedHTLCs:      483,
+		FundingKey:            pk,
+		RevocationPoint:       pk,
+		PaymentPoint:          pk,
+		DelayedPaymentPoint:   pk,
+		HtlcPoint:             pk,
+		FirstCommitmentPoint:  pk,
+		UpfrontShutdownScript: []byte("example"),
+		ExtraData:             extraData,
+	}
+
+	var b bytes.Buffer
+	if _, err := WriteMessage(&b, msg, 0); err != nil {
+		t.Fatalf("cannot write message: %v", err)
+	}
+
+	readers := []struct {
+		name   string
+		reader func(io.Reader) io.Reader
+	}{
+		{
+			name:   "one byte reader",
+			reader: iotest.OneByteReader,
+		},
+		{
+			name:   "half reader",
+			reader: iotest.HalfReader,
+		},
+		{
+			name: "one byte data err reader",
+			reader: func(r io.Reader) io.Reader {
+				return iotest.DataErrReader(
+					iotest.OneByteReader(r),
+				)
+			},
+		},
+	}
+
+	for _, test := range readers {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			r := test.reader(bytes.NewReader(b.Bytes()))
+			decoded, err := ReadMessage(r, 0)
+			if err != nil {
+				t.Fatalf("cannot read message: %v", err)
+			}
+
+			if !msg.Equal(decoded.(*AcceptChannel)) {
+				t.Fatalf("decoded message doesn't match: "+
+					"expected %v, got %v", spew.Sdump(msg),
+					spew.Sdump(decoded))
+			}
+		})
+	}
+}
+
+// TestAcceptChannelDecodeCtxCancel asserts that DecodeCtx returns promptly
+// once its context is cancelled while the reader blocks mid-message.
+func TestAcceptChannelDecodeCtxCancel(t *testing.T) {
+	t.Parallel()
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errChan := make(chan error, 1)
+	go func() {
+		var msg AcceptChannel
+		errChan <- msg.DecodeCtx(ctx, pr, 0)
+	}()
+
+	// Send part of the pending channel ID, then stall.
+	if _, err := pw.Write([]byte{1, 2, 3}); err != nil {
+		t.Fatalf("unable to write: %v", err)
+	}
+
+	select {
+	case err := <-errChan:
+		t.Fatalf("decode returned before cancellation: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+
+	select {
+	case err := <-errChan:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("expected DecodeError, got %v", err)
+		}
+		if decodeErr.Field != "PendingChannelID" {
+			t.Fatalf("expected failure in PendingChannelID, got %v",
+				decodeErr.Field)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatalf("decode didn't return after cancellation")
+	}
+}
+
+// TestAcceptChannelDecodeCtx asserts that DecodeCtx decodes a message like
+// Decode as long as its context isn't cancelled.
+func TestAcceptChannelDecodeCtx(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		PendingChannelID:      [32]byte{1, 2, 3},
+		DustLimit:             573,
+		CsvDelay:              144,
+		FundingKey:            pk,
+		RevocationPoint:       pk,
+		PaymentPoint:          pk,
+		DelayedPaymentPoint:   pk,
+		HtlcPoint:             pk,
+		FirstCommitmentPoint:  pk,
+		UpfrontShutdownScript: []byte("example"),
+	}
+
+	var b bytes.Buffer
+	if err := msg.Encode(&b, 0); err != nil {
+		t.Fatalf("cannot encode message: %v", err)
+	}
+
+	var decoded AcceptChannel
+	err = decoded.DecodeCtx(
+		context.Background(), iotest.OneByteReader(&b), 0,
+	)
+	if err != nil {
+		t.Fatalf("cannot decode message: %v", err)
+	}
+
+	if !msg.Equal(&decoded) {
+		t.Fatalf("decoded message doesn't match: expected %v, got %v",
+			spew.Sdump(msg), spew.Sdump(&decoded))
+	}
+}
+
+// TestAcceptChannelMaxTLVRecords asserts that an AcceptChannel carrying up to
+// MaxTLVRecords TLV records, including the upfront shutdown script, can be
+// decoded, while one carrying a single record more is rejected.
+func TestAcceptChannelMaxTLVRecords(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	tests := []struct {
+		name       string
+		numRecords int
+		expectErr  bool
+	}{
+		{
+			name:       "at cap",
+			numRecords: MaxTLVRecords,
+		},
+		{
+			name:       "just over cap",
+			numRecords: MaxTLVRecords + 1,
+			expectErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			// The shutdown script record is always added in front
+			// of the extra data, so it counts as the first record.
+			tlvMap := make(map[uint64][]byte, test.numRecords-1)
+			for i := 1; i < test.numRecords; i++ {
+				tlvMap[uint64(i)] = []byte{}
+			}
+			records := tlv.MapToRecords(tlvMap)
+			tlv.SortRecords(records)
+
+			var extraData ExtraOpaqueData
+			if err := extraData.PackRecords(records...); err != nil {
+				t.Fatalf("cannot pack records: %v", err)
+			}
+
+			msg := &AcceptChannel{
+				FundingKey:           pk,
+				RevocationPoint:      pk,
+				PaymentPoint:         pk,
+				DelayedPaymentPoint:  pk,
+				HtlcPoint:            pk,
+				FirstCommitmentPoint: pk,
+				ExtraData:            extraData,
+			}
+
+			var b bytes.Buffer
+			if _, err := WriteMessage(&b, msg, 0); err != nil {
+				t.Fatalf("cannot write message: %v", err)
+			}
+
+			_, err := ReadMessage(&b, 0)
+			switch {
+			case test.expectErr &&
+				!errors.Is(err, ErrTooManyTLVRecords):
+
+				t.Fatalf("expected ErrTooManyTLVRecords, got %v",
+					err)
+
+			case !test.expectErr && err != nil:
+				t.Fatalf("cannot read message: %v", err)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelToOpenParams asserts that the reusable constraints of an
+// AcceptChannel are extracted into OpenChannelParams.
+func TestAcceptChannelToOpenParams(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		PendingChannelID:      [32]byte{1, 2, 3},
+		DustLimit:             573,
+		MaxValueInFlight:      1000000,
+		ChannelReserve:        10000,
+		HtlcMinimum:           1000,
+		MinAcceptDepth:        3,
+		CsvDelay:              144,
+		MaxAcceptedHTLCs:      30,
+		FundingKey:            pk,
+		RevocationPoint:       pk,
+		PaymentPoint:          pk,
+		DelayedPaymentPoint:   pk,
+		HtlcPoint:             pk,
+		FirstCommitmentPoint:  pk,
+		UpfrontShutdownScript: []byte("example"),
+	}
+
+	expected := OpenChannelParams{
+		DustLimit:        573,
+		ChannelReserve:   10000,
+		MaxValueInFlight: 1000000,
+		HtlcMinimum:      1000,
+		CsvDelay:         144,
+		MaxAcceptedHTLCs: 30,
+	}
+	if params := msg.ToOpenParams(); params != expected {
+		t.Fatalf("expected params %v, got %v", spew.Sdump(expected),
+			spew.Sdump(params))
+	}
+}
+
+// TestAcceptChannelValidateUpfrontShutdown asserts that decoding tells an
+// absent upfront shutdown script record apart from a zero-length script, and
+// that only the former is rejected if the feature was negotiated.
+func TestAcceptChannelValidateUpfrontShutdown(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("cannot create privkey: %v", err)
+	}
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+
+	var b bytes.Buffer
+	if err := msg.Encode(&b, 0); err != nil {
+		t.Fatalf("cannot encode message: %v", err)
+	}
+	zeroLength := b.Bytes()
+
+	// Without a script or extra data, the message ends with the two byte
+	// zero-length shutdown script record. Stripping it leaves a message
+	// without the record.
+	absent := zeroLength[:len(zeroLength)-2]
+
+	tests := []struct {
+		name       string
+		encoded    []byte
+		negotiated bool
+		expectErr  bool
+	}{
+		{
+			name:       "zero-length script, negotiated",
+			encoded:    zeroLength,
+			negotiated: true,
+		},
+		{
+			name:       "absent script, negotiated",
+			encoded:    absent,
+			negotiated: true,
+			expectErr:  true,
+		},
+		{
+			name:    "absent script, not negotiated",
+			encoded: absent,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var decoded AcceptChannel
+			err := decoded.Decode(bytes.NewReader(test.encoded), 0)
+			if err != nil {
+				t.Fatalf("cannot decode message: %v", err)
+			}
+
+			err = decoded.ValidateUpfrontShutdown(test.negotiated)
+			if !test.expectErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var absentErr *ErrUpfrontShutdownAbsent
+			if !errors.As(err, &absentErr) {
+				t.Fatalf("expected ErrUpfrontShutdownAbsent, "+
+					"got %v", err)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelValidateMinAcceptDepth tests that a MinAcceptDepth of zero
+// is only accepted if zero-conf was offered.
+func TestAcceptChannelValidateMinAcceptDepth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		depth     uint32
+		offered   bool
+		expectErr error
+	}{
+		{
+			name:    "zero depth, offered",
+			depth:   0,
+			offered: true,
+		},
+		{
+			name:      "zero depth, not offered",
+			depth:     0,
+			expectErr: ErrUnexpectedZeroConf,
+		},
+		{
+			name:    "non-zero depth, offered",
+			depth:   3,
+			offered: true,
+		},
+		{
+			name:  "non-zero depth, not offered",
+			depth: 3,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			msg := &AcceptChannel{MinAcceptDepth: test.depth}
+			err := msg.ValidateMinAcceptDepth(test.offered)
+			if err != test.expectErr {
+				t.Fatalf("expected error %v, got %v",
+					test.expectErr, err)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelValidateDustLimit tests that a dust limit below the dust
+// threshold of the upfront shutdown script is rejected.
+func TestAcceptChannelValidateDustLimit(t *testing.T) {
+	t.Parallel()
+
+	// A P2WSH script has a dust threshold of 330.
+	p2wsh := append([]byte{0x00, 0x20}, make([]byte, 32)...)
+
+	tests := []struct {
+		name      string
+		script    DeliveryAddress
+		dustLimit btcutil.Amount
+		expectErr bool
+	}{
+		{
+			name:      "no script",
+			dustLimit: 1,
+		},
+		{
+			name:      "at script threshold",
+			script:    p2wsh,
+			dustLimit: 330,
+		},
+		{
+			name:      "below script threshold",
+			script:    p2wsh,
+			dustLimit: 329,
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			msg := &AcceptChannel{
+				DustLimit:             test.dustLimit,
+				UpfrontShutdownScript: test.script,
+			}
+
+			err := msg.ValidateDustLimit()
+			if !test.expectErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var dustErr *ErrDustLimitBelowScript
+			if !errors.As(err, &dustErr) {
+				t.Fatalf("expected ErrDustLimitBelowScript, "+
+					"got %v", err)
+			}
+			if dustErr.ScriptDustLimit != 330 {
+				t.Fatalf("wrong script dust limit: %v",
+					dustErr.ScriptDustLimit)
+			}
+		})
+	}
+}
+
+// TestAcceptChannelValidate asserts that Validate fails if any of the checks
+// it combines fails.
+func TestAcceptChannelValidate(t *testing.T) {
+	t.Parallel()
+
+	newKey := func() *btcec.PublicKey {
+		priv, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatalf("cannot create privkey: %v", err)
+		}
+		return priv.PubKey()
+	}
+
+	// A P2WSH script has a dust threshold of 330.
+	p2wsh := append([]byte{0x00, 0x20}, make([]byte, 32)...)
+
+	msg := &AcceptChannel{
+		DustLimit:             330,
+		FundingKey:            newKey(),
+		RevocationPoint:       newKey(),
+		PaymentPoint:          newKey(),
+		DelayedPaymentPoint:   newKey(),
+		HtlcPoint:             newKey(),
+		FirstCommitmentPoint:  newKey(),
+		UpfrontShutdownScript: p2wsh,
+	}
+	if err := msg.Validate(); err != nil {
+		t.Fatalf("expected message to be valid, got: %v", err)
+	}
+
+	// A dust limit below the threshold of the shutdown script fails.
+	msg.DustLimit = 329
+	var dustErr *ErrDustLimitBelowScript
+	if err := msg.Validate(); !errors.As(err, &dustErr) {
+		t.Fatalf("expected ErrDustLimitBelowScript, got: %v", err)
+	}
+
+	// Duplicate keys are reported first.
+	msg.HtlcPoint = msg.FundingKey
+	if err := msg.Validate(); err != ErrDuplicatePubKey {
+		t.Fatalf("expected ErrDuplicatePubKey, got: %v", err)
+	}
+}
+
+// TestAcceptChannelUnknownExtraData tests that UnknownExtraData returns only
+// the records of the ExtraData that aren't known to lnd.
+func TestAcceptChannelUnknownExtraData(t *testing.T) {
+	t.Parallel()
+
+	var msg AcceptChannel
+	unknown, err := msg.UnknownExtraData()
+	if err != nil {
+		t.Fatalf("unable to get unknown extra data: %v", err)
+	}
+	if unknown != nil {
+		t.Fatalf("expected no unknown extra data, got %x", unknown)
+	}
+
+	// Known records only don't leave anything behind.
+	if err := msg.SetMaxHtlcExpiryDelta(2016); err != nil {
+		t.Fatalf("unable to set max htlc expiry delta: %v", err)
+	}
+	unknown, err = msg.UnknownExtraData()
+	if err != nil {
+		t.Fatalf("unable to get unknown extra data: %v", err)
+	}
+	if unknown != nil {
+		t.Fatalf("expected no unknown extra data, got %x", unknown)
+	}
+
+	// Add two unknown odd records, one on either side of the known
+	// record, which must be returned without it.
+	extra, err := MergeExtraData(msg.ExtraData, ExtraOpaqueData{
+		0x05, 0x02, 0xbb, 0xcc, 0xfe, 0x00, 0x01, 0x00, 0x07, 0x01,
+		0xaa,
+	})
+	if err != nil {
+		t.Fatalf("unable to merge extra data: %v", err)
+	}
+	msg.ExtraData = extra
+
+	unknown, err = msg.UnknownExtraData()
+	if err != nil {
+		t.Fatalf("unable to get unknown extra data: %v", err)
+	}
+	expected := ExtraOpaqueData{
+		0x05, 0x02, 0xbb, 0xcc, 0xfe, 0x00, 0x01, 0x00, 0x07, 0x01,
+		0xaa,
+	}
+	if !bytes.Equal(unknown, expected) {
+		t.Fatalf("expected unknown extra data %x, got %x", expected,
+			unknown)
+	}
+}
+
+// TestFundingScript tests that the funding script orders the funding keys
+// lexicographically, independent of which of them is the local one.
+func TestFundingScript(t *testing.T) {
+	t.Parallel()
+
+	parseKey := func(keyHex string) *btcec.PublicKey {
+		keyBytes, err := hex.DecodeString(keyHex)
+		if err != nil {
+			t.Fatalf("unable to decode key: %v", err)
+		}
+		key, err := btcec.ParsePubKey(keyBytes, btcec.S256())
+		if err != nil {
+			t.Fatalf("unable to parse key: %v", err)
+		}
+		return key
+	}
+
+	// The generator point sorts before twice the generator point, as its
+	// serialization starts with 0x0279 rather than 0x02c6.
+	g := parseKey("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959" +
+		"f2815b16f81798")
+	g2 := parseKey("02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7ab" +
+		"ac09b95c709ee5")
+
+	// OP_2 <G> <2G> OP_2 OP_CHECKMULTISIG
+	expectedScript, err := hex.DecodeString("52" +
+		"210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b" +
+		"16f81798" +
+		"2102c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b9" +
+		"5c709ee5" +
+		"52ae")
+	if err != nil {
+		t.Fatalf("unable to decode script: %v", err)
+	}
+	scriptHash := sha256.Sum256(expectedScript)
+	expectedPkScript := append([]byte{0x00, 0x20}, scriptHash[:]...)
+
+	tests := []struct {
+		name      string
+		localKey  *btcec.PublicKey
+		remoteKey *btcec.PublicKey
+	}{
+		{
+			name:      "local key sorts first",
+			localKey:  g,
+			remoteKey: g2,
+		},
+		{
+			name:      "remote key sorts first",
+			localKey:  g2,
+			remoteKey: g,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			witnessScript, pkScript, err := FundingScript(
+				test.localKey, test.remoteKey,
+			)
+			if err != nil {
+				t.Fatalf("unable to create funding script: %v",
+					err)
+			}
+
+			if !bytes.Equal(witnessScript, expectedScript) {
+				t.Fatalf("wrong witness script: expected %x, "+
+					"got %x", expectedScript, witnessScript)
+			}
+			if !bytes.Equal(pkScript, expectedPkScript) {
+				t.Fatalf("wrong pkScript: expected %x, got %x",
+					expectedPkScript, pkScript)
+			}
+		})
+	}
+
+	if _, _, err := FundingScript(g, nil); err == nil {
+		t.Fatalf("expected error for missing funding key")
+	}
+}
+
+// TestSortFundingKeys tests that funding keys are ordered lexicographically by
+// their compressed serialization, independent of the order they're passed in.
+func TestSortFundingKeys(t *testing.T) {
+	t.Parallel()
+
+	parseKey := func(keyHex string) *btcec.PublicKey {
+		keyBytes, err := hex.DecodeString(keyHex)
+		if err != nil {
+			t.Fatalf("unable to decode key: %v", err)
+		}
+		key, err := btcec.ParsePubKey(keyBytes, btcec.S256())
+		if err != nil {
+			t.Fatalf("unable to parse key: %v", err)
+		}
+		return key
+	}
+
+	// The generator point sorts before twice the generator point, as its
+	// serialization starts with 0x0279 rather than 0x02c6.
+	g := parseKey("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959" +
+		"f2815b16f81798")
+	g2 := parseKey("02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7ab" +
+		"ac09b95c709ee5")
+
+	tests := []struct {
+		name string
+		a    *btcec.PublicKey
+		b    *btcec.PublicKey
+	}{
+		{
+			name: "sorted",
+			a:    g,
+			b:    g2,
+		},
+		{
+			name: "reversed",
+			a:    g2,
+			b:    g,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			first, second := SortFundingKeys(test.a, test.b)
+			if !first.IsEqual(g) || !second.IsEqual(g2) {
+				t.Fatalf("wrong order: expected %x, %x, got "+
+					"%x, %x", g.SerializeCompressed(),
+					g2.SerializeCompressed(),
+					first.SerializeCompressed(),
+					second.SerializeCompressed())
+			}
+		})
+	}
+}
+
+// TestAcceptChannelLocalToSelfDelay tests that the to_self delay of the
+// initiator is the CsvDelay of the AcceptChannel, independent of the delay the
+// initiator requires from the responder.
+func TestAcceptChannelLocalToSelfDelay(t *testing.T) {
+	t.Parallel()
+
+	pubKey, err := randPubKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	// The initiator requires the responder to delay its to_self output
+	// by 144 blocks, while the responder requires 2016 blocks from the
+	// initiator.
+	open := &OpenChannel{
+		CsvDelay: 144,
+	}
+	accept := &AcceptChannel{
+		CsvDelay:             2016,
+		FundingKey:           pubKey,
+		RevocationPoint:      pubKey,
+		PaymentPoint:         pubKey,
+		DelayedPaymentPoint:  pubKey,
+		HtlcPoint:            pubKey,
+		FirstCommitmentPoint: pubKey,
+	}
+
+	if delay := accept.LocalToSelfDelay(); delay != 2016 {
+		t.Fatalf("expected local to_self delay of 2016, got %v",
+			delay)
+	}
+	if accept.LocalToSelfDelay() == open.CsvDelay {
+		t.Fatalf("local to_self delay must not be the delay of the " +
+			"OpenChannel")
+	}
+
+	// The delay survives encoding, as it's the CsvDelay on the wire.
+	var b bytes.Buffer
+	if err := accept.Encode(&b, 0); err != nil {
+		t.Fatalf("unable to encode AcceptChannel: %v", err)
+	}
+	var decoded AcceptChannel
+	if err := decoded.Decode(&b, 0); err != nil {
+		t.Fatalf("unable to decode AcceptChannel: %v", err)
+	}
+	if delay := decoded.LocalToSelfDelay(); delay != 2016 {
+		t.Fatalf("expected local to_self delay of 2016, got %v",
+			delay)
+	}
+}
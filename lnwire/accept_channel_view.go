@@ -0,0 +1,158 @@
+package lnwire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+)
+
+// The byte offsets of the fixed size fields within a serialized AcceptChannel
+// message, excluding the message type.
+const (
+	acceptPendingChanIDOffset     = 0
+	acceptDustLimitOffset         = acceptPendingChanIDOffset + 32
+	acceptMaxValueInFlightOffset  = acceptDustLimitOffset + 8
+	acceptChannelReserveOffset    = acceptMaxValueInFlightOffset + 8
+	acceptHtlcMinimumOffset       = acceptChannelReserveOffset + 8
+	acceptMinAcceptDepthOffset    = acceptHtlcMinimumOffset + 8
+	acceptCsvDelayOffset          = acceptMinAcceptDepthOffset + 4
+	acceptMaxAcceptedHTLCsOffset  = acceptCsvDelayOffset + 2
+	acceptFundingKeyOffset        = acceptMaxAcceptedHTLCsOffset + 2
+	acceptRevocationPointOffset   = acceptFundingKeyOffset + 33
+	acceptPaymentPointOffset      = acceptRevocationPointOffset + 33
+	acceptDelayedPaymentOffset    = acceptPaymentPointOffset + 33
+	acceptHtlcPointOffset         = acceptDelayedPaymentOffset + 33
+	acceptFirstCommitPointOffset  = acceptHtlcPointOffset + 33
+	acceptTLVOffset               = acceptFirstCommitPointOffset + 33
+	acceptChannelMinPayloadLength = acceptTLVOffset
+)
+
+// AcceptChannelView provides read-only access to the fields of a serialized
+// AcceptChannel message. In contrast to Decode, fields are only decoded when
+// their accessor is called, which avoids allocating the full message when only
+// a few fields are of interest.
+type AcceptChannelView struct {
+	b []byte
+}
+
+// NewAcceptChannelView returns a view over the passed AcceptChannel payload,
+// which shouldn't include the message type. An error is returned if the
+// payload is too short to contain all the mandatory fields. The view
+// references the passed slice, so it must not be modified while the view is in
+// use.
+func NewAcceptChannelView(b []byte) (*AcceptChannelView, error) {
+	if len(b) < acceptChannelMinPayloadLength {
+		return nil, fmt.Errorf("accept channel payload too short: "+
+			"got %d bytes, need at least %d", len(b),
+			acceptChannelMinPayloadLength)
+	}
+
+	return &AcceptChannelView{b: b}, nil
+}
+
+// PendingChannelID returns the pending channel ID of the message.
+func (v *AcceptChannelView) PendingChannelID() [32]byte {
+	var id [32]byte
+	copy(id[:], v.b[acceptPendingChanIDOffset:acceptDustLimitOffset])
+	return id
+}
+
+// DustLimit returns the dust limit the sender would like enforced on their
+// commitment transaction.
+func (v *AcceptChannelView) DustLimit() btcutil.Amount {
+	return btcutil.Amount(v.uint64(acceptDustLimitOffset))
+}
+
+// MaxValueInFlight returns the maximum value the sender allows to be pending
+// within the channel.
+func (v *AcceptChannelView) MaxValueInFlight() MilliSatoshi {
+	return MilliSatoshi(v.uint64(acceptMaxValueInFlightOffset))
+}
+
+// ChannelReserve returns the reserve the receiver must maintain.
+func (v *AcceptChannelView) ChannelReserve() btcutil.Amount {
+	return btcutil.Amount(v.uint64(acceptChannelReserveOffset))
+}
+
+// HtlcMinimum returns the smallest HTLC the sender will accept.
+func (v *AcceptChannelView) HtlcMinimum() MilliSatoshi {
+	return MilliSatoshi(v.uint64(acceptHtlcMinimumOffset))
+}
+
+// MinAcceptDepth returns the minimum depth the sender requires before the
+// channel is considered open.
+func (v *AcceptChannelView) MinAcceptDepth() uint32 {
+	return binary.BigEndian.Uint32(v.b[acceptMinAcceptDepthOffset:])
+}
+
+// CsvDelay returns the relative time lock the sender requires on the
+// to-self output of the receiver's commitment transaction.
+func (v *AcceptChannelView) CsvDelay() uint16 {
+	return binary.BigEndian.Uint16(v.b[acceptCsvDelayOffset:])
+}
+
+// MaxAcceptedHTLCs returns the total number of incoming HTLCs the sender will
+// accept.
+func (v *AcceptChannelView) MaxAcceptedHTLCs() uint16 {
+	return binary.BigEndian.Uint16(v.b[acceptMaxAcceptedHTLCsOffset:])
+}
+
+// FundingKey parses and returns the sender's multi-sig funding key.
+func (v *AcceptChannelView) FundingKey() (*btcec.PublicKey, error) {
+	return v.pubKey(acceptFundingKeyOffset)
+}
+
+// RevocationPoint parses and returns the sender's revocation base point.
+func (v *AcceptChannelView) RevocationPoint() (*btcec.PublicKey, error) {
+	return v.pubKey(acceptRevocationPointOffset)
+}
+
+// PaymentPoint parses and returns the sender's payment base point.
+func (v *AcceptChannelView) PaymentPoint() (*btcec.PublicKey, error) {
+	return v.pubKey(acceptPaymentPointOffset)
+}
+
+// DelayedPaymentPoint parses and returns the sender's delayed payment base
+// point.
+func (v *AcceptChannelView) DelayedPaymentPoint() (*btcec.PublicKey, error) {
+	return v.pubKey(acceptDelayedPaymentOffset)
+}
+
+// HtlcPoint parses and returns the sender's HTLC base point.
+func (v *AcceptChannelView) HtlcPoint() (*btcec.PublicKey, error) {
+	return v.pubKey(acceptHtlcPointOffset)
+}
+
+// FirstCommitmentPoint parses and returns the sender's first per-commitment
+// point.
+func (v *AcceptChannelView) FirstCommitmentPoint() (*btcec.PublicKey, error) {
+	return v.pubKey(acceptFirstCommitPointOffset)
+}
+
+// UpfrontShutdownScript parses the TLV data of the message and returns the
+// upfront shutdown script, along with the remaining extra data, in the same
+// form as Decode would.
+func (v *AcceptChannelView) UpfrontShutdownScript() (DeliveryAddress,
+	ExtraOpaqueData, error) {
+
+	var tlvRecords ExtraOpaqueData
+	err := ReadElement(bytes.NewReader(v.b[acceptTLVOffset:]), &tlvRecords)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parseShutdownScript(tlvRecords, nil)
+}
+
+// uint64 reads a big endian uint64 at the given offset.
+func (v *AcceptChannelView) uint64(offset int) uint64 {
+	return binary.BigEndian.Uint64(v.b[offset:])
+}
+
+// pubKey parses the compressed public key at the given offset.
+func (v *AcceptChannelView) pubKey(offset int) (*btcec.PublicKey, error) {
+	return parsePubKey(v.b[offset : offset+btcec.PubKeyBytesLenCompressed])
+}
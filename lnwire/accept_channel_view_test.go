@@ -0,0 +1,149 @@
+package lnwire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptChannelView asserts that the lazy accessors of AcceptChannelView
+// return the same values as a full decode of the message.
+func TestAcceptChannelView(t *testing.T) {
+	t.Parallel()
+
+	newKey := func() *btcec.PublicKey {
+		priv, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		return priv.PubKey()
+	}
+
+	var (
+		extraData ExtraOpaqueData
+		extraVal  uint8 = 1
+	)
+	require.NoError(t, extraData.PackRecords(
+		tlv.MakePrimitiveRecord(tlv.Type(1), &extraVal),
+	))
+
+	tests := []struct {
+		name           string
+		shutdownScript DeliveryAddress
+		extraData      ExtraOpaqueData
+	}{
+		{
+			name: "no upfront shutdown script",
+		},
+		{
+			name:           "upfront shutdown script set",
+			shutdownScript: []byte("example"),
+		},
+		{
+			name:           "upfront shutdown script and extra data",
+			shutdownScript: []byte("example"),
+			extraData:      extraData,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			msg := &AcceptChannel{
+				PendingChannelID:      [32]byte{1, 2, 3},
+				DustLimit:             573,
+				MaxValueInFlight:      100000000,
+				ChannelReserve:        10000,
+				HtlcMinimum:           1000,
+				MinAcceptDepth:        3,
+				CsvDelay:              144,
+				MaxAcceptedHTLCs:      483,
+				FundingKey:            newKey(),
+				RevocationPoint:       newKey(),
+				PaymentPoint:          newKey(),
+				DelayedPaymentPoint:   newKey(),
+				HtlcPoint:             newKey(),
+				FirstCommitmentPoint:  newKey(),
+				UpfrontShutdownScript: test.shutdownScript,
+				ExtraData:             test.extraData,
+			}
+
+			var b bytes.Buffer
+			require.NoError(t, msg.Encode(&b, 0))
+
+			var decoded AcceptChannel
+			require.NoError(t, decoded.Decode(
+				bytes.NewReader(b.Bytes()), 0,
+			))
+
+			view, err := NewAcceptChannelView(b.Bytes())
+			require.NoError(t, err)
+
+			require.Equal(
+				t, decoded.PendingChannelID,
+				view.PendingChannelID(),
+			)
+			require.Equal(t, decoded.DustLimit, view.DustLimit())
+			require.Equal(
+				t, decoded.MaxValueInFlight,
+				view.MaxValueInFlight(),
+			)
+			require.Equal(
+				t, decoded.ChannelReserve, view.ChannelReserve(),
+			)
+			require.Equal(t, decoded.HtlcMinimum, view.HtlcMinimum())
+			require.Equal(
+				t, decoded.MinAcceptDepth, view.MinAcceptDepth(),
+			)
+			require.Equal(t, decoded.CsvDelay, view.CsvDelay())
+			require.Equal(
+				t, decoded.MaxAcceptedHTLCs,
+				view.MaxAcceptedHTLCs(),
+			)
+
+			keys := []struct {
+				expected *btcec.PublicKey
+				accessor func() (*btcec.PublicKey, error)
+			}{
+				{decoded.FundingKey, view.FundingKey},
+				{decoded.RevocationPoint, view.RevocationPoint},
+				{decoded.PaymentPoint, view.PaymentPoint},
+				{
+					decoded.DelayedPaymentPoint,
+					view.DelayedPaymentPoint,
+				},
+				{decoded.HtlcPoint, view.HtlcPoint},
+				{
+					decoded.FirstCommitmentPoint,
+					view.FirstCommitmentPoint,
+				},
+			}
+			for _, key := range keys {
+				pubKey, err := key.accessor()
+				require.NoError(t, err)
+				require.True(t, key.expected.IsEqual(pubKey))
+			}
+
+			script, extra, err := view.UpfrontShutdownScript()
+			require.NoError(t, err)
+			require.Equal(t, decoded.UpfrontShutdownScript, script)
+			require.Equal(t, decoded.ExtraData, extra)
+		})
+	}
+}
+
+// TestAcceptChannelViewShortPayload asserts that a view can't be created over
+// a payload that is missing mandatory fields.
+func TestAcceptChannelViewShortPayload(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewAcceptChannelView(
+		make([]byte, acceptChannelMinPayloadLength-1),
+	)
+	require.Error(t, err)
+
+	_, err = NewAcceptChannelView(make([]byte, acceptChannelMinPayloadLength))
+	require.NoError(t, err)
+}
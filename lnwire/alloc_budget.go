@@ -0,0 +1,68 @@
+package lnwire
+
+import "fmt"
+
+// ErrAllocBudgetExceeded is returned when decoding a message would allocate
+// more memory for its variable length fields than the budget the decoding was
+// limited to.
+type ErrAllocBudgetExceeded struct {
+	// Budget is the total number of bytes the decoding may allocate.
+	Budget int
+
+	// Field is the name of the field whose allocation exceeded the budget.
+	Field string
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrAllocBudgetExceeded) Error() string {
+	return fmt.Sprintf("decoding %v exceeds allocation budget of %d "+
+		"bytes", e.Field, e.Budget)
+}
+
+// allocBudget keeps track of the bytes allocated for the variable length
+// fields of a message while it is decoded. A nil budget is unlimited.
+type allocBudget struct {
+	limit     int
+	remaining int
+}
+
+// newAllocBudget returns a budget allowing the given number of bytes to be
+// allocated.
+func newAllocBudget(limit int) *allocBudget {
+	return &allocBudget{
+		limit:     limit,
+		remaining: limit,
+	}
+}
+
+// readLimit returns the maximum number of bytes that may be read into a
+// single allocation. One byte more than the remaining budget is allowed, such
+// that exceeding the budget can be detected by charging the result, without
+// allocating more than that. A negative value means no limit.
+func (b *allocBudget) readLimit() int64 {
+	if b == nil {
+		return -1
+	}
+
+	return int64(b.remaining) + 1
+}
+
+// charge deducts an allocation of n bytes for the named field from the
+// budget, returning an *ErrAllocBudgetExceeded if the budget doesn't cover it.
+func (b *allocBudget) charge(field string, n int) error {
+	if b == nil {
+		return nil
+	}
+
+	if n > b.remaining {
+		return &ErrAllocBudgetExceeded{
+			Budget: b.limit,
+			Field:  field,
+		}
+	}
+	b.remaining -= n
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package lnwire
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptChannelDecodeWithBudget asserts that decoding an AcceptChannel
+// fails once the cumulative allocations of its variable length fields exceed
+// the budget, and that an oversized blob isn't read into memory.
+func TestAcceptChannelDecodeWithBudget(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	// The extra data holds a sizeable unknown odd record, which is
+	// allocated along with the shutdown script.
+	extraData := append(
+		ExtraOpaqueData{0xfe, 0x00, 0x01, 0x00, 0x03, 100},
+		bytes.Repeat([]byte{0xaa}, 100)...,
+	)
+
+	msg := &AcceptChannel{
+		FundingKey:            pk,
+		RevocationPoint:       pk,
+		PaymentPoint:          pk,
+		DelayedPaymentPoint:   pk,
+		HtlcPoint:             pk,
+		FirstCommitmentPoint:  pk,
+		UpfrontShutdownScript: bytes.Repeat([]byte{0x51}, 40),
+		ExtraData:             extraData,
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, msg.Encode(&b, 0))
+	payload := b.Bytes()
+
+	// The blob holding all TLV records is allocated first, after which
+	// the shutdown script is extracted from it into an allocation of its
+	// own.
+	blobLen := len(payload) - acceptTLVOffset
+	scriptLen := len(msg.UpfrontShutdownScript)
+
+	tests := []struct {
+		name     string
+		budget   int
+		errField string
+	}{
+		{
+			name:   "exact budget",
+			budget: blobLen + scriptLen,
+		},
+		{
+			name:     "cumulative allocations exceed budget",
+			budget:   blobLen + scriptLen - 1,
+			errField: "UpfrontShutdownScript",
+		},
+		{
+			name:     "blob exceeds budget",
+			budget:   blobLen - 1,
+			errField: "ExtraData",
+		},
+		{
+			name:     "zero budget",
+			budget:   0,
+			errField: "ExtraData",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			r := bytes.NewReader(payload)
+
+			var decoded AcceptChannel
+			err := decoded.DecodeWithBudget(r, 0, test.budget)
+			if test.errField == "" {
+				require.NoError(t, err)
+				require.True(t, decoded.Equal(msg))
+				return
+			}
+
+			var budgetErr *ErrAllocBudgetExceeded
+			require.True(t, errors.As(err, &budgetErr), err)
+			require.Equal(t, test.budget, budgetErr.Budget)
+			require.Equal(t, test.errField, budgetErr.Field)
+
+			// No more than one byte beyond the budget may have been
+			// read into the blob.
+			read := len(payload) - r.Len() - acceptTLVOffset
+			require.LessOrEqual(t, read, test.budget+1)
+		})
+	}
+
+	// Without a budget, Decode accepts the message as before.
+	var decoded AcceptChannel
+	require.NoError(t, decoded.Decode(bytes.NewReader(payload), 0))
+	require.True(t, decoded.Equal(msg))
+}
@@ -0,0 +1,95 @@
+package lnwire
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ArchiveFormat is the encoding of the payload of an ArchiveRecord.
+type ArchiveFormat uint8
+
+const (
+	// ArchiveFormatWire denotes a payload holding the wire encoding of the
+	// message body, without the message type prefix.
+	ArchiveFormatWire ArchiveFormat = iota
+
+	// ArchiveFormatJSON denotes a payload holding the versioned JSON
+	// document written by the MarshalArchive method of the message. Only
+	// AcceptChannel supports this format.
+	ArchiveFormatJSON
+)
+
+// String returns a human readable representation of the format.
+func (f ArchiveFormat) String() string {
+	switch f {
+	case ArchiveFormatWire:
+		return "wire"
+
+	case ArchiveFormatJSON:
+		return "json"
+
+	default:
+		return fmt.Sprintf("ArchiveFormat(%d)", uint8(f))
+	}
+}
+
+// ArchiveRecord is a single message stored in a message archive, framed with
+// its type and the encoding of its payload.
+type ArchiveRecord struct {
+	// Type is the type of the archived message.
+	Type MessageType
+
+	// Format is the encoding of Payload.
+	Format ArchiveFormat
+
+	// Payload is the encoded message.
+	Payload []byte
+}
+
+// FromArchiveRecord decodes the message stored in the given archive record. An
+// error is returned if the payload is larger than MaxMsgBody, if it doesn't
+// decode into a message of the type of the record, or if decoding didn't
+// consume the entire payload.
+func FromArchiveRecord(rec ArchiveRecord) (Message, error) {
+	if len(rec.Payload) > MaxMsgBody {
+		return nil, fmt.Errorf("archived %v payload of %d bytes "+
+			"exceeds max of %d", rec.Type, len(rec.Payload),
+			MaxMsgBody)
+	}
+
+	switch rec.Format {
+	case ArchiveFormatWire:
+		msg, err := makeEmptyMessage(rec.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		r := bytes.NewReader(rec.Payload)
+		if err := msg.Decode(r, 0); err != nil {
+			return nil, err
+		}
+
+		if r.Len() != 0 {
+			return nil, fmt.Errorf("archived %v left %d bytes "+
+				"unread after decoding", rec.Type, r.Len())
+		}
+
+		return msg, nil
+
+	case ArchiveFormatJSON:
+		if rec.Type != MsgAcceptChannel {
+			return nil, fmt.Errorf("archive format %v not "+
+				"supported for %v", rec.Format, rec.Type)
+		}
+
+		var msg AcceptChannel
+		if err := msg.UnmarshalArchive(rec.Payload); err != nil {
+			return nil, err
+		}
+
+		return &msg, nil
+
+	default:
+		return nil, fmt.Errorf("unknown archive format %v", rec.Format)
+	}
+}
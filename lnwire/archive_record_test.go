@@ -0,0 +1,144 @@
+package lnwire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestFromArchiveRecord asserts that messages are decoded from archive records
+// of every supported format, and that malformed records are rejected.
+func TestFromArchiveRecord(t *testing.T) {
+	t.Parallel()
+
+	accept := newArchiveTestAcceptChannel(t, nil)
+
+	var acceptWire bytes.Buffer
+	require.NoError(t, accept.Encode(&acceptWire, 0))
+
+	acceptJSON, err := accept.MarshalArchive()
+	require.NoError(t, err)
+
+	ping := NewPing(10)
+	ping.PaddingBytes = []byte{1, 2, 3}
+
+	var pingWire bytes.Buffer
+	require.NoError(t, ping.Encode(&pingWire, 0))
+
+	tests := []struct {
+		name      string
+		rec       ArchiveRecord
+		expected  Message
+		expectErr bool
+	}{
+		{
+			name: "accept channel wire",
+			rec: ArchiveRecord{
+				Type:    MsgAcceptChannel,
+				Format:  ArchiveFormatWire,
+				Payload: acceptWire.Bytes(),
+			},
+			expected: accept,
+		},
+		{
+			name: "accept channel json",
+			rec: ArchiveRecord{
+				Type:    MsgAcceptChannel,
+				Format:  ArchiveFormatJSON,
+				Payload: acceptJSON,
+			},
+			expected: accept,
+		},
+		{
+			name: "other message wire",
+			rec: ArchiveRecord{
+				Type:    MsgPing,
+				Format:  ArchiveFormatWire,
+				Payload: pingWire.Bytes(),
+			},
+			expected: ping,
+		},
+		{
+			name: "json unsupported for message",
+			rec: ArchiveRecord{
+				Type:    MsgPing,
+				Format:  ArchiveFormatJSON,
+				Payload: acceptJSON,
+			},
+			expectErr: true,
+		},
+		{
+			name: "type mismatch",
+			rec: ArchiveRecord{
+				Type:    MsgAcceptChannel,
+				Format:  ArchiveFormatWire,
+				Payload: pingWire.Bytes(),
+			},
+			expectErr: true,
+		},
+		{
+			name: "trailing bytes",
+			rec: ArchiveRecord{
+				Type:   MsgPing,
+				Format: ArchiveFormatWire,
+				Payload: append(
+					append([]byte{}, pingWire.Bytes()...),
+					0xff,
+				),
+			},
+			expectErr: true,
+		},
+		{
+			name: "unknown message type",
+			rec: ArchiveRecord{
+				Type:    MessageType(65535),
+				Format:  ArchiveFormatWire,
+				Payload: pingWire.Bytes(),
+			},
+			expectErr: true,
+		},
+		{
+			name: "unknown format",
+			rec: ArchiveRecord{
+				Type:    MsgAcceptChannel,
+				Format:  ArchiveFormat(2),
+				Payload: acceptWire.Bytes(),
+			},
+			expectErr: true,
+		},
+		{
+			name: "payload too large",
+			rec: ArchiveRecord{
+				Type:    MsgPing,
+				Format:  ArchiveFormatWire,
+				Payload: make([]byte, MaxMsgBody+1),
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			msg, err := FromArchiveRecord(test.rec)
+			if test.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// Compare the serializations, as fields that are nil
+			// in the original may decode as empty.
+			var expected, actual bytes.Buffer
+			_, err = WriteMessage(&expected, test.expected, 0)
+			require.NoError(t, err)
+			_, err = WriteMessage(&actual, msg, 0)
+			require.NoError(t, err)
+			require.Equal(t, expected.Bytes(), actual.Bytes())
+		})
+	}
+}
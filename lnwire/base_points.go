@@ -0,0 +1,130 @@
+package lnwire
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// BasePoints holds the funding key and the base points a party of a channel
+// sends in its OpenChannel or AcceptChannel message, named by the role they
+// play in deriving the keys of the channel.
+type BasePoints struct {
+	// Funding is the key of the party within the 2-of-2 multi-sig output
+	// of the funding transaction.
+	Funding *btcec.PublicKey
+
+	// Revocation is the base point the counterparty combines with its
+	// per-commitment points to derive the revocation keys of its
+	// commitment transactions.
+	Revocation *btcec.PublicKey
+
+	// Payment is the base point used to derive the keys of the outputs
+	// paying directly to the party.
+	Payment *btcec.PublicKey
+
+	// DelayedPayment is the base point used to derive the keys of the
+	// delayed outputs of the party's own commitment transactions.
+	DelayedPayment *btcec.PublicKey
+
+	// Htlc is the base point used to derive the keys of the party within
+	// the HTLC scripts.
+	Htlc *btcec.PublicKey
+
+	// FirstCommit is the first per-commitment point of the party.
+	FirstCommit *btcec.PublicKey
+}
+
+// BasePoints returns the funding key and the base points of the message.
+func (a *AcceptChannel) BasePoints() BasePoints {
+	return BasePoints{
+		Funding:        a.FundingKey,
+		Revocation:     a.RevocationPoint,
+		Payment:        a.PaymentPoint,
+		DelayedPayment: a.DelayedPaymentPoint,
+		Htlc:           a.HtlcPoint,
+		FirstCommit:    a.FirstCommitmentPoint,
+	}
+}
+
+// ErrBasePointMissing is returned when validating a set of base points in
+// which one of the keys isn't set.
+type ErrBasePointMissing struct {
+	// Role is the name of the missing key.
+	Role string
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrBasePointMissing) Error() string {
+	return fmt.Sprintf("%v base point missing", e.Role)
+}
+
+// basePointRole is one of the keys of a set of base points, along with the
+// name of its role.
+type basePointRole struct {
+	name string
+	key  *btcec.PublicKey
+}
+
+// roles returns the keys of the base points along with their role names, in
+// the order they appear in on the wire.
+func (b *BasePoints) roles() [6]basePointRole {
+	return [6]basePointRole{
+		{"funding", b.Funding},
+		{"revocation", b.Revocation},
+		{"payment", b.Payment},
+		{"delayed payment", b.DelayedPayment},
+		{"htlc", b.Htlc},
+		{"first commitment", b.FirstCommit},
+	}
+}
+
+// ValidateComplete ensures that all keys of the base points are set, returning
+// an *ErrBasePointMissing naming the first missing one otherwise.
+func (b *BasePoints) ValidateComplete() error {
+	for _, role := range b.roles() {
+		if role.key == nil {
+			return &ErrBasePointMissing{Role: role.name}
+		}
+	}
+
+	return nil
+}
+
+// ValidateDistinct ensures that the keys of the base points are pairwise
+// distinct, returning ErrDuplicatePubKey otherwise. Keys that aren't set are
+// skipped.
+func (b *BasePoints) ValidateDistinct() error {
+	type serializedKey [btcec.PubKeyBytesLenCompressed]byte
+
+	roles := b.roles()
+	seen := make(map[serializedKey]struct{}, len(roles))
+	for _, role := range roles {
+		if role.key == nil {
+			continue
+		}
+
+		var serialized serializedKey
+		copy(serialized[:], role.key.SerializeCompressed())
+
+		if _, ok := seen[serialized]; ok {
+			return ErrDuplicatePubKey
+		}
+		seen[serialized] = struct{}{}
+	}
+
+	return nil
+}
+
+// Validate ensures that all keys of the base points are set and pairwise
+// distinct, returning the error of ValidateComplete or ValidateDistinct
+// otherwise.
+func (b *BasePoints) Validate() error {
+	if err := b.ValidateComplete(); err != nil {
+		return err
+	}
+
+	return b.ValidateDistinct()
+}
@@ -0,0 +1,139 @@
+package lnwire
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBasePoints returns a complete set of distinct base points.
+func newTestBasePoints(t *testing.T) BasePoints {
+	t.Helper()
+
+	newKey := func() *btcec.PublicKey {
+		priv, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		return priv.PubKey()
+	}
+
+	return BasePoints{
+		Funding:        newKey(),
+		Revocation:     newKey(),
+		Payment:        newKey(),
+		DelayedPayment: newKey(),
+		Htlc:           newKey(),
+		FirstCommit:    newKey(),
+	}
+}
+
+// TestAcceptChannelBasePoints asserts that each base point of an AcceptChannel
+// is mapped to the field of its role.
+func TestAcceptChannelBasePoints(t *testing.T) {
+	t.Parallel()
+
+	expected := newTestBasePoints(t)
+	msg := &AcceptChannel{
+		FundingKey:           expected.Funding,
+		RevocationPoint:      expected.Revocation,
+		PaymentPoint:         expected.Payment,
+		DelayedPaymentPoint:  expected.DelayedPayment,
+		HtlcPoint:            expected.Htlc,
+		FirstCommitmentPoint: expected.FirstCommit,
+	}
+
+	basePoints := msg.BasePoints()
+	require.Same(t, expected.Funding, basePoints.Funding)
+	require.Same(t, expected.Revocation, basePoints.Revocation)
+	require.Same(t, expected.Payment, basePoints.Payment)
+	require.Same(t, expected.DelayedPayment, basePoints.DelayedPayment)
+	require.Same(t, expected.Htlc, basePoints.Htlc)
+	require.Same(t, expected.FirstCommit, basePoints.FirstCommit)
+}
+
+// TestBasePointsValidate asserts that base points are only valid if all of
+// them are set and pairwise distinct, and that the errors of the individual
+// checks are returned.
+func TestBasePointsValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		modify      func(b *BasePoints)
+		completeErr error
+		distinctErr error
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "funding key missing",
+			modify: func(b *BasePoints) {
+				b.Funding = nil
+			},
+			completeErr: &ErrBasePointMissing{Role: "funding"},
+		},
+		{
+			name: "first missing key reported",
+			modify: func(b *BasePoints) {
+				b.Htlc = nil
+				b.FirstCommit = nil
+			},
+			completeErr: &ErrBasePointMissing{Role: "htlc"},
+		},
+		{
+			name: "duplicate keys",
+			modify: func(b *BasePoints) {
+				b.Htlc = b.Revocation
+			},
+			distinctErr: ErrDuplicatePubKey,
+		},
+		{
+			name: "duplicate keys by value",
+			modify: func(b *BasePoints) {
+				b.FirstCommit, _ = btcec.ParsePubKey(
+					b.Funding.SerializeCompressed(),
+					btcec.S256(),
+				)
+			},
+			distinctErr: ErrDuplicatePubKey,
+		},
+		{
+			name: "missing keys aren't duplicates",
+			modify: func(b *BasePoints) {
+				b.Payment = nil
+				b.DelayedPayment = nil
+			},
+			completeErr: &ErrBasePointMissing{Role: "payment"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			basePoints := newTestBasePoints(t)
+			if test.modify != nil {
+				test.modify(&basePoints)
+			}
+
+			require.Equal(
+				t, test.completeErr,
+				basePoints.ValidateComplete(),
+			)
+			require.Equal(
+				t, test.distinctErr,
+				basePoints.ValidateDistinct(),
+			)
+
+			// Validate reports the missing keys first.
+			expectedErr := test.completeErr
+			if expectedErr == nil {
+				expectedErr = test.distinctErr
+			}
+			require.Equal(t, expectedErr, basePoints.Validate())
+		})
+	}
+}
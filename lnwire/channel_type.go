@@ -0,0 +1,180 @@
+package lnwire
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+// ChannelTypeRecordType is the TLV record type for the channel type within
+// the name space of the OpenChannel and AcceptChannel messages.
+const ChannelTypeRecordType tlv.Type = 1
+
+// ChannelType is the feature vector describing the type of a channel, as
+// negotiated through the OpenChannel and AcceptChannel messages. Contrary to
+// the feature vectors of the init message, all of its bits must be known to
+// the receiver if they are even, since they change the channel itself.
+type ChannelType RawFeatureVector
+
+// featureBitLen returns the length in bytes of the encoded channel type.
+func (c *ChannelType) featureBitLen() uint64 {
+	fv := RawFeatureVector(*c)
+	return uint64(fv.SerializeSize())
+}
+
+// NewRecord returns a TLV record that can be used to encode the channel type
+// within the ExtraData TLV stream.
+func (c *ChannelType) NewRecord() tlv.Record {
+	return tlv.MakeDynamicRecord(
+		ChannelTypeRecordType, c, c.featureBitLen, channelTypeEncoder,
+		channelTypeDecoder,
+	)
+}
+
+// channelTypeEncoder is a custom TLV encoder for the ChannelType record.
+func channelTypeEncoder(w io.Writer, val interface{}, buf *[8]byte) error {
+	if v, ok := val.(*ChannelType); ok {
+		fv := RawFeatureVector(*v)
+		return fv.EncodeBase256(w)
+	}
+
+	return tlv.NewTypeForEncodingErr(val, "*lnwire.ChannelType")
+}
+
+// channelTypeDecoder is a custom TLV decoder for the ChannelType record.
+func channelTypeDecoder(r io.Reader, val interface{}, buf *[8]byte,
+	l uint64) error {
+
+	if v, ok := val.(*ChannelType); ok {
+		fv := NewRawFeatureVector()
+		if err := fv.DecodeBase256(r, int(l)); err != nil {
+			return err
+		}
+
+		*v = ChannelType(*fv)
+		return nil
+	}
+
+	return tlv.NewTypeForDecodingErr(val, "*lnwire.ChannelType", l, l)
+}
+
+// ErrUnknownChannelType is returned when validating a message whose channel
+// type sets even feature bits we don't know of. As opposed to odd bits, which
+// can safely be ignored, the channel can't be created without understanding
+// them.
+type ErrUnknownChannelType struct {
+	// Unknown holds the unknown even feature bits of the channel type.
+	Unknown []FeatureBit
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrUnknownChannelType) Error() string {
+	return fmt.Sprintf("channel type requires unknown features %v",
+		e.Unknown)
+}
+
+// ErrInvalidChannelTypeCombo is returned when validating a message whose
+// channel type combines feature bits that can't be used together, such as
+// anchor outputs without static remote keys.
+var ErrInvalidChannelTypeCombo = errors.New("channel type contains an " +
+	"invalid combination of features")
+
+// validateCombination ensures the feature bits of the channel type can be used
+// together. Both anchor variants require static remote keys, and they are
+// mutually exclusive.
+func (c *ChannelType) validateCombination() error {
+	fv := RawFeatureVector(*c)
+	return validateChannelTypeBits(fv.IsSet)
+}
+
+// validateChannelTypeBits implements validateCombination for a channel type
+// whose feature bits are reported by isSet.
+func validateChannelTypeBits(isSet func(FeatureBit) bool) error {
+	hasAny := func(bits ...FeatureBit) bool {
+		for _, bit := range bits {
+			if isSet(bit) {
+				return true
+			}
+		}
+		return false
+	}
+
+	staticRemoteKey := hasAny(
+		StaticRemoteKeyRequired, StaticRemoteKeyOptional,
+	)
+	anchors := hasAny(AnchorsRequired, AnchorsOptional)
+	zeroFeeAnchors := hasAny(
+		AnchorsZeroFeeHtlcTxRequired, AnchorsZeroFeeHtlcTxOptional,
+	)
+
+	switch {
+	case anchors && zeroFeeAnchors:
+		return ErrInvalidChannelTypeCombo
+
+	case (anchors || zeroFeeAnchors) && !staticRemoteKey:
+		return ErrInvalidChannelTypeCombo
+	}
+
+	return nil
+}
+
+// ChannelType returns the channel type carried in the ExtraData of the
+// message, or nil if the message doesn't carry one.
+func (a *AcceptChannel) ChannelType() (*ChannelType, error) {
+	var chanType ChannelType
+	typeMap, err := a.ExtraData.ExtractRecords(chanType.NewRecord())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := typeMap[ChannelTypeRecordType]; !ok {
+		return nil, nil
+	}
+
+	return &chanType, nil
+}
+
+// HasScidAlias returns true if the channel type carried in the message sets
+// the scid-alias feature bit, meaning the channel must only be referred to by
+// its alias short channel ID. False is returned if the message doesn't carry a
+// channel type, or one that can't be decoded.
+func (a *AcceptChannel) HasScidAlias() bool {
+	chanType, err := a.ChannelType()
+	if err != nil || chanType == nil {
+		return false
+	}
+
+	fv := RawFeatureVector(*chanType)
+	return fv.IsSet(ScidAliasRequired) || fv.IsSet(ScidAliasOptional)
+}
+
+// ValidateChannelType ensures that the channel type carried in the message,
+// if any, doesn't set any even feature bit we don't know of, returning an
+// *ErrUnknownChannelType otherwise. ErrInvalidChannelTypeCombo is returned if
+// the known feature bits can't be used together.
+func (a *AcceptChannel) ValidateChannelType() error {
+	chanType, err := a.ChannelType()
+	if err != nil {
+		return err
+	}
+	if chanType == nil {
+		return nil
+	}
+
+	fv := RawFeatureVector(*chanType)
+	unknown := NewFeatureVector(&fv, Features).UnknownRequiredFeatures()
+	if len(unknown) > 0 {
+		sort.Slice(unknown, func(i, j int) bool {
+			return unknown[i] < unknown[j]
+		})
+
+		return &ErrUnknownChannelType{Unknown: unknown}
+	}
+
+	return chanType.validateCombination()
+}
@@ -0,0 +1,258 @@
+package lnwire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptChannelChannelType asserts that the channel type of an
+// AcceptChannel survives an encode/decode round trip, and that only unknown
+// even feature bits are rejected by ValidateChannelType.
+func TestAcceptChannelChannelType(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	const (
+		unknownEven FeatureBit = 100
+		unknownOdd  FeatureBit = 101
+	)
+
+	tests := []struct {
+		name       string
+		bits       []FeatureBit
+		absent     bool
+		expUnknown []FeatureBit
+	}{
+		{
+			name:   "no channel type",
+			absent: true,
+		},
+		{
+			name: "empty channel type",
+		},
+		{
+			name: "known bits",
+			bits: []FeatureBit{
+				StaticRemoteKeyRequired,
+				AnchorsZeroFeeHtlcTxRequired,
+			},
+		},
+		{
+			name: "unknown odd bit",
+			bits: []FeatureBit{StaticRemoteKeyRequired, unknownOdd},
+		},
+		{
+			name: "unknown even bit",
+			bits: []FeatureBit{
+				StaticRemoteKeyRequired, unknownEven,
+			},
+			expUnknown: []FeatureBit{unknownEven},
+		},
+		{
+			name:       "unknown even and odd bits",
+			bits:       []FeatureBit{unknownOdd, unknownEven},
+			expUnknown: []FeatureBit{unknownEven},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			msg := &AcceptChannel{
+				FundingKey:           pk,
+				RevocationPoint:      pk,
+				PaymentPoint:         pk,
+				DelayedPaymentPoint:  pk,
+				HtlcPoint:            pk,
+				FirstCommitmentPoint: pk,
+			}
+			if !test.absent {
+				chanType := ChannelType(
+					*NewRawFeatureVector(test.bits...),
+				)
+				require.NoError(t, msg.ExtraData.PackRecords(
+					chanType.NewRecord(),
+				))
+			}
+
+			var b bytes.Buffer
+			_, err := WriteMessage(&b, msg, 0)
+			require.NoError(t, err)
+
+			decodedMsg, err := ReadMessage(&b, 0)
+			require.NoError(t, err)
+			decoded := decodedMsg.(*AcceptChannel)
+
+			chanType, err := decoded.ChannelType()
+			require.NoError(t, err)
+			if test.absent {
+				require.Nil(t, chanType)
+			} else {
+				require.NotNil(t, chanType)
+				fv := RawFeatureVector(*chanType)
+				require.Equal(
+					t, NewRawFeatureVector(test.bits...),
+					&fv,
+				)
+			}
+
+			err = decoded.ValidateChannelType()
+			if len(test.expUnknown) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			require.IsType(t, &ErrUnknownChannelType{}, err)
+			require.Equal(
+				t, test.expUnknown,
+				err.(*ErrUnknownChannelType).Unknown,
+			)
+		})
+	}
+}
+
+// TestAcceptChannelChannelTypeCombo asserts that ValidateChannelType rejects
+// channel types combining feature bits that can't be used together.
+func TestAcceptChannelChannelTypeCombo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		bits   []FeatureBit
+		expErr error
+	}{
+		{
+			name: "static remote key",
+			bits: []FeatureBit{StaticRemoteKeyRequired},
+		},
+		{
+			name: "anchors with static remote key",
+			bits: []FeatureBit{
+				StaticRemoteKeyRequired, AnchorsRequired,
+			},
+		},
+		{
+			name: "zero fee anchors with static remote key",
+			bits: []FeatureBit{
+				StaticRemoteKeyRequired,
+				AnchorsZeroFeeHtlcTxRequired,
+			},
+		},
+		{
+			name:   "anchors without static remote key",
+			bits:   []FeatureBit{AnchorsRequired},
+			expErr: ErrInvalidChannelTypeCombo,
+		},
+		{
+			name:   "zero fee anchors without static remote key",
+			bits:   []FeatureBit{AnchorsZeroFeeHtlcTxOptional},
+			expErr: ErrInvalidChannelTypeCombo,
+		},
+		{
+			name: "both anchor variants",
+			bits: []FeatureBit{
+				StaticRemoteKeyRequired, AnchorsRequired,
+				AnchorsZeroFeeHtlcTxRequired,
+			},
+			expErr: ErrInvalidChannelTypeCombo,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var msg AcceptChannel
+			chanType := ChannelType(
+				*NewRawFeatureVector(test.bits...),
+			)
+			require.NoError(t, msg.ExtraData.PackRecords(
+				chanType.NewRecord(),
+			))
+
+			err := msg.ValidateChannelType()
+			if test.expErr == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, test.expErr)
+		})
+	}
+}
+
+// TestAcceptChannelHasScidAlias asserts that HasScidAlias only reports the
+// scid-alias feature if the message carries a channel type setting it.
+func TestAcceptChannelHasScidAlias(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		bits     []FeatureBit
+		absent   bool
+		malform  bool
+		expAlias bool
+	}{
+		{
+			name:   "no channel type",
+			absent: true,
+		},
+		{
+			name:    "malformed channel type",
+			malform: true,
+		},
+		{
+			name: "without scid alias",
+			bits: []FeatureBit{StaticRemoteKeyRequired},
+		},
+		{
+			name: "scid alias required",
+			bits: []FeatureBit{
+				StaticRemoteKeyRequired, ScidAliasRequired,
+			},
+			expAlias: true,
+		},
+		{
+			name:     "scid alias optional",
+			bits:     []FeatureBit{ScidAliasOptional},
+			expAlias: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var msg AcceptChannel
+			switch {
+			case test.malform:
+				// A zero-length record is followed by a
+				// truncated record, which fails to parse.
+				msg.ExtraData = ExtraOpaqueData{0x01, 0x00, 0x03}
+
+			case !test.absent:
+				chanType := ChannelType(
+					*NewRawFeatureVector(test.bits...),
+				)
+				require.NoError(t, msg.ExtraData.PackRecords(
+					chanType.NewRecord(),
+				))
+			}
+
+			require.Equal(t, test.expAlias, msg.HasScidAlias())
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package lnwire
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader wraps an io.Reader such that reads are aborted once the
+// context is cancelled. As an io.Reader can't be interrupted in general, each
+// read is performed in a goroutine, which is abandoned if the context is
+// cancelled before the read returns. The underlying reader must therefore not
+// be used anymore once a read failed due to the cancellation.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// readResult is the outcome of a single read of the underlying reader.
+type readResult struct {
+	n   int
+	err error
+}
+
+// Read reads from the underlying reader, returning the error of the context
+// if it is cancelled before the read completes.
+//
+// NOTE: This is part of the io.Reader interface.
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// Read into a separate buffer, so an abandoned read can't write into
+	// p after we returned.
+	buf := make([]byte, len(p))
+	resultChan := make(chan readResult, 1)
+	go func() {
+		n, err := c.r.Read(buf)
+		resultChan <- readResult{n: n, err: err}
+	}()
+
+	select {
+	case result := <-resultChan:
+		copy(p, buf[:result.n])
+		return result.n, result.err
+
+	case <-c.ctx.Done():
+		return 0, c.ctx.Err()
+	}
+}
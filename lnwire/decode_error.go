@@ -0,0 +1,67 @@
+package lnwire
+
+import (
+	"fmt"
+	"io"
+)
+
+// DecodeError is returned when a message field can't be decoded. It records
+// the name of the field along with its byte offset within the serialized
+// message, excluding the message type, which makes it possible to pinpoint
+// the failure in a captured frame.
+type DecodeError struct {
+	// Offset is the byte offset at which the field that failed to decode
+	// starts.
+	Offset int
+
+	// Field is the name of the field that failed to decode.
+	Field string
+
+	// Err is the underlying decoding error.
+	Err error
+}
+
+// Error returns a human readable description of the decode failure.
+//
+// NOTE: This is part of the error interface.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("unable to decode %v at offset %d: %v", e.Field,
+		e.Offset, e.Err)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// offsetReader wraps an io.Reader and keeps track of the number of bytes read
+// from it.
+type offsetReader struct {
+	r      io.Reader
+	offset int
+}
+
+// Read reads from the underlying reader, advancing the offset by the number of
+// bytes read.
+//
+// NOTE: This is part of the io.Reader interface.
+func (o *offsetReader) Read(p []byte) (int, error) {
+	n, err := o.r.Read(p)
+	o.offset += n
+	return n, err
+}
+
+// readField reads a single element from the reader, wrapping any failure in a
+// DecodeError for the named field.
+func (o *offsetReader) readField(field string, element interface{}) error {
+	start := o.offset
+	if err := ReadElement(o, element); err != nil {
+		return &DecodeError{
+			Offset: start,
+			Field:  field,
+			Err:    err,
+		}
+	}
+
+	return nil
+}
@@ -24,6 +24,10 @@ const (
 	// FundingOpen request for a channel that is above their current
 	// soft-limit.
 	ErrChanTooLarge FundingError = 3
+
+	// ErrMaxChannels is returned by a remote peer when the total number
+	// of its channels, pending or open, reached its maximum policy limit.
+	ErrMaxChannels FundingError = 4
 )
 
 // String returns a human readable version of the target FundingError.
@@ -35,6 +39,8 @@ func (e FundingError) String() string {
 		return "Synchronizing blockchain"
 	case ErrChanTooLarge:
 		return "channel too large"
+	case ErrMaxChannels:
+		return "Number of channels exceed maximum"
 	default:
 		return "unknown error"
 	}
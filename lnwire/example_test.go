@@ -0,0 +1,56 @@
+package lnwire_test
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// ExampleNewAcceptChannel shows how to construct an AcceptChannel message.
+// As the amounts are grouped by their unit, passing a milli-satoshi amount
+// where satoshis are expected is caught by the compiler. For instance, the
+// following doesn't compile, since ChannelReserve is a btcutil.Amount:
+//
+//	lnwire.AcceptChannelAmounts{
+//		ChannelReserve: lnwire.MilliSatoshi(5000000),
+//	}
+func ExampleNewAcceptChannel() {
+	_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{1})
+
+	accept, err := lnwire.NewAcceptChannel(lnwire.AcceptChannelParams{
+		Amounts: lnwire.AcceptChannelAmounts{
+			DustLimit:      354,
+			ChannelReserve: 5000,
+		},
+		MSatAmounts: lnwire.AcceptChannelMSatAmounts{
+			MaxValueInFlight: lnwire.NewMSatFromSatoshis(100000),
+			HtlcMinimum:      1000,
+		},
+		MinAcceptDepth:   3,
+		CsvDelay:         144,
+		MaxAcceptedHTLCs: 483,
+		Keys: lnwire.AcceptChannelKeys{
+			FundingKey:           pubKey,
+			RevocationPoint:      pubKey,
+			PaymentPoint:         pubKey,
+			DelayedPaymentPoint:  pubKey,
+			HtlcPoint:            pubKey,
+			FirstCommitmentPoint: pubKey,
+		},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(accept.ChannelReserve, accept.MaxValueInFlight)
+
+	// Leaving out one of the keys is rejected.
+	_, err = lnwire.NewAcceptChannel(lnwire.AcceptChannelParams{})
+	fmt.Println(err)
+
+	// Output:
+	// 0.00005 BTC 100000000 mSAT
+	// accept channel is missing its funding key
+}
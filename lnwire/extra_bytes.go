@@ -2,12 +2,39 @@ package lnwire
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 
 	"github.com/lightningnetwork/lnd/tlv"
 )
 
+// MaxTLVRecords is the maximum number of TLV records we'll parse from the
+// extra data of an OpenChannel or AcceptChannel message. A crafted message
+// packing many tiny records would otherwise cause excessive iterations when
+// parsing it.
+var MaxTLVRecords = 256
+
+// ErrTooManyTLVRecords is returned when the extra data of a message contains
+// more than MaxTLVRecords TLV records.
+var ErrTooManyTLVRecords = errors.New("extra data contains too many tlv " +
+	"records")
+
+// ErrDuplicateTLVType is returned when merging extra data blobs that contain
+// a TLV record of the same type.
+type ErrDuplicateTLVType struct {
+	// Type is the type of the TLV record found in more than one blob.
+	Type tlv.Type
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrDuplicateTLVType) Error() string {
+	return fmt.Sprintf("duplicate tlv record type %d", e.Type)
+}
+
 // ExtraOpaqueData is the set of data that was appended to this message, some
 // of which we may not actually know how to iterate or parse. By holding onto
 // this data, we ensure that we're able to properly validate the set of
@@ -66,6 +93,38 @@ func (e *ExtraOpaqueData) PackRecords(records ...tlv.Record) error {
 	return nil
 }
 
+// checkNumRecords returns ErrTooManyTLVRecords if the raw bytes contain more
+// than maxRecords TLV records. Only the type and length of each record are
+// read, and any malformed record ends the scan, as it will be rejected when
+// the stream is actually parsed.
+func (e *ExtraOpaqueData) checkNumRecords(maxRecords int) error {
+	var (
+		r       = bytes.NewReader(*e)
+		buf     [8]byte
+		records int
+	)
+	for r.Len() > 0 {
+		if _, err := tlv.ReadVarInt(r, &buf); err != nil {
+			return nil
+		}
+		length, err := tlv.ReadVarInt(r, &buf)
+		if err != nil || length > uint64(r.Len()) {
+			return nil
+		}
+
+		records++
+		if records > maxRecords {
+			return ErrTooManyTLVRecords
+		}
+
+		if _, err := r.Seek(int64(length), io.SeekCurrent); err != nil {
+			return nil
+		}
+	}
+
+	return nil
+}
+
 // ExtractRecords attempts to decode any types in the internal raw bytes as if
 // it were a tlv stream. The set of raw parsed types is returned, and any
 // passed records (if found in the stream) will be parsed into the proper
@@ -82,3 +141,65 @@ func (e *ExtraOpaqueData) ExtractRecords(records ...tlv.Record) (
 
 	return tlvStream.DecodeWithParsedTypes(extraBytesReader)
 }
+
+// replaceRecord packs the given record into the extra data, replacing any
+// existing record of the same type. All other records are kept as they are.
+func (e *ExtraOpaqueData) replaceRecord(record tlv.Record) error {
+	typeMap, err := e.ExtractRecords()
+	if err != nil {
+		return err
+	}
+
+	tlvMap := make(map[uint64][]byte, len(typeMap))
+	for typ, value := range typeMap {
+		if typ == record.Type() {
+			continue
+		}
+		tlvMap[uint64(typ)] = value
+	}
+
+	records := append(tlv.MapToRecords(tlvMap), record)
+	tlv.SortRecords(records)
+
+	return e.PackRecords(records...)
+}
+
+// MergeExtraData merges the TLV records of the given extra data blobs into a
+// single blob, with the records sorted by type as required for a canonical
+// stream. Each blob must be a valid TLV stream itself. If a record type is
+// found in more than one blob, an *ErrDuplicateTLVType is returned. A nil
+// blob is returned if none of the blobs contain any records.
+func MergeExtraData(blobs ...ExtraOpaqueData) (ExtraOpaqueData, error) {
+	tlvMap := make(map[uint64][]byte)
+	for _, blob := range blobs {
+		if len(blob) == 0 {
+			continue
+		}
+
+		types, err := blob.ExtractRecords()
+		if err != nil {
+			return nil, err
+		}
+
+		for typ, value := range types {
+			if _, ok := tlvMap[uint64(typ)]; ok {
+				return nil, &ErrDuplicateTLVType{Type: typ}
+			}
+			tlvMap[uint64(typ)] = value
+		}
+	}
+
+	if len(tlvMap) == 0 {
+		return nil, nil
+	}
+
+	records := tlv.MapToRecords(tlvMap)
+	tlv.SortRecords(records)
+
+	var merged ExtraOpaqueData
+	if err := merged.PackRecords(records...); err != nil {
+		return nil, err
+	}
+
+	return merged, nil
+}
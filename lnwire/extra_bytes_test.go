@@ -145,3 +145,74 @@ func TestExtraOpaqueDataPackUnpackRecords(t *testing.T) {
 		t.Fatalf("type2 not found in typeMap")
 	}
 }
+
+// TestMergeExtraData tests that merging disjoint extra data blobs results in a
+// single canonical blob, and that conflicting blobs are rejected.
+func TestMergeExtraData(t *testing.T) {
+	t.Parallel()
+
+	var (
+		type1 tlv.Type = 1
+		type2 tlv.Type = 2
+		type3 tlv.Type = 3
+
+		val1 uint8  = 1
+		val2 uint32 = 2
+		val3 uint16 = 3
+	)
+
+	packRecords := func(records ...tlv.Record) ExtraOpaqueData {
+		var extraData ExtraOpaqueData
+		if err := extraData.PackRecords(records...); err != nil {
+			t.Fatalf("unable to pack records: %v", err)
+		}
+		return extraData
+	}
+
+	blob1 := packRecords(tlv.MakePrimitiveRecord(type3, &val3))
+	blob2 := packRecords(tlv.MakePrimitiveRecord(type1, &val1))
+	blob3 := packRecords(tlv.MakePrimitiveRecord(type2, &val2))
+
+	// Merging the disjoint blobs out of order should result in the same
+	// blob as packing all records at once.
+	merged, err := MergeExtraData(blob1, nil, blob2, blob3)
+	if err != nil {
+		t.Fatalf("unable to merge extra data: %v", err)
+	}
+
+	expected := packRecords(
+		tlv.MakePrimitiveRecord(type1, &val1),
+		tlv.MakePrimitiveRecord(type2, &val2),
+		tlv.MakePrimitiveRecord(type3, &val3),
+	)
+	if !bytes.Equal(merged, expected) {
+		t.Fatalf("wrong merged blob: expected %x, got %x", expected,
+			merged)
+	}
+
+	// Merging only empty blobs should result in an empty blob.
+	merged, err = MergeExtraData(nil, ExtraOpaqueData{})
+	if err != nil {
+		t.Fatalf("unable to merge extra data: %v", err)
+	}
+	if merged != nil {
+		t.Fatalf("expected nil blob, got %x", merged)
+	}
+
+	// Merging blobs that both contain type 1 should fail, reporting the
+	// conflicting type.
+	_, err = MergeExtraData(blob2, blob3, blob2)
+	dupErr, ok := err.(*ErrDuplicateTLVType)
+	if !ok {
+		t.Fatalf("expected ErrDuplicateTLVType, got %v", err)
+	}
+	if dupErr.Type != type1 {
+		t.Fatalf("wrong duplicate type: expected %v, got %v", type1,
+			dupErr.Type)
+	}
+
+	// Finally, a blob that isn't a valid TLV stream should be rejected.
+	if _, err := MergeExtraData(blob1, ExtraOpaqueData{0x01}); err == nil {
+		t.Fatalf("expected invalid blob to be rejected")
+	}
+}
@@ -139,6 +139,41 @@ const (
 	// sender-generated preimages according to BOLT XX.
 	AMPOptional FeatureBit = 31
 
+	// ScidAliasRequired is a required feature bit that signals that the
+	// node requires channels to be referred to by an alias short channel
+	// ID rather than the one of their funding output.
+	ScidAliasRequired FeatureBit = 46
+
+	// ScidAliasOptional is an optional feature bit that signals that the
+	// node supports referring to channels by an alias short channel ID
+	// rather than the one of their funding output.
+	ScidAliasOptional FeatureBit = 47
+
+	// ZeroConfRequired is a required feature bit that signals that the
+	// node requires channels to be usable before their funding
+	// transaction confirmed.
+	ZeroConfRequired FeatureBit = 50
+
+	// ZeroConfOptional is an optional feature bit that signals that the
+	// node supports using channels before their funding transaction
+	// confirmed.
+	//
+	// NOTE: lnd doesn't support zero-conf channels, as it waits for at
+	// least one confirmation of the funding transaction, so it never
+	// advertises this bit and rejects an AcceptChannel with a
+	// MinAcceptDepth of zero.
+	ZeroConfOptional FeatureBit = 51
+
+	// SimpleTaprootChannelsRequiredStaging is a required feature bit that
+	// signals that the node requires channels to use the staging version
+	// of the simple taproot channel commitment format.
+	SimpleTaprootChannelsRequiredStaging FeatureBit = 180
+
+	// SimpleTaprootChannelsOptionalStaging is an optional feature bit that
+	// signals that the node supports channels using the staging version
+	// of the simple taproot channel commitment format.
+	SimpleTaprootChannelsOptionalStaging FeatureBit = 181
+
 	// maxAllowedSize is a maximum allowed size of feature vector.
 	//
 	// NOTE: Within the protocol, the maximum allowed message size is 65535
@@ -184,6 +219,13 @@ var Features = map[FeatureBit]string{
 	WumboChannelsOptional:         "wumbo-channels",
 	AMPRequired:                   "amp",
 	AMPOptional:                   "amp",
+	ScidAliasRequired:             "scid-alias",
+	ScidAliasOptional:             "scid-alias",
+	ZeroConfRequired:              "zero-conf",
+	ZeroConfOptional:              "zero-conf",
+
+	SimpleTaprootChannelsRequiredStaging: "simple-taproot-chans-x",
+	SimpleTaprootChannelsOptionalStaging: "simple-taproot-chans-x",
 }
 
 // RawFeatureVector represents a set of feature bits as defined in BOLT-09.  A
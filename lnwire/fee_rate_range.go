@@ -0,0 +1,161 @@
+package lnwire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+// FeeRateRangeType is the TLV record type for the preferred commitment fee
+// rate range within the name space of the AcceptChannel message. As the
+// record isn't part of the spec, it uses an odd type of the custom range, so
+// that peers not knowing about it will ignore it.
+const FeeRateRangeType tlv.Type = 65545
+
+// ErrInvalidFeeRateRange is returned when a fee rate range has a minimum
+// above its maximum.
+type ErrInvalidFeeRateRange struct {
+	// Range is the invalid range.
+	Range FeeRateRange
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrInvalidFeeRateRange) Error() string {
+	return fmt.Sprintf("invalid fee rate range %v", e.Range)
+}
+
+// ErrFeeRateRangesDisjoint is returned when reconciling two fee rate ranges
+// that don't overlap.
+type ErrFeeRateRangesDisjoint struct {
+	// Local is the range we proposed.
+	Local FeeRateRange
+
+	// Remote is the range preferred by the remote party.
+	Remote FeeRateRange
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrFeeRateRangesDisjoint) Error() string {
+	return fmt.Sprintf("fee rate range %v doesn't overlap with the "+
+		"preferred range %v", e.Local, e.Remote)
+}
+
+// FeeRateRange is a range of commitment fee rates, in sat/kw, that the
+// sender of an AcceptChannel prefers. Both bounds are inclusive.
+type FeeRateRange struct {
+	// Min is the lowest preferred fee rate.
+	Min uint32
+
+	// Max is the highest preferred fee rate.
+	Max uint32
+}
+
+// String returns a human readable representation of the range.
+func (r FeeRateRange) String() string {
+	return fmt.Sprintf("[%d, %d] sat/kw", r.Min, r.Max)
+}
+
+// Validate returns an error if the minimum of the range is above its maximum.
+func (r FeeRateRange) Validate() error {
+	if r.Min > r.Max {
+		return &ErrInvalidFeeRateRange{Range: r}
+	}
+
+	return nil
+}
+
+// Reconcile returns the fee rates both the local range and the remote range
+// contain. An ErrFeeRateRangesDisjoint is returned if there are none.
+func (r FeeRateRange) Reconcile(remote FeeRateRange) (FeeRateRange, error) {
+	reconciled := r
+	if remote.Min > reconciled.Min {
+		reconciled.Min = remote.Min
+	}
+	if remote.Max < reconciled.Max {
+		reconciled.Max = remote.Max
+	}
+
+	if reconciled.Min > reconciled.Max {
+		return FeeRateRange{}, &ErrFeeRateRangesDisjoint{
+			Local:  r,
+			Remote: remote,
+		}
+	}
+
+	return reconciled, nil
+}
+
+// NewRecord returns a TLV record that can be used to encode the fee rate
+// range within the ExtraData TLV stream.
+func (r *FeeRateRange) NewRecord() tlv.Record {
+	return tlv.MakeStaticRecord(
+		FeeRateRangeType, r, 8, feeRateRangeEncoder,
+		feeRateRangeDecoder,
+	)
+}
+
+// feeRateRangeEncoder is a custom TLV encoder for the FeeRateRange record.
+func feeRateRangeEncoder(w io.Writer, val interface{}, buf *[8]byte) error {
+	if v, ok := val.(*FeeRateRange); ok {
+		if err := tlv.EUint32T(w, v.Min, buf); err != nil {
+			return err
+		}
+
+		return tlv.EUint32T(w, v.Max, buf)
+	}
+
+	return tlv.NewTypeForEncodingErr(val, "lnwire.FeeRateRange")
+}
+
+// feeRateRangeDecoder is a custom TLV decoder for the FeeRateRange record.
+func feeRateRangeDecoder(r io.Reader, val interface{}, buf *[8]byte,
+	l uint64) error {
+
+	if v, ok := val.(*FeeRateRange); ok && l == 8 {
+		if err := tlv.DUint32(r, &v.Min, buf, 4); err != nil {
+			return err
+		}
+
+		return tlv.DUint32(r, &v.Max, buf, 4)
+	}
+
+	return tlv.NewTypeForDecodingErr(val, "lnwire.FeeRateRange", l, 8)
+}
+
+// FeeRateRange returns the preferred commitment fee rate range carried in the
+// ExtraData of the message. The boolean is false if the message doesn't carry
+// the record, in which case the sender has no preference. An error is
+// returned if the range carried is invalid.
+func (a *AcceptChannel) FeeRateRange() (FeeRateRange, bool, error) {
+	var feeRange FeeRateRange
+	typeMap, err := a.ExtraData.ExtractRecords(feeRange.NewRecord())
+	if err != nil {
+		return FeeRateRange{}, false, err
+	}
+
+	if _, ok := typeMap[FeeRateRangeType]; !ok {
+		return FeeRateRange{}, false, nil
+	}
+
+	if err := feeRange.Validate(); err != nil {
+		return FeeRateRange{}, false, err
+	}
+
+	return feeRange, true, nil
+}
+
+// SetFeeRateRange stores the given preferred commitment fee rate range in the
+// ExtraData of the message, replacing any existing fee rate range record. All
+// other records are kept as they are.
+func (a *AcceptChannel) SetFeeRateRange(feeRange FeeRateRange) error {
+	if err := feeRange.Validate(); err != nil {
+		return err
+	}
+
+	return a.ExtraData.replaceRecord(feeRange.NewRecord())
+}
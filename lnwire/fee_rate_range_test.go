@@ -0,0 +1,133 @@
+package lnwire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptChannelFeeRateRange asserts that the fee rate range record
+// survives an encode/decode round trip of the AcceptChannel, and that invalid
+// ranges are refused.
+func TestAcceptChannelFeeRateRange(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+
+	// Without the record, the accessor reports that it is absent.
+	_, ok, err := msg.FeeRateRange()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	invalid := FeeRateRange{Min: 2000, Max: 1000}
+	require.Equal(
+		t, &ErrInvalidFeeRateRange{Range: invalid},
+		msg.SetFeeRateRange(invalid),
+	)
+
+	feeRange := FeeRateRange{Min: 253, Max: 5000}
+	require.NoError(t, msg.SetFeeRateRange(FeeRateRange{Min: 1, Max: 2}))
+	require.NoError(t, msg.SetFeeRateRange(feeRange))
+
+	var b bytes.Buffer
+	_, err = WriteMessage(&b, msg, 0)
+	require.NoError(t, err)
+
+	decodedMsg, err := ReadMessage(&b, 0)
+	require.NoError(t, err)
+	decoded := decodedMsg.(*AcceptChannel)
+
+	decodedRange, ok, err := decoded.FeeRateRange()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, feeRange, decodedRange)
+
+	// An invalid range set by the peer is reported by the accessor.
+	require.NoError(t, decoded.ExtraData.replaceRecord(invalid.NewRecord()))
+	_, _, err = decoded.FeeRateRange()
+	require.Equal(t, &ErrInvalidFeeRateRange{Range: invalid}, err)
+}
+
+// TestFeeRateRangeReconcile asserts that reconciling two fee rate ranges
+// yields their overlap, and fails if they are disjoint.
+func TestFeeRateRangeReconcile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		local    FeeRateRange
+		remote   FeeRateRange
+		expRange FeeRateRange
+		expErr   bool
+	}{
+		{
+			name:     "partial overlap",
+			local:    FeeRateRange{Min: 1000, Max: 3000},
+			remote:   FeeRateRange{Min: 2000, Max: 4000},
+			expRange: FeeRateRange{Min: 2000, Max: 3000},
+		},
+		{
+			name:     "remote within local",
+			local:    FeeRateRange{Min: 1000, Max: 5000},
+			remote:   FeeRateRange{Min: 2000, Max: 3000},
+			expRange: FeeRateRange{Min: 2000, Max: 3000},
+		},
+		{
+			name:     "single rate within remote",
+			local:    FeeRateRange{Min: 2500, Max: 2500},
+			remote:   FeeRateRange{Min: 2000, Max: 3000},
+			expRange: FeeRateRange{Min: 2500, Max: 2500},
+		},
+		{
+			name:     "touching bounds",
+			local:    FeeRateRange{Min: 1000, Max: 2000},
+			remote:   FeeRateRange{Min: 2000, Max: 3000},
+			expRange: FeeRateRange{Min: 2000, Max: 2000},
+		},
+		{
+			name:   "remote above local",
+			local:  FeeRateRange{Min: 1000, Max: 1999},
+			remote: FeeRateRange{Min: 2000, Max: 3000},
+			expErr: true,
+		},
+		{
+			name:   "remote below local",
+			local:  FeeRateRange{Min: 3001, Max: 4000},
+			remote: FeeRateRange{Min: 2000, Max: 3000},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			reconciled, err := test.local.Reconcile(test.remote)
+			if test.expErr {
+				require.Equal(t, &ErrFeeRateRangesDisjoint{
+					Local:  test.local,
+					Remote: test.remote,
+				}, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expRange, reconciled)
+		})
+	}
+}
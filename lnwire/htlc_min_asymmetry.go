@@ -0,0 +1,84 @@
+package lnwire
+
+import (
+	"fmt"
+)
+
+// HtlcMinPolicy bounds how far apart the HtlcMinimum we require from the
+// remote party and the one it requires from us may lie. As each party chooses
+// the minimum of the HTLCs offered to it, payments routed over the channel in
+// one direction may need to be much larger than in the other.
+type HtlcMinPolicy struct {
+	// MaxRatio is the largest factor by which either minimum may exceed
+	// the other.
+	MaxRatio float64
+
+	// Tolerance is the amount by which either minimum may always exceed
+	// the other, regardless of MaxRatio. It prevents small minimums, such
+	// as ones of a single satoshi, from failing the ratio.
+	Tolerance MilliSatoshi
+}
+
+// ErrHtlcMinAsymmetry is returned when the HtlcMinimum of one party exceeds
+// the one of the other by more than the HtlcMinPolicy allows.
+type ErrHtlcMinAsymmetry struct {
+	// LocalHtlcMin is the minimum of the HTLCs offered to us.
+	LocalHtlcMin MilliSatoshi
+
+	// RemoteHtlcMin is the minimum of the HTLCs we offer.
+	RemoteHtlcMin MilliSatoshi
+
+	// MaxHtlcMin is the largest minimum the policy allows given the
+	// smaller of both.
+	MaxHtlcMin MilliSatoshi
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrHtlcMinAsymmetry) Error() string {
+	return fmt.Sprintf("local htlc minimum of %v and remote htlc minimum "+
+		"of %v are further apart than the maximum of %v allows",
+		e.LocalHtlcMin, e.RemoteHtlcMin, e.MaxHtlcMin)
+}
+
+// EffectiveHtlcMin returns the smallest HTLC that can be routed over a channel
+// in both directions, given the HtlcMinimum we require from the remote party
+// and the one it requires from us, along with the difference between both
+// minimums.
+func EffectiveHtlcMin(local, remote MilliSatoshi) (MilliSatoshi,
+	MilliSatoshi) {
+
+	if local > remote {
+		return local, local - remote
+	}
+
+	return remote, remote - local
+}
+
+// ValidateHtlcMinAsymmetry ensures that neither of the given HtlcMinimums
+// exceeds the other by more than the given policy allows, returning an
+// *ErrHtlcMinAsymmetry otherwise. The larger minimum may always be as large as
+// the smaller one plus the Tolerance of the policy, and at most MaxRatio times
+// the smaller one beyond that.
+func ValidateHtlcMinAsymmetry(local, remote MilliSatoshi,
+	policy HtlcMinPolicy) error {
+
+	larger, asymmetry := EffectiveHtlcMin(local, remote)
+	smaller := larger - asymmetry
+
+	maxHtlcMin := MilliSatoshi(float64(smaller) * policy.MaxRatio)
+	if maxHtlcMin < smaller+policy.Tolerance {
+		maxHtlcMin = smaller + policy.Tolerance
+	}
+
+	if larger > maxHtlcMin {
+		return &ErrHtlcMinAsymmetry{
+			LocalHtlcMin:  local,
+			RemoteHtlcMin: remote,
+			MaxHtlcMin:    maxHtlcMin,
+		}
+	}
+
+	return nil
+}
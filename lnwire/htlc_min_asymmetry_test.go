@@ -0,0 +1,128 @@
+package lnwire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEffectiveHtlcMin asserts that the effective minimum is the larger of
+// both minimums, independent of which side requires it.
+func TestEffectiveHtlcMin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		local             MilliSatoshi
+		remote            MilliSatoshi
+		expectedMin       MilliSatoshi
+		expectedAsymmetry MilliSatoshi
+	}{
+		{
+			name:        "symmetric",
+			local:       1000,
+			remote:      1000,
+			expectedMin: 1000,
+		},
+		{
+			name:              "local larger",
+			local:             5000,
+			remote:            1000,
+			expectedMin:       5000,
+			expectedAsymmetry: 4000,
+		},
+		{
+			name:              "remote larger",
+			local:             1000,
+			remote:            5000,
+			expectedMin:       5000,
+			expectedAsymmetry: 4000,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			min, asymmetry := EffectiveHtlcMin(test.local, test.remote)
+			require.Equal(t, test.expectedMin, min)
+			require.Equal(t, test.expectedAsymmetry, asymmetry)
+		})
+	}
+}
+
+// TestValidateHtlcMinAsymmetry asserts that HTLC minimums are only accepted if
+// neither exceeds the other by more than the policy allows.
+func TestValidateHtlcMinAsymmetry(t *testing.T) {
+	t.Parallel()
+
+	policy := HtlcMinPolicy{
+		MaxRatio:  10,
+		Tolerance: 1000,
+	}
+
+	tests := []struct {
+		name      string
+		local     MilliSatoshi
+		remote    MilliSatoshi
+		maxMin    MilliSatoshi
+		expectErr bool
+	}{
+		{
+			name:   "symmetric",
+			local:  1000,
+			remote: 1000,
+		},
+		{
+			name:   "at max ratio",
+			local:  10000,
+			remote: 100000,
+		},
+		{
+			name:      "remote above max ratio",
+			local:     10000,
+			remote:    100001,
+			maxMin:    100000,
+			expectErr: true,
+		},
+		{
+			name:      "local above max ratio",
+			local:     100001,
+			remote:    10000,
+			maxMin:    100000,
+			expectErr: true,
+		},
+		{
+			name:   "within tolerance",
+			local:  0,
+			remote: 1000,
+		},
+		{
+			name:      "above tolerance",
+			local:     0,
+			remote:    1001,
+			maxMin:    1000,
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateHtlcMinAsymmetry(
+				test.local, test.remote, policy,
+			)
+			if !test.expectErr {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Equal(t, &ErrHtlcMinAsymmetry{
+				LocalHtlcMin:  test.local,
+				RemoteHtlcMin: test.remote,
+				MaxHtlcMin:    test.maxMin,
+			}, err)
+		})
+	}
+}
@@ -478,19 +478,19 @@ func ReadElement(r io.Reader, element interface{}) error {
 		*e = alias
 	case *ShortChanIDEncoding:
 		var b [1]uint8
-		if _, err := r.Read(b[:]); err != nil {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
 			return err
 		}
 		*e = ShortChanIDEncoding(b[0])
 	case *uint8:
 		var b [1]uint8
-		if _, err := r.Read(b[:]); err != nil {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
 			return err
 		}
 		*e = b[0]
 	case *FundingFlag:
 		var b [1]uint8
-		if _, err := r.Read(b[:]); err != nil {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
 			return err
 		}
 		*e = FundingFlag(b[0])
@@ -502,13 +502,13 @@ func ReadElement(r io.Reader, element interface{}) error {
 		*e = binary.BigEndian.Uint16(b[:])
 	case *ChanUpdateMsgFlags:
 		var b [1]uint8
-		if _, err := r.Read(b[:]); err != nil {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
 			return err
 		}
 		*e = ChanUpdateMsgFlags(b[0])
 	case *ChanUpdateChanFlags:
 		var b [1]uint8
-		if _, err := r.Read(b[:]); err != nil {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
 			return err
 		}
 		*e = ChanUpdateChanFlags(b[0])
@@ -542,7 +542,7 @@ func ReadElement(r io.Reader, element interface{}) error {
 			return err
 		}
 
-		pubKey, err := btcec.ParsePubKey(b[:], btcec.S256())
+		pubKey, err := parsePubKey(b[:])
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,49 @@
+package lnwire
+
+import (
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+// MaxHtlcExpiryDeltaType is the TLV record type for the maximum HTLC expiry
+// delta within the name space of the AcceptChannel message. As the record
+// isn't part of the spec, it uses an odd type of the custom range, so that
+// peers not knowing about it will ignore it.
+const MaxHtlcExpiryDeltaType tlv.Type = 65541
+
+// MaxHtlcExpiryDelta is the maximum number of blocks the expiry of an HTLC
+// offered to the sender of an AcceptChannel may lie beyond the current block
+// height. It bounds the time the funds of a force closed channel can remain
+// locked in HTLC outputs.
+type MaxHtlcExpiryDelta uint32
+
+// NewRecord returns a TLV record that can be used to encode the maximum HTLC
+// expiry delta within the ExtraData TLV stream.
+func (m *MaxHtlcExpiryDelta) NewRecord() tlv.Record {
+	return tlv.MakePrimitiveRecord(MaxHtlcExpiryDeltaType, (*uint32)(m))
+}
+
+// MaxHtlcExpiryDelta returns the maximum HTLC expiry delta carried in the
+// ExtraData of the message. The boolean is false if the message doesn't carry
+// the record, in which case the expiry of offered HTLCs isn't bound by the
+// channel.
+func (a *AcceptChannel) MaxHtlcExpiryDelta() (uint32, bool, error) {
+	var delta MaxHtlcExpiryDelta
+	typeMap, err := a.ExtraData.ExtractRecords(delta.NewRecord())
+	if err != nil {
+		return 0, false, err
+	}
+
+	if _, ok := typeMap[MaxHtlcExpiryDeltaType]; !ok {
+		return 0, false, nil
+	}
+
+	return uint32(delta), true, nil
+}
+
+// SetMaxHtlcExpiryDelta stores the given maximum HTLC expiry delta in the
+// ExtraData of the message, replacing any existing maximum HTLC expiry delta
+// record. All other records are kept as they are.
+func (a *AcceptChannel) SetMaxHtlcExpiryDelta(delta uint32) error {
+	maxDelta := MaxHtlcExpiryDelta(delta)
+	return a.ExtraData.replaceRecord(maxDelta.NewRecord())
+}
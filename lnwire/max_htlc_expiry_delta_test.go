@@ -0,0 +1,65 @@
+package lnwire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptChannelMaxHtlcExpiryDelta asserts that the maximum HTLC expiry
+// delta record survives an encode/decode round trip of the AcceptChannel, and
+// that setting it keeps the other records of the ExtraData.
+func TestAcceptChannelMaxHtlcExpiryDelta(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+
+	// Without the record, the accessor reports that it is absent.
+	_, ok, err := msg.MaxHtlcExpiryDelta()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Pack an unrelated record, and set the delta twice to assert that
+	// the record is replaced rather than duplicated.
+	other := []byte("other")
+	require.NoError(t, msg.ExtraData.PackRecords(
+		tlv.MakePrimitiveRecord(3, &other),
+	))
+	require.NoError(t, msg.SetMaxHtlcExpiryDelta(1000))
+	require.NoError(t, msg.SetMaxHtlcExpiryDelta(2016))
+
+	var b bytes.Buffer
+	_, err = WriteMessage(&b, msg, 0)
+	require.NoError(t, err)
+
+	decodedMsg, err := ReadMessage(&b, 0)
+	require.NoError(t, err)
+	decoded := decodedMsg.(*AcceptChannel)
+
+	delta, ok, err := decoded.MaxHtlcExpiryDelta()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, 2016, delta)
+
+	var decodedOther []byte
+	typeMap, err := decoded.ExtraData.ExtractRecords(
+		tlv.MakePrimitiveRecord(3, &decodedOther),
+	)
+	require.NoError(t, err)
+	require.Len(t, typeMap, 2)
+	require.Equal(t, other, decodedOther)
+}
@@ -7,6 +7,7 @@ package lnwire
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -287,6 +288,36 @@ func WriteMessage(buf *bytes.Buffer, msg Message, pver uint32) (int, error) {
 // ReadMessage reads, validates, and parses the next Lightning message from r
 // for the provided protocol version.
 func ReadMessage(r io.Reader, pver uint32) (Message, error) {
+	return readMessage(r, func(msg Message) error {
+		return msg.Decode(r, pver)
+	})
+}
+
+// ctxDecoder is implemented by the messages that can be decoded with a
+// context, see AcceptChannel.DecodeCtx.
+type ctxDecoder interface {
+	DecodeCtx(ctx context.Context, r io.Reader, pver uint32) error
+}
+
+// ReadMessageCtx is like ReadMessage, but decodes the messages implementing
+// DecodeCtx with the passed context, such that the options attached to it,
+// like the quirks of WithShutdownScriptQuirks, apply to them.
+func ReadMessageCtx(ctx context.Context, r io.Reader, pver uint32) (Message,
+	error) {
+
+	return readMessage(r, func(msg Message) error {
+		if decoder, ok := msg.(ctxDecoder); ok {
+			return decoder.DecodeCtx(ctx, r, pver)
+		}
+
+		return msg.Decode(r, pver)
+	})
+}
+
+// readMessage reads the type of the next Lightning message from r, and
+// decodes the message of that type using the passed decode function.
+func readMessage(r io.Reader, decode func(Message) error) (Message, error) {
+
 	// First, we'll read out the first two bytes of the message so we can
 	// create the proper empty message.
 	var mType [2]byte
@@ -302,9 +333,33 @@ func ReadMessage(r io.Reader, pver uint32) (Message, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := msg.Decode(r, pver); err != nil {
+	if err := decode(msg); err != nil {
 		return nil, err
 	}
 
 	return msg, nil
 }
+
+// RoundTrip encodes the passed message using the given protocol version, and
+// decodes the result into a fresh message of the same type. This makes it easy
+// to assert that a message survives serialization unchanged. An error is
+// returned if the message can't be encoded or decoded, or if decoding didn't
+// consume the entire serialization.
+func RoundTrip(msg Message, pver uint32) (Message, error) {
+	var b bytes.Buffer
+	if _, err := WriteMessage(&b, msg, pver); err != nil {
+		return nil, err
+	}
+
+	decoded, err := ReadMessage(&b, pver)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Len() != 0 {
+		return nil, fmt.Errorf("%v left %d bytes unread after "+
+			"decoding", msg.MsgType(), b.Len())
+	}
+
+	return decoded, nil
+}
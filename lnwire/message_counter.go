@@ -0,0 +1,56 @@
+package lnwire
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// CountMessageTypes tallies the types of the messages of a stream of framed
+// messages, framed as expected by NewAcceptChannelIterator. Only the length
+// prefix and the type of each message are read, while its payload is skipped
+// without being decoded, making this much faster than decoding the stream.
+// An error is returned if the stream doesn't end at a frame boundary.
+func CountMessageTypes(r io.Reader) (map[MessageType]int, error) {
+	counts := make(map[MessageType]int)
+
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(r, header[:2]); err != nil {
+			// The stream ended cleanly at a frame boundary.
+			if err == io.EOF {
+				return counts, nil
+			}
+			return nil, err
+		}
+
+		frameLen := binary.BigEndian.Uint16(header[:2])
+		if frameLen < 2 {
+			return nil, fmt.Errorf("frame of %d bytes too short "+
+				"for message type", frameLen)
+		}
+
+		if _, err := io.ReadFull(r, header[2:]); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		msgType := MessageType(binary.BigEndian.Uint16(header[2:]))
+
+		payloadLen := int64(frameLen) - 2
+		_, err := io.CopyN(ioutil.Discard, r, payloadLen)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		counts[msgType]++
+	}
+}
+
+// unexpectedEOF converts an io.EOF encountered within a frame into an
+// io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package lnwire
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCountMessageTypes asserts that the message types of a stream of framed
+// messages are tallied correctly, and that a truncated stream is reported.
+func TestCountMessageTypes(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	accept := &AcceptChannel{
+		DustLimit:             573,
+		CsvDelay:              144,
+		FundingKey:            pk,
+		RevocationPoint:       pk,
+		PaymentPoint:          pk,
+		DelayedPaymentPoint:   pk,
+		HtlcPoint:             pk,
+		FirstCommitmentPoint:  pk,
+		UpfrontShutdownScript: []byte{},
+	}
+
+	var stream bytes.Buffer
+	for i := 0; i < 3; i++ {
+		writeTestFrame(t, &stream, accept)
+	}
+	for i := 0; i < 5; i++ {
+		writeTestFrame(t, &stream, NewPing(uint16(i)))
+		writeTestFrame(t, &stream, NewPong(make([]byte, i)))
+	}
+	writeTestFrame(t, &stream, &Error{Data: []byte("error")})
+
+	counts, err := CountMessageTypes(bytes.NewReader(stream.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, map[MessageType]int{
+		MsgAcceptChannel: 3,
+		MsgPing:          5,
+		MsgPong:          5,
+		MsgError:         1,
+	}, counts)
+
+	// An empty stream has no messages.
+	counts, err = CountMessageTypes(bytes.NewReader(nil))
+	require.NoError(t, err)
+	require.Empty(t, counts)
+
+	// A stream truncated within a frame is reported, whether the
+	// truncation is within the type or the payload.
+	for _, n := range []int{stream.Len() - 1, 3} {
+		truncated := bytes.NewReader(stream.Bytes()[:n])
+		_, err = CountMessageTypes(truncated)
+		require.Equal(t, io.ErrUnexpectedEOF, err)
+	}
+}
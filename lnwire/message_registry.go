@@ -0,0 +1,74 @@
+package lnwire
+
+// FieldDescriptor describes a single field of a wire message, allowing tooling
+// to render messages generically without knowing their concrete type.
+type FieldDescriptor struct {
+	// Name is the name of the field within the message struct.
+	Name string
+
+	// Type is the Go type of the field, formatted as by reflect.
+	Type string
+
+	// Size is the serialized size of the field in bytes. It is zero for
+	// fields of variable size.
+	Size int
+
+	// TLV is true if the field is carried within the TLV stream of the
+	// message rather than as a fixed field.
+	TLV bool
+}
+
+// messageFields maps the types of the messages we have descriptors for to the
+// descriptors of their fields, in wire order.
+var messageFields = map[MessageType][]FieldDescriptor{
+	MsgAcceptChannel: {
+		{Name: "PendingChannelID", Type: "[32]uint8", Size: 32},
+		{Name: "DustLimit", Type: "btcutil.Amount", Size: 8},
+		{
+			Name: "MaxValueInFlight",
+			Type: "lnwire.MilliSatoshi",
+			Size: 8,
+		},
+		{Name: "ChannelReserve", Type: "btcutil.Amount", Size: 8},
+		{Name: "HtlcMinimum", Type: "lnwire.MilliSatoshi", Size: 8},
+		{Name: "MinAcceptDepth", Type: "uint32", Size: 4},
+		{Name: "CsvDelay", Type: "uint16", Size: 2},
+		{Name: "MaxAcceptedHTLCs", Type: "uint16", Size: 2},
+		{Name: "FundingKey", Type: "*btcec.PublicKey", Size: 33},
+		{Name: "RevocationPoint", Type: "*btcec.PublicKey", Size: 33},
+		{Name: "PaymentPoint", Type: "*btcec.PublicKey", Size: 33},
+		{
+			Name: "DelayedPaymentPoint",
+			Type: "*btcec.PublicKey",
+			Size: 33,
+		},
+		{Name: "HtlcPoint", Type: "*btcec.PublicKey", Size: 33},
+		{
+			Name: "FirstCommitmentPoint",
+			Type: "*btcec.PublicKey",
+			Size: 33,
+		},
+		{
+			Name: "UpfrontShutdownScript",
+			Type: "lnwire.DeliveryAddress",
+			TLV:  true,
+		},
+		{Name: "ExtraData", Type: "lnwire.ExtraOpaqueData", TLV: true},
+	},
+}
+
+// MessageFields returns the descriptors of the fields of the message with the
+// given type, in wire order. The second return value is false if there are no
+// descriptors for the message type. The returned slice is a copy, so the
+// registry can't be modified through it.
+func MessageFields(msgType MessageType) ([]FieldDescriptor, bool) {
+	fields, ok := messageFields[msgType]
+	if !ok {
+		return nil, false
+	}
+
+	fieldsCopy := make([]FieldDescriptor, len(fields))
+	copy(fieldsCopy, fields)
+
+	return fieldsCopy, true
+}
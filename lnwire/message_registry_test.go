@@ -0,0 +1,57 @@
+package lnwire
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMessageFieldsAcceptChannel asserts that the field descriptors of the
+// AcceptChannel message match the fields of its struct, and that the sizes of
+// the fixed fields add up to the minimum payload length.
+func TestMessageFieldsAcceptChannel(t *testing.T) {
+	t.Parallel()
+
+	fields, ok := MessageFields(MsgAcceptChannel)
+	if !ok {
+		t.Fatalf("no descriptors for AcceptChannel")
+	}
+
+	structType := reflect.TypeOf(AcceptChannel{})
+	if len(fields) != structType.NumField() {
+		t.Fatalf("expected %d descriptors, got %d",
+			structType.NumField(), len(fields))
+	}
+
+	var fixedSize int
+	for i, field := range fields {
+		structField := structType.Field(i)
+		if field.Name != structField.Name {
+			t.Fatalf("descriptor %d: expected name %v, got %v", i,
+				structField.Name, field.Name)
+		}
+		if field.Type != structField.Type.String() {
+			t.Fatalf("descriptor %v: expected type %v, got %v",
+				field.Name, structField.Type, field.Type)
+		}
+
+		if !field.TLV {
+			fixedSize += field.Size
+		}
+	}
+
+	if fixedSize != acceptChannelMinPayloadLength {
+		t.Fatalf("expected fixed size of %d, got %d",
+			acceptChannelMinPayloadLength, fixedSize)
+	}
+
+	// Modifying the returned descriptors must not affect the registry.
+	fields[0].Name = "modified"
+	fields, _ = MessageFields(MsgAcceptChannel)
+	if fields[0].Name != "PendingChannelID" {
+		t.Fatalf("registry modified through returned descriptors")
+	}
+
+	if _, ok := MessageFields(MsgPing); ok {
+		t.Fatalf("unexpected descriptors for Ping")
+	}
+}
@@ -2,6 +2,7 @@ package lnwire
 
 import (
 	"bytes"
+	"context"
 	"io"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -74,8 +75,11 @@ type OpenChannel struct {
 	// package. Currently this will cause an import cycle.
 	FeePerKiloWeight uint32
 
-	// CsvDelay is the number of blocks to use for the relative time lock
-	// in the pay-to-self output of both commitment transactions.
+	// CsvDelay is the number of blocks the sender of this message, the
+	// initiator, requires the to_self output of the responder to be
+	// delayed by in the commitment transactions of the responder. It
+	// doesn't apply to the to_self output of the initiator, whose delay
+	// is the CsvDelay of the AcceptChannel.
 	CsvDelay uint16
 
 	// MaxAcceptedHTLCs is the total number of incoming HTLC's that the
@@ -243,6 +247,28 @@ func (o *OpenChannel) Encode(w *bytes.Buffer, pver uint32) error {
 //
 // This is part of the lnwire.Message interface.
 func (o *OpenChannel) Decode(r io.Reader, pver uint32) error {
+	return o.decode(r, pver, nil)
+}
+
+// DecodeCtx is like Decode, but aborts reading from r once the passed context
+// is cancelled, in which case the returned error wraps the error of the
+// context. Any shutdown script quirks attached to the context with
+// WithShutdownScriptQuirks are used to recover a mis-encoded upfront shutdown
+// script.
+func (o *OpenChannel) DecodeCtx(ctx context.Context, r io.Reader,
+	pver uint32) error {
+
+	return o.decode(
+		&contextReader{ctx: ctx, r: r}, pver,
+		shutdownScriptQuirksFromContext(ctx),
+	)
+}
+
+// decode deserializes the OpenChannel, using the given quirks to recover a
+// mis-encoded upfront shutdown script.
+func (o *OpenChannel) decode(r io.Reader, pver uint32,
+	quirks []ShutdownScriptQuirk) error {
+
 	// Read all the mandatory fields in the open message.
 	err := ReadElements(r,
 		o.ChainHash[:],
@@ -277,7 +303,7 @@ func (o *OpenChannel) Decode(r io.Reader, pver uint32) error {
 	}
 
 	o.UpfrontShutdownScript, o.ExtraData, err = parseShutdownScript(
-		tlvRecords,
+		tlvRecords, quirks,
 	)
 	if err != nil {
 		return err
@@ -0,0 +1,71 @@
+package lnwire
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidUTF8 is returned by ValidatePrintable if the data isn't valid
+// UTF-8.
+type ErrInvalidUTF8 struct {
+	// Offset is the offset of the first byte that isn't part of a valid
+	// UTF-8 sequence.
+	Offset int
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrInvalidUTF8) Error() string {
+	return fmt.Sprintf("invalid utf-8 sequence at offset %v", e.Offset)
+}
+
+// ErrNonPrintable is returned by ValidatePrintable if the data contains a
+// character that isn't printable.
+type ErrNonPrintable struct {
+	// Offset is the offset of the first byte of the character.
+	Offset int
+
+	// Char is the non-printable character.
+	Char rune
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrNonPrintable) Error() string {
+	return fmt.Sprintf("non-printable character %U at offset %v", e.Char,
+		e.Offset)
+}
+
+// ValidatePrintable ensures that the given data, such as the value of a TLV
+// record from the ExtraData of a message that carries text, is valid UTF-8
+// consisting solely of printable characters, so that it can be displayed or
+// logged verbatim. Printable characters are the ones unicode.IsPrint accepts,
+// which includes the ASCII space, but no other white space or control
+// characters such as tabs and newlines. An *ErrInvalidUTF8 or
+// *ErrNonPrintable is returned for the first offending byte sequence. Empty
+// data is valid.
+func ValidatePrintable(b []byte) error {
+	for offset := 0; offset < len(b); {
+		char, size := utf8.DecodeRune(b[offset:])
+
+		// A RuneError of a single byte signals an invalid encoding,
+		// while an encoded U+FFFD has a size of three bytes.
+		if char == utf8.RuneError && size == 1 {
+			return &ErrInvalidUTF8{Offset: offset}
+		}
+
+		if !unicode.IsPrint(char) {
+			return &ErrNonPrintable{
+				Offset: offset,
+				Char:   char,
+			}
+		}
+
+		offset += size
+	}
+
+	return nil
+}
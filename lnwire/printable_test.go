@@ -0,0 +1,95 @@
+package lnwire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidatePrintable tests that only valid UTF-8 consisting of printable
+// characters is accepted, and that the first offending sequence is reported.
+func TestValidatePrintable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expectedErr error
+	}{
+		{
+			name: "empty",
+			data: nil,
+		},
+		{
+			name: "ascii",
+			data: []byte("channel to bob, 1 BTC ~ ok!"),
+		},
+		{
+			name: "multi-byte",
+			data: []byte("zahlung für café ⚡ 🚀"),
+		},
+		{
+			name: "encoded replacement character",
+			data: []byte{0xef, 0xbf, 0xbd},
+		},
+		{
+			name:        "invalid byte",
+			data:        []byte{'o', 'k', 0xff},
+			expectedErr: &ErrInvalidUTF8{Offset: 2},
+		},
+		{
+			name:        "truncated sequence",
+			data:        []byte{'a', 0xe2, 0x9a},
+			expectedErr: &ErrInvalidUTF8{Offset: 1},
+		},
+		{
+			name:        "overlong encoding",
+			data:        []byte{0xc0, 0xaf},
+			expectedErr: &ErrInvalidUTF8{Offset: 0},
+		},
+		{
+			name:        "surrogate half",
+			data:        []byte{0xed, 0xa0, 0x80},
+			expectedErr: &ErrInvalidUTF8{Offset: 0},
+		},
+		{
+			name:        "null byte",
+			data:        []byte{'a', 0x00, 'b'},
+			expectedErr: &ErrNonPrintable{Offset: 1, Char: 0x00},
+		},
+		{
+			name:        "newline",
+			data:        []byte("line\nbreak"),
+			expectedErr: &ErrNonPrintable{Offset: 4, Char: '\n'},
+		},
+		{
+			name:        "tab",
+			data:        []byte("a\tb"),
+			expectedErr: &ErrNonPrintable{Offset: 1, Char: '\t'},
+		},
+		{
+			name:        "delete",
+			data:        []byte{'a', 0x7f},
+			expectedErr: &ErrNonPrintable{Offset: 1, Char: 0x7f},
+		},
+		{
+			name:        "zero width space after multi-byte",
+			data:        []byte("f\u00fcr\u200b"),
+			expectedErr: &ErrNonPrintable{Offset: 4, Char: 0x200b},
+		},
+		{
+			name:        "right-to-left override",
+			data:        []byte("\u202etxt.exe"),
+			expectedErr: &ErrNonPrintable{Offset: 0, Char: 0x202e},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidatePrintable(test.data)
+			require.Equal(t, test.expectedErr, err)
+		})
+	}
+}
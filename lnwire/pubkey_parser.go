@@ -0,0 +1,55 @@
+package lnwire
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// PubKeyParser is a function that parses a serialized public key read from
+// the wire.
+type PubKeyParser func(pubKeyStr []byte) (*btcec.PublicKey, error)
+
+// CurvePubKeyParser returns a PubKeyParser that parses public keys on the
+// given curve.
+func CurvePubKeyParser(curve *btcec.KoblitzCurve) PubKeyParser {
+	return func(pubKeyStr []byte) (*btcec.PublicKey, error) {
+		return btcec.ParsePubKey(pubKeyStr, curve)
+	}
+}
+
+var (
+	// pubKeyParser is the parser used to decode the public keys read from
+	// the wire. It defaults to parsing keys on the secp256k1 curve.
+	pubKeyParser = CurvePubKeyParser(btcec.S256())
+
+	// pubKeyParserMtx guards pubKeyParser.
+	pubKeyParserMtx sync.RWMutex
+)
+
+// SetPubKeyParser replaces the parser used to decode the public keys read
+// from the wire, and returns the previous one so it can be restored. This
+// allows tests and experimental networks to decode keys with different curve
+// parameters, or to inject a mock parser.
+//
+// NOTE: The parser is global to the package, so it should be set before any
+// messages are decoded.
+func SetPubKeyParser(parser PubKeyParser) PubKeyParser {
+	pubKeyParserMtx.Lock()
+	defer pubKeyParserMtx.Unlock()
+
+	prev := pubKeyParser
+	pubKeyParser = parser
+
+	return prev
+}
+
+// parsePubKey parses the serialized public key using the currently set
+// PubKeyParser.
+func parsePubKey(pubKeyStr []byte) (*btcec.PublicKey, error) {
+	pubKeyParserMtx.RLock()
+	parser := pubKeyParser
+	pubKeyParserMtx.RUnlock()
+
+	return parser(pubKeyStr)
+}
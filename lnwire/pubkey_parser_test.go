@@ -0,0 +1,80 @@
+package lnwire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSetPubKeyParser asserts that the public keys of an AcceptChannel are
+// decoded using the parser set through SetPubKeyParser, both when decoding
+// the message and when reading it through an AcceptChannelView.
+//
+// NOTE: This test must not be run in parallel, as the stub parser would
+// otherwise leak into other tests decoding public keys.
+func TestSetPubKeyParser(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	stubPriv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	stubKey := stubPriv.PubKey()
+
+	msg := &AcceptChannel{
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, msg.Encode(&b, 0))
+
+	// Replace the funding key with bytes that aren't a valid point on the
+	// secp256k1 curve, so the message can't be decoded by default.
+	invalidKey := bytes.Repeat([]byte{0xff}, btcec.PubKeyBytesLenCompressed)
+	encoded := b.Bytes()
+	copy(encoded[acceptFundingKeyOffset:], invalidKey)
+
+	var decoded AcceptChannel
+	require.Error(t, decoded.Decode(bytes.NewReader(encoded), 0))
+
+	// With a stub parser that maps the invalid key to the stub key, the
+	// message decodes, and the other keys are passed through the parser
+	// as well.
+	var parsed [][]byte
+	prev := SetPubKeyParser(func(pubKeyStr []byte) (*btcec.PublicKey,
+		error) {
+
+		parsed = append(parsed, append([]byte(nil), pubKeyStr...))
+		if bytes.Equal(pubKeyStr, invalidKey) {
+			return stubKey, nil
+		}
+
+		return btcec.ParsePubKey(pubKeyStr, btcec.S256())
+	})
+	t.Cleanup(func() {
+		SetPubKeyParser(prev)
+	})
+
+	require.NoError(t, decoded.Decode(bytes.NewReader(encoded), 0))
+	require.True(t, decoded.FundingKey.IsEqual(stubKey))
+	require.True(t, decoded.RevocationPoint.IsEqual(pk))
+	require.Len(t, parsed, 6)
+	require.Equal(t, invalidKey, parsed[0])
+
+	view, err := NewAcceptChannelView(encoded)
+	require.NoError(t, err)
+	fundingKey, err := view.FundingKey()
+	require.NoError(t, err)
+	require.True(t, fundingKey.IsEqual(stubKey))
+
+	// Restoring the previous parser rejects the invalid key again.
+	SetPubKeyParser(prev)
+	require.Error(t, decoded.Decode(bytes.NewReader(encoded), 0))
+}
@@ -0,0 +1,73 @@
+package lnwire
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// ReservePolicy bounds how much larger the channel reserve we're required to
+// keep may be than the one we require from the remote party. As each party
+// chooses the reserve of the other, a peer could otherwise lock up a much
+// larger part of our balance than we do of theirs.
+type ReservePolicy struct {
+	// MaxRatio is the largest factor by which our reserve may exceed the
+	// reserve of the remote party.
+	MaxRatio float64
+
+	// Tolerance is the amount by which our reserve may always exceed the
+	// reserve of the remote party, regardless of MaxRatio. It prevents
+	// small reserves, such as ones at the dust limit, from failing the
+	// ratio.
+	Tolerance btcutil.Amount
+}
+
+// ErrReserveAsymmetry is returned when the channel reserve we're required to
+// keep exceeds the reserve of the remote party by more than our ReservePolicy
+// allows.
+type ErrReserveAsymmetry struct {
+	// LocalReserve is the reserve we're required to keep.
+	LocalReserve btcutil.Amount
+
+	// RemoteReserve is the reserve the remote party is required to keep.
+	RemoteReserve btcutil.Amount
+
+	// MaxLocalReserve is the largest local reserve the policy allows.
+	MaxLocalReserve btcutil.Amount
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrReserveAsymmetry) Error() string {
+	return fmt.Sprintf("local reserve of %v exceeds the maximum of %v "+
+		"for a remote reserve of %v", e.LocalReserve,
+		e.MaxLocalReserve, e.RemoteReserve)
+}
+
+// ValidateReserveAsymmetry ensures that the reserve we're required to keep
+// doesn't exceed the reserve the remote party is required to keep by more
+// than the given policy allows, returning an *ErrReserveAsymmetry otherwise.
+// The local reserve may always be as large as the remote reserve plus the
+// Tolerance of the policy, and at most MaxRatio times the remote reserve
+// beyond that.
+func ValidateReserveAsymmetry(localReserve, remoteReserve btcutil.Amount,
+	policy ReservePolicy) error {
+
+	maxLocalReserve := btcutil.Amount(
+		float64(remoteReserve) * policy.MaxRatio,
+	)
+	if maxLocalReserve < remoteReserve+policy.Tolerance {
+		maxLocalReserve = remoteReserve + policy.Tolerance
+	}
+
+	if localReserve > maxLocalReserve {
+		return &ErrReserveAsymmetry{
+			LocalReserve:    localReserve,
+			RemoteReserve:   remoteReserve,
+			MaxLocalReserve: maxLocalReserve,
+		}
+	}
+
+	return nil
+}
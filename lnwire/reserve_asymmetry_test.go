@@ -0,0 +1,99 @@
+package lnwire
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateReserveAsymmetry asserts that a local reserve is only accepted
+// if it doesn't exceed the remote reserve by more than the policy allows.
+func TestValidateReserveAsymmetry(t *testing.T) {
+	t.Parallel()
+
+	policy := ReservePolicy{
+		MaxRatio:  2,
+		Tolerance: 1000,
+	}
+
+	tests := []struct {
+		name          string
+		localReserve  btcutil.Amount
+		remoteReserve btcutil.Amount
+		policy        ReservePolicy
+		maxLocal      btcutil.Amount
+		expectErr     bool
+	}{
+		{
+			name:          "symmetric",
+			localReserve:  10000,
+			remoteReserve: 10000,
+			policy:        policy,
+		},
+		{
+			name:          "local below remote",
+			localReserve:  1000,
+			remoteReserve: 10000,
+			policy:        policy,
+		},
+		{
+			name:          "at max ratio",
+			localReserve:  20000,
+			remoteReserve: 10000,
+			policy:        policy,
+		},
+		{
+			name:          "above max ratio",
+			localReserve:  20001,
+			remoteReserve: 10000,
+			policy:        policy,
+			maxLocal:      20000,
+			expectErr:     true,
+		},
+		{
+			name:          "within tolerance",
+			localReserve:  1354,
+			remoteReserve: 354,
+			policy:        policy,
+		},
+		{
+			name:          "above tolerance",
+			localReserve:  1355,
+			remoteReserve: 354,
+			policy:        policy,
+			maxLocal:      1354,
+			expectErr:     true,
+		},
+		{
+			name:          "zero policy requires symmetry",
+			localReserve:  10001,
+			remoteReserve: 10000,
+			maxLocal:      10000,
+			expectErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateReserveAsymmetry(
+				test.localReserve, test.remoteReserve,
+				test.policy,
+			)
+			if !test.expectErr {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Equal(t, &ErrReserveAsymmetry{
+				LocalReserve:    test.localReserve,
+				RemoteReserve:   test.remoteReserve,
+				MaxLocalReserve: test.maxLocal,
+			}, err)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package lnwire
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+// ReserveMsatType is the TLV record type for the channel reserve in
+// millisatoshi within the name space of the AcceptChannel message. As the
+// record isn't part of the spec, it uses an odd type of the custom range, so
+// that peers not knowing about it will ignore it.
+const ReserveMsatType tlv.Type = 65549
+
+// ReserveMsat is the channel reserve the sender of an AcceptChannel requires
+// from the initiator with millisatoshi precision. If present, it takes
+// precedence over the ChannelReserve of the message, which must still carry
+// the reserve rounded to whole satoshis for peers that don't know the record.
+type ReserveMsat MilliSatoshi
+
+// NewRecord returns a TLV record that can be used to encode the millisatoshi
+// reserve within the ExtraData TLV stream.
+func (r *ReserveMsat) NewRecord() tlv.Record {
+	return tlv.MakePrimitiveRecord(ReserveMsatType, (*uint64)(r))
+}
+
+// ReserveMsat returns the millisatoshi reserve carried in the ExtraData of the
+// message. The boolean is false if the message doesn't carry the record, in
+// which case the ChannelReserve applies.
+func (a *AcceptChannel) ReserveMsat() (MilliSatoshi, bool, error) {
+	var reserve ReserveMsat
+	typeMap, err := a.ExtraData.ExtractRecords(reserve.NewRecord())
+	if err != nil {
+		return 0, false, err
+	}
+
+	if _, ok := typeMap[ReserveMsatType]; !ok {
+		return 0, false, nil
+	}
+
+	return MilliSatoshi(reserve), true, nil
+}
+
+// SetReserveMsat stores the given millisatoshi reserve in the ExtraData of the
+// message, replacing any existing millisatoshi reserve record. All other
+// records are kept as they are. The ChannelReserve isn't changed, so that the
+// caller remains in control of how it is rounded.
+func (a *AcceptChannel) SetReserveMsat(reserve MilliSatoshi) error {
+	reserveMsat := ReserveMsat(reserve)
+	return a.ExtraData.replaceRecord(reserveMsat.NewRecord())
+}
+
+// EffectiveReserve returns the channel reserve the sender of the message
+// requires from the initiator: the millisatoshi reserve if the message carries
+// it, and the ChannelReserve otherwise.
+func (a *AcceptChannel) EffectiveReserve() (MilliSatoshi, error) {
+	reserve, ok, err := a.ReserveMsat()
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return NewMSatFromSatoshis(a.ChannelReserve), nil
+	}
+
+	return reserve, nil
+}
+
+// ErrReserveMsatMismatch is returned when the millisatoshi reserve of an
+// AcceptChannel doesn't round to its ChannelReserve.
+type ErrReserveMsatMismatch struct {
+	// ChannelReserve is the reserve in whole satoshis.
+	ChannelReserve btcutil.Amount
+
+	// ReserveMsat is the reserve in millisatoshi.
+	ReserveMsat MilliSatoshi
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrReserveMsatMismatch) Error() string {
+	return fmt.Sprintf("channel reserve of %v doesn't match reserve of %v",
+		e.ChannelReserve, e.ReserveMsat)
+}
+
+// ValidateReserveMsat ensures that the millisatoshi reserve of the message, if
+// present, is consistent with its ChannelReserve, returning an
+// *ErrReserveMsatMismatch otherwise. As the spec doesn't mandate a rounding
+// mode, the ChannelReserve may be the millisatoshi reserve rounded either down
+// or up to whole satoshis.
+func (a *AcceptChannel) ValidateReserveMsat() error {
+	reserve, ok, err := a.ReserveMsat()
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return nil
+	}
+
+	return validateReserveMsat(a.ChannelReserve, reserve)
+}
+
+// validateReserveMsat ensures that the given channel reserve is the given
+// millisatoshi reserve rounded either down or up to whole satoshis.
+func validateReserveMsat(channelReserve btcutil.Amount,
+	reserve MilliSatoshi) error {
+
+	roundedDown := reserve.ToSatoshis()
+	roundedUp := roundedDown
+	if NewMSatFromSatoshis(roundedDown) != reserve {
+		roundedUp++
+	}
+
+	if channelReserve != roundedDown && channelReserve != roundedUp {
+		return &ErrReserveMsatMismatch{
+			ChannelReserve: channelReserve,
+			ReserveMsat:    reserve,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package lnwire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptChannelReserveMsat asserts that the millisatoshi reserve record
+// survives an encode/decode round trip of the AcceptChannel, and that it takes
+// precedence over the ChannelReserve.
+func TestAcceptChannelReserveMsat(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		ChannelReserve:       10000,
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+
+	// Without the record, the ChannelReserve applies.
+	_, ok, err := msg.ReserveMsat()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	reserve, err := msg.EffectiveReserve()
+	require.NoError(t, err)
+	require.Equal(t, MilliSatoshi(10000000), reserve)
+
+	// Set the reserve twice to assert that the record is replaced rather
+	// than duplicated.
+	require.NoError(t, msg.SetReserveMsat(9999000))
+	require.NoError(t, msg.SetReserveMsat(10000400))
+
+	var b bytes.Buffer
+	_, err = WriteMessage(&b, msg, 0)
+	require.NoError(t, err)
+
+	decodedMsg, err := ReadMessage(&b, 0)
+	require.NoError(t, err)
+	decoded := decodedMsg.(*AcceptChannel)
+	require.True(t, msg.Equal(decoded))
+
+	reserve, ok, err = decoded.ReserveMsat()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, MilliSatoshi(10000400), reserve)
+
+	reserve, err = decoded.EffectiveReserve()
+	require.NoError(t, err)
+	require.Equal(t, MilliSatoshi(10000400), reserve)
+	require.NoError(t, decoded.ValidateReserveMsat())
+}
+
+// TestAcceptChannelValidateReserveMsat asserts that the millisatoshi reserve
+// is only accepted if the ChannelReserve is the reserve rounded to whole
+// satoshis.
+func TestAcceptChannelValidateReserveMsat(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		channelReserve btcutil.Amount
+		reserveMsat    *MilliSatoshi
+		valid          bool
+	}{{
+		name:           "no record",
+		channelReserve: 1000,
+		valid:          true,
+	}, {
+		name:           "exact",
+		channelReserve: 1000,
+		reserveMsat:    msatPtr(1000000),
+		valid:          true,
+	}, {
+		name:           "rounded down",
+		channelReserve: 1000,
+		reserveMsat:    msatPtr(1000999),
+		valid:          true,
+	}, {
+		name:           "rounded up",
+		channelReserve: 1001,
+		reserveMsat:    msatPtr(1000001),
+		valid:          true,
+	}, {
+		name:           "zero",
+		channelReserve: 0,
+		reserveMsat:    msatPtr(0),
+		valid:          true,
+	}, {
+		name:           "below",
+		channelReserve: 999,
+		reserveMsat:    msatPtr(1000000),
+	}, {
+		name:           "above",
+		channelReserve: 1001,
+		reserveMsat:    msatPtr(1000000),
+	}, {
+		name:           "rounded up too far",
+		channelReserve: 1002,
+		reserveMsat:    msatPtr(1000001),
+	}}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			msg := &AcceptChannel{
+				ChannelReserve: testCase.channelReserve,
+			}
+			if testCase.reserveMsat != nil {
+				require.NoError(t, msg.SetReserveMsat(
+					*testCase.reserveMsat,
+				))
+			}
+
+			err := msg.ValidateReserveMsat()
+			if testCase.valid {
+				require.NoError(t, err)
+				return
+			}
+
+			var mismatchErr *ErrReserveMsatMismatch
+			require.ErrorAs(t, err, &mismatchErr)
+			require.Equal(
+				t, msg.ChannelReserve,
+				mismatchErr.ChannelReserve,
+			)
+			require.Equal(
+				t, *testCase.reserveMsat,
+				mismatchErr.ReserveMsat,
+			)
+		})
+	}
+}
+
+// TestAcceptChannelReserveMsatInvalid asserts that a malformed millisatoshi
+// reserve record is reported by the accessors and the consistency check.
+func TestAcceptChannelReserveMsatInvalid(t *testing.T) {
+	t.Parallel()
+
+	// A single byte value can't be decoded into a uint64.
+	value := []byte{0x01}
+	msg := &AcceptChannel{}
+	require.NoError(t, msg.ExtraData.PackRecords(
+		tlv.MakePrimitiveRecord(ReserveMsatType, &value),
+	))
+
+	_, _, err := msg.ReserveMsat()
+	require.Error(t, err)
+
+	_, err = msg.EffectiveReserve()
+	require.Error(t, err)
+
+	require.Error(t, msg.ValidateReserveMsat())
+}
+
+func msatPtr(m MilliSatoshi) *MilliSatoshi {
+	return &m
+}
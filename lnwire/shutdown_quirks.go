@@ -0,0 +1,114 @@
+package lnwire
+
+import (
+	"context"
+	"encoding/binary"
+)
+
+// ShutdownScriptQuirk describes a known mis-encoding of the upfront shutdown
+// script record by some peer implementations, along with a way to recover the
+// script from it.
+type ShutdownScriptQuirk struct {
+	// Name identifies the quirk in log messages.
+	Name string
+
+	// Recover attempts to recover the upfront shutdown script from extra
+	// data that failed to parse as a TLV stream. It returns the script and
+	// the remaining extra data, or false if the extra data doesn't exhibit
+	// the quirk.
+	Recover func(tlvRecords ExtraOpaqueData) (DeliveryAddress,
+		ExtraOpaqueData, bool)
+}
+
+// U16ShutdownScriptQuirk recovers upfront shutdown script records whose length
+// is encoded as a two byte big endian integer, as in the encoding of the
+// script that predates TLV, rather than as a BigSize varint. Only scripts
+// recognized by DustLimitForScript are recovered, and the remaining extra data
+// must be a valid TLV stream.
+var U16ShutdownScriptQuirk = ShutdownScriptQuirk{
+	Name:    "u16-shutdown-script-length",
+	Recover: recoverU16ShutdownScript,
+}
+
+// shutdownScriptQuirksKey is the context key under which the shutdown script
+// quirks are stored.
+type shutdownScriptQuirksKey struct{}
+
+// WithShutdownScriptQuirks returns a copy of the context that enables
+// recovering upfront shutdown scripts that were mis-encoded as described by
+// the given quirks, when decoding OpenChannel and AcceptChannel messages with
+// DecodeCtx. Quirks are only consulted for extra data that fails to parse,
+// and in the given order. As the context is used for the messages of a single
+// peer, the quirks only apply to the peers known to need them.
+func WithShutdownScriptQuirks(ctx context.Context,
+	quirks ...ShutdownScriptQuirk) context.Context {
+
+	return context.WithValue(ctx, shutdownScriptQuirksKey{}, quirks)
+}
+
+// shutdownScriptQuirksFromContext returns the shutdown script quirks attached
+// to the context, if any.
+func shutdownScriptQuirksFromContext(
+	ctx context.Context) []ShutdownScriptQuirk {
+
+	quirks, _ := ctx.Value(shutdownScriptQuirksKey{}).([]ShutdownScriptQuirk)
+	return quirks
+}
+
+// recoverShutdownScript attempts to recover the upfront shutdown script from
+// the given extra data using the given quirks, logging a warning if one of
+// them succeeds.
+func recoverShutdownScript(quirks []ShutdownScriptQuirk,
+	tlvRecords ExtraOpaqueData) (DeliveryAddress, ExtraOpaqueData, bool) {
+
+	for _, quirk := range quirks {
+		addr, rest, ok := quirk.Recover(tlvRecords)
+		if !ok {
+			continue
+		}
+
+		log.Warnf("Recovered upfront shutdown script %x mis-encoded "+
+			"by peer using quirk %v", addr, quirk.Name)
+
+		return addr, rest, true
+	}
+
+	return nil, nil, false
+}
+
+// recoverU16ShutdownScript implements the Recover function of the
+// U16ShutdownScriptQuirk.
+func recoverU16ShutdownScript(tlvRecords ExtraOpaqueData) (DeliveryAddress,
+	ExtraOpaqueData, bool) {
+
+	// The record starts with the single byte type of the shutdown script,
+	// followed by the two byte length.
+	if len(tlvRecords) < 3 || tlvRecords[0] != byte(DeliveryAddrType) {
+		return nil, nil, false
+	}
+
+	scriptLen := int(binary.BigEndian.Uint16(tlvRecords[1:3]))
+	if len(tlvRecords) < 3+scriptLen {
+		return nil, nil, false
+	}
+
+	addr := DeliveryAddress(tlvRecords[3 : 3+scriptLen])
+	if DustLimitForScript(addr) == 0 {
+		return nil, nil, false
+	}
+
+	// The records following the script must be valid, and must not
+	// contain another shutdown script.
+	rest := tlvRecords[3+scriptLen:]
+	if len(rest) > 0 {
+		types, err := rest.ExtractRecords()
+		if err != nil {
+			return nil, nil, false
+		}
+		if _, ok := types[DeliveryAddrType]; ok {
+			return nil, nil, false
+		}
+	}
+
+	return addr, rest, true
+}
@@ -0,0 +1,200 @@
+package lnwire
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// p2wpkhScript is a P2WPKH script used as upfront shutdown script.
+var p2wpkhScript = append([]byte{0x00, 0x14}, bytes.Repeat([]byte{1}, 20)...)
+
+// u16ShutdownScript returns the given script encoded as upfront shutdown
+// script record with the two byte length prefix of the U16ShutdownScriptQuirk.
+func u16ShutdownScript(script []byte) []byte {
+	return append([]byte{0x00, 0x00, byte(len(script))}, script...)
+}
+
+// TestRecoverU16ShutdownScript tests that the U16ShutdownScriptQuirk only
+// recovers standard scripts followed by valid TLV records.
+func TestRecoverU16ShutdownScript(t *testing.T) {
+	t.Parallel()
+
+	// An HTLC CSV delay record of 144 blocks.
+	csvRecord := []byte{0xfe, 0x00, 0x01, 0x00, 0x01, 0x02, 0x00, 0x90}
+
+	tests := []struct {
+		name         string
+		extraData    []byte
+		expectOk     bool
+		expectedRest []byte
+	}{
+		{
+			name:      "script only",
+			extraData: u16ShutdownScript(p2wpkhScript),
+			expectOk:  true,
+		},
+		{
+			name: "script and record",
+			extraData: append(
+				u16ShutdownScript(p2wpkhScript), csvRecord...,
+			),
+			expectOk:     true,
+			expectedRest: csvRecord,
+		},
+		{
+			name: "non-standard script",
+			extraData: u16ShutdownScript(
+				[]byte{0x51, 0x52, 0x53},
+			),
+		},
+		{
+			name:      "truncated script",
+			extraData: u16ShutdownScript(p2wpkhScript)[:20],
+		},
+		{
+			name: "invalid record",
+			extraData: append(
+				u16ShutdownScript(p2wpkhScript), 0xfe,
+			),
+		},
+		{
+			name:      "wrong type",
+			extraData: append([]byte{0x02}, p2wpkhScript...),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			addr, rest, ok := recoverU16ShutdownScript(
+				test.extraData,
+			)
+			require.Equal(t, test.expectOk, ok)
+			if !test.expectOk {
+				return
+			}
+
+			require.Equal(t, DeliveryAddress(p2wpkhScript), addr)
+			require.True(t, bytes.Equal(test.expectedRest, rest))
+		})
+	}
+}
+
+// TestDecodeU16ShutdownScript tests that an AcceptChannel carrying an upfront
+// shutdown script with a two byte length prefix is only decoded if the
+// U16ShutdownScriptQuirk is attached to the context of DecodeCtx.
+func TestDecodeU16ShutdownScript(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	msg := &AcceptChannel{
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+	require.NoError(t, msg.SetMaxHtlcExpiryDelta(144))
+
+	var b bytes.Buffer
+	require.NoError(t, msg.Encode(&b, 0))
+
+	// Replace the zero-length shutdown script record the message starts
+	// its extra data with by the mis-encoded one.
+	encoded := b.Bytes()
+	extraStart := len(encoded) - len(msg.ExtraData) - 2
+	buggy := append([]byte{}, encoded[:extraStart]...)
+	buggy = append(buggy, u16ShutdownScript(p2wpkhScript)...)
+	buggy = append(buggy, msg.ExtraData...)
+
+	// Without the quirk, the message can't be decoded, neither with nor
+	// without a context.
+	var decoded AcceptChannel
+	err = decoded.Decode(bytes.NewReader(buggy), 0)
+	require.Error(t, err)
+
+	decoded = AcceptChannel{}
+	err = decoded.DecodeCtx(
+		context.Background(), bytes.NewReader(buggy), 0,
+	)
+	require.Error(t, err)
+
+	// Once the quirk is attached to the context, the script is recovered.
+	ctx := WithShutdownScriptQuirks(
+		context.Background(), U16ShutdownScriptQuirk,
+	)
+	decoded = AcceptChannel{}
+	err = decoded.DecodeCtx(ctx, bytes.NewReader(buggy), 0)
+	require.NoError(t, err)
+	require.Equal(
+		t, DeliveryAddress(p2wpkhScript), decoded.UpfrontShutdownScript,
+	)
+
+	delta, ok, err := decoded.MaxHtlcExpiryDelta()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.EqualValues(t, 144, delta)
+
+	// The quirk doesn't leak into decoding without the context.
+	decoded = AcceptChannel{}
+	err = decoded.Decode(bytes.NewReader(buggy), 0)
+	require.Error(t, err)
+
+	// Correctly encoded messages are unaffected by the quirk.
+	decoded = AcceptChannel{}
+	err = decoded.DecodeCtx(ctx, bytes.NewReader(encoded), 0)
+	require.NoError(t, err)
+	require.Equal(t, DeliveryAddress{}, decoded.UpfrontShutdownScript)
+}
+
+// TestReadMessageCtxU16ShutdownScript tests that ReadMessageCtx recovers a
+// mis-encoded upfront shutdown script of an OpenChannel using the quirks of
+// its context, while ReadMessage doesn't.
+func TestReadMessageCtxU16ShutdownScript(t *testing.T) {
+	t.Parallel()
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	msg := &OpenChannel{
+		FundingKey:           pk,
+		RevocationPoint:      pk,
+		PaymentPoint:         pk,
+		DelayedPaymentPoint:  pk,
+		HtlcPoint:            pk,
+		FirstCommitmentPoint: pk,
+	}
+
+	var b bytes.Buffer
+	_, err = WriteMessage(&b, msg, 0)
+	require.NoError(t, err)
+
+	// The message ends with the zero-length shutdown script record, which
+	// we replace by the mis-encoded one.
+	encoded := b.Bytes()
+	buggy := append([]byte{}, encoded[:len(encoded)-2]...)
+	buggy = append(buggy, u16ShutdownScript(p2wpkhScript)...)
+
+	_, err = ReadMessage(bytes.NewReader(buggy), 0)
+	require.Error(t, err)
+
+	ctx := WithShutdownScriptQuirks(
+		context.Background(), U16ShutdownScriptQuirk,
+	)
+	decoded, err := ReadMessageCtx(ctx, bytes.NewReader(buggy), 0)
+	require.NoError(t, err)
+	require.Equal(
+		t, DeliveryAddress(p2wpkhScript),
+		decoded.(*OpenChannel).UpfrontShutdownScript,
+	)
+}
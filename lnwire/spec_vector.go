@@ -0,0 +1,149 @@
+package lnwire
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+// acceptChannelSpecFields are the BOLT #2 names of the fixed size fields of
+// the AcceptChannel message, in wire order, along with the offsets at which
+// they end within the payload.
+var acceptChannelSpecFields = []struct {
+	name string
+	end  int
+}{
+	{"temporary_channel_id", acceptDustLimitOffset},
+	{"dust_limit_satoshis", acceptMaxValueInFlightOffset},
+	{"max_htlc_value_in_flight_msat", acceptChannelReserveOffset},
+	{"channel_reserve_satoshis", acceptHtlcMinimumOffset},
+	{"htlc_minimum_msat", acceptMinAcceptDepthOffset},
+	{"minimum_depth", acceptCsvDelayOffset},
+	{"to_self_delay", acceptMaxAcceptedHTLCsOffset},
+	{"max_accepted_htlcs", acceptFundingKeyOffset},
+	{"funding_pubkey", acceptRevocationPointOffset},
+	{"revocation_basepoint", acceptPaymentPointOffset},
+	{"payment_basepoint", acceptDelayedPaymentOffset},
+	{"delayed_payment_basepoint", acceptHtlcPointOffset},
+	{"htlc_basepoint", acceptFirstCommitPointOffset},
+	{"first_per_commitment_point", acceptTLVOffset},
+}
+
+// SpecVectorField is a single annotated field of a SpecVector.
+type SpecVectorField struct {
+	// Name is the name of the field, as used by the BOLT specification.
+	Name string
+
+	// Hex is the hex encoded serialization of the field.
+	Hex string
+}
+
+// SpecVector is a serialized message in the format of the BOLT test vectors:
+// the hex encoded message, followed by the hex encoding of each of its fields
+// annotated with their names.
+type SpecVector struct {
+	// Message is the hex encoded serialization of the complete message,
+	// including the message type.
+	Message string
+
+	// Fields are the fields of the message in wire order, starting with
+	// the message type. The serializations of the fields concatenate to
+	// Message.
+	Fields []SpecVectorField
+}
+
+// String returns the vector as it is laid out in the BOLT specification, with
+// the message on the first line and one annotated field per line.
+func (v SpecVector) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "message: %s\n", v.Message)
+	for _, field := range v.Fields {
+		fmt.Fprintf(&b, "    [%s]: %s\n", field.Name, field.Hex)
+	}
+
+	return b.String()
+}
+
+// ToSpecVector serializes the message and returns it in the format of the BOLT
+// test vectors. Each TLV record is annotated separately, with the upfront
+// shutdown script record named after its BOLT #2 field, and any other record
+// named after its type. An error is returned if the message can't be
+// encoded.
+func (a *AcceptChannel) ToSpecVector() (SpecVector, error) {
+	var msg bytes.Buffer
+	if _, err := WriteMessage(&msg, a, 0); err != nil {
+		return SpecVector{}, err
+	}
+	raw := msg.Bytes()
+
+	// The payload follows the two byte message type.
+	payload := raw[2:]
+	fields := []SpecVectorField{{
+		Name: "type",
+		Hex:  hex.EncodeToString(raw[:2]),
+	}}
+
+	start := 0
+	for _, field := range acceptChannelSpecFields {
+		fields = append(fields, SpecVectorField{
+			Name: field.name,
+			Hex:  hex.EncodeToString(payload[start:field.end]),
+		})
+		start = field.end
+	}
+
+	tlvFields, err := specTLVFields(payload[acceptTLVOffset:])
+	if err != nil {
+		return SpecVector{}, err
+	}
+
+	return SpecVector{
+		Message: hex.EncodeToString(raw),
+		Fields:  append(fields, tlvFields...),
+	}, nil
+}
+
+// specTLVFields splits the given TLV stream of an AcceptChannel into its
+// records, and annotates each of them.
+func specTLVFields(stream []byte) ([]SpecVectorField, error) {
+	var (
+		r      = bytes.NewReader(stream)
+		buf    [8]byte
+		fields []SpecVectorField
+	)
+	for r.Len() > 0 {
+		start := len(stream) - r.Len()
+
+		typ, err := tlv.ReadVarInt(r, &buf)
+		if err != nil {
+			return nil, err
+		}
+		length, err := tlv.ReadVarInt(r, &buf)
+		if err != nil {
+			return nil, err
+		}
+		if length > uint64(r.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if _, err := r.Seek(int64(length), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+
+		name := fmt.Sprintf("tlv_type_%d", typ)
+		if tlv.Type(typ) == DeliveryAddrType {
+			name = "upfront_shutdown_script"
+		}
+
+		end := len(stream) - r.Len()
+		fields = append(fields, SpecVectorField{
+			Name: name,
+			Hex:  hex.EncodeToString(stream[start:end]),
+		})
+	}
+
+	return fields, nil
+}
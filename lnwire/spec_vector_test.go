@@ -0,0 +1,93 @@
+package lnwire
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptChannelToSpecVector asserts that an AcceptChannel is exported as
+// a known test vector, and that its annotated fields add up to the message.
+func TestAcceptChannelToSpecVector(t *testing.T) {
+	t.Parallel()
+
+	key := func(b byte) *btcec.PublicKey {
+		_, pub := btcec.PrivKeyFromBytes(
+			btcec.S256(), bytes.Repeat([]byte{b}, 32),
+		)
+		return pub
+	}
+
+	msg := &AcceptChannel{
+		PendingChannelID:      [32]byte{0x02},
+		DustLimit:             546,
+		MaxValueInFlight:      1000000000,
+		ChannelReserve:        10000,
+		HtlcMinimum:           1000,
+		MinAcceptDepth:        3,
+		CsvDelay:              144,
+		MaxAcceptedHTLCs:      483,
+		FundingKey:            key(1),
+		RevocationPoint:       key(2),
+		PaymentPoint:          key(3),
+		DelayedPaymentPoint:   key(4),
+		HtlcPoint:             key(5),
+		FirstCommitmentPoint:  key(6),
+		UpfrontShutdownScript: DeliveryAddress{0x00, 0x14, 0x01},
+		ExtraData: ExtraOpaqueData{
+			0xfe, 0x00, 0x01, 0x00, 0x01, 0x02, 0x00, 0x90,
+		},
+	}
+
+	expectedFields := []SpecVectorField{
+		{"type", "0021"},
+		{"temporary_channel_id", "02000000000000000000000000000000" +
+			"00000000000000000000000000000000"},
+		{"dust_limit_satoshis", "0000000000000222"},
+		{"max_htlc_value_in_flight_msat", "000000003b9aca00"},
+		{"channel_reserve_satoshis", "0000000000002710"},
+		{"htlc_minimum_msat", "00000000000003e8"},
+		{"minimum_depth", "00000003"},
+		{"to_self_delay", "0090"},
+		{"max_accepted_htlcs", "01e3"},
+		{"funding_pubkey", "031b84c5567b126440995d3ed5aaba0565" +
+			"d71e1834604819ff9c17f5e9d5dd078f"},
+		{"revocation_basepoint", "024d4b6cd1361032ca9bd2aeb9d900aa4d" +
+			"45d9ead80ac9423374c451a7254d0766"},
+		{"payment_basepoint", "02531fe6068134503d2723133227c867ac" +
+			"8fa6c83c537e9a44c3c5bdbdcb1fe337"},
+		{"delayed_payment_basepoint", "03462779ad4aad3951461475" +
+			"1a71085f2f10e1c7a593e4e030efb5b8721ce55b0b"},
+		{"htlc_basepoint", "0362c0a046dacce86ddd0343c6d3c7c79c" +
+			"2208ba0d9c9cf24a6d046d21d21f90f7"},
+		{"first_per_commitment_point", "03f006a18d5653c4edf5391f" +
+			"f23a61f03ff83d237e880ee61187fa9f379a028e0a"},
+		{"upfront_shutdown_script", "0003001401"},
+		{"tlv_type_65537", "fe00010001020090"},
+	}
+
+	var expectedMsg strings.Builder
+	for _, field := range expectedFields {
+		expectedMsg.WriteString(field.Hex)
+	}
+
+	vector, err := msg.ToSpecVector()
+	require.NoError(t, err)
+	require.Equal(t, expectedMsg.String(), vector.Message)
+	require.Equal(t, expectedFields, vector.Fields)
+
+	// The vector is laid out with the message first, followed by one
+	// annotated field per line.
+	lines := strings.Split(strings.TrimSpace(vector.String()), "\n")
+	require.Len(t, lines, len(expectedFields)+1)
+	require.Equal(t, "message: "+vector.Message, lines[0])
+	require.Equal(t, "    [to_self_delay]: 0090", lines[8])
+
+	// A message that can't be encoded can't be exported either.
+	msg.FundingKey = nil
+	_, err = msg.ToSpecVector()
+	require.Error(t, err)
+}
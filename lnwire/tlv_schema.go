@@ -0,0 +1,155 @@
+package lnwire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+// TLVRule describes the constraints the value of a TLV record permitted by a
+// TLVSchema must satisfy.
+type TLVRule struct {
+	// MinLength is the minimum length of the value in bytes.
+	MinLength uint64
+
+	// MaxLength is the maximum length of the value in bytes. A value of
+	// zero means the length isn't bounded.
+	MaxLength uint64
+
+	// Check optionally validates the raw value of the record, once its
+	// length is known to be within bounds.
+	Check func(value []byte) error
+}
+
+// TLVSchema maps the types of the optional TLV records permitted within the
+// extra data of a message to the constraints on their values. Unknown records
+// of an even type violate the schema, while unknown records of an odd type are
+// ignored, as the receiver of a message may always skip them.
+type TLVSchema map[tlv.Type]TLVRule
+
+// NewUintRangeRule returns a rule for a record holding a big-endian unsigned
+// integer of the given size in bytes, which must be one of 1, 2, 4 or 8, such
+// as a record created by tlv.MakePrimitiveRecord. The value must lie within
+// [min, max].
+func NewUintRangeRule(size uint64, min, max uint64) TLVRule {
+	return TLVRule{
+		MinLength: size,
+		MaxLength: size,
+		Check: func(value []byte) error {
+			var v uint64
+			switch size {
+			case 1:
+				v = uint64(value[0])
+			case 2:
+				v = uint64(binary.BigEndian.Uint16(value))
+			case 4:
+				v = uint64(binary.BigEndian.Uint32(value))
+			case 8:
+				v = binary.BigEndian.Uint64(value)
+			default:
+				return fmt.Errorf("unsupported integer size %d",
+					size)
+			}
+
+			if v < min || v > max {
+				return fmt.Errorf("value %d not in range "+
+					"[%d, %d]", v, min, max)
+			}
+
+			return nil
+		},
+	}
+}
+
+// ErrTLVSchemaViolation is returned when validating a message whose extra data
+// contains a TLV record violating a TLVSchema.
+type ErrTLVSchemaViolation struct {
+	// Type is the type of the record violating the schema.
+	Type tlv.Type
+
+	// Err describes the violation.
+	Err error
+}
+
+// Error returns a human readable string describing the error.
+//
+// NOTE: implements the error interface.
+func (e *ErrTLVSchemaViolation) Error() string {
+	return fmt.Sprintf("tlv record type %d violates schema: %v", e.Type,
+		e.Err)
+}
+
+// Unwrap returns the error describing the violation.
+func (e *ErrTLVSchemaViolation) Unwrap() error {
+	return e.Err
+}
+
+// validate ensures all records of the given extra data satisfy the schema,
+// returning an *ErrTLVSchemaViolation for the record of the lowest type that
+// doesn't.
+func (s TLVSchema) validate(extraData ExtraOpaqueData) error {
+	if len(extraData) == 0 {
+		return nil
+	}
+
+	typeMap, err := extraData.ExtractRecords()
+	if err != nil {
+		return err
+	}
+
+	types := make([]tlv.Type, 0, len(typeMap))
+	for typ := range typeMap {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+
+	for _, typ := range types {
+		value := typeMap[typ]
+
+		rule, ok := s[typ]
+		switch {
+		case !ok && typ%2 == 0:
+			return &ErrTLVSchemaViolation{
+				Type: typ,
+				Err:  errors.New("unknown even type"),
+			}
+
+		case !ok:
+			continue
+		}
+
+		length := uint64(len(value))
+		if length < rule.MinLength ||
+			(rule.MaxLength != 0 && length > rule.MaxLength) {
+
+			return &ErrTLVSchemaViolation{
+				Type: typ,
+				Err: fmt.Errorf("length %d not in range "+
+					"[%d, %d]", length, rule.MinLength,
+					rule.MaxLength),
+			}
+		}
+
+		if rule.Check == nil {
+			continue
+		}
+		if err := rule.Check(value); err != nil {
+			return &ErrTLVSchemaViolation{Type: typ, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// ValidateTLVSchema ensures the optional TLV records carried in the ExtraData
+// of the message satisfy the given schema, returning an
+// *ErrTLVSchemaViolation otherwise. The upfront shutdown script isn't part of
+// the ExtraData, and is therefore not subject to the schema.
+func (a *AcceptChannel) ValidateTLVSchema(schema TLVSchema) error {
+	return schema.validate(a.ExtraData)
+}
@@ -0,0 +1,138 @@
+package lnwire
+
+import (
+	"testing"
+
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAcceptChannelValidateTLVSchema asserts that ValidateTLVSchema only
+// accepts extra data whose records are permitted by the schema and satisfy
+// its constraints, while unknown odd records are ignored.
+func TestAcceptChannelValidateTLVSchema(t *testing.T) {
+	t.Parallel()
+
+	schema := TLVSchema{
+		ChannelTypeRecordType:  {MaxLength: 8},
+		MaxHtlcExpiryDeltaType: NewUintRangeRule(4, 144, 2016),
+	}
+
+	var (
+		unknownOdd  tlv.Type = 65543
+		unknownEven tlv.Type = 65544
+	)
+	rawRecord := func(typ tlv.Type, value []byte) tlv.Record {
+		return tlv.MakePrimitiveRecord(typ, &value)
+	}
+
+	tests := []struct {
+		name       string
+		records    func() []tlv.Record
+		expErr     bool
+		expErrType tlv.Type
+	}{
+		{
+			name: "no records",
+			records: func() []tlv.Record {
+				return nil
+			},
+		},
+		{
+			name: "permitted records",
+			records: func() []tlv.Record {
+				chanType := ChannelType(*NewRawFeatureVector(
+					StaticRemoteKeyRequired,
+				))
+				delta := MaxHtlcExpiryDelta(2016)
+				return []tlv.Record{
+					chanType.NewRecord(), delta.NewRecord(),
+				}
+			},
+		},
+		{
+			name: "unknown odd record",
+			records: func() []tlv.Record {
+				return []tlv.Record{
+					rawRecord(unknownOdd, []byte{1, 2, 3}),
+				}
+			},
+		},
+		{
+			name: "unknown even record",
+			records: func() []tlv.Record {
+				return []tlv.Record{
+					rawRecord(unknownEven, []byte{1}),
+				}
+			},
+			expErr:     true,
+			expErrType: unknownEven,
+		},
+		{
+			name: "value below range",
+			records: func() []tlv.Record {
+				delta := MaxHtlcExpiryDelta(143)
+				return []tlv.Record{delta.NewRecord()}
+			},
+			expErr:     true,
+			expErrType: MaxHtlcExpiryDeltaType,
+		},
+		{
+			name: "value above range",
+			records: func() []tlv.Record {
+				delta := MaxHtlcExpiryDelta(2017)
+				return []tlv.Record{delta.NewRecord()}
+			},
+			expErr:     true,
+			expErrType: MaxHtlcExpiryDeltaType,
+		},
+		{
+			name: "value of wrong length",
+			records: func() []tlv.Record {
+				return []tlv.Record{
+					rawRecord(
+						MaxHtlcExpiryDeltaType,
+						[]byte{1},
+					),
+				}
+			},
+			expErr:     true,
+			expErrType: MaxHtlcExpiryDeltaType,
+		},
+		{
+			name: "value too long",
+			records: func() []tlv.Record {
+				return []tlv.Record{rawRecord(
+					ChannelTypeRecordType, make([]byte, 9),
+				)}
+			},
+			expErr:     true,
+			expErrType: ChannelTypeRecordType,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var msg AcceptChannel
+			require.NoError(t, msg.ExtraData.PackRecords(
+				test.records()...,
+			))
+
+			err := msg.ValidateTLVSchema(schema)
+			if !test.expErr {
+				require.NoError(t, err)
+				return
+			}
+
+			require.IsType(t, &ErrTLVSchemaViolation{}, err)
+			require.Equal(
+				t, test.expErrType,
+				err.(*ErrTLVSchemaViolation).Type,
+			)
+		})
+	}
+}
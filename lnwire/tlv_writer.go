@@ -0,0 +1,80 @@
+package lnwire
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+var (
+	// tlvWriters holds the TLV writers registered for each message type.
+	tlvWriters = make(map[MessageType][]func() tlv.Record)
+
+	// tlvWritersMtx guards tlvWriters.
+	tlvWritersMtx sync.RWMutex
+)
+
+// RegisterTLVWriter registers a writer returning a TLV record that should be
+// attached to every message of the given type when encoding it. This allows
+// extensions to add TLV records to a message without modifying its struct.
+// The records are packed after the upfront shutdown script record, together
+// with the message's ExtraData, and must not share a type with any of them.
+//
+// NOTE: Only AcceptChannel currently consults the registered writers.
+func RegisterTLVWriter(msgType MessageType, writer func() tlv.Record) {
+	tlvWritersMtx.Lock()
+	defer tlvWritersMtx.Unlock()
+
+	tlvWriters[msgType] = append(tlvWriters[msgType], writer)
+}
+
+// appendRegisteredTLVs packs the records of all writers registered for the
+// given message type together with the records already present in the passed
+// extra data. The result is a single TLV stream in canonical order. If no
+// writers are registered, the extra data is returned as is.
+func appendRegisteredTLVs(msgType MessageType,
+	extraData ExtraOpaqueData) (ExtraOpaqueData, error) {
+
+	tlvWritersMtx.RLock()
+	writers := tlvWriters[msgType]
+	tlvWritersMtx.RUnlock()
+
+	if len(writers) == 0 {
+		return extraData, nil
+	}
+
+	// Parse the existing records, so they can be sorted in with the ones
+	// of the registered writers.
+	types, err := extraData.ExtractRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	tlvMap := make(map[uint64][]byte, len(types))
+	for typ, val := range types {
+		tlvMap[uint64(typ)] = val
+	}
+	records := tlv.MapToRecords(tlvMap)
+
+	for _, writer := range writers {
+		record := writer()
+		if _, ok := types[record.Type()]; ok {
+			return nil, fmt.Errorf("registered tlv writer for %v "+
+				"returned duplicate type %d", msgType,
+				record.Type())
+		}
+
+		types[record.Type()] = nil
+		records = append(records, record)
+	}
+
+	tlv.SortRecords(records)
+
+	var packed ExtraOpaqueData
+	if err := packed.PackRecords(records...); err != nil {
+		return nil, err
+	}
+
+	return packed, nil
+}
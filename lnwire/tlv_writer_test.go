@@ -0,0 +1,93 @@
+package lnwire
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/lightningnetwork/lnd/tlv"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRegisterTLVWriter asserts that the records of a registered TLV writer
+// are included when encoding an AcceptChannel, and can be extracted from the
+// ExtraData of the decoded message.
+//
+// NOTE: This test must not be run in parallel, as the registered writer would
+// otherwise leak into other tests encoding an AcceptChannel.
+func TestRegisterTLVWriter(t *testing.T) {
+	const (
+		customType  tlv.Type = 65537
+		customValue uint64   = 0xdeadbeef
+	)
+
+	t.Cleanup(func() {
+		tlvWritersMtx.Lock()
+		delete(tlvWriters, MsgAcceptChannel)
+		tlvWritersMtx.Unlock()
+	})
+
+	RegisterTLVWriter(MsgAcceptChannel, func() tlv.Record {
+		val := customValue
+		return tlv.MakePrimitiveRecord(customType, &val)
+	})
+
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pk := priv.PubKey()
+
+	// Also add a record of a lower type to the ExtraData, which must end up
+	// in front of the registered record in the encoding.
+	var (
+		extraData ExtraOpaqueData
+		extraVal  uint8 = 1
+	)
+	require.NoError(t, extraData.PackRecords(
+		tlv.MakePrimitiveRecord(tlv.Type(1), &extraVal),
+	))
+
+	msg := &AcceptChannel{
+		FundingKey:            pk,
+		RevocationPoint:       pk,
+		PaymentPoint:          pk,
+		DelayedPaymentPoint:   pk,
+		HtlcPoint:             pk,
+		FirstCommitmentPoint:  pk,
+		UpfrontShutdownScript: []byte("example"),
+		ExtraData:             extraData,
+	}
+
+	var b bytes.Buffer
+	_, err = WriteMessage(&b, msg, 0)
+	require.NoError(t, err)
+
+	decodedMsg, err := ReadMessage(&b, 0)
+	require.NoError(t, err)
+	decoded := decodedMsg.(*AcceptChannel)
+
+	require.Equal(t, msg.UpfrontShutdownScript, decoded.UpfrontShutdownScript)
+
+	var (
+		gotExtra  uint8
+		gotCustom uint64
+	)
+	types, err := decoded.ExtraData.ExtractRecords(
+		tlv.MakePrimitiveRecord(tlv.Type(1), &gotExtra),
+		tlv.MakePrimitiveRecord(customType, &gotCustom),
+	)
+	require.NoError(t, err)
+	require.Contains(t, types, tlv.Type(1))
+	require.Contains(t, types, customType)
+	require.Equal(t, extraVal, gotExtra)
+	require.Equal(t, customValue, gotCustom)
+
+	// A writer returning a type that is already present in the ExtraData
+	// must be rejected.
+	RegisterTLVWriter(MsgAcceptChannel, func() tlv.Record {
+		val := extraVal
+		return tlv.MakePrimitiveRecord(tlv.Type(1), &val)
+	})
+
+	b.Reset()
+	require.Error(t, msg.Encode(&b, 0))
+}
@@ -1,6 +1,8 @@
 package lnwire
 
 import (
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
 	"github.com/lightningnetwork/lnd/tlv"
 )
 
@@ -19,6 +21,29 @@ const (
 	deliveryAddressMaxSize = 34
 )
 
+const (
+	// p2pkhDustLimit is the dust threshold of a pay to pubkey hash output,
+	// as specified in BOLT #3.
+	p2pkhDustLimit = btcutil.Amount(546)
+
+	// p2shDustLimit is the dust threshold of a pay to script hash output,
+	// as specified in BOLT #3.
+	p2shDustLimit = btcutil.Amount(540)
+
+	// p2wpkhDustLimit is the dust threshold of a pay to witness pubkey
+	// hash output, as specified in BOLT #3.
+	p2wpkhDustLimit = btcutil.Amount(294)
+
+	// p2wshDustLimit is the dust threshold of a pay to witness script hash
+	// output, as specified in BOLT #3.
+	p2wshDustLimit = btcutil.Amount(330)
+
+	// futureSegwitDustLimit is the dust threshold of an output paying to a
+	// witness program of any version above zero, such as a taproot output,
+	// as specified in BOLT #3.
+	futureSegwitDustLimit = btcutil.Amount(354)
+)
+
 // DeliveryAddress is used to communicate the address to which funds from a
 // closed channel should be sent. The address can be a p2wsh, p2pkh, p2sh or
 // p2wpkh.
@@ -39,3 +64,50 @@ func (d *DeliveryAddress) NewRecord() tlv.Record {
 		tlv.EVarBytes, tlv.DVarBytes,
 	)
 }
+
+// DustLimitForScript returns the dust threshold of an output paying to the
+// given delivery address. A channel whose cooperative close pays out to the
+// script must use a dust limit of at least this amount, otherwise the closing
+// transaction could contain an output that isn't relayed. Zero is returned for
+// an empty script, or a script of a type we don't know of.
+func DustLimitForScript(script DeliveryAddress) btcutil.Amount {
+	switch {
+	// OP_DUP OP_HASH160 <20-byte hash> OP_EQUALVERIFY OP_CHECKSIG
+	case len(script) == 25 && script[0] == txscript.OP_DUP &&
+		script[1] == txscript.OP_HASH160 &&
+		script[2] == txscript.OP_DATA_20 &&
+		script[23] == txscript.OP_EQUALVERIFY &&
+		script[24] == txscript.OP_CHECKSIG:
+
+		return p2pkhDustLimit
+
+	// OP_HASH160 <20-byte hash> OP_EQUAL
+	case len(script) == 23 && script[0] == txscript.OP_HASH160 &&
+		script[1] == txscript.OP_DATA_20 &&
+		script[22] == txscript.OP_EQUAL:
+
+		return p2shDustLimit
+
+	// OP_0 <20-byte hash>
+	case len(script) == 22 && script[0] == txscript.OP_0 &&
+		script[1] == txscript.OP_DATA_20:
+
+		return p2wpkhDustLimit
+
+	// OP_0 <32-byte hash>
+	case len(script) == 34 && script[0] == txscript.OP_0 &&
+		script[1] == txscript.OP_DATA_32:
+
+		return p2wshDustLimit
+
+	// OP_1 through OP_16 followed by a witness program of 2 to 40 bytes.
+	case len(script) >= 4 && len(script) <= 42 &&
+		script[0] >= txscript.OP_1 && script[0] <= txscript.OP_16 &&
+		int(script[1]) == len(script)-2:
+
+		return futureSegwitDustLimit
+
+	default:
+		return 0
+	}
+}
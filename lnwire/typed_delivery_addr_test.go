@@ -3,6 +3,8 @@ package lnwire
 import (
 	"bytes"
 	"testing"
+
+	"github.com/btcsuite/btcutil"
 )
 
 // TestDeliveryAddressEncodeDecode tests that we're able to properly
@@ -35,3 +37,83 @@ func TestDeliveryAddressEncodeDecode(t *testing.T) {
 			addr2[:])
 	}
 }
+
+// TestDustLimitForScript tests that the dust threshold of each known script
+// type is returned, and that unknown scripts don't impose a threshold.
+func TestDustLimitForScript(t *testing.T) {
+	t.Parallel()
+
+	hash20 := bytes.Repeat([]byte{0x01}, 20)
+	hash32 := bytes.Repeat([]byte{0x02}, 32)
+
+	concat := func(parts ...[]byte) DeliveryAddress {
+		return DeliveryAddress(bytes.Join(parts, nil))
+	}
+
+	tests := []struct {
+		name      string
+		script    DeliveryAddress
+		dustLimit btcutil.Amount
+	}{
+		{
+			name: "p2pkh",
+			script: concat(
+				[]byte{0x76, 0xa9, 0x14}, hash20,
+				[]byte{0x88, 0xac},
+			),
+			dustLimit: 546,
+		},
+		{
+			name:      "p2sh",
+			script:    concat([]byte{0xa9, 0x14}, hash20, []byte{0x87}),
+			dustLimit: 540,
+		},
+		{
+			name:      "p2wpkh",
+			script:    concat([]byte{0x00, 0x14}, hash20),
+			dustLimit: 294,
+		},
+		{
+			name:      "p2wsh",
+			script:    concat([]byte{0x00, 0x20}, hash32),
+			dustLimit: 330,
+		},
+		{
+			name:      "p2tr",
+			script:    concat([]byte{0x51, 0x20}, hash32),
+			dustLimit: 354,
+		},
+		{
+			name:      "future segwit version",
+			script:    concat([]byte{0x60, 0x02}, []byte{0x03, 0x04}),
+			dustLimit: 354,
+		},
+		{
+			name:      "empty",
+			script:    DeliveryAddress{},
+			dustLimit: 0,
+		},
+		{
+			name:      "truncated p2wsh",
+			script:    concat([]byte{0x00, 0x20}, hash20),
+			dustLimit: 0,
+		},
+		{
+			name:      "witness program too short",
+			script:    DeliveryAddress{0x51, 0x01, 0x01},
+			dustLimit: 0,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			dustLimit := DustLimitForScript(test.script)
+			if dustLimit != test.dustLimit {
+				t.Fatalf("wrong dust limit: expected %v, "+
+					"got %v", test.dustLimit, dustLimit)
+			}
+		})
+	}
+}
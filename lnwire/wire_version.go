@@ -0,0 +1,73 @@
+package lnwire
+
+const (
+	// WireVersionUpfrontShutdown is set in a negotiated wire version if
+	// both parties signal option_upfront_shutdown_script, meaning the
+	// upfront shutdown script record is expected in OpenChannel and
+	// AcceptChannel.
+	WireVersionUpfrontShutdown uint32 = 1 << iota
+
+	// WireVersionStaticRemoteKey is set in a negotiated wire version if
+	// both parties signal option_static_remotekey.
+	WireVersionStaticRemoteKey
+
+	// WireVersionAnchors is set in a negotiated wire version if both
+	// parties signal option_anchor_outputs.
+	WireVersionAnchors
+
+	// WireVersionAnchorsZeroFeeHtlcTx is set in a negotiated wire version
+	// if both parties signal option_anchors_zero_fee_htlc_tx.
+	WireVersionAnchorsZeroFeeHtlcTx
+
+	// WireVersionZeroConf is set in a negotiated wire version if both
+	// parties signal option_zeroconf, meaning a MinAcceptDepth of zero is
+	// acceptable in AcceptChannel.
+	WireVersionZeroConf
+
+	// WireVersionScidAlias is set in a negotiated wire version if both
+	// parties signal option_scid_alias.
+	WireVersionScidAlias
+
+	// WireVersionTaproot is set in a negotiated wire version if both
+	// parties signal the staging version of option_simple_taproot.
+	WireVersionTaproot
+)
+
+// wireVersionFeatures maps each wire version flag to the required bit of the
+// feature pair that must be shared by both parties for the flag to be set.
+var wireVersionFeatures = map[uint32]FeatureBit{
+	WireVersionUpfrontShutdown:      UpfrontShutdownScriptRequired,
+	WireVersionStaticRemoteKey:      StaticRemoteKeyRequired,
+	WireVersionAnchors:              AnchorsRequired,
+	WireVersionAnchorsZeroFeeHtlcTx: AnchorsZeroFeeHtlcTxRequired,
+	WireVersionZeroConf:             ZeroConfRequired,
+	WireVersionScidAlias:            ScidAliasRequired,
+	WireVersionTaproot:              SimpleTaprootChannelsRequiredStaging,
+}
+
+// NegotiatedWireVersion returns a protocol version for the channel funding
+// messages exchanged between two peers with the given feature vectors. The
+// version is a bit field of the WireVersion flags, one for each feature
+// signalled, either as optional or required, by both sides. It can be passed
+// as the pver argument to Encode and Decode to gate optional records.
+func NegotiatedWireVersion(local, remote *FeatureVector) uint32 {
+	if local == nil || remote == nil {
+		return 0
+	}
+
+	// hasFeature reports whether either bit of the pair starting at the
+	// given required bit is set. Both bits are checked directly, since
+	// the vectors aren't guaranteed to carry feature names.
+	hasFeature := func(fv *FeatureVector, bit FeatureBit) bool {
+		return fv.IsSet(bit) || fv.IsSet(bit^1)
+	}
+
+	var version uint32
+	for flag, bit := range wireVersionFeatures {
+		if hasFeature(local, bit) && hasFeature(remote, bit) {
+			version |= flag
+		}
+	}
+
+	return version
+}
@@ -0,0 +1,178 @@
+package lnwire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestNegotiatedWireVersion asserts that the negotiated wire version only
+// includes the features signalled by both parties.
+func TestNegotiatedWireVersion(t *testing.T) {
+	t.Parallel()
+
+	newVector := func(bits ...FeatureBit) *FeatureVector {
+		return NewFeatureVector(NewRawFeatureVector(bits...), Features)
+	}
+
+	tests := []struct {
+		name     string
+		local    *FeatureVector
+		remote   *FeatureVector
+		expected uint32
+	}{
+		{
+			name:     "nil vectors",
+			expected: 0,
+		},
+		{
+			name:     "no features",
+			local:    newVector(),
+			remote:   newVector(),
+			expected: 0,
+		},
+		{
+			name: "anchors both optional",
+			local: newVector(
+				StaticRemoteKeyOptional, AnchorsOptional,
+			),
+			remote: newVector(
+				StaticRemoteKeyOptional, AnchorsOptional,
+			),
+			expected: WireVersionStaticRemoteKey |
+				WireVersionAnchors,
+		},
+		{
+			name: "anchors required and optional",
+			local: newVector(
+				StaticRemoteKeyRequired, AnchorsRequired,
+			),
+			remote: newVector(
+				StaticRemoteKeyOptional, AnchorsOptional,
+			),
+			expected: WireVersionStaticRemoteKey |
+				WireVersionAnchors,
+		},
+		{
+			name: "anchors only local",
+			local: newVector(
+				StaticRemoteKeyOptional, AnchorsOptional,
+			),
+			remote:   newVector(StaticRemoteKeyOptional),
+			expected: WireVersionStaticRemoteKey,
+		},
+		{
+			name: "zero fee anchors",
+			local: newVector(
+				StaticRemoteKeyOptional,
+				AnchorsZeroFeeHtlcTxOptional,
+			),
+			remote: newVector(
+				StaticRemoteKeyRequired,
+				AnchorsZeroFeeHtlcTxOptional,
+			),
+			expected: WireVersionStaticRemoteKey |
+				WireVersionAnchorsZeroFeeHtlcTx,
+		},
+		{
+			name: "upfront shutdown",
+			local: newVector(
+				UpfrontShutdownScriptOptional,
+				DataLossProtectRequired,
+			),
+			remote: newVector(
+				UpfrontShutdownScriptOptional,
+				GossipQueriesOptional,
+			),
+			expected: WireVersionUpfrontShutdown,
+		},
+		{
+			name: "zero conf both sides",
+			local: newVector(
+				StaticRemoteKeyOptional, AnchorsOptional,
+				ScidAliasOptional, ZeroConfOptional,
+			),
+			remote: newVector(
+				StaticRemoteKeyOptional, AnchorsOptional,
+				ScidAliasRequired, ZeroConfRequired,
+			),
+			expected: WireVersionStaticRemoteKey |
+				WireVersionAnchors | WireVersionScidAlias |
+				WireVersionZeroConf,
+		},
+		{
+			name: "zero conf only local",
+			local: newVector(
+				StaticRemoteKeyOptional, ZeroConfOptional,
+			),
+			remote:   newVector(StaticRemoteKeyOptional),
+			expected: WireVersionStaticRemoteKey,
+		},
+		{
+			name: "taproot",
+			local: newVector(
+				StaticRemoteKeyOptional,
+				AnchorsZeroFeeHtlcTxOptional,
+				SimpleTaprootChannelsOptionalStaging,
+			),
+			remote: newVector(
+				StaticRemoteKeyOptional,
+				AnchorsZeroFeeHtlcTxOptional,
+				SimpleTaprootChannelsRequiredStaging,
+			),
+			expected: WireVersionStaticRemoteKey |
+				WireVersionAnchorsZeroFeeHtlcTx |
+				WireVersionTaproot,
+		},
+		{
+			name: "taproot only remote",
+			local: newVector(
+				StaticRemoteKeyOptional,
+				AnchorsZeroFeeHtlcTxOptional,
+			),
+			remote: newVector(
+				StaticRemoteKeyOptional,
+				AnchorsZeroFeeHtlcTxOptional,
+				SimpleTaprootChannelsOptionalStaging,
+			),
+			expected: WireVersionStaticRemoteKey |
+				WireVersionAnchorsZeroFeeHtlcTx,
+		},
+		{
+			name: "taproot and zero conf",
+			local: newVector(
+				StaticRemoteKeyRequired,
+				AnchorsZeroFeeHtlcTxOptional,
+				SimpleTaprootChannelsOptionalStaging,
+				ZeroConfOptional,
+			),
+			remote: newVector(
+				StaticRemoteKeyRequired,
+				AnchorsZeroFeeHtlcTxOptional,
+				SimpleTaprootChannelsOptionalStaging,
+				ZeroConfOptional,
+			),
+			expected: WireVersionStaticRemoteKey |
+				WireVersionAnchorsZeroFeeHtlcTx |
+				WireVersionTaproot | WireVersionZeroConf,
+		},
+		{
+			name: "no feature names",
+			local: NewFeatureVector(
+				NewRawFeatureVector(AnchorsOptional), nil,
+			),
+			remote: NewFeatureVector(
+				NewRawFeatureVector(AnchorsRequired), nil,
+			),
+			expected: WireVersionAnchors,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			version := NegotiatedWireVersion(test.local, test.remote)
+			require.Equal(t, test.expected, version)
+		})
+	}
+}
@@ -120,9 +120,16 @@ func WritePublicKey(buf *bytes.Buffer, pub *btcec.PublicKey) error {
 		return ErrNilPublicKey
 	}
 
-	serializedPubkey := pub.SerializeCompressed()
-	return WriteBytes(buf, serializedPubkey)
-
+	// Serialize the key in its compressed form into a fixed size array
+	// rather than using SerializeCompressed, which allocates both the
+	// serialized key and the big endian bytes of its x coordinate. The
+	// format byte is 0x02 for an even y coordinate and 0x03 for an odd
+	// one.
+	var serializedPubkey [btcec.PubKeyBytesLenCompressed]byte
+	serializedPubkey[0] = 0x02 | byte(pub.Y.Bit(0))
+	pub.X.FillBytes(serializedPubkey[1:])
+
+	return WriteBytes(buf, serializedPubkey[:])
 }
 
 // WriteChannelID appends the ChannelID to the provided buffer.
@@ -8,6 +8,7 @@ import (
 	"net"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
@@ -108,6 +109,73 @@ func TestWritePublicKey(t *testing.T) {
 	require.Equal(t, expectedBytes, buf.Bytes())
 }
 
+// TestWritePublicKeyEncoding asserts that WritePublicKey produces the same
+// bytes as SerializeCompressed for keys with an even and odd y coordinate, as
+// well as keys whose x coordinate has leading zero bytes.
+func TestWritePublicKeyEncoding(t *testing.T) {
+	t.Parallel()
+
+	var seenEven, seenOdd, seenLeadingZero bool
+	for i := 0; i < 10000; i++ {
+		if i >= 100 && seenEven && seenOdd && seenLeadingZero {
+			break
+		}
+
+		pub, err := randPubKey()
+		require.NoError(t, err)
+
+		expected := pub.SerializeCompressed()
+		switch {
+		case expected[0] == 0x02:
+			seenEven = true
+		case expected[0] == 0x03:
+			seenOdd = true
+		}
+		if expected[1] == 0x00 {
+			seenLeadingZero = true
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, WritePublicKey(&buf, pub))
+		require.Equal(t, expected, buf.Bytes())
+	}
+
+	require.True(t, seenEven, "no key with an even y coordinate")
+	require.True(t, seenOdd, "no key with an odd y coordinate")
+	require.True(t, seenLeadingZero, "no key with a leading zero x byte")
+}
+
+// BenchmarkWritePublicKey benchmarks the performance of WritePublicKey against
+// writing the output of SerializeCompressed, which it used to be based on.
+func BenchmarkWritePublicKey(b *testing.B) {
+	pub, err := randPubKey()
+	require.NoError(b, err)
+
+	var buf bytes.Buffer
+	buf.Grow(btcec.PubKeyBytesLenCompressed)
+
+	b.Run("WritePublicKey", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			if err := WritePublicKey(&buf, pub); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("SerializeCompressed", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			err := WriteBytes(&buf, pub.SerializeCompressed())
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func TestWriteChannelID(t *testing.T) {
 	buf := new(bytes.Buffer)
 	data := ChannelID{1}
@@ -3,6 +3,8 @@ package peer
 import (
 	"bytes"
 	"container/list"
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"net"
@@ -150,6 +152,11 @@ type Config struct {
 	// default features field.
 	LegacyFeatures *lnwire.FeatureVector
 
+	// ShutdownScriptQuirks are the quirks applied to the upfront shutdown
+	// scripts of the channel opening messages of this peer, in order to
+	// recover scripts it is known to mis-encode.
+	ShutdownScriptQuirks []lnwire.ShutdownScriptQuirk
+
 	// OutgoingCltvRejectDelta defines the number of blocks before expiry of
 	// an htlc where we don't offer it anymore.
 	OutgoingCltvRejectDelta uint32
@@ -833,6 +840,7 @@ func (p *Brontide) addLink(chanPoint *wire.OutPoint,
 		OutgoingCltvRejectDelta: p.cfg.OutgoingCltvRejectDelta,
 		TowerClient:             towerClient,
 		MaxOutgoingCltvExpiry:   p.cfg.MaxOutgoingCltvExpiry,
+		MaxHtlcExpiryDelta:      lnChan.State().MaxHtlcExpiryDelta,
 		MaxFeeAllocation:        p.cfg.MaxChannelFeeAllocation,
 		MaxAnchorsCommitFeeRate: p.cfg.MaxAnchorsCommitFeeRate,
 		NotifyActiveLink:        p.cfg.ChannelNotifier.NotifyActiveLinkEvent,
@@ -968,8 +976,12 @@ func (p *Brontide) readNextMessage() (lnwire.Message, error) {
 	// Next, create a new io.Reader implementation from the raw message,
 	// and use this to decode the message directly from.
 	msgReader := bytes.NewReader(rawMsg)
-	nextMsg, err := lnwire.ReadMessage(msgReader, 0)
+	ctx := lnwire.WithShutdownScriptQuirks(
+		context.Background(), p.cfg.ShutdownScriptQuirks...,
+	)
+	nextMsg, err := lnwire.ReadMessageCtx(ctx, msgReader, 0)
 	if err != nil {
+		p.handleMalformedMsg(rawMsg, err)
 		return nil, err
 	}
 
@@ -978,6 +990,29 @@ func (p *Brontide) readNextMessage() (lnwire.Message, error) {
 	return nextMsg, nil
 }
 
+// handleMalformedMsg inspects a raw message that we failed to decode. If it is
+// an AcceptChannel, the funding manager is notified so that the reservation it
+// references is released right away, rather than only once the disconnect that
+// follows the decode failure has been processed.
+func (p *Brontide) handleMalformedMsg(rawMsg []byte, decodeErr error) {
+	// The 2-byte message type is directly followed by the 32-byte pending
+	// channel ID, so we need at least that much to know which reservation
+	// to cancel.
+	if len(rawMsg) < 2+32 {
+		return
+	}
+
+	msgType := lnwire.MessageType(binary.BigEndian.Uint16(rawMsg[:2]))
+	if msgType != lnwire.MsgAcceptChannel {
+		return
+	}
+
+	var pendingChanID [32]byte
+	copy(pendingChanID[:], rawMsg[2:34])
+
+	p.cfg.FundingManager.ProcessMalformedAccept(pendingChanID, decodeErr, p)
+}
+
 // msgStream implements a goroutine-safe, in-order stream of messages to be
 // delivered via closure to a receiver. These messages MUST be in order due to
 // the nature of the lightning channel commitment and gossiper state machines.
@@ -2412,34 +2447,27 @@ func (p *Brontide) fetchActiveChanCloser(chanID lnwire.ChannelID) (
 	return chanCloser, nil
 }
 
-// chooseDeliveryScript takes two optionally set shutdown scripts and returns
-// a suitable script to close out to. This may be nil if neither script is
-// set. If both scripts are set, this function will error if they do not match.
-func chooseDeliveryScript(upfront,
+// chooseDeliveryScript takes the optionally set upfront shutdown scripts of
+// both parties and an optionally requested script, and returns a suitable
+// script to close out to. This may be nil if neither our upfront script nor a
+// requested script is set. This function will error if the requested script
+// violates the upfront constraints, as checked by chancloser.CanCoopClose.
+func chooseDeliveryScript(upfront, remoteUpfront,
 	requested lnwire.DeliveryAddress) (lnwire.DeliveryAddress, error) {
 
+	err := chancloser.CanCoopClose(upfront, remoteUpfront, requested)
+	if err != nil {
+		return nil, err
+	}
+
 	// If no upfront shutdown script was provided, return the user
 	// requested address (which may be nil).
 	if len(upfront) == 0 {
 		return requested, nil
 	}
 
-	// If an upfront shutdown script was provided, and the user did not request
-	// a custom shutdown script, return the upfront address.
-	if len(requested) == 0 {
-		return upfront, nil
-	}
-
-	// If both an upfront shutdown script and a custom close script were
-	// provided, error if the user provided shutdown script does not match
-	// the upfront shutdown script (because closing out to a different script
-	// would violate upfront shutdown).
-	if !bytes.Equal(upfront, requested) {
-		return nil, chancloser.ErrUpfrontShutdownScriptMismatch
-	}
-
-	// The user requested script matches the upfront shutdown script, so we
-	// can return it without error.
+	// Otherwise the requested script is either unset or matches the
+	// upfront shutdown script, so we can return the upfront address.
 	return upfront, nil
 }
 
@@ -2477,7 +2505,9 @@ func (p *Brontide) handleLocalCloseReq(req *htlcswitch.ChanClose) {
 		// appropriate address to close out to (which may be nil if neither
 		// are set) and error if they are both set and do not match.
 		deliveryScript, err := chooseDeliveryScript(
-			channel.LocalUpfrontShutdownScript(), req.DeliveryScript,
+			channel.LocalUpfrontShutdownScript(),
+			channel.RemoteUpfrontShutdownScript(),
+			req.DeliveryScript,
 		)
 		if err != nil {
 			peerLog.Errorf("cannot close channel %v: %v", req.ChanPoint, err)
@@ -710,7 +710,7 @@ func TestChooseDeliveryScript(t *testing.T) {
 
 		t.Run(test.name, func(t *testing.T) {
 			script, err := chooseDeliveryScript(
-				test.shutdownScript, test.userScript,
+				test.shutdownScript, nil, test.userScript,
 			)
 			if err != test.expectedError {
 				t.Fatalf("Expected: %v, got: %v", test.expectedError, err)
@@ -984,6 +984,40 @@ func TestStaticRemoteDowngrade(t *testing.T) {
 	}
 }
 
+// TestAddLinkMaxHtlcExpiryDelta asserts that the link of a channel is
+// configured with the max HTLC expiry delta stored with the channel.
+func TestAddLinkMaxHtlcExpiryDelta(t *testing.T) {
+	t.Parallel()
+
+	notifier := &mock.ChainNotifier{
+		SpendChan: make(chan *chainntnfs.SpendDetail),
+		EpochChan: make(chan *chainntnfs.BlockEpoch),
+		ConfChan:  make(chan *chainntnfs.TxConfirmation),
+	}
+	broadcastTxChan := make(chan *wire.MsgTx)
+
+	alicePeer, bobChan, cleanUp, err := createTestPeer(
+		notifier, broadcastTxChan, noUpdate,
+	)
+	require.NoError(t, err)
+	defer cleanUp()
+
+	chanPoint := bobChan.ChannelPoint()
+	aliceChan := alicePeer.activeChannels[lnwire.NewChanIDFromOutPoint(
+		chanPoint,
+	)]
+	aliceChan.State().MaxHtlcExpiryDelta = 1008
+
+	err = alicePeer.addLink(
+		chanPoint, aliceChan, &htlcswitch.ForwardingPolicy{}, nil,
+		false,
+	)
+	require.NoError(t, err)
+
+	htlcSwitch := alicePeer.cfg.Switch.(*mockMessageSwitch)
+	require.EqualValues(t, 1008, htlcSwitch.linkCfg.MaxHtlcExpiryDelta)
+}
+
 // genScript creates a script paying out to the address provided, which must
 // be a valid address.
 func genScript(t *testing.T, address string) lnwire.DeliveryAddress {
@@ -375,7 +375,10 @@ func createTestPeer(notifier chainntnfs.ChainNotifier,
 
 // mockMessageSwitch is a mock implementation of the messageSwitch interface
 // used for testing without relying on a *htlcswitch.Switch in unit tests.
-type mockMessageSwitch struct{}
+type mockMessageSwitch struct {
+	// linkCfg is the config of the last link created.
+	linkCfg htlcswitch.ChannelLinkConfig
+}
 
 // BestHeight currently returns a dummy value.
 func (m *mockMessageSwitch) BestHeight() uint32 {
@@ -390,10 +393,11 @@ func (m *mockMessageSwitch) CircuitModifier() htlcswitch.CircuitModifier {
 // RemoveLink currently does nothing.
 func (m *mockMessageSwitch) RemoveLink(cid lnwire.ChannelID) {}
 
-// CreateAndAddLink currently returns a dummy value.
+// CreateAndAddLink records the config of the link and returns a dummy value.
 func (m *mockMessageSwitch) CreateAndAddLink(cfg htlcswitch.ChannelLinkConfig,
 	lnChan *lnwallet.LightningChannel) error {
 
+	m.linkCfg = cfg
 	return nil
 }
 
@@ -512,6 +512,31 @@ func MainRPCServerPermissions() map[string][]bakery.Op {
 			Entity: "offchain",
 			Action: "write",
 		}},
+		"/lnrpc.Lightning/AbortFunding": {{
+			Entity: "onchain",
+			Action: "write",
+		}, {
+			Entity: "offchain",
+			Action: "write",
+		}},
+		"/lnrpc.Lightning/SimulateOpenChannel": {{
+			Entity: "onchain",
+			Action: "read",
+		}, {
+			Entity: "offchain",
+			Action: "read",
+		}},
+		"/lnrpc.Lightning/DiagnoseAcceptChannel": {{
+			Entity: "onchain",
+			Action: "read",
+		}, {
+			Entity: "offchain",
+			Action: "read",
+		}},
+		"/lnrpc.Lightning/ListPendingNegotiations": {{
+			Entity: "offchain",
+			Action: "read",
+		}},
 	}
 }
 
@@ -2471,6 +2496,10 @@ func (r *rpcServer) AbandonChannel(_ context.Context,
 		return nil, err
 	}
 
+	// The funding manager keeps the AcceptChannel of pending channels in
+	// memory until they are open, which an abandoned channel never is.
+	r.server.fundingMgr.ForgetAbandonedChannel(*chanPoint)
+
 	// Finally, notify the backup listeners that the channel can be removed
 	// from any channel backups.
 	r.server.channelNotifier.NotifyClosedChannelEvent(*chanPoint)
@@ -2745,6 +2774,25 @@ func (r *rpcServer) ListPeers(ctx context.Context,
 			}
 		}
 
+		// Expose how long the peer took to complete the funding of the
+		// channels we opened with it, to help with peer selection.
+		latency, err := r.server.fundingMgr.FundingLatency(
+			serverPeer.IdentityKey(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if latency != nil {
+			rpcPeer.FundingLatency = &lnrpc.FundingLatency{
+				NumFundings:    latency.NumFundings,
+				LastLatencyNs:  int64(latency.Last),
+				MinLatencyNs:   int64(latency.Min),
+				MaxLatencyNs:   int64(latency.Max),
+				AvgLatencyNs:   int64(latency.Average()),
+				LastRecordedNs: latency.LastRecorded.UnixNano(),
+			}
+		}
+
 		resp.Peers = append(resp.Peers, rpcPeer)
 	}
 
@@ -3035,6 +3083,26 @@ func (r *rpcServer) PendingChannels(ctx context.Context,
 			FeePerKw:     int64(localCommitment.FeePerKw),
 			// TODO(roasbeef): need to track confirmation height
 		}
+
+		// Expose the parameters the remote peer sent in its
+		// AcceptChannel, for channels we initiated.
+		accept, ok := r.server.fundingMgr.AcceptMsg(
+			pendingChan.FundingOutpoint,
+		)
+		if ok {
+			resp.PendingOpenChannels[i].AcceptParams =
+				lnrpc.MarshalAcceptChannelParams(accept)
+		}
+
+		// Expose the records of the AcceptChannel of the remote peer
+		// we don't know about, to help debug experimental features.
+		extraData, ok := r.server.fundingMgr.AcceptExtraData(
+			pendingChan.FundingOutpoint,
+		)
+		if ok {
+			resp.PendingOpenChannels[i].AcceptExtraData =
+				hex.EncodeToString(extraData)
+		}
 	}
 
 	_, currentHeight, err := r.server.cc.ChainIO.GetBestBlock()
@@ -6923,3 +6991,167 @@ func (r *rpcServer) FundingStateStep(ctx context.Context,
 	// current state?
 	return &lnrpc.FundingStateStepResp{}, nil
 }
+
+// AbortFunding aborts a pending funding negotiation with a remote peer that
+// has accepted the channel, but for which the FundingCreated message hasn't
+// been sent yet. The reservation of the channel is canceled, the coins locked
+// for it are released, and the remote peer is notified of the cancellation.
+func (r *rpcServer) AbortFunding(ctx context.Context,
+	in *lnrpc.AbortFundingRequest) (*lnrpc.AbortFundingResponse, error) {
+
+	if len(in.PendingChanId) != 32 {
+		return nil, fmt.Errorf("pending chan ID not set, must be 32 " +
+			"bytes")
+	}
+
+	var pendingChanID [32]byte
+	copy(pendingChanID[:], in.PendingChanId)
+
+	rpcsLog.Debugf("[abortfunding] pending_id=%x", pendingChanID[:])
+
+	err := r.server.fundingMgr.AbortFunding(pendingChanID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.AbortFundingResponse{}, nil
+}
+
+// SimulateOpenChannel previews the channel that would result from opening a
+// channel with a connected peer, without broadcasting anything, reserving any
+// coins or sending any messages to the peer.
+func (r *rpcServer) SimulateOpenChannel(ctx context.Context,
+	in *lnrpc.SimulateOpenChannelRequest) (*lnrpc.SimulateOpenChannelResponse,
+	error) {
+
+	if in.LocalFundingAmount <= 0 {
+		return nil, fmt.Errorf("local funding amount must be positive")
+	}
+	if in.PushSat < 0 {
+		return nil, fmt.Errorf("push amount must not be negative")
+	}
+
+	nodePub, err := btcec.ParsePubKey(in.NodePubkey, btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	peer, err := r.server.FindPeer(nodePub)
+	if err != nil {
+		return nil, fmt.Errorf("peer %x is not online", in.NodePubkey)
+	}
+
+	rpcsLog.Debugf("[simulateopenchannel] peer=%x, local_amt=%v, "+
+		"push_amt=%v", in.NodePubkey, in.LocalFundingAmount,
+		in.PushSat)
+
+	sim, err := r.server.fundingMgr.SimulateOpenChannel(
+		peer, btcutil.Amount(in.LocalFundingAmount),
+		lnwire.NewMSatFromSatoshis(btcutil.Amount(in.PushSat)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var commitmentType lnrpc.CommitmentType
+	switch sim.CommitType {
+	case lnwallet.CommitmentTypeAnchorsZeroFeeHtlcTx:
+		commitmentType = lnrpc.CommitmentType_ANCHORS
+
+	case lnwallet.CommitmentTypeTweakless:
+		commitmentType = lnrpc.CommitmentType_STATIC_REMOTE_KEY
+
+	default:
+		commitmentType = lnrpc.CommitmentType_LEGACY
+	}
+
+	return &lnrpc.SimulateOpenChannelResponse{
+		CommitmentType: commitmentType,
+		FeePerKw:       int64(sim.CommitFeePerKw),
+		CommitFee:      int64(sim.CommitFee),
+		ChanReserveSat: uint64(sim.ChanReserve),
+		MinAcceptDepth: uint32(sim.MinAcceptDepth),
+		LocalBalance:   int64(sim.LocalBalance.ToSatoshis()),
+		RemoteBalance:  int64(sim.RemoteBalance.ToSatoshis()),
+	}, nil
+}
+
+// DiagnoseAcceptChannel estimates the cost of force closing a channel we
+// initiated at the current fee rate, based on the parameters the remote peer
+// set in its AcceptChannel message.
+func (r *rpcServer) DiagnoseAcceptChannel(ctx context.Context,
+	in *lnrpc.DiagnoseAcceptChannelRequest) (
+	*lnrpc.DiagnoseAcceptChannelResponse, error) {
+
+	if in.ChannelPoint == nil {
+		return nil, fmt.Errorf("channel point must be set")
+	}
+
+	txid, err := lnrpc.GetChanPointFundingTxid(in.GetChannelPoint())
+	if err != nil {
+		return nil, err
+	}
+	chanPoint := wire.OutPoint{
+		Hash:  *txid,
+		Index: in.ChannelPoint.OutputIndex,
+	}
+
+	rpcsLog.Debugf("[diagnoseacceptchannel] chan_point=%v", chanPoint)
+
+	diag, err := r.server.fundingMgr.DiagnoseChannel(chanPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.DiagnoseAcceptChannelResponse{
+		FeePerKw:       int64(diag.FeePerKw),
+		CommitFee:      int64(diag.CommitFee),
+		SweepFee:       int64(diag.SweepFee),
+		ForceCloseCost: int64(diag.ForceCloseCost),
+		ChanReserveSat: int64(diag.ChanReserve),
+		Capacity:       int64(diag.Capacity),
+		Uneconomical:   diag.Uneconomical,
+	}, nil
+}
+
+// ListPendingNegotiations returns the funding negotiations that are still in
+// progress, for which no funding transaction exists yet.
+func (r *rpcServer) ListPendingNegotiations(ctx context.Context,
+	in *lnrpc.ListPendingNegotiationsRequest) (
+	*lnrpc.ListPendingNegotiationsResponse, error) {
+
+	negotiations := r.server.fundingMgr.PendingNegotiations()
+
+	resp := &lnrpc.ListPendingNegotiationsResponse{
+		Negotiations: make(
+			[]*lnrpc.PendingNegotiation, 0, len(negotiations),
+		),
+	}
+	for _, negotiation := range negotiations {
+		pendingChanID := negotiation.PendingChanID
+		rpcNegotiation := &lnrpc.PendingNegotiation{
+			PendingChanId: pendingChanID[:],
+			RemoteNodePub: hex.EncodeToString(
+				negotiation.Peer.SerializeCompressed(),
+			),
+			Capacity: int64(negotiation.Capacity),
+		}
+
+		if accept := negotiation.Accept; accept != nil {
+			rpcNegotiation.Accepted = true
+			rpcNegotiation.MinAcceptDepth = accept.MinAcceptDepth
+			rpcNegotiation.CsvDelay = uint32(accept.CsvDelay)
+			rpcNegotiation.ChanReserveSat = uint64(
+				accept.ChannelReserve,
+			)
+		}
+
+		if !negotiation.LastUpdated.IsZero() {
+			rpcNegotiation.LastUpdated = negotiation.LastUpdated.Unix()
+		}
+
+		resp.Negotiations = append(resp.Negotiations, rpcNegotiation)
+	}
+
+	return resp, nil
+}
@@ -192,6 +192,10 @@ type server struct {
 	peerConnectedListeners    map[string][]chan<- lnpeer.Peer
 	peerDisconnectedListeners map[string][]chan<- struct{}
 
+	// shutdownScriptCompatPeers is the set of known-buggy peers whose
+	// mis-encoded upfront shutdown scripts are recovered.
+	shutdownScriptCompatPeers map[route.Vertex]struct{}
+
 	persistentPeers        map[string]bool
 	persistentPeersBackoff map[string]time.Duration
 	persistentConnReqs     map[string][]*connmgr.ConnReq
@@ -1040,6 +1044,33 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		return nil, err
 	}
 
+	requiredCommitType, err := parseRequiredCommitType(
+		cfg.RequireChannelType,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	zeroReservePeers, err := parsePeerSet(
+		"allow-zero-reserve", cfg.AllowZeroReserve,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upfront shutdown scripts that the configured known-buggy peers
+	// mis-encode are recovered rather than failing their funding flows.
+	s.shutdownScriptCompatPeers, err = parsePeerSet(
+		"shutdown-script-compat", cfg.ShutdownScriptCompat,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The default max value in flight leaves out the reserve of our
+	// reserve policy, so both are derived from the same policy.
+	reservePolicy := funding.ReservePolicy(funding.DefaultReservePolicy)
+
 	s.fundingMgr, err = funding.NewFundingManager(funding.Config{
 		NoWumboChans:       !cfg.ProtocolOptions.Wumbo(),
 		IDKey:              nodeKeyECDH.PubKey(),
@@ -1129,6 +1160,8 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 			}
 			return uint16(conf)
 		},
+		LightClient:         chainCfg.Node == "neutrino",
+		MaxSeenCommitPoints: funding.DefaultMaxSeenCommitPoints,
 		RequiredRemoteDelay: func(chanAmt btcutil.Amount) uint16 {
 			// We scale the remote CSV delay (the time the
 			// remote have to claim funds in case of a unilateral
@@ -1186,28 +1219,15 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 			cid := lnwire.NewChanIDFromOutPoint(&chanPoint)
 			return s.htlcSwitch.UpdateShortChanID(cid)
 		},
-		RequiredRemoteChanReserve: func(chanAmt,
-			dustLimit btcutil.Amount) btcutil.Amount {
-
-			// By default, we'll require the remote peer to maintain
-			// at least 1% of the total channel capacity at all
-			// times. If this value ends up dipping below the dust
-			// limit, then we'll use the dust limit itself as the
-			// reserve as required by BOLT #2.
-			reserve := chanAmt / 100
-			if reserve < dustLimit {
-				reserve = dustLimit
-			}
-
-			return reserve
-		},
-		RequiredRemoteMaxValue: func(chanAmt btcutil.Amount) lnwire.MilliSatoshi {
-			// By default, we'll allow the remote peer to fully
-			// utilize the full bandwidth of the channel, minus our
-			// required reserve.
-			reserve := lnwire.NewMSatFromSatoshis(chanAmt / 100)
-			return lnwire.NewMSatFromSatoshis(chanAmt) - reserve
+		ReservePolicy:      reservePolicy,
+		MinAbsoluteReserve: btcutil.Amount(cfg.MinAbsoluteReserve),
+		AllowZeroReserve: func(peer *btcec.PublicKey) bool {
+			_, ok := zeroReservePeers[route.NewVertex(peer)]
+			return ok
 		},
+		RequiredRemoteMaxValue: funding.DefaultMaxValueInFlight(
+			reservePolicy,
+		),
 		RequiredRemoteMaxHTLCs: func(chanAmt btcutil.Amount) uint16 {
 			if cfg.DefaultRemoteMaxHtlcs > 0 {
 				return cfg.DefaultRemoteMaxHtlcs
@@ -1222,7 +1242,15 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		MinChanSize:                   btcutil.Amount(cfg.MinChanSize),
 		MaxChanSize:                   btcutil.Amount(cfg.MaxChanSize),
 		MaxPendingChannels:            cfg.MaxPendingChannels,
+		MaxChannels:                   cfg.MaxChannels,
 		RejectPush:                    cfg.RejectPush,
+		RequiredCommitType:            requiredCommitType,
+		MinRemoteMaxHtlcs:             cfg.MinRemoteMaxHtlcs,
+		RequireRemoteUpfrontShutdown:  cfg.RequireRemoteUpfrontShutdown,
+		RejectIdentityFundingKey:      cfg.RejectIdentityFundingKey,
+		RejectExcessMaxValueInFlight:  cfg.RejectExcessMaxValueInFlight,
+		MaxCommitFeeAllocation:        cfg.MaxCommitFeeAllocation,
+		AllowCommitTypeDowngrade:      cfg.AllowCommitTypeDowngrade,
 		MaxLocalCSVDelay:              chainCfg.MaxLocalDelay,
 		NotifyOpenChannelEvent:        s.channelNotifier.NotifyOpenChannelEvent,
 		OpenChannelPredicate:          chanPredicate,
@@ -3285,6 +3313,12 @@ func (s *server) peerConnected(conn net.Conn, connReq *connmgr.ConnReq,
 	copy(pCfg.PubKeyBytes[:], peerAddr.IdentityKey.SerializeCompressed())
 	copy(pCfg.ServerPubKey[:], s.identityECDH.PubKey().SerializeCompressed())
 
+	if _, ok := s.shutdownScriptCompatPeers[pCfg.PubKeyBytes]; ok {
+		pCfg.ShutdownScriptQuirks = []lnwire.ShutdownScriptQuirk{
+			lnwire.U16ShutdownScriptQuirk,
+		}
+	}
+
 	p := peer.NewBrontide(pCfg)
 
 	// TODO(roasbeef): update IP address for link-node
@@ -3973,3 +4007,47 @@ func shouldPeerBootstrap(cfg *Config) bool {
 
 	return !cfg.NoNetBootstrap && !isDevNetwork
 }
+
+// parseRequiredCommitType parses the commitment type set through the
+// require-channel-type option. If the option isn't set, nil is returned.
+func parseRequiredCommitType(channelType string) (*lnwallet.CommitmentType,
+	error) {
+
+	var commitType lnwallet.CommitmentType
+	switch channelType {
+	case "":
+		return nil, nil
+
+	case "legacy":
+		commitType = lnwallet.CommitmentTypeLegacy
+
+	case "tweakless":
+		commitType = lnwallet.CommitmentTypeTweakless
+
+	case "anchors":
+		commitType = lnwallet.CommitmentTypeAnchorsZeroFeeHtlcTx
+
+	default:
+		return nil, fmt.Errorf("unknown channel type: %v", channelType)
+	}
+
+	return &commitType, nil
+}
+
+// parsePeerSet parses the public keys of the peers set through the given
+// option.
+func parsePeerSet(option string, pubKeys []string) (map[route.Vertex]struct{},
+	error) {
+
+	peers := make(map[route.Vertex]struct{}, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		vertex, err := route.NewVertexFromStr(pubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v peer %v: %v",
+				option, pubKey, err)
+		}
+		peers[vertex] = struct{}{}
+	}
+
+	return peers, nil
+}